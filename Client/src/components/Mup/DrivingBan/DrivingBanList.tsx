@@ -10,7 +10,10 @@ const DrivingBanList = ({drivingBans}: DrivingBansProps) => {
   const content = drivingBans.map(drivingBan =>
     <DrivingBansCardStyled>
       <h1>Driving ban reason: {drivingBan.reason}</h1>
-      <h3>Duration: {drivingBan.duration.replace("T", " ").replace("Z", "")}</h3>
+      <h3>From: {drivingBan.start.replace("T", " ").replace("Z", "")}</h3>
+      <h3>Until: {drivingBan.end.replace("T", " ").replace("Z", "")}</h3>
+      <h3>Status: {drivingBan.status}</h3>
+      {drivingBan.lifted && <h3>Lifted: {drivingBan.lifted.reason}</h3>}
     </DrivingBansCardStyled>
   );
 
@@ -1,8 +1,15 @@
 type DrivingBan = {
   id: string; 
   reason: string;
-  duration: string;
+  start: string;
+  end: string;
   person: string;
+  authority: string;
+  status: string;
+  lifted?: {
+    at: string;
+    reason: string;
+  };
 };
 
 
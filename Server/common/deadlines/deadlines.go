@@ -0,0 +1,23 @@
+package deadlines
+
+import (
+	"log"
+	"net/http"
+	"time"
+)
+
+// Gives the handler its own read and write deadlines instead of the server wide ones,
+// for routes transferring files too large to fit the short timeouts of other requests
+func Extend(timeout time.Duration, next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		deadline := time.Now().Add(timeout)
+		rc := http.NewResponseController(w)
+		if err := rc.SetReadDeadline(deadline); err != nil {
+			log.Printf("Failed to extend read deadline: %v\n", err)
+		}
+		if err := rc.SetWriteDeadline(deadline); err != nil {
+			log.Printf("Failed to extend write deadline: %v\n", err)
+		}
+		next(w, r)
+	}
+}
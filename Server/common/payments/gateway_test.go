@@ -0,0 +1,42 @@
+package payments
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"testing"
+)
+
+func TestVerifyNotice(t *testing.T) {
+	secret := []byte("shared-secret")
+	body := []byte(`{"model":"97","referenceNumber":"0924101912345678","amount":3500,"transactionID":"TX-1"}`)
+	mac := hmac.New(sha256.New, secret)
+	mac.Write(body)
+	signature := hex.EncodeToString(mac.Sum(nil))
+	unkeyed := hmac.New(sha256.New, nil)
+	unkeyed.Write(body)
+
+	tests := []struct {
+		name      string
+		secret    []byte
+		body      []byte
+		signature string
+		want      bool
+	}{
+		{name: "signed by the gateway", secret: secret, body: body, signature: signature, want: true},
+		{name: "body changed", secret: secret, body: append([]byte(`{"amount":1,`), body[1:]...), signature: signature},
+		{name: "other secret", secret: []byte("other-secret"), body: body, signature: signature},
+		{name: "no signature", secret: secret, body: body},
+		{name: "signature not hex", secret: secret, body: body, signature: "not-a-signature"},
+		// Without a secret an empty key would sign anything
+		{name: "no secret", body: body, signature: hex.EncodeToString(unkeyed.Sum(nil))},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := VerifyNotice(tt.secret, tt.body, tt.signature); got != tt.want {
+				t.Errorf("VerifyNotice() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,29 @@
+package payments
+
+import (
+	"fmt"
+	"math/rand"
+	"time"
+)
+
+// Returns payment reference ("poziv na broj") by model 97: two control
+// digits followed by the date of issue and a random part
+func GenerateReference97() string {
+	b := make([]byte, 8)
+	for i := range b {
+		b[i] = byte('0' + rand.Intn(10))
+	}
+	base := time.Now().Format("060102") + string(b)
+
+	return Control97(base) + base
+}
+
+// Returns control digits of a numeric reference by ISO 7064 MOD 97-10,
+// as used for model 97
+func Control97(base string) string {
+	remainder := 0
+	for _, digit := range base + "00" {
+		remainder = (remainder*10 + int(digit-'0')) % 97
+	}
+	return fmt.Sprintf("%02d", 98-remainder)
+}
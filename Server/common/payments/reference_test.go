@@ -0,0 +1,52 @@
+package payments
+
+import (
+	"math/big"
+	"testing"
+	"time"
+)
+
+func TestControl97(t *testing.T) {
+	tests := []struct {
+		base string
+		want string
+	}{
+		{"0", "98"},
+		{"1", "95"},
+		{"123456", "76"},
+		{"2410190000001", "09"},
+		{"99999999999999", "39"},
+		// Longer than fits an int64, the remainder is taken digit by digit
+		{"12345678901234567890", "89"},
+	}
+
+	for _, tt := range tests {
+		if got := Control97(tt.base); got != tt.want {
+			t.Errorf("Control97(%q) = %q, want %q", tt.base, got, tt.want)
+		}
+	}
+}
+
+func TestGenerateReference97(t *testing.T) {
+	for i := 0; i < 100; i++ {
+		before := time.Now().Format("060102")
+		reference := GenerateReference97()
+		after := time.Now().Format("060102")
+
+		if len(reference) != 16 {
+			t.Fatalf("GenerateReference97() = %q, want 16 digits", reference)
+		}
+		if date := reference[2:8]; date != before && date != after {
+			t.Errorf("GenerateReference97() = %q, want the date of issue %s after the control digits", reference, after)
+		}
+
+		// The base followed by its control digits gives a remainder of 1 by ISO 7064
+		number, ok := new(big.Int).SetString(reference[2:]+reference[:2], 10)
+		if !ok {
+			t.Fatalf("GenerateReference97() = %q, want digits only", reference)
+		}
+		if remainder := new(big.Int).Mod(number, big.NewInt(97)); remainder.Int64() != 1 {
+			t.Errorf("GenerateReference97() = %q, control digits don't check out, remainder %v", reference, remainder)
+		}
+	}
+}
@@ -0,0 +1,13 @@
+package signing
+
+import "strings"
+
+// Masks every word of the name except its first letter, e.g. "Petar Petrović" to "P**** P*******"
+func MaskName(name string) string {
+	words := strings.Fields(name)
+	for i, word := range words {
+		runes := []rune(word)
+		words[i] = string(runes[0]) + strings.Repeat("*", len(runes)-1)
+	}
+	return strings.Join(words, " ")
+}
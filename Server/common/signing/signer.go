@@ -0,0 +1,262 @@
+package signing
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"sync"
+	"time"
+
+	"github.com/golang-jwt/jwt"
+)
+
+// Keys are reloaded for an unknown key ID at most this often, so tokens with made up
+// IDs can't make every verification hit the store
+const reloadInterval = 30 * time.Second
+
+var (
+	ErrInvalidToken = errors.New("invalid document token")
+	ErrTokenExpired = errors.New("document token expired")
+)
+
+// Key pair used for signing document tokens. Retired keys keep only the public
+// key, so tokens they signed can still be verified
+type Key struct {
+	ID         string    `bson:"_id" json:"id"`
+	PrivateKey []byte    `bson:"privateKey,omitempty" json:"-"`
+	PublicKey  []byte    `bson:"publicKey" json:"publicKey"`
+	Active     bool      `bson:"active" json:"active"`
+	CreatedAt  time.Time `bson:"createdAt" json:"createdAt"`
+	RetiredAt  time.Time `bson:"retiredAt,omitempty" json:"retiredAt,omitempty"`
+}
+
+type Keys []Key
+
+// Persists signing keys, so tokens stay verifiable across restarts and key rotations
+type KeyStore interface {
+	GetSigningKeys(ctx context.Context) (Keys, error)
+	RotateSigningKey(ctx context.Context, key Key) error
+}
+
+// Claims of a signed document token. Subject is the document number
+type DocumentClaims struct {
+	Document string `json:"doc"`
+	Holder   string `json:"holder"`
+	jwt.StandardClaims
+}
+
+// Signs document tokens as compact JWS (ES256) with the active key and verifies
+// them with any known key, selected by the "kid" header
+type Signer struct {
+	store  KeyStore
+	issuer string
+
+	mu        sync.RWMutex
+	activeID  string
+	activeKey *ecdsa.PrivateKey
+	keys      map[string]*ecdsa.PublicKey
+	loadedAt  time.Time
+}
+
+// Loads signing keys from the store, creating the first key if there is none
+func NewSigner(ctx context.Context, store KeyStore, issuer string) (*Signer, error) {
+	s := &Signer{store: store, issuer: issuer}
+
+	if err := s.load(ctx); err != nil {
+		return nil, err
+	}
+
+	if s.activeKey == nil {
+		if _, err := s.Rotate(ctx); err != nil {
+			return nil, err
+		}
+	}
+
+	return s, nil
+}
+
+// Returns a signed token for the document, valid until expiresAt
+func (s *Signer) Sign(document, number, holder string, expiresAt time.Time) (string, error) {
+	s.mu.RLock()
+	kid, key := s.activeID, s.activeKey
+	s.mu.RUnlock()
+
+	claims := DocumentClaims{
+		Document: document,
+		Holder:   holder,
+		StandardClaims: jwt.StandardClaims{
+			Issuer:    s.issuer,
+			Subject:   number,
+			IssuedAt:  time.Now().Unix(),
+			ExpiresAt: expiresAt.Unix(),
+		},
+	}
+
+	token := jwt.NewWithClaims(jwt.SigningMethodES256, claims)
+	token.Header["kid"] = kid
+
+	return token.SignedString(key)
+}
+
+// Verifies signature and issuer of the token. Claims of a genuine but expired
+// token are returned together with ErrTokenExpired
+func (s *Signer) Verify(ctx context.Context, tokenStr string) (*DocumentClaims, error) {
+	claims := &DocumentClaims{}
+	_, err := jwt.ParseWithClaims(tokenStr, claims, func(token *jwt.Token) (interface{}, error) {
+		if token.Method != jwt.SigningMethodES256 {
+			return nil, ErrInvalidToken
+		}
+		kid, _ := token.Header["kid"].(string)
+		return s.publicKey(ctx, kid)
+	})
+
+	if err != nil {
+		var ve *jwt.ValidationError
+		if errors.As(err, &ve) && ve.Errors == jwt.ValidationErrorExpired && claims.Issuer == s.issuer {
+			return claims, ErrTokenExpired
+		}
+		return nil, ErrInvalidToken
+	}
+
+	if claims.Issuer != s.issuer {
+		return nil, ErrInvalidToken
+	}
+
+	return claims, nil
+}
+
+// Creates a new active key and retires the current one. Tokens signed with
+// retired keys stay verifiable
+func (s *Signer) Rotate(ctx context.Context) (Key, error) {
+	private, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		return Key{}, err
+	}
+
+	privateDER, err := x509.MarshalPKCS8PrivateKey(private)
+	if err != nil {
+		return Key{}, err
+	}
+
+	publicDER, err := x509.MarshalPKIXPublicKey(&private.PublicKey)
+	if err != nil {
+		return Key{}, err
+	}
+
+	id := make([]byte, 8)
+	if _, err := rand.Read(id); err != nil {
+		return Key{}, err
+	}
+
+	key := Key{
+		ID:         hex.EncodeToString(id),
+		PrivateKey: privateDER,
+		PublicKey:  publicDER,
+		Active:     true,
+		CreatedAt:  time.Now(),
+	}
+
+	if err := s.store.RotateSigningKey(ctx, key); err != nil {
+		return Key{}, err
+	}
+
+	if err := s.load(ctx); err != nil {
+		return Key{}, err
+	}
+
+	return key, nil
+}
+
+// Returns public parts of all keys, for verifying tokens offline
+func (s *Signer) PublicKeys(ctx context.Context) (Keys, error) {
+	return s.store.GetSigningKeys(ctx)
+}
+
+// Finds the public key by its ID, reloading keys in case it was created by
+// another instance unless they were loaded recently
+func (s *Signer) publicKey(ctx context.Context, kid string) (*ecdsa.PublicKey, error) {
+	s.mu.RLock()
+	key, ok := s.keys[kid]
+	recent := time.Since(s.loadedAt) < reloadInterval
+	s.mu.RUnlock()
+	if ok {
+		return key, nil
+	}
+	if recent {
+		return nil, ErrInvalidToken
+	}
+
+	if err := s.load(ctx); err != nil {
+		return nil, err
+	}
+
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	if key, ok := s.keys[kid]; ok {
+		return key, nil
+	}
+	return nil, ErrInvalidToken
+}
+
+func (s *Signer) load(ctx context.Context) error {
+	stored, err := s.store.GetSigningKeys(ctx)
+	if err != nil {
+		return err
+	}
+
+	keys := make(map[string]*ecdsa.PublicKey, len(stored))
+	var activeID string
+	var activeKey *ecdsa.PrivateKey
+
+	for _, sk := range stored {
+		public, err := x509.ParsePKIXPublicKey(sk.PublicKey)
+		if err != nil {
+			return fmt.Errorf("failed to parse signing key %s: %v", sk.ID, err)
+		}
+		ecPublic, ok := public.(*ecdsa.PublicKey)
+		if !ok {
+			return fmt.Errorf("signing key %s is not an ECDSA key", sk.ID)
+		}
+		keys[sk.ID] = ecPublic
+
+		if sk.Active && len(sk.PrivateKey) > 0 {
+			private, err := x509.ParsePKCS8PrivateKey(sk.PrivateKey)
+			if err != nil {
+				return fmt.Errorf("failed to parse signing key %s: %v", sk.ID, err)
+			}
+			ecPrivate, ok := private.(*ecdsa.PrivateKey)
+			if !ok {
+				return fmt.Errorf("signing key %s is not an ECDSA key", sk.ID)
+			}
+			activeID, activeKey = sk.ID, ecPrivate
+		}
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.keys = keys
+	s.loadedAt = time.Now()
+	// Keep signing with the current key if another instance retired it meanwhile
+	if activeKey != nil {
+		s.activeID, s.activeKey = activeID, activeKey
+	}
+
+	return nil
+}
+
+func (k *Key) ToJSON(w io.Writer) error {
+	e := json.NewEncoder(w)
+	return e.Encode(k)
+}
+
+func (ks *Keys) ToJSON(w io.Writer) error {
+	e := json.NewEncoder(w)
+	return e.Encode(ks)
+}
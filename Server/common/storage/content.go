@@ -0,0 +1,91 @@
+package storage
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"io"
+	"net/http"
+	"slices"
+	"time"
+)
+
+// Longest removing content for records that weren't saved may take
+const discardTimeout = 10 * time.Second
+
+var (
+	ErrContentTooLarge    = errors.New("content exceeds the size limit")
+	ErrUnsupportedContent = errors.New("content type is not accepted")
+)
+
+// Content stored by StoreContent
+type StoredContent struct {
+	Key         string
+	ContentType string
+	Size        int64
+	SHA256      string
+}
+
+// Reads at most maxSize bytes and stores them under the prefix followed by their checksum.
+// The content type is sniffed from the content itself and must be one of the allowed ones
+func StoreContent(ctx context.Context, store BlobStore, prefix string, r io.Reader, maxSize int64, allowed []string) (StoredContent, error) {
+	content, err := io.ReadAll(io.LimitReader(r, maxSize+1))
+	if err != nil {
+		return StoredContent{}, err
+	}
+	if int64(len(content)) > maxSize {
+		return StoredContent{}, ErrContentTooLarge
+	}
+
+	contentType := http.DetectContentType(content)
+	if !slices.Contains(allowed, contentType) {
+		return StoredContent{}, ErrUnsupportedContent
+	}
+
+	sum := sha256.Sum256(content)
+	stored := StoredContent{
+		ContentType: contentType,
+		Size:        int64(len(content)),
+		SHA256:      hex.EncodeToString(sum[:]),
+	}
+	stored.Key = prefix + stored.SHA256
+
+	// Blobs are keyed by checksum, so a failed submit leaves content the retry reuses
+	if err := store.Put(ctx, stored.Key, bytes.NewReader(content), stored.Size, contentType); err != nil {
+		return StoredContent{}, err
+	}
+
+	return stored, nil
+}
+
+// Removes content stored for records that weren't saved. Content is keyed by its checksum
+// and may belong to records saved before, so keys referenced reports as still in use are
+// kept. Removal goes on after ctx is cancelled, a request that timed out still cleans up
+func DiscardContent(ctx context.Context, store BlobStore, keys []string, referenced func(ctx context.Context, key string) (bool, error)) error {
+	ctx, cancel := context.WithTimeout(context.WithoutCancel(ctx), discardTimeout)
+	defer cancel()
+
+	var errs []error
+	seen := map[string]bool{}
+	for _, key := range keys {
+		if seen[key] {
+			continue
+		}
+		seen[key] = true
+
+		inUse, err := referenced(ctx, key)
+		if err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		if inUse {
+			continue
+		}
+		if err := store.Delete(ctx, key); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
+}
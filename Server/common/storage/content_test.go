@@ -0,0 +1,59 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestDiscardContent(t *testing.T) {
+	store, err := NewFileSystemStore(t.TempDir())
+	if err != nil {
+		t.Fatalf("NewFileSystemStore() error = %v", err)
+	}
+
+	keys := []string{"attachments/aa11", "attachments/bb22", "attachments/cc33"}
+	for _, key := range keys {
+		if err := store.Put(context.Background(), key, strings.NewReader(key), int64(len(key)), "application/pdf"); err != nil {
+			t.Fatalf("Put(%q) error = %v", key, err)
+		}
+	}
+
+	// The first key belongs to a record saved before, the last can't be checked
+	checked := map[string]int{}
+	failed := errors.New("database unavailable")
+	referenced := func(ctx context.Context, key string) (bool, error) {
+		checked[key]++
+		switch key {
+		case keys[0]:
+			return true, nil
+		case keys[2]:
+			return false, failed
+		}
+		return false, nil
+	}
+
+	// A cancelled request still cleans up after itself
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	err = DiscardContent(ctx, store, append(keys, keys[1]), referenced)
+	if !errors.Is(err, failed) {
+		t.Errorf("DiscardContent() error = %v, want %v", err, failed)
+	}
+
+	want := map[string]bool{keys[0]: true, keys[1]: false, keys[2]: true}
+	for key, kept := range want {
+		content, err := store.Get(context.Background(), key)
+		if err == nil {
+			content.Close()
+		}
+		if (err == nil) != kept {
+			t.Errorf("content %q kept = %v, want %v", key, err == nil, kept)
+		}
+		if checked[key] != 1 {
+			t.Errorf("content %q checked %d times, want once", key, checked[key])
+		}
+	}
+}
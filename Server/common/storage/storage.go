@@ -0,0 +1,18 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"io"
+)
+
+var ErrBlobNotFound = errors.New("blob not found")
+
+// Keeps binary content, such as request attachments and evidence, under keys chosen by the caller
+type BlobStore interface {
+	// Stores size bytes read from r under the key, replacing existing content
+	Put(ctx context.Context, key string, r io.Reader, size int64, contentType string) error
+	// Returns reader of the content stored under the key, or ErrBlobNotFound
+	Get(ctx context.Context, key string) (io.ReadCloser, error)
+	Delete(ctx context.Context, key string) error
+}
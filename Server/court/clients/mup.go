@@ -25,16 +25,14 @@ func NewMUPClient(client *http.Client, address string) MUPClient {
 // Client methods
 
 // Notifies MUP to create driving ban based on created suspension
-func (mc *MUPClient) NotifyOfSuspension(ctx context.Context, newSuspension data.NewSuspension, token string) error {
-	toDateTime, err := time.Parse("2006-01-02T15:04:05", newSuspension.To)
-	if err != nil {
-		return err
-	}
-
+func (mc *MUPClient) NotifyOfSuspension(ctx context.Context, suspension data.Suspension, token string) error {
 	drivingBan := data.DrivingBan{
-		Reason:   "License suspension",
-		Duration: toDateTime,
-		Person:   newSuspension.Person,
+		Reason:    "License suspension",
+		Start:     suspension.From,
+		End:       suspension.To,
+		Person:    suspension.Person,
+		Authority: "COURT",
+		CaseID:    suspension.ID.Hex(),
 	}
 
 	requestBody, err := json.Marshal(drivingBan)
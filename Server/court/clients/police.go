@@ -0,0 +1,88 @@
+package clients
+
+import (
+	"context"
+	"court/data"
+	"court/domain"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/url"
+	"time"
+)
+
+type PoliceClient struct {
+	client  *http.Client
+	address string
+}
+
+func NewPoliceClient(client *http.Client, address string) PoliceClient {
+	return PoliceClient{
+		client:  client,
+		address: address,
+	}
+}
+
+// Client methods
+
+// Returns evidence the police attached to the violation. The police records the
+// listing in the chain of custody of every piece of evidence under the token subject
+func (pc *PoliceClient) GetViolationEvidence(ctx context.Context, violation string, token string) ([]data.EvidenceRef, error) {
+	reqURL := pc.address + "/traffic-violation/" + violation + "/evidence"
+	resp, err := pc.get(ctx, reqURL, token)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	evidence := []data.EvidenceRef{}
+	if err := json.NewDecoder(resp.Body).Decode(&evidence); err != nil {
+		return nil, err
+	}
+
+	return evidence, nil
+}
+
+// Returns reader of the evidence content, which the caller closes, with the headers
+// the police sent it with. The police answers with status 404 unless the evidence is
+// attached to the violation, and records the download in the chain of custody
+func (pc *PoliceClient) OpenEvidence(ctx context.Context, violation, evidence string, token string) (io.ReadCloser, http.Header, error) {
+	reqURL := pc.address + "/evidence/" + url.PathEscape(evidence) + "?violation=" + url.QueryEscape(violation)
+	resp, err := pc.get(ctx, reqURL, token)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return resp.Body, resp.Header, nil
+}
+
+func (pc *PoliceClient) get(ctx context.Context, reqURL string, token string) (*http.Response, error) {
+	var timeout time.Duration
+	deadline, reqHasDeadline := ctx.Deadline()
+	if reqHasDeadline {
+		timeout = time.Until(deadline)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, reqURL, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	req.Header.Set("Authorization", "Bearer "+token)
+
+	resp, err := pc.client.Do(req)
+	if err != nil {
+		return nil, handleHttpReqErr(err, reqURL, http.MethodGet, timeout)
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		resp.Body.Close()
+		return nil, domain.ErrResp{
+			URL:        resp.Request.URL.String(),
+			Method:     resp.Request.Method,
+			StatusCode: resp.StatusCode,
+		}
+	}
+
+	return resp, nil
+}
@@ -0,0 +1,57 @@
+package data
+
+import (
+	"encoding/json"
+	"io"
+	"time"
+)
+
+// Violation whose fine the offender contested, sent over by the police with the
+// violation record and the evidence attached
+type ContestedViolation struct {
+	Fine            string          `bson:"fine" json:"fine"`
+	ReferenceNumber string          `bson:"referenceNumber" json:"referenceNumber"`
+	Amount          float64         `bson:"amount" json:"amount"`
+	Currency        string          `bson:"currency" json:"currency"`
+	Statement       string          `bson:"statement" json:"statement"`
+	SubmittedAt     time.Time       `bson:"submittedAt" json:"submittedAt"`
+	Violation       ViolationRecord `bson:"violation" json:"violation"`
+	Evidence        []EvidenceRef   `bson:"evidence" json:"evidence"`
+}
+
+// Traffic violation as the police recorded it
+type ViolationRecord struct {
+	ID           string             `bson:"id" json:"id"`
+	ViolatorJMBG string             `bson:"violatorJMBG" json:"violatorJMBG"`
+	Reason       string             `bson:"reason" json:"reason"`
+	Description  string             `bson:"description" json:"description"`
+	Time         time.Time          `bson:"time" json:"time"`
+	Location     string             `bson:"location" json:"location"`
+	Offences     []ViolationOffence `bson:"offences" json:"offences"`
+}
+
+type ViolationOffence struct {
+	Code         string  `bson:"code" json:"code"`
+	Title        string  `bson:"title" json:"title"`
+	LegalArticle string  `bson:"legalArticle" json:"legalArticle"`
+	Severity     string  `bson:"severity" json:"severity"`
+	FineMin      float64 `bson:"fineMin" json:"fineMin"`
+	FineMax      float64 `bson:"fineMax" json:"fineMax"`
+	Points       int     `bson:"points" json:"points"`
+}
+
+// Evidence kept by the police, downloaded from URL with an administrator token
+type EvidenceRef struct {
+	ID          string `bson:"id" json:"id"`
+	Kind        string `bson:"kind" json:"kind"`
+	FileName    string `bson:"fileName" json:"fileName"`
+	ContentType string `bson:"contentType" json:"contentType"`
+	Size        int64  `bson:"size" json:"size"`
+	SHA256      string `bson:"sha256" json:"sha256"`
+	URL         string `bson:"url" json:"url"`
+}
+
+func (cv *ContestedViolation) FromJSON(r io.Reader) error {
+	d := json.NewDecoder(r)
+	return d.Decode(cv)
+}
@@ -28,6 +28,12 @@ type CourtHearingPerson struct {
 	DateTime time.Time          `bson:"dateTime" json:"dateTime"`
 	Court    primitive.ObjectID `bson:"court" json:"court"`
 	Person   string             `bson:"person" json:"person"`
+	// Police fine the hearing was scheduled for, if it was scheduled for an unpaid fine
+	Fine string `bson:"fine,omitempty" json:"fine,omitempty"`
+	// Police traffic violation the hearing is about, its evidence is fetched from the police
+	Violation string `bson:"violation,omitempty" json:"violation,omitempty"`
+	// Violation record and evidence, if the offender contested the fine
+	ContestedViolation *ContestedViolation `bson:"contestedViolation,omitempty" json:"contestedViolation,omitempty"`
 }
 
 type CourtHearingLegalEntity struct {
@@ -297,6 +297,33 @@ func (cr *CourtRepo) GetSuspensionByJMBG(jmbg string) (Suspension, error) {
 	return suspension, nil
 }
 
+// Finds suspension based on provided id
+func (cr *CourtRepo) GetSuspensionByID(id string) (Suspension, error) {
+	collection := cr.getSuspensionsCollection()
+
+	objID, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return Suspension{}, err
+	}
+
+	filter := bson.M{"_id": objID}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	var suspension Suspension
+
+	err = collection.FindOne(ctx, filter).Decode(&suspension)
+	if err != nil {
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			return Suspension{}, errors.New("suspension not found")
+		}
+		return Suspension{}, err
+	}
+
+	return suspension, nil
+}
+
 // Finds warrants based on provided JMBG
 func (cr *CourtRepo) GetWarrantsByJMBG(jmbg string) (Warrants, error) {
 	collection := cr.getWarrantsCollection()
@@ -326,6 +353,41 @@ func (cr *CourtRepo) GetWarrantsByJMBG(jmbg string) (Warrants, error) {
 	return warrants, nil
 }
 
+// Marks the active warrant executed by the officer
+func (cr *CourtRepo) ExecuteWarrant(id string, execution WarrantExecution) (Warrant, error) {
+	collection := cr.getWarrantsCollection()
+
+	objID, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return Warrant{}, ErrWarrantNotFound
+	}
+
+	filter := bson.M{"_id": objID, "executedAt": bson.M{"$exists": false}}
+	update := bson.M{"$set": bson.M{"executedAt": execution.ExecutedAt, "executedBy": execution.ExecutedBy}}
+	opts := options.FindOneAndUpdate().SetReturnDocument(options.After)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	var warrant Warrant
+	err = collection.FindOneAndUpdate(ctx, filter, update, opts).Decode(&warrant)
+	if err != nil {
+		if !errors.Is(err, mongo.ErrNoDocuments) {
+			return Warrant{}, err
+		}
+		count, err := collection.CountDocuments(ctx, bson.M{"_id": objID})
+		if err != nil {
+			return Warrant{}, err
+		}
+		if count == 0 {
+			return Warrant{}, ErrWarrantNotFound
+		}
+		return Warrant{}, ErrWarrantExecuted
+	}
+
+	return warrant, nil
+}
+
 // Inserts new court hearing for a person into collection
 func (cr *CourtRepo) CreateHearingPerson(newHearing NewCourtHearingPerson) error {
 	collection := cr.getHearingsPersonCollection()
@@ -343,11 +405,12 @@ func (cr *CourtRepo) CreateHearingPerson(newHearing NewCourtHearingPerson) error
 	}
 
 	hearing := CourtHearingPerson{
-		ID:       primitive.NewObjectID(),
-		Reason:   newHearing.Reason,
-		DateTime: dateTime,
-		Court:    courtID,
-		Person:   newHearing.Person,
+		ID:        primitive.NewObjectID(),
+		Reason:    newHearing.Reason,
+		DateTime:  dateTime,
+		Court:     courtID,
+		Person:    newHearing.Person,
+		Violation: newHearing.Violation,
 	}
 
 	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
@@ -363,6 +426,69 @@ func (cr *CourtRepo) CreateHearingPerson(newHearing NewCourtHearingPerson) error
 	return nil
 }
 
+// Finds the hearing scheduled for the unpaid police fine
+func (cr *CourtRepo) GetHearingByFine(fine string) (CourtHearingPerson, error) {
+	collection := cr.getHearingsPersonCollection()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	var hearing CourtHearingPerson
+	err := collection.FindOne(ctx, bson.M{"fine": fine}).Decode(&hearing)
+	if err != nil {
+		return CourtHearingPerson{}, err
+	}
+
+	return hearing, nil
+}
+
+// Returns the hearing scheduled on a crime report of the police traffic violation
+func (cr *CourtRepo) GetHearingByReportedViolation(violation string) (CourtHearingPerson, error) {
+	collection := cr.getHearingsPersonCollection()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	// Hearings of fines carry the violation too, reported violations have no fine
+	filter := bson.M{"violation": violation, "fine": bson.M{"$exists": false}}
+
+	var hearing CourtHearingPerson
+	err := collection.FindOne(ctx, filter).Decode(&hearing)
+	if err != nil {
+		return CourtHearingPerson{}, err
+	}
+
+	return hearing, nil
+}
+
+// Schedules a hearing for the police fine, unpaid or contested, unless one was already scheduled for it
+func (cr *CourtRepo) CreateFineHearing(hearing *CourtHearingPerson) error {
+	collection := cr.getHearingsPersonCollection()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	filter := bson.M{"fine": hearing.Fine}
+	insert := bson.M{
+		"_id":      primitive.NewObjectID(),
+		"reason":   hearing.Reason,
+		"dateTime": hearing.DateTime,
+		"court":    hearing.Court,
+		"person":   hearing.Person,
+		"fine":     hearing.Fine,
+	}
+	if hearing.Violation != "" {
+		insert["violation"] = hearing.Violation
+	}
+	if hearing.ContestedViolation != nil {
+		insert["contestedViolation"] = hearing.ContestedViolation
+	}
+	update := bson.M{"$setOnInsert": insert}
+	opts := options.FindOneAndUpdate().SetUpsert(true).SetReturnDocument(options.After)
+
+	return collection.FindOneAndUpdate(ctx, filter, update, opts).Decode(hearing)
+}
+
 // Inserts new court hearing for a legal entity into collection
 func (cr *CourtRepo) CreateHearingLegalEntity(newHearing NewCourtHearingLegalEntity) error {
 	collection := cr.getHearingsLegalEntityCollection()
@@ -430,19 +556,19 @@ func (cr *CourtRepo) CreateWarrant(newWarrant NewWarrant) error {
 }
 
 // Inserts a new suspension into collection
-func (cr *CourtRepo) CreateSuspension(newSuspension NewSuspension) error {
+func (cr *CourtRepo) CreateSuspension(newSuspension NewSuspension) (Suspension, error) {
 	collection := cr.getSuspensionsCollection()
 
 	fromDateTime, err := time.Parse("2006-01-02T15:04:05", newSuspension.From)
 	if err != nil {
 		cr.logger.Println("Error while parsing date")
-		return err
+		return Suspension{}, err
 	}
 
 	toDateTime, err := time.Parse("2006-01-02T15:04:05", newSuspension.To)
 	if err != nil {
 		cr.logger.Println("Error while parsing date")
-		return err
+		return Suspension{}, err
 	}
 
 	suspension := Suspension{
@@ -457,11 +583,11 @@ func (cr *CourtRepo) CreateSuspension(newSuspension NewSuspension) error {
 
 	_, err = collection.InsertOne(ctx, suspension)
 	if err != nil {
-		cr.logger.Fatalln("Failed to insert new suspension")
-		return err
+		cr.logger.Println("Failed to insert new suspension")
+		return Suspension{}, err
 	}
 
-	return nil
+	return suspension, nil
 }
 
 // Reschedules court hearing for a later date and time
@@ -546,6 +672,49 @@ func (cr *CourtRepo) RescheduleCourtHearingLegalEntity(rescheduledHearing Resche
 	return errors.New("invalid hearing ID")
 }
 
+// Finds all document signing keys, newest first
+func (cr *CourtRepo) GetSigningKeys(ctx context.Context) (SigningKeys, error) {
+	collection := cr.getSigningKeysCollection()
+
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	opts := options.Find().SetSort(bson.M{"createdAt": -1})
+	cursor, err := collection.Find(ctx, bson.M{}, opts)
+	if err != nil {
+		return nil, err
+	}
+
+	keys := SigningKeys{}
+	if err := cursor.All(ctx, &keys); err != nil {
+		return nil, err
+	}
+
+	return keys, nil
+}
+
+// Inserts new active signing key and retires the previous ones, dropping their private parts
+func (cr *CourtRepo) RotateSigningKey(ctx context.Context, key SigningKey) error {
+	collection := cr.getSigningKeysCollection()
+
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	_, err := collection.InsertOne(ctx, key)
+	if err != nil {
+		return err
+	}
+
+	filter := bson.M{"_id": bson.M{"$ne": key.ID}, "active": true}
+	update := bson.M{
+		"$set":   bson.M{"active": false, "retiredAt": time.Now()},
+		"$unset": bson.M{"privateKey": ""},
+	}
+
+	_, err = collection.UpdateMany(ctx, filter, update)
+	return err
+}
+
 // Getters for collections
 
 func (cr *CourtRepo) getCourtCollection() *mongo.Collection {
@@ -567,3 +736,7 @@ func (cr *CourtRepo) getWarrantsCollection() *mongo.Collection {
 func (cr *CourtRepo) getSuspensionsCollection() *mongo.Collection {
 	return cr.cli.Database("courtDB").Collection("suspensions")
 }
+
+func (cr *CourtRepo) getSigningKeysCollection() *mongo.Collection {
+	return cr.cli.Database("courtDB").Collection("signingKeys")
+}
@@ -6,10 +6,14 @@ import (
 	"time"
 )
 
+// Driving ban MUP issues for a suspension, CaseID is the ID of the suspension
 type DrivingBan struct {
-	Reason   string    `bson:"reason" json:"reason"`
-	Duration time.Time `bson:"duration" json:"duration"`
-	Person   string    `bson:"person" json:"person"`
+	Reason    string    `bson:"reason" json:"reason"`
+	Start     time.Time `bson:"start" json:"start"`
+	End       time.Time `bson:"end" json:"end"`
+	Person    string    `bson:"person" json:"person"`
+	Authority string    `bson:"authority" json:"authority"`
+	CaseID    string    `bson:"caseID" json:"caseID"`
 }
 
 func (db *DrivingBan) ToJSON(w io.Writer) error {
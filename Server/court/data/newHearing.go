@@ -6,10 +6,11 @@ import (
 )
 
 type NewCourtHearingPerson struct {
-	Reason   string `bson:"reason" json:"reason"`
-	DateTime string `bson:"dateTime" json:"dateTime"`
-	Court    string `bson:"court" json:"court"`
-	Person   string `bson:"person" json:"person"`
+	Reason    string `bson:"reason" json:"reason"`
+	DateTime  string `bson:"dateTime" json:"dateTime"`
+	Court     string `bson:"court" json:"court"`
+	Person    string `bson:"person" json:"person"`
+	Violation string `bson:"violation,omitempty" json:"violation,omitempty"`
 }
 
 type NewCourtHearingLegalEntity struct {
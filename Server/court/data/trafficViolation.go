@@ -6,6 +6,7 @@ import (
 )
 
 type TrafficViolation struct {
+	ID           string `bson:"id" json:"id"`
 	Reason       string `bson:"reason" json:"reason"`
 	Description  string `bson:"description" json:"description"`
 	Time         string `bson:"time" json:"time"`
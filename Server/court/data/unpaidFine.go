@@ -0,0 +1,24 @@
+package data
+
+import (
+	"encoding/json"
+	"io"
+	"time"
+)
+
+// Fine the police sends over once the offender didn't pay it by its due date
+type UnpaidFine struct {
+	Fine            string    `json:"fine"`
+	Violation       string    `json:"violation"`
+	ViolatorJMBG    string    `json:"violatorJMBG"`
+	Reason          string    `json:"reason"`
+	Amount          float64   `json:"amount"`
+	Currency        string    `json:"currency"`
+	ReferenceNumber string    `json:"referenceNumber"`
+	DueDate         time.Time `json:"dueDate"`
+}
+
+func (uf *UnpaidFine) FromJSON(r io.Reader) error {
+	d := json.NewDecoder(r)
+	return d.Decode(uf)
+}
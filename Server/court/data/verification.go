@@ -0,0 +1,40 @@
+package data
+
+import (
+	"common/signing"
+	"encoding/json"
+	"io"
+	"time"
+)
+
+const (
+	DocumentHearingSummons   = "hearingSummons"
+	DocumentSuspensionRuling = "suspensionRuling"
+)
+
+// Minimal disclosure about a document, returned by the public verification endpoints.
+// Only validity is reported for tokens that fail signature verification
+type DocumentVerification struct {
+	Valid          bool      `json:"valid"`
+	Expired        bool      `json:"expired,omitempty"`
+	Document       string    `json:"document,omitempty"`
+	Number         string    `json:"number,omitempty"`
+	Holder         string    `json:"holder,omitempty"`
+	ExpirationDate time.Time `json:"expirationDate,omitempty"`
+}
+
+// Signed token of a document together with the link the token is verified at
+type SignedDocument struct {
+	Token     string `json:"token"`
+	VerifyURL string `json:"verifyURL"`
+}
+
+// Keys the documents are signed with, kept by the repo for the shared signer
+type SigningKey = signing.Key
+
+type SigningKeys = signing.Keys
+
+func (dv *DocumentVerification) ToJSON(w io.Writer) error {
+	e := json.NewEncoder(w)
+	return e.Encode(dv)
+}
@@ -2,21 +2,36 @@ package data
 
 import (
 	"encoding/json"
+	"errors"
 	"io"
 	"time"
 
 	"go.mongodb.org/mongo-driver/bson/primitive"
 )
 
+var (
+	ErrWarrantNotFound = errors.New("warrant not found")
+	ErrWarrantExecuted = errors.New("warrant is already executed")
+)
+
+// Warrant is active until it is executed, when the officer who executed it is recorded
 type Warrant struct {
 	ID               primitive.ObjectID `bson:"_id" json:"id"`
 	TrafficViolation primitive.ObjectID `bson:"trafficViolation" json:"trafficViolation"`
 	IssuedOn         time.Time          `bson:"issuedOn" json:"issuedOn"`
 	IssuedFor        string             `bson:"issuedFor" json:"issuedFor"`
+	ExecutedAt       *time.Time         `bson:"executedAt,omitempty" json:"executedAt,omitempty"`
+	ExecutedBy       string             `bson:"executedBy,omitempty" json:"executedBy,omitempty"`
 }
 
 type Warrants []Warrant
 
+// Officer who executed the warrant and when, now if ExecutedAt is empty
+type WarrantExecution struct {
+	ExecutedBy string    `json:"executedBy"`
+	ExecutedAt time.Time `json:"executedAt"`
+}
+
 type NewWarrant struct {
 	TrafficViolation string `bson:"trafficViolation" json:"trafficViolation"`
 	IssuedFor        string `bson:"issuedFor" json:"issuedFor"`
@@ -51,3 +66,8 @@ func (nw *NewWarrant) FromJSON(r io.Reader) error {
 	d := json.NewDecoder(r)
 	return d.Decode(nw)
 }
+
+func (we *WarrantExecution) FromJSON(r io.Reader) error {
+	d := json.NewDecoder(r)
+	return d.Decode(we)
+}
@@ -0,0 +1,42 @@
+package documents
+
+import (
+	"court/data"
+	"fmt"
+	"io"
+	"time"
+)
+
+// Writes summons (poziv) to a court hearing
+func HearingSummons(w io.Writer, hearing data.CourtHearing, court data.Court, subjectName, subjectID, verifyURL string) error {
+	doc := newDocument(court.Name, "Poziv za raspravu")
+
+	doc.section("Rasprava")
+	doc.field("Broj predmeta", hearing.GetID().Hex())
+	doc.field("Razlog", hearing.GetReason())
+	doc.field("Datum i vreme", formatDateTime(hearing.GetDateTime()))
+	doc.field("Sud", court.Name)
+	doc.field("Adresa suda", formatAddress(court.Address))
+
+	doc.section("Pozvani")
+	doc.field("Naziv / ime i prezime", subjectName)
+	doc.field("JMBG / MB", subjectID)
+
+	doc.section("Pouka")
+	doc.paragraph("Pozvani je dužan da se odazove pozivu i da sa sobom ponese ličnu ispravu. " +
+		"Ukoliko se ne odazove pozivu, a izostanak ne opravda, sud može narediti prinudno dovođenje.")
+	doc.field("Datum izdavanja", formatDate(time.Now()))
+
+	if err := doc.verificationCode(verifyURL); err != nil {
+		return err
+	}
+
+	return doc.write(w)
+}
+
+func formatAddress(address data.Address) string {
+	if address.StreetName == "" {
+		return address.Locality
+	}
+	return fmt.Sprintf("%s %d, %s", address.StreetName, address.StreetNumber, address.Locality)
+}
@@ -0,0 +1,107 @@
+package documents
+
+import (
+	"bytes"
+	"io"
+	"strings"
+	"time"
+
+	"github.com/go-pdf/fpdf"
+	"github.com/skip2/go-qrcode"
+)
+
+const (
+	dateFormat     = "02.01.2006"
+	dateTimeFormat = "02.01.2006 u 15:04"
+)
+
+// Core PDF fonts are cp1252, which has no glyphs for č, ć and đ
+var latinReplacer = strings.NewReplacer("Č", "C", "č", "c", "Ć", "C", "ć", "c", "Đ", "Dj", "đ", "dj")
+
+// Document being written, with the text translated into the font encoding
+type document struct {
+	pdf *fpdf.Fpdf
+	tr  func(string) string
+}
+
+func newDocument(issuer, title string) *document {
+	pdf := fpdf.New("P", "mm", "A4", "")
+	pdf.SetTitle(title, true)
+	pdf.SetCreator(issuer, true)
+	pdf.AddPage()
+
+	cp1252 := pdf.UnicodeTranslatorFromDescriptor("")
+	doc := &document{
+		pdf: pdf,
+		tr: func(s string) string {
+			return cp1252(latinReplacer.Replace(s))
+		},
+	}
+
+	pdf.SetFont("Helvetica", "", 10)
+	pdf.CellFormat(0, 6, doc.tr("Republika Srbija"), "", 1, "L", false, 0, "")
+	pdf.CellFormat(0, 6, doc.tr(issuer), "", 1, "L", false, 0, "")
+	pdf.Ln(8)
+
+	pdf.SetFont("Helvetica", "B", 16)
+	pdf.CellFormat(0, 10, doc.tr(title), "", 1, "C", false, 0, "")
+	pdf.Ln(6)
+
+	return doc
+}
+
+func (d *document) section(title string) {
+	d.pdf.Ln(4)
+	d.pdf.SetFont("Helvetica", "B", 12)
+	d.pdf.CellFormat(0, 8, d.tr(title), "B", 1, "L", false, 0, "")
+	d.pdf.Ln(2)
+}
+
+func (d *document) field(label, value string) {
+	d.pdf.SetFont("Helvetica", "B", 10)
+	d.pdf.CellFormat(60, 7, d.tr(label), "", 0, "L", false, 0, "")
+	d.pdf.SetFont("Helvetica", "", 10)
+	d.pdf.MultiCell(0, 7, d.tr(value), "", "L", false)
+}
+
+func (d *document) paragraph(text string) {
+	d.pdf.SetFont("Helvetica", "", 10)
+	d.pdf.MultiCell(0, 6, d.tr(text), "", "L", false)
+}
+
+// Draws QR code linking to the verification URL at the bottom of the page
+func (d *document) verificationCode(verifyURL string) error {
+	png, err := qrcode.Encode(verifyURL, qrcode.Medium, 256)
+	if err != nil {
+		return err
+	}
+
+	d.pdf.RegisterImageOptionsReader("verification", fpdf.ImageOptions{ImageType: "PNG"}, bytes.NewReader(png))
+
+	_, pageHeight := d.pdf.GetPageSize()
+	d.pdf.ImageOptions("verification", 15, pageHeight-60, 40, 40, false, fpdf.ImageOptions{ImageType: "PNG"}, 0, verifyURL)
+
+	d.pdf.SetXY(60, pageHeight-50)
+	d.pdf.SetFont("Helvetica", "", 8)
+	d.pdf.MultiCell(0, 4, d.tr("Autentičnost dokumenta proverite skeniranjem QR koda ili na adresi:\n"+verifyURL), "", "L", false)
+
+	return nil
+}
+
+func (d *document) write(w io.Writer) error {
+	return d.pdf.Output(w)
+}
+
+func formatDate(t time.Time) string {
+	if t.IsZero() {
+		return "-"
+	}
+	return t.Format(dateFormat)
+}
+
+func formatDateTime(t time.Time) string {
+	if t.IsZero() {
+		return "-"
+	}
+	return t.Format(dateTimeFormat)
+}
@@ -2,11 +2,15 @@ module court
 
 go 1.22.1
 
-require github.com/gorilla/handlers v1.5.2
+require (
+	github.com/go-pdf/fpdf v0.9.0
+	github.com/gorilla/handlers v1.5.2
+	github.com/skip2/go-qrcode v0.0.0-20200617195104-da1b6568686e
+)
 
 require (
 	github.com/golang/snappy v0.0.1 // indirect
-	github.com/klauspost/compress v1.13.6 // indirect
+	github.com/klauspost/compress v1.17.4 // indirect
 	github.com/montanaflynn/stats v0.0.0-20171201202039-1bf9dbcd8cbe // indirect
 	github.com/xdg-go/pbkdf2 v1.0.0 // indirect
 	github.com/xdg-go/scram v1.1.2 // indirect
@@ -18,8 +22,11 @@ require (
 )
 
 require (
+	common v0.0.0
 	github.com/felixge/httpsnoop v1.0.3 // indirect
 	github.com/golang-jwt/jwt v3.2.2+incompatible
 	github.com/gorilla/mux v1.8.1
 	go.mongodb.org/mongo-driver v1.14.0
 )
+
+replace common => ../common
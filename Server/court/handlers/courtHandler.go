@@ -1,10 +1,12 @@
 package handlers
 
 import (
+	"common/signing"
 	"context"
 	"court/clients"
 	"court/data"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"log"
 	"net/http"
@@ -13,12 +15,16 @@ import (
 	"github.com/golang-jwt/jwt"
 	"github.com/gorilla/mux"
 	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
 )
 
 type CourtHandler struct {
-	repo *data.CourtRepo
-	sso  clients.SSOClient
-	mup  clients.MUPClient
+	repo      *data.CourtRepo
+	sso       clients.SSOClient
+	mup       clients.MUPClient
+	police    clients.PoliceClient
+	signer    *signing.Signer
+	publicURL string
 }
 
 var secretKey = []byte("eUpravaT2")
@@ -26,9 +32,9 @@ var secretKey = []byte("eUpravaT2")
 const InvalidRequestBody = "Invalid request body"
 const InvalidRequestBodyError = "Error while decoding body"
 
-// Constructor
-func NewCourtHandler(r *data.CourtRepo, s clients.SSOClient, m clients.MUPClient) *CourtHandler {
-	return &CourtHandler{r, s, m}
+// Constructor. publicURL is the address the service is reachable at from outside, used in verification links
+func NewCourtHandler(r *data.CourtRepo, s clients.SSOClient, m clients.MUPClient, p clients.PoliceClient, signer *signing.Signer, publicURL string) *CourtHandler {
+	return &CourtHandler{r, s, m, p, signer, publicURL}
 }
 
 // Ping
@@ -297,6 +303,48 @@ func (ch *CourtHandler) CheckForWarrants(w http.ResponseWriter, r *http.Request)
 	}
 }
 
+// Marks the warrant executed by the officer who found the person it was issued for
+func (ch *CourtHandler) ExecuteWarrant(w http.ResponseWriter, r *http.Request) {
+	params := mux.Vars(r)
+	id := params["id"]
+
+	log.Printf("Executing warrant: %s", id)
+
+	var execution data.WarrantExecution
+	if err := execution.FromJSON(r.Body); err != nil {
+		http.Error(w, InvalidRequestBody, http.StatusBadRequest)
+		log.Println(InvalidRequestBodyError)
+		return
+	}
+	if execution.ExecutedBy == "" {
+		http.Error(w, "Field executedBy is required", http.StatusBadRequest)
+		return
+	}
+	if execution.ExecutedAt.IsZero() {
+		execution.ExecutedAt = time.Now()
+	}
+
+	warrant, err := ch.repo.ExecuteWarrant(id, execution)
+	if err != nil {
+		switch {
+		case errors.Is(err, data.ErrWarrantNotFound):
+			http.Error(w, "Warrant not found", http.StatusNotFound)
+		case errors.Is(err, data.ErrWarrantExecuted):
+			http.Error(w, err.Error(), http.StatusConflict)
+		default:
+			http.Error(w, "Failed to execute warrant", http.StatusInternalServerError)
+			log.Printf("Failed to execute warrant: %s", err.Error())
+		}
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	warrant.ToJSON(w)
+
+	log.Println("Successfully executed warrant")
+}
+
 func (ch *CourtHandler) CheckForSuspension(w http.ResponseWriter, r *http.Request) {
 	params := mux.Vars(r)
 	jmbg := params["jmbg"]
@@ -336,7 +384,7 @@ func (ch *CourtHandler) CreateSuspension(w http.ResponseWriter, r *http.Request)
 		return
 	}
 
-	err := ch.repo.CreateSuspension(newSuspension)
+	suspension, err := ch.repo.CreateSuspension(newSuspension)
 	if err != nil {
 		http.Error(w, "Failed to create new suspension", http.StatusInternalServerError)
 		log.Printf("Failed to create new suspension: %s", err.Error())
@@ -350,7 +398,7 @@ func (ch *CourtHandler) CreateSuspension(w http.ResponseWriter, r *http.Request)
 
 	log.Println("Notifying MUP of suspension")
 
-	err = ch.mup.NotifyOfSuspension(ctx, newSuspension, token)
+	err = ch.mup.NotifyOfSuspension(ctx, suspension, token)
 	if err != nil {
 		http.Error(w, "Error with services communication", http.StatusInternalServerError)
 		log.Printf("Error while communicating with MUP service: %s", err.Error())
@@ -371,6 +419,21 @@ func (ch *CourtHandler) RecieveCrimeReport(w http.ResponseWriter, r *http.Reques
 		return
 	}
 
+	// The police retry reports they didn't get an answer to, a violation already
+	// reported keeps the hearing scheduled for it the first time
+	if trafficViolation.ID != "" {
+		_, err := ch.repo.GetHearingByReportedViolation(trafficViolation.ID)
+		if err == nil {
+			w.WriteHeader(http.StatusOK)
+			log.Printf("Crime report of violation %s already received", trafficViolation.ID)
+			return
+		} else if !errors.Is(err, mongo.ErrNoDocuments) {
+			http.Error(w, "Error while retrieving hearing", http.StatusInternalServerError)
+			log.Printf("Error while retrieving hearing for violation: %s", err.Error())
+			return
+		}
+	}
+
 	ctx, cancel := context.WithTimeout(r.Context(), 4*time.Second)
 	defer cancel()
 
@@ -385,10 +448,11 @@ func (ch *CourtHandler) RecieveCrimeReport(w http.ResponseWriter, r *http.Reques
 	courtID, _ := primitive.ObjectIDFromHex("64c13ab08edf48a008793cac")
 
 	courtHearing := data.NewCourtHearingPerson{
-		Reason:   trafficViolation.Reason,
-		DateTime: time.Now().Add(72 * time.Hour).Format("2006-01-02T15:04:05"),
-		Court:    courtID.Hex(),
-		Person:   person.Account.ID.Hex(),
+		Reason:    trafficViolation.Reason,
+		DateTime:  time.Now().Add(72 * time.Hour).Format("2006-01-02T15:04:05"),
+		Court:     courtID.Hex(),
+		Person:    person.Account.ID.Hex(),
+		Violation: trafficViolation.ID,
 	}
 
 	err = ch.repo.CreateHearingPerson(courtHearing)
@@ -402,6 +466,102 @@ func (ch *CourtHandler) RecieveCrimeReport(w http.ResponseWriter, r *http.Reques
 	log.Println("Successfully scheduled court hearing after crime report")
 }
 
+// Schedules a hearing for a fine the police didn't get paid by its due date.
+// The police may send the same fine again, it gets the hearing scheduled the first time
+func (ch *CourtHandler) RecieveUnpaidFine(w http.ResponseWriter, r *http.Request) {
+	log.Println("Recieved unpaid fine")
+
+	var unpaidFine data.UnpaidFine
+	if err := unpaidFine.FromJSON(r.Body); err != nil {
+		http.Error(w, InvalidRequestBody, http.StatusBadRequest)
+		log.Println(InvalidRequestBody)
+		return
+	}
+	if unpaidFine.Fine == "" || unpaidFine.ViolatorJMBG == "" {
+		http.Error(w, "Fine and violator are required", http.StatusBadRequest)
+		return
+	}
+
+	ch.scheduleFineHearing(w, r, unpaidFine.ViolatorJMBG, data.CourtHearingPerson{
+		Reason:    fmt.Sprintf("%s (%.2f %s)", unpaidFine.Reason, unpaidFine.Amount, unpaidFine.Currency),
+		DateTime:  time.Now().AddDate(0, 0, 30).Truncate(time.Hour),
+		Fine:      unpaidFine.Fine,
+		Violation: unpaidFine.Violation,
+	})
+}
+
+// Schedules a hearing for a violation whose fine the offender contested, with the
+// violation record and the evidence attached to it. The police may send the same
+// fine again, it gets the hearing scheduled the first time
+func (ch *CourtHandler) RecieveContestedViolation(w http.ResponseWriter, r *http.Request) {
+	log.Println("Recieved contested violation")
+
+	var contested data.ContestedViolation
+	if err := contested.FromJSON(r.Body); err != nil {
+		http.Error(w, InvalidRequestBody, http.StatusBadRequest)
+		log.Println(InvalidRequestBody)
+		return
+	}
+	if contested.Fine == "" || contested.Violation.ViolatorJMBG == "" {
+		http.Error(w, "Fine and violator are required", http.StatusBadRequest)
+		return
+	}
+	if contested.Evidence == nil {
+		contested.Evidence = []data.EvidenceRef{}
+	}
+
+	ch.scheduleFineHearing(w, r, contested.Violation.ViolatorJMBG, data.CourtHearingPerson{
+		Reason:             fmt.Sprintf("Prigovor na prekršajni nalog %s: %s", contested.ReferenceNumber, contested.Violation.Reason),
+		DateTime:           time.Now().AddDate(0, 0, 15).Truncate(time.Hour),
+		Fine:               contested.Fine,
+		Violation:          contested.Violation.ID,
+		ContestedViolation: &contested,
+	})
+}
+
+// Schedules the hearing in the default court for the person with the JMBG and writes
+// its ID, or the ID of the hearing already scheduled for the fine
+func (ch *CourtHandler) scheduleFineHearing(w http.ResponseWriter, r *http.Request, jmbg string, hearing data.CourtHearingPerson) {
+	existing, err := ch.repo.GetHearingByFine(hearing.Fine)
+	if err == nil {
+		writeHearingID(w, existing.ID)
+		return
+	} else if !errors.Is(err, mongo.ErrNoDocuments) {
+		http.Error(w, "Error while retrieving hearing", http.StatusInternalServerError)
+		log.Printf("Error while retrieving hearing for fine: %s", err.Error())
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(r.Context(), 4*time.Second)
+	defer cancel()
+
+	token := ch.extractTokenFromHeader(r)
+	person, err := ch.sso.GetPersonByJMBG(ctx, jmbg, token)
+	if err != nil {
+		http.Error(w, "Error with services communication", http.StatusInternalServerError)
+		log.Printf("Error while communicating with SSO service: %s", err.Error())
+		return
+	}
+
+	hearing.Court, _ = primitive.ObjectIDFromHex("64c13ab08edf48a008793cac")
+	hearing.Person = person.Account.ID.Hex()
+
+	if err := ch.repo.CreateFineHearing(&hearing); err != nil {
+		http.Error(w, "Error while creating new hearing", http.StatusInternalServerError)
+		log.Printf("Error while creating hearing for fine: %s", err.Error())
+		return
+	}
+
+	log.Printf("Successfully scheduled court hearing for fine %s", hearing.Fine)
+	writeHearingID(w, hearing.ID)
+}
+
+func writeHearingID(w http.ResponseWriter, id primitive.ObjectID) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(map[string]string{"id": id.Hex()})
+}
+
 // Helper function for parsing court hearing interface into structs.
 // Retrieves court hearing from repo and converts it
 func (ch *CourtHandler) getHearing(id string) (data.CourtHearing, error) {
@@ -0,0 +1,254 @@
+package handlers
+
+import (
+	"bytes"
+	"common/signing"
+	"court/data"
+	"court/documents"
+	"encoding/json"
+	"errors"
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/golang-jwt/jwt"
+	"github.com/gorilla/mux"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// Returns hearing summons as PDF to the summoned person or legal entity, or to an admin
+func (ch *CourtHandler) GetHearingSummons(w http.ResponseWriter, r *http.Request) {
+	hearingID := mux.Vars(r)["id"]
+	tokenString := ch.extractTokenFromHeader(r)
+
+	subject, role, err := ch.getSubjectAndRole(tokenString)
+	if err != nil {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	log.Printf("Creating summons for hearing with id '%s'", hearingID)
+
+	hearing, err := ch.getHearing(hearingID)
+	if err != nil {
+		http.Error(w, "Court hearing not found", http.StatusNotFound)
+		log.Printf("Failed to retrieve court hearing: %s", err.Error())
+		return
+	}
+
+	if role != data.Admin && hearing.GetSubjet() != subject {
+		http.Error(w, "Forbidden", http.StatusForbidden)
+		return
+	}
+
+	court, err := ch.repo.GetCourtByID(hearing.GetCourt().Hex())
+	if err != nil {
+		http.Error(w, "Failed to get court", http.StatusInternalServerError)
+		log.Printf("Failed to get court: %s", err.Error())
+		return
+	}
+
+	subjectName := ch.getSubjectName(r, hearing, tokenString)
+	verifyURL := ch.publicURL + "/api/v1/verify/hearing/" + hearing.GetID().Hex()
+
+	var buf bytes.Buffer
+	if err := documents.HearingSummons(&buf, hearing, court, subjectName, hearing.GetSubjet(), verifyURL); err != nil {
+		http.Error(w, "Failed to create hearing summons", http.StatusInternalServerError)
+		log.Printf("Failed to create hearing summons: %s", err.Error())
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/pdf")
+	w.Header().Set("Content-Disposition", "inline; filename=\"poziv-"+hearing.GetID().Hex()+".pdf\"")
+	w.WriteHeader(http.StatusOK)
+	w.Write(buf.Bytes())
+
+	log.Println("Successfully created hearing summons")
+}
+
+// Public endpoint the hearing summons QR code links to
+func (ch *CourtHandler) VerifyHearingSummons(w http.ResponseWriter, r *http.Request) {
+	objectID, err := primitive.ObjectIDFromHex(mux.Vars(r)["id"])
+	if err != nil {
+		http.Error(w, "Invalid hearing ID", http.StatusBadRequest)
+		return
+	}
+
+	_, err = ch.getHearing(objectID.Hex())
+
+	verification := data.DocumentVerification{
+		Document: data.DocumentHearingSummons,
+		Number:   objectID.Hex(),
+		Valid:    err == nil,
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	if err := json.NewEncoder(w).Encode(verification); err != nil {
+		http.Error(w, "Error while encoding body", http.StatusInternalServerError)
+	}
+}
+
+// Returns full name of the summoned person or name of the legal entity,
+// falling back to the identifier if SSO is not reachable
+func (ch *CourtHandler) getSubjectName(r *http.Request, hearing data.CourtHearing, tokenString string) string {
+	switch hearing.(type) {
+	case *data.CourtHearingPerson:
+		person, err := ch.sso.GetPersonByJMBG(r.Context(), hearing.GetSubjet(), tokenString)
+		if err == nil {
+			return person.FirstName + " " + person.LastName
+		}
+		log.Printf("Failed to retrieve person: %s", err.Error())
+	case *data.CourtHearingLegalEntity:
+		legalEntity, err := ch.sso.GetLegalEntityByMB(r.Context(), hearing.GetSubjet(), tokenString)
+		if err == nil {
+			return legalEntity.Name
+		}
+		log.Printf("Failed to retrieve legal entity: %s", err.Error())
+	}
+
+	return hearing.GetSubjet()
+}
+
+// Returns subject and role of the token owner
+func (ch *CourtHandler) getSubjectAndRole(tokenString string) (string, string, error) {
+	claims := jwt.MapClaims{}
+	token, err := jwt.ParseWithClaims(tokenString, claims, func(token *jwt.Token) (interface{}, error) {
+		return secretKey, nil
+	})
+	if err != nil || !token.Valid {
+		return "", "", errors.New("invalid token")
+	}
+
+	subject, ok1 := claims["sub"].(string)
+	role, ok2 := claims["role"].(string)
+	if !ok1 || !ok2 {
+		return "", "", errors.New("invalid token claims")
+	}
+
+	return subject, role, nil
+}
+
+// Returns signed token of the suspension ruling to the suspended person or to an admin
+func (ch *CourtHandler) GetSuspensionToken(w http.ResponseWriter, r *http.Request) {
+	suspensionID := mux.Vars(r)["id"]
+	tokenString := ch.extractTokenFromHeader(r)
+
+	subject, role, err := ch.getSubjectAndRole(tokenString)
+	if err != nil {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	suspension, err := ch.repo.GetSuspensionByID(suspensionID)
+	if err != nil {
+		http.Error(w, "Suspension not found", http.StatusNotFound)
+		log.Printf("Failed to get suspension: %s", err.Error())
+		return
+	}
+
+	if role != data.Admin && suspension.Person != subject {
+		http.Error(w, "Forbidden", http.StatusForbidden)
+		return
+	}
+
+	person, err := ch.sso.GetPersonByJMBG(r.Context(), suspension.Person, tokenString)
+	if err != nil {
+		http.Error(w, "Failed to retrieve person", http.StatusInternalServerError)
+		log.Printf("Failed to retrieve person: %s", err.Error())
+		return
+	}
+	holder := signing.MaskName(person.FirstName + " " + person.LastName)
+
+	token, err := ch.signer.Sign(data.DocumentSuspensionRuling, suspension.ID.Hex(), holder, suspension.To)
+	if err != nil {
+		http.Error(w, "Failed to sign suspension ruling", http.StatusInternalServerError)
+		log.Printf("Failed to sign suspension ruling: %s", err.Error())
+		return
+	}
+
+	signed := data.SignedDocument{
+		Token:     token,
+		VerifyURL: ch.publicURL + "/api/v1/verify?token=" + token,
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	if err := json.NewEncoder(w).Encode(signed); err != nil {
+		http.Error(w, "Error while encoding body", http.StatusInternalServerError)
+	}
+
+	log.Println("Successfully signed suspension ruling")
+}
+
+// Public endpoint for signed ruling tokens, discloses only validity, expiry and masked holder name
+func (ch *CourtHandler) VerifyDocument(w http.ResponseWriter, r *http.Request) {
+	token := r.URL.Query().Get("token")
+	if token == "" {
+		http.Error(w, "Missing token", http.StatusBadRequest)
+		return
+	}
+
+	verification := data.DocumentVerification{Valid: false}
+
+	claims, err := ch.signer.Verify(r.Context(), token)
+	switch {
+	case errors.Is(err, signing.ErrInvalidToken):
+		// Forged or malformed tokens disclose nothing
+	case err != nil && !errors.Is(err, signing.ErrTokenExpired):
+		http.Error(w, "Failed to verify document", http.StatusInternalServerError)
+		log.Printf("Failed to verify document: %s", err.Error())
+		return
+	case claims.Document == data.DocumentSuspensionRuling:
+		verification = data.DocumentVerification{
+			Expired:        errors.Is(err, signing.ErrTokenExpired),
+			Document:       claims.Document,
+			Number:         claims.Subject,
+			Holder:         claims.Holder,
+			ExpirationDate: time.Unix(claims.ExpiresAt, 0),
+		}
+
+		_, err := ch.repo.GetSuspensionByID(claims.Subject)
+		verification.Valid = err == nil && !verification.Expired
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	if err := verification.ToJSON(w); err != nil {
+		http.Error(w, "Error while encoding body", http.StatusInternalServerError)
+	}
+}
+
+// Public keys for verifying ruling tokens offline
+func (ch *CourtHandler) GetSigningKeys(w http.ResponseWriter, r *http.Request) {
+	keys, err := ch.signer.PublicKeys(r.Context())
+	if err != nil {
+		http.Error(w, "Failed to retrieve signing keys", http.StatusInternalServerError)
+		log.Printf("Failed to retrieve signing keys: %s", err.Error())
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	if err := json.NewEncoder(w).Encode(keys); err != nil {
+		http.Error(w, "Error while encoding body", http.StatusInternalServerError)
+	}
+}
+
+// Replaces the active signing key, tokens signed with the old key stay valid
+func (ch *CourtHandler) RotateSigningKey(w http.ResponseWriter, r *http.Request) {
+	key, err := ch.signer.Rotate(r.Context())
+	if err != nil {
+		http.Error(w, "Failed to rotate signing key", http.StatusInternalServerError)
+		log.Printf("Failed to rotate signing key: %s", err.Error())
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	if err := json.NewEncoder(w).Encode(key); err != nil {
+		http.Error(w, "Error while encoding body", http.StatusInternalServerError)
+	}
+
+	log.Printf("Signing key rotated, new key is '%s'", key.ID)
+}
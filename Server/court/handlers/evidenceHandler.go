@@ -0,0 +1,96 @@
+package handlers
+
+import (
+	"context"
+	"court/data"
+	"court/domain"
+	"encoding/json"
+	"errors"
+	"io"
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/gorilla/mux"
+)
+
+// Lists evidence the police attached to the violation the hearing is about
+func (ch *CourtHandler) GetHearingEvidence(w http.ResponseWriter, r *http.Request) {
+	violation, ok := ch.getHearingViolation(w, mux.Vars(r)["id"])
+	if !ok {
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(r.Context(), 4*time.Second)
+	defer cancel()
+
+	evidence, err := ch.police.GetViolationEvidence(ctx, violation, ch.extractTokenFromHeader(r))
+	if err != nil {
+		http.Error(w, "Error with services communication", http.StatusInternalServerError)
+		log.Printf("Error while communicating with police service: %s", err.Error())
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	if err := json.NewEncoder(w).Encode(evidence); err != nil {
+		log.Printf("Error while encoding evidence: %s", err.Error())
+	}
+}
+
+// Streams evidence of the hearing from the police. The police records the download
+// under the judge whose token is passed on
+func (ch *CourtHandler) DownloadHearingEvidence(w http.ResponseWriter, r *http.Request) {
+	params := mux.Vars(r)
+	violation, ok := ch.getHearingViolation(w, params["id"])
+	if !ok {
+		return
+	}
+
+	// The police checks the evidence belongs to the violation, so listing it isn't recorded
+	// in the chain of custody of every other piece on each download
+	content, header, err := ch.police.OpenEvidence(r.Context(), violation, params["evidenceID"], ch.extractTokenFromHeader(r))
+	if err != nil {
+		var respErr domain.ErrResp
+		errors.As(err, &respErr)
+		switch respErr.StatusCode {
+		case http.StatusNotFound, http.StatusBadRequest:
+			http.Error(w, "Evidence not found for the hearing", http.StatusNotFound)
+		case http.StatusConflict:
+			http.Error(w, "Evidence is kept elsewhere, see its reference", http.StatusConflict)
+		default:
+			http.Error(w, "Error with services communication", http.StatusInternalServerError)
+			log.Printf("Error while communicating with police service: %s", err.Error())
+		}
+		return
+	}
+	defer content.Close()
+
+	for _, key := range []string{"Content-Type", "Content-Length", "Content-Disposition", "X-Checksum-SHA256"} {
+		if value := header.Get(key); value != "" {
+			w.Header().Set(key, value)
+		}
+	}
+	w.WriteHeader(http.StatusOK)
+	if _, err := io.Copy(w, content); err != nil {
+		log.Printf("Error while writing evidence: %s", err.Error())
+	}
+}
+
+// Returns the police violation of the hearing, writes an error if it has none
+func (ch *CourtHandler) getHearingViolation(w http.ResponseWriter, id string) (string, bool) {
+	hearing, err := ch.getHearing(id)
+	if err != nil {
+		http.Error(w, "Failed to retrieve court hearing", http.StatusInternalServerError)
+		log.Printf("Failed to retrieve court hearing: %s", err.Error())
+		return "", false
+	}
+
+	hearingPerson, ok := hearing.(*data.CourtHearingPerson)
+	if !ok || hearingPerson.Violation == "" {
+		http.Error(w, "Hearing is not about a traffic violation", http.StatusNotFound)
+		return "", false
+	}
+
+	return hearingPerson.Violation, true
+}
@@ -1,6 +1,8 @@
 package main
 
 import (
+	"common/deadlines"
+	"common/signing"
 	"context"
 	"court/clients"
 	"court/data"
@@ -16,6 +18,9 @@ import (
 	"github.com/gorilla/mux"
 )
 
+// Evidence files are up to tens of megabytes, far more than fits the server timeouts
+const evidenceTransferTimeout = 5 * time.Minute
+
 func main() {
 	port := os.Getenv("PORT")
 	if len(port) == 0 {
@@ -67,10 +72,35 @@ func main() {
 
 	mup := clients.NewMUPClient(mupClient, os.Getenv("MUP_SERVICE_URI"))
 
+	policeClient := &http.Client{
+		Transport: &http.Transport{
+			MaxIdleConns:        10,
+			MaxIdleConnsPerHost: 10,
+			MaxConnsPerHost:     10,
+		},
+	}
+
+	police := clients.NewPoliceClient(policeClient, os.Getenv("POLICE_SERVICE_URI"))
+
 	// Handler & router init
-	courtHandler := handlers.NewCourtHandler(store, sso, mup)
+	publicURL := os.Getenv("PUBLIC_URL")
+	if publicURL == "" {
+		publicURL = "http://localhost:" + port
+	}
+
+	signer, err := signing.NewSigner(context.Background(), store, "court")
+	if err != nil {
+		logger.Fatalf("Failed to load signing keys: %s", err.Error())
+	}
+
+	courtHandler := handlers.NewCourtHandler(store, sso, mup, police, signer, publicURL)
 	router := mux.NewRouter()
 
+	// Public document verification
+	router.HandleFunc("/api/v1/verify/hearing/{id}", courtHandler.VerifyHearingSummons).Methods("GET")
+	router.HandleFunc("/api/v1/verify", courtHandler.VerifyDocument).Methods("GET")
+	router.HandleFunc("/api/v1/verify/keys", courtHandler.GetSigningKeys).Methods("GET")
+
 	// Router methods
 	adminRouter := router.Methods("GET", "POST").Subrouter()
 	adminRouter.HandleFunc("/api/v1/get-hearing/{id}", courtHandler.GetCourtHearingByID).Methods("GET")
@@ -78,7 +108,13 @@ func main() {
 	adminRouter.HandleFunc("/api/v1/create-hearing-entity", courtHandler.CreateHearingLegalEntity).Methods("POST")
 	adminRouter.HandleFunc("/api/v1/suspensions", courtHandler.CreateSuspension).Methods("POST")
 	adminRouter.HandleFunc("/api/v1/warrants", courtHandler.CreateWarrant).Methods("POST")
+	adminRouter.HandleFunc("/api/v1/warrants/{id}/execute", courtHandler.ExecuteWarrant).Methods("POST")
 	adminRouter.HandleFunc("/api/v1/crime-report", courtHandler.RecieveCrimeReport).Methods("POST")
+	adminRouter.HandleFunc("/api/v1/unpaid-fines", courtHandler.RecieveUnpaidFine).Methods("POST")
+	adminRouter.HandleFunc("/api/v1/contested-violations", courtHandler.RecieveContestedViolation).Methods("POST")
+	adminRouter.HandleFunc("/api/v1/hearings/{id}/evidence", courtHandler.GetHearingEvidence).Methods("GET")
+	adminRouter.HandleFunc("/api/v1/hearings/{id}/evidence/{evidenceID}", deadlines.Extend(evidenceTransferTimeout, courtHandler.DownloadHearingEvidence)).Methods("GET")
+	adminRouter.HandleFunc("/api/v1/signing-keys/rotate", courtHandler.RotateSigningKey).Methods("POST")
 	adminRouter.Use(courtHandler.AuthorizeRoles("ADMIN"))
 
 	authorizedRouter := router.Methods("GET", "PUT").Subrouter()
@@ -86,7 +122,9 @@ func main() {
 	authorizedRouter.HandleFunc("/api/v1/update-hearing-person", courtHandler.UpdateHearingPerson).Methods("PUT")
 	authorizedRouter.HandleFunc("/api/v1/update-hearing-entity", courtHandler.UpdateHearingLegalEntity).Methods("PUT")
 	authorizedRouter.HandleFunc("/api/v1/hearings/{jmbg}", courtHandler.GetCourtHearingsByJMBG).Methods("GET")
+	authorizedRouter.HandleFunc("/api/v1/hearings/{id}/summons", courtHandler.GetHearingSummons).Methods("GET")
 	authorizedRouter.HandleFunc("/api/v1/suspensions/{jmbg}", courtHandler.CheckForSuspension).Methods("GET")
+	authorizedRouter.HandleFunc("/api/v1/suspensions/{id}/token", courtHandler.GetSuspensionToken).Methods("GET")
 	authorizedRouter.HandleFunc("/api/v1/warrants/{jmbg}", courtHandler.CheckForWarrants).Methods("GET")
 	authorizedRouter.Use(courtHandler.AuthorizeRoles("USER", "ADMIN"))
 
@@ -0,0 +1,96 @@
+package data
+
+import (
+	"encoding/json"
+	"io"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+const (
+	RequestTypeRegistration     = "registration"
+	RequestTypeTrafficPermit    = "trafficPermit"
+	RequestTypePermitConversion = "permitConversion"
+)
+
+// Hours an office works on a weekday, given as "15:04" in office local time
+type WorkingHours struct {
+	Weekday time.Weekday `bson:"weekday" json:"weekday"`
+	Start   string       `bson:"start" json:"start"`
+	End     string       `bson:"end" json:"end"`
+}
+
+// Appointment configuration of an office. Capacity is the number of
+// appointments a single slot can take, holidays are dates as "2006-01-02"
+type OfficeSchedule struct {
+	Office       primitive.ObjectID `bson:"_id" json:"office"`
+	WorkingHours []WorkingHours     `bson:"workingHours" json:"workingHours"`
+	SlotMinutes  int                `bson:"slotMinutes" json:"slotMinutes"`
+	Capacity     int                `bson:"capacity" json:"capacity"`
+	Holidays     []string           `bson:"holidays" json:"holidays"`
+}
+
+// Counter of appointments booked for a single slot of an office
+type AppointmentSlot struct {
+	ID     AppointmentSlotID `bson:"_id"`
+	Booked int               `bson:"booked"`
+}
+
+type AppointmentSlotID struct {
+	Office primitive.ObjectID `bson:"office"`
+	Start  time.Time          `bson:"start"`
+}
+
+type Slot struct {
+	Start time.Time `json:"start"`
+	End   time.Time `json:"end"`
+	Free  int       `json:"free"`
+}
+
+type Slots []Slot
+
+// Counter visit for a pending registration or traffic permit request
+type Appointment struct {
+	ID          primitive.ObjectID `bson:"_id,omitempty" json:"id"`
+	Office      primitive.ObjectID `bson:"office" json:"office"`
+	Person      string             `bson:"person" json:"person"`
+	Start       time.Time          `bson:"start" json:"start"`
+	End         time.Time          `bson:"end" json:"end"`
+	RequestType string             `bson:"requestType" json:"requestType"`
+	RequestID   string             `bson:"requestID" json:"requestID"`
+	Cancelled   bool               `bson:"cancelled" json:"cancelled"`
+	CreatedAt   time.Time          `bson:"createdAt" json:"createdAt"`
+}
+
+type Appointments []Appointment
+
+func (sch *OfficeSchedule) ToJSON(w io.Writer) error {
+	e := json.NewEncoder(w)
+	return e.Encode(sch)
+}
+
+func (sch *OfficeSchedule) FromJSON(r io.Reader) error {
+	d := json.NewDecoder(r)
+	return d.Decode(sch)
+}
+
+func (s *Slots) ToJSON(w io.Writer) error {
+	e := json.NewEncoder(w)
+	return e.Encode(s)
+}
+
+func (a *Appointment) ToJSON(w io.Writer) error {
+	e := json.NewEncoder(w)
+	return e.Encode(a)
+}
+
+func (a *Appointment) FromJSON(r io.Reader) error {
+	d := json.NewDecoder(r)
+	return d.Decode(a)
+}
+
+func (a *Appointments) ToJSON(w io.Writer) error {
+	e := json.NewEncoder(w)
+	return e.Encode(a)
+}
@@ -0,0 +1,217 @@
+package data
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+var (
+	ErrScheduleNotFound    = errors.New("office schedule not found")
+	ErrSlotFull            = errors.New("appointment slot is full")
+	ErrAppointmentExists   = errors.New("request already has an appointment")
+	ErrAppointmentNotFound = errors.New("appointment not found")
+	ErrRequestNotFound     = errors.New("pending request not found")
+)
+
+//Appointment methods
+
+// Creates or replaces appointment configuration of an office
+func (mr *MUPRepo) SaveOfficeSchedule(ctx context.Context, schedule *OfficeSchedule) error {
+	collection := mr.getMupCollection("officeSchedule")
+
+	filter := bson.D{{Key: "_id", Value: schedule.Office}}
+	_, err := collection.ReplaceOne(ctx, filter, schedule, options.Replace().SetUpsert(true))
+	return err
+}
+
+func (mr *MUPRepo) GetOfficeSchedule(ctx context.Context, office primitive.ObjectID) (OfficeSchedule, error) {
+	collection := mr.getMupCollection("officeSchedule")
+
+	var schedule OfficeSchedule
+	err := collection.FindOne(ctx, bson.D{{Key: "_id", Value: office}}).Decode(&schedule)
+	if err != nil {
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			return OfficeSchedule{}, ErrScheduleNotFound
+		}
+		return OfficeSchedule{}, err
+	}
+
+	return schedule, nil
+}
+
+// Returns booking counters of office slots starting within [from, to)
+func (mr *MUPRepo) GetBookedSlots(ctx context.Context, office primitive.ObjectID, from, to time.Time) ([]AppointmentSlot, error) {
+	collection := mr.getMupCollection("appointmentSlot")
+
+	filter := bson.D{
+		{Key: "_id.office", Value: office},
+		{Key: "_id.start", Value: bson.D{{Key: "$gte", Value: from}, {Key: "$lt", Value: to}}},
+	}
+
+	cursor, err := collection.Find(ctx, filter)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	var slots []AppointmentSlot
+	if err = cursor.All(ctx, &slots); err != nil {
+		return nil, err
+	}
+
+	return slots, nil
+}
+
+// Returns office of the person's open request an appointment can be booked for
+func (mr *MUPRepo) GetPendingRequestOffice(ctx context.Context, requestType, requestID, person string) (primitive.ObjectID, error) {
+	collection, filter, err := mr.requestFilter(requestType, requestID)
+	if err != nil {
+		return primitive.NilObjectID, err
+	}
+
+	ownerField := "person"
+	if requestType == RequestTypeRegistration {
+		ownerField = "owner"
+	}
+
+	filter = append(filter,
+		bson.E{Key: ownerField, Value: person},
+		bson.E{Key: "status", Value: bson.D{{Key: "$in", Value: OpenStatuses}}})
+
+	var request struct {
+		Office primitive.ObjectID `bson:"office"`
+	}
+	err = collection.FindOne(ctx, filter).Decode(&request)
+	if err != nil {
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			return primitive.NilObjectID, ErrRequestNotFound
+		}
+		return primitive.NilObjectID, err
+	}
+
+	return request.Office, nil
+}
+
+// Books appointment in a single transaction. The slot counter is only
+// incremented while it is below capacity, and a full slot makes the upsert
+// collide with the existing counter, so concurrent bookings can't overfill it
+func (mr *MUPRepo) BookAppointment(ctx context.Context, appointment *Appointment, capacity int) error {
+	appointments := mr.getMupCollection("appointment")
+	slots := mr.getMupCollection("appointmentSlot")
+
+	return mr.withTransaction(ctx, func(sessCtx mongo.SessionContext) error {
+		filter := bson.D{
+			{Key: "requestType", Value: appointment.RequestType},
+			{Key: "requestID", Value: appointment.RequestID},
+			{Key: "cancelled", Value: false},
+		}
+
+		count, err := appointments.CountDocuments(sessCtx, filter)
+		if err != nil {
+			return err
+		}
+		if count > 0 {
+			return ErrAppointmentExists
+		}
+
+		slotID := AppointmentSlotID{Office: appointment.Office, Start: appointment.Start}
+		slotFilter := bson.D{
+			{Key: "_id", Value: slotID},
+			{Key: "booked", Value: bson.D{{Key: "$lt", Value: capacity}}},
+		}
+		update := bson.D{{Key: "$inc", Value: bson.D{{Key: "booked", Value: 1}}}}
+
+		_, err = slots.UpdateOne(sessCtx, slotFilter, update, options.Update().SetUpsert(true))
+		if err != nil {
+			if mongo.IsDuplicateKeyError(err) {
+				return ErrSlotFull
+			}
+			return err
+		}
+
+		appointment.ID = primitive.NewObjectID()
+		appointment.Cancelled = false
+		appointment.CreatedAt = time.Now()
+
+		_, err = appointments.InsertOne(sessCtx, appointment)
+		return err
+	})
+}
+
+// Cancels person's appointment and frees its slot
+func (mr *MUPRepo) CancelAppointment(ctx context.Context, appointmentID primitive.ObjectID, person string) (Appointment, error) {
+	appointments := mr.getMupCollection("appointment")
+	slots := mr.getMupCollection("appointmentSlot")
+
+	var appointment Appointment
+	err := mr.withTransaction(ctx, func(sessCtx mongo.SessionContext) error {
+		filter := bson.D{{Key: "_id", Value: appointmentID}, {Key: "person", Value: person}}
+
+		err := appointments.FindOne(sessCtx, filter).Decode(&appointment)
+		if err != nil {
+			if errors.Is(err, mongo.ErrNoDocuments) {
+				return ErrAppointmentNotFound
+			}
+			return err
+		}
+
+		// Cancelling twice leaves the slot counter as it is
+		if appointment.Cancelled {
+			return nil
+		}
+
+		filter = append(filter, bson.E{Key: "cancelled", Value: false})
+		update := bson.D{{Key: "$set", Value: bson.D{{Key: "cancelled", Value: true}}}}
+
+		_, err = appointments.UpdateOne(sessCtx, filter, update)
+		if err != nil {
+			return err
+		}
+
+		slotID := AppointmentSlotID{Office: appointment.Office, Start: appointment.Start}
+		slotFilter := bson.D{{Key: "_id", Value: slotID}, {Key: "booked", Value: bson.D{{Key: "$gt", Value: 0}}}}
+		_, err = slots.UpdateOne(sessCtx, slotFilter, bson.D{{Key: "$inc", Value: bson.D{{Key: "booked", Value: -1}}}})
+		if err != nil {
+			return err
+		}
+
+		appointment.Cancelled = true
+		return nil
+	})
+
+	return appointment, err
+}
+
+// Returns active appointments starting within [from, to) ordered by start,
+// of the given office or of all offices for primitive.NilObjectID
+func (mr *MUPRepo) GetOfficeAppointments(ctx context.Context, office primitive.ObjectID, from, to time.Time) (Appointments, error) {
+	collection := mr.getMupCollection("appointment")
+
+	filter := bson.D{
+		{Key: "start", Value: bson.D{{Key: "$gte", Value: from}, {Key: "$lt", Value: to}}},
+		{Key: "cancelled", Value: false},
+	}
+
+	if !office.IsZero() {
+		filter = append(filter, bson.E{Key: "office", Value: office})
+	}
+
+	cursor, err := collection.Find(ctx, filter, options.Find().SetSort(bson.D{{Key: "start", Value: 1}}))
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	appointments := Appointments{}
+	if err = cursor.All(ctx, &appointments); err != nil {
+		return nil, err
+	}
+
+	return appointments, nil
+}
@@ -0,0 +1,45 @@
+package data
+
+import (
+	"encoding/json"
+	"io"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// Documents citizens can attach, by request type
+var AttachmentKinds = map[string][]string{
+	RequestTypeRegistration:     {"purchaseContract", "customsDocument", "vehiclePhoto"},
+	RequestTypeTrafficPermit:    {"medicalCertificate", "drivingSchoolDiploma"},
+	RequestTypePermitConversion: {"foreignPermit", "certifiedTranslation", "medicalCertificate", "residencePermit"},
+}
+
+// Metadata of a file attached to a request. The content is kept in the blob store under
+// BlobKey, which is derived from the checksum, so identical files share their content
+type Attachment struct {
+	ID          primitive.ObjectID `bson:"_id" json:"id"`
+	RequestType string             `bson:"requestType" json:"requestType"`
+	RequestID   string             `bson:"requestID" json:"requestID"`
+	Office      primitive.ObjectID `bson:"office" json:"office"`
+	Kind        string             `bson:"kind" json:"kind"`
+	FileName    string             `bson:"fileName" json:"fileName"`
+	ContentType string             `bson:"contentType" json:"contentType"`
+	Size        int64              `bson:"size" json:"size"`
+	SHA256      string             `bson:"sha256" json:"sha256"`
+	BlobKey     string             `bson:"blobKey" json:"-"`
+	UploadedBy  string             `bson:"uploadedBy" json:"uploadedBy"`
+	UploadedAt  time.Time          `bson:"uploadedAt" json:"uploadedAt"`
+}
+
+type Attachments []Attachment
+
+func (a *Attachment) ToJSON(w io.Writer) error {
+	e := json.NewEncoder(w)
+	return e.Encode(a)
+}
+
+func (as *Attachments) ToJSON(w io.Writer) error {
+	e := json.NewEncoder(w)
+	return e.Encode(as)
+}
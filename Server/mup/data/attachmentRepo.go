@@ -0,0 +1,88 @@
+package data
+
+import (
+	"context"
+	"errors"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+var ErrAttachmentNotFound = errors.New("attachment not found")
+
+//Attachment methods
+
+func (mr *MUPRepo) GetAttachment(ctx context.Context, id primitive.ObjectID) (Attachment, error) {
+	collection := mr.getMupCollection("attachment")
+
+	var attachment Attachment
+	err := collection.FindOne(ctx, bson.D{{Key: "_id", Value: id}}).Decode(&attachment)
+	if err != nil {
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			return Attachment{}, ErrAttachmentNotFound
+		}
+		return Attachment{}, err
+	}
+
+	return attachment, nil
+}
+
+// Returns attachments of the request in upload order
+func (mr *MUPRepo) GetRequestAttachments(ctx context.Context, requestType, requestID string) (Attachments, error) {
+	collection := mr.getMupCollection("attachment")
+
+	filter := bson.D{{Key: "requestType", Value: requestType}, {Key: "requestID", Value: requestID}}
+	opts := options.Find().SetSort(bson.D{{Key: "uploadedAt", Value: 1}})
+
+	cursor, err := collection.Find(ctx, filter, opts)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	attachments := Attachments{}
+	if err = cursor.All(ctx, &attachments); err != nil {
+		return nil, err
+	}
+
+	return attachments, nil
+}
+
+// Whether an attachment keeps its content under the blob key
+func (mr *MUPRepo) AttachmentBlobInUse(ctx context.Context, key string) (bool, error) {
+	count, err := mr.getMupCollection("attachment").CountDocuments(ctx, bson.D{{Key: "blobKey", Value: key}}, options.Count().SetLimit(1))
+	if err != nil {
+		return false, err
+	}
+	return count > 0, nil
+}
+
+// Links attachments to the request. Files the request already has, by kind and
+// checksum, are kept as they are, so a retried submit doesn't duplicate them. Each
+// file is linked in a single upsert, two retries racing each other link it once
+func (mr *MUPRepo) saveAttachments(sessCtx mongo.SessionContext, requestType, requestID string, office primitive.ObjectID, attachments Attachments) error {
+	collection := mr.getMupCollection("attachment")
+
+	for _, attachment := range attachments {
+		filter := bson.D{
+			{Key: "requestType", Value: requestType},
+			{Key: "requestID", Value: requestID},
+			{Key: "kind", Value: attachment.Kind},
+			{Key: "sha256", Value: attachment.SHA256},
+		}
+
+		attachment.RequestType = requestType
+		attachment.RequestID = requestID
+		attachment.Office = office
+		update := bson.D{{Key: "$setOnInsert", Value: attachment}}
+
+		_, err := collection.UpdateOne(sessCtx, filter, update, options.Update().SetUpsert(true))
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
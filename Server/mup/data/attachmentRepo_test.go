@@ -0,0 +1,72 @@
+package data
+
+import (
+	"context"
+	"testing"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/integration/mtest"
+)
+
+func TestSaveAttachments(t *testing.T) {
+	mt := mtest.New(t, mtest.NewOptions().ClientType(mtest.Mock))
+
+	mt.Run("retried submit links each file once", func(mt *mtest.T) {
+		office := primitive.NewObjectID()
+		attachments := Attachments{
+			{ID: primitive.NewObjectID(), Kind: "ID_CARD", FileName: "id.pdf", SHA256: "aa11", BlobKey: "attachments/aa11"},
+			{ID: primitive.NewObjectID(), Kind: "PROOF_OF_OWNERSHIP", FileName: "contract.pdf", SHA256: "bb22", BlobKey: "attachments/bb22"},
+		}
+		for range attachments {
+			mt.AddMockResponses(mtest.CreateSuccessResponse(bson.E{Key: "n", Value: 1}, bson.E{Key: "nModified", Value: 0}))
+		}
+
+		mr := &MUPRepo{cli: mt.Client}
+		err := mr.saveAttachments(mongo.NewSessionContext(context.Background(), nil), RequestTypeRegistration, "REG-1", office, attachments)
+		if err != nil {
+			mt.Fatalf("saveAttachments() error = %v", err)
+		}
+
+		for _, attachment := range attachments {
+			update := mt.GetStartedEvent()
+			if update == nil || update.CommandName != "update" {
+				mt.Fatalf("attachment %s wasn't linked", attachment.FileName)
+			}
+
+			var got struct {
+				Updates []struct {
+					Q      bson.M `bson:"q"`
+					U      bson.M `bson:"u"`
+					Upsert bool   `bson:"upsert"`
+				} `bson:"updates"`
+			}
+			if err := bson.Unmarshal(update.Command, &got); err != nil || len(got.Updates) != 1 {
+				mt.Fatalf("update %v can't be read: %v", update.Command, err)
+			}
+			linked := got.Updates[0]
+
+			// Found by the request, kind and checksum, inserted only when the request doesn't
+			// have the file yet, so inserting it again changes nothing
+			want := bson.M{"requestType": RequestTypeRegistration, "requestID": "REG-1", "kind": attachment.Kind, "sha256": attachment.SHA256}
+			for key, value := range want {
+				if linked.Q[key] != value {
+					mt.Errorf("attachment %s linked with filter %v, want %v", attachment.FileName, linked.Q, want)
+					break
+				}
+			}
+			if len(linked.Q) != len(want) {
+				mt.Errorf("attachment %s linked with filter %v, want %v", attachment.FileName, linked.Q, want)
+			}
+
+			inserted, ok := linked.U["$setOnInsert"].(bson.M)
+			if !ok || len(linked.U) != 1 || !linked.Upsert {
+				mt.Fatalf("attachment %s linked with %v upsert %v, want it set on insert only", attachment.FileName, linked.U, linked.Upsert)
+			}
+			if inserted["blobKey"] != attachment.BlobKey || inserted["office"] != office || inserted["requestID"] != "REG-1" {
+				mt.Errorf("attachment %s inserted as %v, want it linked to the request", attachment.FileName, inserted)
+			}
+		}
+	})
+}
@@ -0,0 +1,129 @@
+package data
+
+import (
+	"encoding/json"
+	"io"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// Authorities that can ban a person from driving
+const (
+	BanAuthorityCourt  = "COURT"
+	BanAuthorityPoints = "POINTS"
+)
+
+// States of a driving ban, derived from its dates and lifting
+const (
+	BanStatusUpcoming = "UPCOMING"
+	BanStatusActive   = "ACTIVE"
+	BanStatusExpired  = "EXPIRED"
+	BanStatusLifted   = "LIFTED"
+)
+
+// States of an issued driving permit, following StatusApproved
+const (
+	StatusSuspended = "SUSPENDED"
+	StatusRevoked   = "REVOKED"
+)
+
+// Ban on driving in effect from Start until End. CaseID refers to the court case or
+// points record that caused it. Status is derived when the ban is read
+type DrivingBan struct {
+	ID        primitive.ObjectID `bson:"_id,omitempty" json:"id"`
+	Reason    string             `bson:"reason" json:"reason"`
+	Start     time.Time          `bson:"start" json:"start"`
+	End       time.Time          `bson:"end" json:"end"`
+	Person    string             `bson:"person" json:"person"`
+	Office    primitive.ObjectID `bson:"office" json:"office"`
+	Authority string             `bson:"authority" json:"authority"`
+	CaseID    string             `bson:"caseID,omitempty" json:"caseID,omitempty"`
+	IssuedAt  time.Time          `bson:"issuedAt" json:"issuedAt"`
+	Lifted    *BanLifting        `bson:"lifted,omitempty" json:"lifted,omitempty"`
+	Appeal    *BanAppeal         `bson:"appeal,omitempty" json:"appeal,omitempty"`
+	Status    string             `bson:"-" json:"status"`
+}
+
+type DrivingBans []DrivingBan
+
+// Ends the ban before its end date, e.g. after a successful appeal
+type BanLifting struct {
+	At     time.Time `bson:"at" json:"at"`
+	By     string    `bson:"by" json:"by"`
+	Reason string    `bson:"reason" json:"reason"`
+}
+
+// Appeal filed against the ban. The ban stays in effect until it is lifted
+type BanAppeal struct {
+	CaseID  string    `bson:"caseID" json:"caseID"`
+	URL     string    `bson:"url,omitempty" json:"url,omitempty"`
+	FiledAt time.Time `bson:"filedAt" json:"filedAt"`
+}
+
+type LiftBanRequest struct {
+	Reason string `json:"reason"`
+}
+
+type RevokePermitRequest struct {
+	Reason string `json:"reason"`
+}
+
+func (db DrivingBan) ActiveAt(t time.Time) bool {
+	return db.Lifted == nil && !t.Before(db.Start) && t.Before(db.End)
+}
+
+func (db DrivingBan) StatusAt(t time.Time) string {
+	switch {
+	case db.Lifted != nil:
+		return BanStatusLifted
+	case t.Before(db.Start):
+		return BanStatusUpcoming
+	case t.Before(db.End):
+		return BanStatusActive
+	default:
+		return BanStatusExpired
+	}
+}
+
+// Fills in the status of every ban as of t
+func (db DrivingBans) SetStatuses(t time.Time) {
+	for i := range db {
+		db[i].Status = db[i].StatusAt(t)
+	}
+}
+
+func (db *DrivingBan) ToJSON(w io.Writer) error {
+	e := json.NewEncoder(w)
+	return e.Encode(db)
+}
+
+func (db *DrivingBan) FromJSON(r io.Reader) error {
+	d := json.NewDecoder(r)
+	return d.Decode(db)
+}
+
+func (db *DrivingBans) ToJSON(w io.Writer) error {
+	e := json.NewEncoder(w)
+	return e.Encode(db)
+}
+
+func (db *DrivingBans) FromJSON(r io.Reader) error {
+	d := json.NewDecoder(r)
+	return d.Decode(db)
+}
+
+func (ba *BanAppeal) FromJSON(r io.Reader) error {
+	d := json.NewDecoder(r)
+	return d.Decode(ba)
+}
+
+func (lbr *LiftBanRequest) FromJSON(r io.Reader) error {
+	d := json.NewDecoder(r)
+	return d.Decode(lbr)
+}
+
+func (rpr *RevokePermitRequest) FromJSON(r io.Reader) error {
+	d := json.NewDecoder(r)
+	return d.Decode(rpr)
+}
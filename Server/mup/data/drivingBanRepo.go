@@ -0,0 +1,310 @@
+package data
+
+import (
+	"context"
+	"errors"
+	"log"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+var (
+	ErrDrivingBanNotFound = errors.New("driving ban not found")
+	ErrBanAlreadyLifted   = errors.New("driving ban is already lifted")
+)
+
+//Driving ban methods
+
+// Saves driving ban and suspends the person's permits if the ban is in effect.
+// Issuing the same ban for a person again returns the already saved one
+func (mr *MUPRepo) IssueDrivingBan(ctx context.Context, drivingBan *DrivingBan) error {
+	collection := mr.getMupCollection("drivingBan")
+
+	// Mongo keeps milliseconds, anything finer would break matching of a repeated ban
+	drivingBan.Start = drivingBan.Start.Truncate(time.Millisecond)
+	drivingBan.End = drivingBan.End.Truncate(time.Millisecond)
+
+	return mr.withTransaction(ctx, func(sessCtx mongo.SessionContext) error {
+		filter := bson.D{
+			{Key: "person", Value: drivingBan.Person},
+			{Key: "reason", Value: drivingBan.Reason},
+			{Key: "start", Value: drivingBan.Start},
+			{Key: "end", Value: drivingBan.End},
+		}
+
+		var existing DrivingBan
+		err := collection.FindOne(sessCtx, filter).Decode(&existing)
+		if err == nil {
+			*drivingBan = existing
+			return nil
+		}
+		if !errors.Is(err, mongo.ErrNoDocuments) {
+			return err
+		}
+
+		drivingBan.ID = primitive.NewObjectID()
+		drivingBan.IssuedAt = time.Now()
+
+		_, err = collection.InsertOne(sessCtx, drivingBan)
+		if err != nil {
+			log.Printf("Failed to create driving ban: %v", err)
+			return err
+		}
+
+		return mr.reconcilePersonsPermits(sessCtx, drivingBan.Person, drivingBan.Authority, drivingBan.Reason)
+	})
+}
+
+func (mr *MUPRepo) GetDrivingBanByID(ctx context.Context, id primitive.ObjectID) (DrivingBan, error) {
+	collection := mr.getMupCollection("drivingBan")
+
+	var drivingBan DrivingBan
+	err := collection.FindOne(ctx, bson.D{{Key: "_id", Value: id}}).Decode(&drivingBan)
+	if err != nil {
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			return DrivingBan{}, ErrDrivingBanNotFound
+		}
+		return DrivingBan{}, err
+	}
+
+	return drivingBan, nil
+}
+
+// Returns the ban of the person that ends last
+func (mr *MUPRepo) GetDrivingBan(ctx context.Context, jmbg string) (DrivingBan, error) {
+	collection := mr.getMupCollection("drivingBan")
+
+	filter := bson.D{{Key: "person", Value: jmbg}}
+	opts := options.FindOne().SetSort(bson.D{{Key: "end", Value: -1}})
+
+	var drivingBan DrivingBan
+	err := collection.FindOne(ctx, filter, opts).Decode(&drivingBan)
+	if err != nil {
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			return DrivingBan{}, nil
+		}
+		return DrivingBan{}, err
+	}
+
+	return drivingBan, nil
+}
+
+// Returns all bans of the person, latest first
+func (mr *MUPRepo) CheckForPersonsDrivingBans(ctx context.Context, userID string) (DrivingBans, error) {
+	collection := mr.getMupCollection("drivingBan")
+
+	filter := bson.D{{Key: "person", Value: userID}}
+	opts := options.Find().SetSort(bson.D{{Key: "start", Value: -1}})
+
+	cursor, err := collection.Find(ctx, filter, opts)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	drivingBans := DrivingBans{}
+	if err = cursor.All(ctx, &drivingBans); err != nil {
+		return nil, err
+	}
+
+	return drivingBans, nil
+}
+
+// Returns bans of the person in effect at the given time
+func (mr *MUPRepo) GetActiveDrivingBans(ctx context.Context, jmbg string, at time.Time) (DrivingBans, error) {
+	collection := mr.getMupCollection("drivingBan")
+
+	cursor, err := collection.Find(ctx, activeBanFilter(jmbg, at), options.Find().SetSort(bson.D{{Key: "end", Value: -1}}))
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	drivingBans := DrivingBans{}
+	if err = cursor.All(ctx, &drivingBans); err != nil {
+		return nil, err
+	}
+
+	return drivingBans, nil
+}
+
+// Lifts the ban before its end date and reinstates the person's permits
+// unless another ban is still in effect
+func (mr *MUPRepo) LiftDrivingBan(ctx context.Context, id primitive.ObjectID, lifting BanLifting) (DrivingBan, error) {
+	collection := mr.getMupCollection("drivingBan")
+
+	var drivingBan DrivingBan
+	err := mr.withTransaction(ctx, func(sessCtx mongo.SessionContext) error {
+		filter := bson.D{{Key: "_id", Value: id}, {Key: "lifted", Value: bson.D{{Key: "$exists", Value: false}}}}
+		update := bson.D{{Key: "$set", Value: bson.D{{Key: "lifted", Value: lifting}}}}
+
+		err := collection.FindOneAndUpdate(sessCtx, filter, update, options.FindOneAndUpdate().SetReturnDocument(options.After)).Decode(&drivingBan)
+		if err != nil {
+			if !errors.Is(err, mongo.ErrNoDocuments) {
+				return err
+			}
+			if _, err := mr.GetDrivingBanByID(sessCtx, id); err != nil {
+				return err
+			}
+			return ErrBanAlreadyLifted
+		}
+
+		return mr.reconcilePersonsPermits(sessCtx, drivingBan.Person, lifting.By, lifting.Reason)
+	})
+	if err != nil {
+		return DrivingBan{}, err
+	}
+
+	return drivingBan, nil
+}
+
+// Records appeal filed against the ban
+func (mr *MUPRepo) SetDrivingBanAppeal(ctx context.Context, id primitive.ObjectID, appeal BanAppeal) (DrivingBan, error) {
+	collection := mr.getMupCollection("drivingBan")
+
+	update := bson.D{{Key: "$set", Value: bson.D{{Key: "appeal", Value: appeal}}}}
+
+	var drivingBan DrivingBan
+	err := collection.FindOneAndUpdate(ctx, bson.D{{Key: "_id", Value: id}}, update, options.FindOneAndUpdate().SetReturnDocument(options.After)).Decode(&drivingBan)
+	if err != nil {
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			return DrivingBan{}, ErrDrivingBanNotFound
+		}
+		return DrivingBan{}, err
+	}
+
+	return drivingBan, nil
+}
+
+// Permanently revokes an issued permit, whether it is in force or suspended
+func (mr *MUPRepo) RevokeTrafficPermit(ctx context.Context, permitID primitive.ObjectID, by, reason string) (TrafficPermit, error) {
+	collection := mr.getMupCollection("trafficPermit")
+
+	var permit TrafficPermit
+	err := collection.FindOne(ctx, bson.D{{Key: "_id", Value: permitID}}).Decode(&permit)
+	if err != nil {
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			return TrafficPermit{}, ErrTrafficPermitNotFound
+		}
+		return TrafficPermit{}, err
+	}
+
+	if permit.Status != StatusApproved && permit.Status != StatusSuspended {
+		return TrafficPermit{}, ErrInvalidTransition
+	}
+
+	change := StatusChange{From: permit.Status, To: StatusRevoked, By: by, At: time.Now(), Reason: reason}
+	filter := bson.D{{Key: "_id", Value: permitID}, {Key: "status", Value: permit.Status}}
+	update := bson.D{
+		{Key: "$set", Value: bson.D{{Key: "status", Value: change.To}}},
+		{Key: "$push", Value: bson.D{{Key: "timeline", Value: change}}},
+	}
+
+	result, err := collection.UpdateOne(ctx, filter, update)
+	if err != nil {
+		return TrafficPermit{}, err
+	}
+	if result.MatchedCount == 0 {
+		return TrafficPermit{}, ErrInvalidTransition
+	}
+
+	permit.Status = change.To
+	permit.Timeline = append(permit.Timeline, change)
+	return permit, nil
+}
+
+// Brings permit states in line with bans that started or ended since the last run.
+// Bans issued or lifted reconcile the person's permits right away, this catches
+// bans that start in the future and bans that run out
+func (mr *MUPRepo) ReconcilePermitStatuses(ctx context.Context) error {
+	now := time.Now()
+
+	banned, err := mr.getMupCollection("drivingBan").Distinct(ctx, "person", activeBanFilter("", now))
+	if err != nil {
+		return err
+	}
+
+	suspended, err := mr.getMupCollection("trafficPermit").Distinct(ctx, "person", bson.D{{Key: "status", Value: StatusSuspended}})
+	if err != nil {
+		return err
+	}
+
+	for _, person := range append(banned, suspended...) {
+		jmbg, ok := person.(string)
+		if !ok {
+			continue
+		}
+		if err := mr.reconcilePersonsPermits(ctx, jmbg, "", ""); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// Converts bans that kept only their end date in 'duration'. The issue time
+// stands in for the start, as bans used to take effect when they were issued
+func (mr *MUPRepo) MigrateDrivingBans(ctx context.Context) error {
+	collection := mr.getMupCollection("drivingBan")
+
+	// Repeated bans used to be told apart by their end date. Once it is unset, every
+	// ban of the person for the same reason would collide in the old unique index
+	if err := mr.dropIndex(ctx, "drivingBan", "person_1_reason_1_duration_1"); err != nil {
+		return err
+	}
+
+	filter := bson.D{{Key: "duration", Value: bson.D{{Key: "$exists", Value: true}}}}
+	issuedAt := bson.D{{Key: "$toDate", Value: "$_id"}}
+	update := mongo.Pipeline{
+		{{Key: "$set", Value: bson.D{
+			{Key: "start", Value: issuedAt},
+			{Key: "end", Value: "$duration"},
+			{Key: "issuedAt", Value: issuedAt},
+			{Key: "authority", Value: BanAuthorityCourt},
+		}}},
+		{{Key: "$unset", Value: "duration"}},
+	}
+
+	_, err := collection.UpdateMany(ctx, filter, update)
+	return err
+}
+
+// Suspends issued permits of the person while a ban is in effect and reinstates
+// them once none is. By and reason are recorded in the permit timeline
+func (mr *MUPRepo) reconcilePersonsPermits(ctx context.Context, person, by, reason string) error {
+	active, err := mr.getMupCollection("drivingBan").CountDocuments(ctx, activeBanFilter(person, time.Now()))
+	if err != nil {
+		return err
+	}
+
+	change := StatusChange{From: StatusSuspended, To: StatusApproved, By: by, At: time.Now(), Reason: reason}
+	if active > 0 {
+		change.From, change.To = StatusApproved, StatusSuspended
+	}
+
+	filter := bson.D{{Key: "person", Value: person}, {Key: "status", Value: change.From}}
+	update := bson.D{
+		{Key: "$set", Value: bson.D{{Key: "status", Value: change.To}}},
+		{Key: "$push", Value: bson.D{{Key: "timeline", Value: change}}},
+	}
+
+	_, err = mr.getMupCollection("trafficPermit").UpdateMany(ctx, filter, update)
+	return err
+}
+
+// Selects bans in effect at the given time, of the person if one is given
+func activeBanFilter(person string, at time.Time) bson.D {
+	filter := bson.D{
+		{Key: "start", Value: bson.D{{Key: "$lte", Value: at}}},
+		{Key: "end", Value: bson.D{{Key: "$gt", Value: at}}},
+		{Key: "lifted", Value: bson.D{{Key: "$exists", Value: false}}},
+	}
+	if person != "" {
+		filter = append(bson.D{{Key: "person", Value: person}}, filter...)
+	}
+	return filter
+}
@@ -0,0 +1,105 @@
+package data
+
+import (
+	"context"
+	"testing"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo/integration/mtest"
+)
+
+func TestMigrateDrivingBans(t *testing.T) {
+	mt := mtest.New(t, mtest.NewOptions().ClientType(mtest.Mock))
+
+	tests := []struct {
+		name        string
+		dropped     bson.D
+		wantErr     bool
+		wantMigrate bool
+	}{
+		{
+			name:        "legacy index is dropped first",
+			dropped:     mtest.CreateSuccessResponse(),
+			wantMigrate: true,
+		},
+		{
+			name:        "legacy index already dropped",
+			dropped:     mtest.CreateCommandErrorResponse(mtest.CommandError{Code: 27, Name: "IndexNotFound", Message: "index not found"}),
+			wantMigrate: true,
+		},
+		{
+			name:        "no bans yet",
+			dropped:     mtest.CreateCommandErrorResponse(mtest.CommandError{Code: 26, Name: "NamespaceNotFound", Message: "ns not found"}),
+			wantMigrate: true,
+		},
+		{
+			name:    "legacy index can't be dropped",
+			dropped: mtest.CreateCommandErrorResponse(mtest.CommandError{Code: 13, Name: "Unauthorized", Message: "not authorized"}),
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		mt.Run(tt.name, func(mt *mtest.T) {
+			mt.AddMockResponses(tt.dropped, mtest.CreateSuccessResponse(bson.E{Key: "n", Value: 2}, bson.E{Key: "nModified", Value: 2}))
+
+			mr := &MUPRepo{cli: mt.Client}
+			err := mr.MigrateDrivingBans(context.Background())
+			if (err != nil) != tt.wantErr {
+				mt.Fatalf("MigrateDrivingBans() error = %v, want error %v", err, tt.wantErr)
+			}
+
+			drop := mt.GetStartedEvent()
+			if drop.CommandName != "dropIndexes" || drop.Command.Lookup("index").StringValue() != "person_1_reason_1_duration_1" {
+				mt.Fatalf("first command = %v, want the legacy index dropped", drop.Command)
+			}
+
+			update := mt.GetStartedEvent()
+			if !tt.wantMigrate {
+				if update != nil {
+					mt.Fatalf("bans were migrated with %v, want them left alone", update.Command)
+				}
+				return
+			}
+			if update == nil || update.CommandName != "update" {
+				mt.Fatalf("bans weren't migrated")
+			}
+
+			var got struct {
+				Updates []struct {
+					Q     bson.M   `bson:"q"`
+					U     []bson.M `bson:"u"`
+					Multi bool     `bson:"multi"`
+				} `bson:"updates"`
+			}
+			if err := bson.Unmarshal(update.Command, &got); err != nil || len(got.Updates) != 1 {
+				mt.Fatalf("update %v can't be read: %v", update.Command, err)
+			}
+			migration := got.Updates[0]
+
+			// Only bans that still keep their end in 'duration' are converted, all of them at once
+			if _, ok := migration.Q["duration"]; !ok || !migration.Multi {
+				mt.Errorf("bans are migrated with filter %v multi %v, want every ban with a duration", migration.Q, migration.Multi)
+			}
+			if len(migration.U) != 2 {
+				mt.Fatalf("bans are migrated with %v, want the new fields set and duration unset", migration.U)
+			}
+
+			set, ok := migration.U[0]["$set"].(bson.M)
+			if !ok {
+				mt.Fatalf("first stage = %v, want $set", migration.U[0])
+			}
+			if set["end"] != "$duration" || set["authority"] != BanAuthorityCourt {
+				mt.Errorf("bans are set %v, want the end taken from duration and the court as authority", set)
+			}
+			for _, field := range []string{"start", "issuedAt"} {
+				if _, ok := set[field]; !ok {
+					mt.Errorf("bans are set %v, want %s taken from the issue time", set, field)
+				}
+			}
+			if migration.U[1]["$unset"] != "duration" {
+				mt.Errorf("second stage = %v, want duration unset", migration.U[1])
+			}
+		})
+	}
+}
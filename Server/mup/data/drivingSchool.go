@@ -0,0 +1,145 @@
+package data
+
+import (
+	"encoding/json"
+	"io"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+const (
+	ExamTheory    = "THEORY"
+	ExamPractical = "PRACTICAL"
+)
+
+// Driving school accredited to train candidates for the listed categories
+type DrivingSchool struct {
+	ID                  primitive.ObjectID `bson:"_id,omitempty" json:"id"`
+	Name                string             `bson:"name" json:"name"`
+	AccreditationNumber string             `bson:"accreditationNumber" json:"accreditationNumber"`
+	Address             Address            `bson:"address" json:"address"`
+	Categories          []string           `bson:"categories" json:"categories"`
+	AccreditedUntil     time.Time          `bson:"accreditedUntil" json:"accreditedUntil"`
+}
+
+type DrivingSchools []DrivingSchool
+
+// Person training for a category at a driving school
+type Candidate struct {
+	ID         primitive.ObjectID `bson:"_id,omitempty" json:"id"`
+	Person     string             `bson:"person" json:"person"`
+	School     primitive.ObjectID `bson:"school" json:"school"`
+	Category   string             `bson:"category" json:"category"`
+	EnrolledAt time.Time          `bson:"enrolledAt" json:"enrolledAt"`
+}
+
+type Candidates []Candidate
+
+// Outcome of a theory or practical exam. Failed attempts are kept as well
+type ExamResult struct {
+	ID         primitive.ObjectID `bson:"_id,omitempty" json:"id"`
+	Candidate  primitive.ObjectID `bson:"candidate" json:"candidate"`
+	Person     string             `bson:"person" json:"person"`
+	School     primitive.ObjectID `bson:"school" json:"school"`
+	Category   string             `bson:"category" json:"category"`
+	Type       string             `bson:"type" json:"type"`
+	Passed     bool               `bson:"passed" json:"passed"`
+	Points     int                `bson:"points,omitempty" json:"points,omitempty"`
+	Examiner   string             `bson:"examiner" json:"examiner"`
+	TakenAt    time.Time          `bson:"takenAt" json:"takenAt"`
+	RecordedAt time.Time          `bson:"recordedAt" json:"recordedAt"`
+}
+
+type ExamResults []ExamResult
+
+// Certificate of the health institution that the person is fit to drive
+type MedicalCertificate struct {
+	ID          primitive.ObjectID `bson:"_id,omitempty" json:"id"`
+	Person      string             `bson:"person" json:"person"`
+	Number      string             `bson:"number" json:"number"`
+	Institution string             `bson:"institution" json:"institution"`
+	IssuedDate  time.Time          `bson:"issuedDate" json:"issuedDate"`
+	ValidUntil  time.Time          `bson:"validUntil" json:"validUntil"`
+}
+
+type MedicalCertificates []MedicalCertificate
+
+// Whether the person meets the conditions for a driving permit of the category
+type Qualification struct {
+	Person                  string    `json:"person"`
+	Category                string    `json:"category"`
+	TheoryPassed            bool      `json:"theoryPassed"`
+	PracticalPassed         bool      `json:"practicalPassed"`
+	MedicalCertificateValid bool      `json:"medicalCertificateValid"`
+	MedicalValidUntil       time.Time `json:"medicalValidUntil,omitempty"`
+}
+
+func (q Qualification) Qualified() bool {
+	return q.TheoryPassed && q.PracticalPassed && q.MedicalCertificateValid
+}
+
+func (ds *DrivingSchool) ToJSON(w io.Writer) error {
+	e := json.NewEncoder(w)
+	return e.Encode(ds)
+}
+
+func (ds *DrivingSchool) FromJSON(r io.Reader) error {
+	d := json.NewDecoder(r)
+	return d.Decode(ds)
+}
+
+func (dss *DrivingSchools) ToJSON(w io.Writer) error {
+	e := json.NewEncoder(w)
+	return e.Encode(dss)
+}
+
+func (c *Candidate) ToJSON(w io.Writer) error {
+	e := json.NewEncoder(w)
+	return e.Encode(c)
+}
+
+func (c *Candidate) FromJSON(r io.Reader) error {
+	d := json.NewDecoder(r)
+	return d.Decode(c)
+}
+
+func (cs *Candidates) ToJSON(w io.Writer) error {
+	e := json.NewEncoder(w)
+	return e.Encode(cs)
+}
+
+func (er *ExamResult) ToJSON(w io.Writer) error {
+	e := json.NewEncoder(w)
+	return e.Encode(er)
+}
+
+func (er *ExamResult) FromJSON(r io.Reader) error {
+	d := json.NewDecoder(r)
+	return d.Decode(er)
+}
+
+func (ers *ExamResults) ToJSON(w io.Writer) error {
+	e := json.NewEncoder(w)
+	return e.Encode(ers)
+}
+
+func (mc *MedicalCertificate) ToJSON(w io.Writer) error {
+	e := json.NewEncoder(w)
+	return e.Encode(mc)
+}
+
+func (mc *MedicalCertificate) FromJSON(r io.Reader) error {
+	d := json.NewDecoder(r)
+	return d.Decode(mc)
+}
+
+func (mcs *MedicalCertificates) ToJSON(w io.Writer) error {
+	e := json.NewEncoder(w)
+	return e.Encode(mcs)
+}
+
+func (q *Qualification) ToJSON(w io.Writer) error {
+	e := json.NewEncoder(w)
+	return e.Encode(q)
+}
@@ -0,0 +1,225 @@
+package data
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+var (
+	ErrDrivingSchoolNotFound = errors.New("driving school not found")
+	ErrDrivingSchoolExists   = errors.New("driving school with the accreditation number already exists")
+	ErrCandidateNotFound     = errors.New("candidate not found")
+	ErrCandidateExists       = errors.New("person is already a candidate for the category at the school")
+)
+
+//Driving school methods
+
+func (mr *MUPRepo) CreateDrivingSchool(ctx context.Context, school *DrivingSchool) error {
+	collection := mr.getMupCollection("drivingSchool")
+
+	school.ID = primitive.NewObjectID()
+	_, err := collection.InsertOne(ctx, school)
+	if err != nil {
+		if mongo.IsDuplicateKeyError(err) {
+			return ErrDrivingSchoolExists
+		}
+		return err
+	}
+
+	return nil
+}
+
+func (mr *MUPRepo) GetDrivingSchools(ctx context.Context) (DrivingSchools, error) {
+	collection := mr.getMupCollection("drivingSchool")
+
+	opts := options.Find().SetSort(bson.D{{Key: "name", Value: 1}})
+	cursor, err := collection.Find(ctx, bson.D{}, opts)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	schools := DrivingSchools{}
+	if err = cursor.All(ctx, &schools); err != nil {
+		return nil, err
+	}
+
+	return schools, nil
+}
+
+func (mr *MUPRepo) GetDrivingSchool(ctx context.Context, id primitive.ObjectID) (DrivingSchool, error) {
+	collection := mr.getMupCollection("drivingSchool")
+
+	var school DrivingSchool
+	err := collection.FindOne(ctx, bson.D{{Key: "_id", Value: id}}).Decode(&school)
+	if err != nil {
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			return DrivingSchool{}, ErrDrivingSchoolNotFound
+		}
+		return DrivingSchool{}, err
+	}
+
+	return school, nil
+}
+
+func (mr *MUPRepo) EnrollCandidate(ctx context.Context, candidate *Candidate) error {
+	collection := mr.getMupCollection("candidate")
+
+	candidate.ID = primitive.NewObjectID()
+	_, err := collection.InsertOne(ctx, candidate)
+	if err != nil {
+		if mongo.IsDuplicateKeyError(err) {
+			return ErrCandidateExists
+		}
+		return err
+	}
+
+	return nil
+}
+
+func (mr *MUPRepo) GetCandidate(ctx context.Context, id primitive.ObjectID) (Candidate, error) {
+	collection := mr.getMupCollection("candidate")
+
+	var candidate Candidate
+	err := collection.FindOne(ctx, bson.D{{Key: "_id", Value: id}}).Decode(&candidate)
+	if err != nil {
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			return Candidate{}, ErrCandidateNotFound
+		}
+		return Candidate{}, err
+	}
+
+	return candidate, nil
+}
+
+func (mr *MUPRepo) GetSchoolCandidates(ctx context.Context, school primitive.ObjectID) (Candidates, error) {
+	collection := mr.getMupCollection("candidate")
+
+	opts := options.Find().SetSort(bson.D{{Key: "enrolledAt", Value: -1}})
+	cursor, err := collection.Find(ctx, bson.D{{Key: "school", Value: school}}, opts)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	candidates := Candidates{}
+	if err = cursor.All(ctx, &candidates); err != nil {
+		return nil, err
+	}
+
+	return candidates, nil
+}
+
+func (mr *MUPRepo) SaveExamResult(ctx context.Context, result *ExamResult) error {
+	collection := mr.getMupCollection("examResult")
+
+	result.ID = primitive.NewObjectID()
+	_, err := collection.InsertOne(ctx, result)
+	return err
+}
+
+// Returns exam results of the person, latest first
+func (mr *MUPRepo) GetPersonsExamResults(ctx context.Context, jmbg string) (ExamResults, error) {
+	collection := mr.getMupCollection("examResult")
+
+	opts := options.Find().SetSort(bson.D{{Key: "takenAt", Value: -1}})
+	cursor, err := collection.Find(ctx, bson.D{{Key: "person", Value: jmbg}}, opts)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	results := ExamResults{}
+	if err = cursor.All(ctx, &results); err != nil {
+		return nil, err
+	}
+
+	return results, nil
+}
+
+// Reports whether the person passed the exam of the type for the category
+func (mr *MUPRepo) HasPassedExam(ctx context.Context, jmbg, category, examType string) (bool, error) {
+	collection := mr.getMupCollection("examResult")
+
+	filter := bson.D{
+		{Key: "person", Value: jmbg},
+		{Key: "category", Value: category},
+		{Key: "type", Value: examType},
+		{Key: "passed", Value: true},
+	}
+
+	count, err := collection.CountDocuments(ctx, filter, options.Count().SetLimit(1))
+	if err != nil {
+		return false, err
+	}
+
+	return count > 0, nil
+}
+
+func (mr *MUPRepo) SaveMedicalCertificate(ctx context.Context, certificate *MedicalCertificate) error {
+	collection := mr.getMupCollection("medicalCertificate")
+
+	certificate.ID = primitive.NewObjectID()
+	_, err := collection.InsertOne(ctx, certificate)
+	return err
+}
+
+// Returns medical certificates of the person, latest first
+func (mr *MUPRepo) GetPersonsMedicalCertificates(ctx context.Context, jmbg string) (MedicalCertificates, error) {
+	collection := mr.getMupCollection("medicalCertificate")
+
+	opts := options.Find().SetSort(bson.D{{Key: "issuedDate", Value: -1}})
+	cursor, err := collection.Find(ctx, bson.D{{Key: "person", Value: jmbg}}, opts)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	certificates := MedicalCertificates{}
+	if err = cursor.All(ctx, &certificates); err != nil {
+		return nil, err
+	}
+
+	return certificates, nil
+}
+
+// Returns exam and medical status of the person for the category at the given time
+func (mr *MUPRepo) GetQualification(ctx context.Context, jmbg, category string, at time.Time) (Qualification, error) {
+	qualification := Qualification{Person: jmbg, Category: category}
+
+	var err error
+	qualification.TheoryPassed, err = mr.HasPassedExam(ctx, jmbg, category, ExamTheory)
+	if err != nil {
+		return Qualification{}, err
+	}
+
+	qualification.PracticalPassed, err = mr.HasPassedExam(ctx, jmbg, category, ExamPractical)
+	if err != nil {
+		return Qualification{}, err
+	}
+
+	filter := bson.D{
+		{Key: "person", Value: jmbg},
+		{Key: "issuedDate", Value: bson.D{{Key: "$lte", Value: at}}},
+		{Key: "validUntil", Value: bson.D{{Key: "$gte", Value: at}}},
+	}
+	opts := options.FindOne().SetSort(bson.D{{Key: "validUntil", Value: -1}})
+
+	var certificate MedicalCertificate
+	err = mr.getMupCollection("medicalCertificate").FindOne(ctx, filter, opts).Decode(&certificate)
+	if err != nil && !errors.Is(err, mongo.ErrNoDocuments) {
+		return Qualification{}, err
+	}
+	if err == nil {
+		qualification.MedicalCertificateValid = true
+		qualification.MedicalValidUntil = certificate.ValidUntil
+	}
+
+	return qualification, nil
+}
@@ -0,0 +1,71 @@
+package data
+
+import (
+	"encoding/json"
+	"io"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// Road traffic conventions under which an international driving permit is issued
+const (
+	Convention1949 = "1949"
+	Convention1968 = "1968"
+)
+
+// International driving permit. It is only valid together with the domestic
+// permit it was issued for
+type InternationalPermit struct {
+	ID             primitive.ObjectID `bson:"_id,omitempty" json:"id"`
+	Number         string             `bson:"number" json:"number"`
+	Person         string             `bson:"person" json:"person"`
+	Permit         primitive.ObjectID `bson:"permit" json:"permit"`
+	Convention     string             `bson:"convention" json:"convention"`
+	Categories     []string           `bson:"categories" json:"categories"`
+	IssuedDate     time.Time          `bson:"issuedDate" json:"issuedDate"`
+	ExpirationDate time.Time          `bson:"expirationDate" json:"expirationDate"`
+	Office         primitive.ObjectID `bson:"office" json:"office"`
+	IssuedBy       string             `bson:"issuedBy" json:"issuedBy"`
+	Printout       PermitPrintout     `bson:"printout" json:"printout"`
+}
+
+type InternationalPermits []InternationalPermit
+
+// Content of the permit booklet as laid out by the convention. It is kept as
+// printed, so reprints match the original even when the holder's data changes
+type PermitPrintout struct {
+	Title            string   `bson:"title" json:"title"`
+	IssuingCountry   string   `bson:"issuingCountry" json:"issuingCountry"`
+	DistinguishSign  string   `bson:"distinguishSign" json:"distinguishSign"`
+	Authority        string   `bson:"authority" json:"authority"`
+	Place            string   `bson:"place" json:"place"`
+	IssuedDate       string   `bson:"issuedDate" json:"issuedDate"`
+	ValidUntil       string   `bson:"validUntil" json:"validUntil"`
+	DomesticNumber   string   `bson:"domesticNumber" json:"domesticNumber"`
+	Surname          string   `bson:"surname" json:"surname"`
+	OtherNames       string   `bson:"otherNames" json:"otherNames"`
+	DateOfBirth      string   `bson:"dateOfBirth" json:"dateOfBirth"`
+	PermanentAddress string   `bson:"permanentAddress" json:"permanentAddress"`
+	Categories       []string `bson:"categories" json:"categories"`
+}
+
+type InternationalPermitRequest struct {
+	Permit     primitive.ObjectID `json:"permit"`
+	Convention string             `json:"convention"`
+}
+
+func (ip *InternationalPermit) ToJSON(w io.Writer) error {
+	e := json.NewEncoder(w)
+	return e.Encode(ip)
+}
+
+func (ips *InternationalPermits) ToJSON(w io.Writer) error {
+	e := json.NewEncoder(w)
+	return e.Encode(ips)
+}
+
+func (ipr *InternationalPermitRequest) FromJSON(r io.Reader) error {
+	d := json.NewDecoder(r)
+	return d.Decode(ipr)
+}
@@ -0,0 +1,83 @@
+package data
+
+import (
+	"context"
+	"errors"
+	"log"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+var ErrInternationalPermitNotFound = errors.New("international driving permit not found")
+
+//International driving permit methods
+
+// Saves international permit. While a permit of the same convention issued for the
+// domestic permit is still valid, that one is returned instead
+func (mr *MUPRepo) IssueInternationalPermit(ctx context.Context, permit *InternationalPermit) error {
+	collection := mr.getMupCollection("internationalPermit")
+
+	filter := bson.D{
+		{Key: "permit", Value: permit.Permit},
+		{Key: "convention", Value: permit.Convention},
+		{Key: "expirationDate", Value: bson.D{{Key: "$gt", Value: time.Now()}}},
+	}
+
+	var existing InternationalPermit
+	err := collection.FindOne(ctx, filter).Decode(&existing)
+	if err == nil {
+		*permit = existing
+		return nil
+	}
+	if !errors.Is(err, mongo.ErrNoDocuments) {
+		return err
+	}
+
+	permit.ID = primitive.NewObjectID()
+
+	_, err = collection.InsertOne(ctx, permit)
+	if err != nil {
+		log.Printf("Failed to create international permit: %v", err)
+		return err
+	}
+
+	return nil
+}
+
+func (mr *MUPRepo) GetInternationalPermit(ctx context.Context, id primitive.ObjectID) (InternationalPermit, error) {
+	collection := mr.getMupCollection("internationalPermit")
+
+	var permit InternationalPermit
+	err := collection.FindOne(ctx, bson.D{{Key: "_id", Value: id}}).Decode(&permit)
+	if err != nil {
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			return InternationalPermit{}, ErrInternationalPermitNotFound
+		}
+		return InternationalPermit{}, err
+	}
+
+	return permit, nil
+}
+
+// Returns all international permits of the person, latest first
+func (mr *MUPRepo) GetPersonsInternationalPermits(ctx context.Context, jmbg string) (InternationalPermits, error) {
+	collection := mr.getMupCollection("internationalPermit")
+
+	opts := options.Find().SetSort(bson.D{{Key: "issuedDate", Value: -1}})
+	cursor, err := collection.Find(ctx, bson.D{{Key: "person", Value: jmbg}}, opts)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	permits := InternationalPermits{}
+	if err = cursor.All(ctx, &permits); err != nil {
+		return nil, err
+	}
+
+	return permits, nil
+}
@@ -8,25 +8,28 @@ import (
 	"go.mongodb.org/mongo-driver/bson/primitive"
 )
 
+// MUP office (police administration). Records issued by an office reference it,
+// so the office document itself stays small
 type Mup struct {
-	ID             primitive.ObjectID   `bson:"_id,omitempty" json:"id"`
-	Name           string               `bson:"name" json:"name"`
-	Address        Address              `bson:"address" json:"address"`
-	Vehicles       []primitive.ObjectID `bson:"vehicles" json:"vehicles"`
-	TrafficPermits []primitive.ObjectID `bson:"trafficPermits" json:"trafficPermits"`
-	Plates         []string             `bson:"plates" json:"plates"`
-	DrivingBans    []primitive.ObjectID `bson:"drivingBans" json:"drivingBans"`
-	Registrations  []string             `bson:"registrations" json:"registrations"`
+	ID      primitive.ObjectID `bson:"_id,omitempty" json:"id"`
+	Name    string             `bson:"name" json:"name"`
+	Address Address            `bson:"address" json:"address"`
 }
 
-type DrivingBan struct {
-	ID       primitive.ObjectID `bson:"_id,omitempty" json:"id"`
-	Reason   string             `bson:"reason" json:"reason"`
-	Duration time.Time          `bson:"duration" json:"duration"`
-	Person   string             `bson:"person" json:"person"`
+type Mups []Mup
+
+// Queue length and average time from submission to approval of an office
+type OfficeQueueStats struct {
+	Office                          primitive.ObjectID `json:"office"`
+	Name                            string             `json:"name"`
+	Municipality                    string             `json:"municipality"`
+	PendingRegistrations            int64              `json:"pendingRegistrations"`
+	PendingTrafficPermits           int64              `json:"pendingTrafficPermits"`
+	AvgRegistrationProcessingHours  float64            `json:"avgRegistrationProcessingHours"`
+	AvgTrafficPermitProcessingHours float64            `json:"avgTrafficPermitProcessingHours"`
 }
 
-type DrivingBans []DrivingBan
+type OfficeQueueStatsList []OfficeQueueStats
 
 type TrafficPermit struct {
 	ID             primitive.ObjectID `bson:"_id,omitempty" json:"id"`
@@ -35,6 +38,15 @@ type TrafficPermit struct {
 	ExpirationDate time.Time          `bson:"expirationDate" json:"expirationDate"`
 	Approved       bool               `bson:"approved" json:"approved"`
 	Person         string             `bson:"person" json:"person"`
+	Office         primitive.ObjectID `bson:"office" json:"office"`
+	ProcessedAt    time.Time          `bson:"processedAt,omitempty" json:"processedAt"`
+	Category       string             `bson:"category" json:"category"`
+	PaymentOrder   primitive.ObjectID `bson:"paymentOrder,omitempty" json:"paymentOrder"`
+	Paid           bool               `bson:"paid" json:"paid"`
+	Status         string             `bson:"status" json:"status"`
+	Timeline       Timeline           `bson:"timeline" json:"timeline"`
+	// Conversion request the permit was issued on, for converted foreign licences
+	Conversion primitive.ObjectID `bson:"conversion,omitempty" json:"conversion,omitempty"`
 }
 
 type TrafficPermits []TrafficPermit
@@ -46,8 +58,13 @@ type TrafficPermitDetails struct {
 	ExpirationDate time.Time          `json:"expirationDate"`
 	Approved       bool               `json:"approved"`
 	Person         string             `json:"person"`
+	Office         primitive.ObjectID `json:"office"`
 	FirstName      string             `json:"firstName"`
 	LastName       string             `json:"lastName"`
+	Status         string             `json:"status"`
+	Timeline       Timeline           `json:"timeline"`
+	Attachments    Attachments        `json:"attachments"`
+	Qualification  Qualification      `json:"qualification"`
 }
 
 type TrafficPermitDetailsList []TrafficPermitDetails
@@ -61,6 +78,8 @@ type DrivingPermitDetails struct {
 	Person         string             `bson:"person" json:"person"`
 	FirstName      string             `bson:"firstName" json:"firstName"`
 	LastName       string             `bson:"lastName" json:"lastName"`
+	Status         string             `bson:"status" json:"status"`
+	Timeline       Timeline           `bson:"timeline" json:"timeline"`
 }
 
 type DrivingPermitDetailsList []DrivingPermitDetails
@@ -95,24 +114,19 @@ func (m *Mup) FromJSON(r io.Reader) error {
 	return d.Decode(m)
 }
 
-func (db *DrivingBan) ToJSON(w io.Writer) error {
+func (m *Mups) ToJSON(w io.Writer) error {
 	e := json.NewEncoder(w)
-	return e.Encode(db)
+	return e.Encode(m)
 }
 
-func (db *DrivingBan) FromJSON(r io.Reader) error {
+func (m *Mups) FromJSON(r io.Reader) error {
 	d := json.NewDecoder(r)
-	return d.Decode(db)
+	return d.Decode(m)
 }
 
-func (db *DrivingBans) ToJSON(w io.Writer) error {
+func (oqs *OfficeQueueStatsList) ToJSON(w io.Writer) error {
 	e := json.NewEncoder(w)
-	return e.Encode(db)
-}
-
-func (db *DrivingBans) FromJSON(r io.Reader) error {
-	d := json.NewDecoder(r)
-	return d.Decode(db)
+	return e.Encode(oqs)
 }
 
 func (tp *TrafficPermit) ToJSON(w io.Writer) error {
@@ -16,6 +16,16 @@ import (
 	"go.mongodb.org/mongo-driver/mongo/readpref"
 )
 
+var (
+	ErrRegistrationNotFound  = errors.New("registration not found")
+	ErrVehicleNotFound       = errors.New("vehicle not found")
+	ErrMupNotFound           = errors.New("mup not found")
+	ErrMupAlreadyExists      = errors.New("mup already exists")
+	ErrTrafficPermitNotFound = errors.New("traffic permit not found")
+	ErrWrongOffice           = errors.New("request belongs to another office")
+	ErrInvalidTransition     = errors.New("request can't move to the requested status")
+)
+
 type MUPRepo struct {
 	cli    *mongo.Client
 	logger *log.Logger
@@ -67,6 +77,117 @@ func (mr *MUPRepo) Ping() {
 	mr.logger.Println(databases)
 }
 
+// Creates indexes the repo relies on. Unique indexes keep concurrent retries
+// of the same request from creating duplicates, the rest back lookups by person,
+// owner, plates and the per office queues
+func (mr *MUPRepo) EnsureIndexes(ctx context.Context) error {
+	openRequest := bson.D{{Key: "status", Value: bson.D{{Key: "$in", Value: OpenStatuses}}}}
+
+	// Open requests used to be told apart by the approved flag alone
+	legacy := map[string]string{"registration": "vehicleID_1", "trafficPermit": "person_1"}
+	for collection, name := range legacy {
+		if err := mr.dropIndex(ctx, collection, name); err != nil {
+			return err
+		}
+	}
+
+	indexes := map[string][]mongo.IndexModel{
+		"vehicle": {
+			{Keys: bson.D{{Key: "owner", Value: 1}}},
+			{Keys: bson.D{{Key: "plates", Value: 1}}},
+		},
+		"registration": {
+			{Keys: bson.D{{Key: "registrationNumber", Value: 1}}, Options: options.Index().SetUnique(true)},
+			{Keys: bson.D{{Key: "vehicleID", Value: 1}}, Options: options.Index().SetName("vehicleID_open").SetUnique(true).SetPartialFilterExpression(openRequest)},
+			{Keys: bson.D{{Key: "owner", Value: 1}}},
+			{Keys: bson.D{{Key: "plates", Value: 1}}},
+			{Keys: bson.D{{Key: "office", Value: 1}, {Key: "status", Value: 1}}},
+		},
+		"plates": {
+			{Keys: bson.D{{Key: "registrationNumber", Value: 1}}, Options: options.Index().SetUnique(true)},
+			{Keys: bson.D{{Key: "platesNumber", Value: 1}}, Options: options.Index().SetUnique(true)},
+			{Keys: bson.D{{Key: "owner", Value: 1}}},
+		},
+		"trafficPermit": {
+			{Keys: bson.D{{Key: "person", Value: 1}}, Options: options.Index().SetName("person_open").SetUnique(true).SetPartialFilterExpression(openRequest)},
+			{Keys: bson.D{{Key: "person", Value: 1}, {Key: "approved", Value: 1}}},
+			{Keys: bson.D{{Key: "office", Value: 1}, {Key: "status", Value: 1}}},
+		},
+		"mup": {
+			{Keys: bson.D{{Key: "name", Value: 1}}, Options: options.Index().SetUnique(true)},
+			{Keys: bson.D{{Key: "address.municipality", Value: 1}}},
+		},
+		"appointment": {
+			{Keys: bson.D{{Key: "requestType", Value: 1}, {Key: "requestID", Value: 1}}, Options: options.Index().SetUnique(true).SetPartialFilterExpression(bson.D{{Key: "cancelled", Value: false}})},
+			{Keys: bson.D{{Key: "office", Value: 1}, {Key: "start", Value: 1}}},
+			{Keys: bson.D{{Key: "person", Value: 1}}},
+		},
+		"appointmentSlot": {
+			{Keys: bson.D{{Key: "_id.office", Value: 1}, {Key: "_id.start", Value: 1}}},
+		},
+		"fee": {
+			{Keys: bson.D{{Key: "requestType", Value: 1}, {Key: "vehicleCategory", Value: 1}}, Options: options.Index().SetUnique(true)},
+		},
+		"paymentOrder": {
+			{Keys: bson.D{{Key: "referenceNumber", Value: 1}}, Options: options.Index().SetUnique(true)},
+			{Keys: bson.D{{Key: "requestType", Value: 1}, {Key: "requestID", Value: 1}}, Options: options.Index().SetUnique(true)},
+			{Keys: bson.D{{Key: "payer", Value: 1}}},
+		},
+		"signingKey": {
+			{Keys: bson.D{{Key: "active", Value: 1}}, Options: options.Index().SetUnique(true).SetPartialFilterExpression(bson.D{{Key: "active", Value: true}})},
+		},
+		"drivingSchool": {
+			{Keys: bson.D{{Key: "accreditationNumber", Value: 1}}, Options: options.Index().SetUnique(true)},
+		},
+		"candidate": {
+			{Keys: bson.D{{Key: "person", Value: 1}, {Key: "school", Value: 1}, {Key: "category", Value: 1}}, Options: options.Index().SetUnique(true)},
+			{Keys: bson.D{{Key: "school", Value: 1}}},
+		},
+		"examResult": {
+			{Keys: bson.D{{Key: "person", Value: 1}, {Key: "category", Value: 1}, {Key: "type", Value: 1}}},
+		},
+		"medicalCertificate": {
+			{Keys: bson.D{{Key: "person", Value: 1}, {Key: "validUntil", Value: -1}}},
+		},
+		"attachment": {
+			{Keys: bson.D{{Key: "requestType", Value: 1}, {Key: "requestID", Value: 1}, {Key: "kind", Value: 1}, {Key: "sha256", Value: 1}}, Options: options.Index().SetUnique(true)},
+			{Keys: bson.D{{Key: "blobKey", Value: 1}}},
+		},
+		"drivingBan": {
+			{Keys: bson.D{{Key: "person", Value: 1}, {Key: "reason", Value: 1}, {Key: "start", Value: 1}, {Key: "end", Value: 1}}, Options: options.Index().SetUnique(true)},
+			{Keys: bson.D{{Key: "end", Value: 1}}},
+		},
+		"permitConversion": {
+			{Keys: bson.D{{Key: "person", Value: 1}, {Key: "country", Value: 1}, {Key: "originalNumber", Value: 1}}, Options: options.Index().SetName("conversion_open").SetUnique(true).SetPartialFilterExpression(openRequest)},
+			{Keys: bson.D{{Key: "office", Value: 1}, {Key: "status", Value: 1}}},
+		},
+		"internationalPermit": {
+			{Keys: bson.D{{Key: "number", Value: 1}}, Options: options.Index().SetUnique(true)},
+			{Keys: bson.D{{Key: "permit", Value: 1}, {Key: "convention", Value: 1}}},
+			{Keys: bson.D{{Key: "person", Value: 1}}},
+		},
+	}
+
+	for collection, models := range indexes {
+		_, err := mr.getMupCollection(collection).Indexes().CreateMany(ctx, models)
+		if err != nil {
+			return fmt.Errorf("failed to create indexes for %s: %v", collection, err)
+		}
+	}
+
+	return nil
+}
+
+// Drops the index by its name, if the collection has it
+func (mr *MUPRepo) dropIndex(ctx context.Context, collection, name string) error {
+	_, err := mr.getMupCollection(collection).Indexes().DropOne(ctx, name)
+	var cmdErr mongo.CommandError
+	if err != nil && !(errors.As(err, &cmdErr) && (cmdErr.Name == "IndexNotFound" || cmdErr.Name == "NamespaceNotFound")) {
+		return fmt.Errorf("failed to drop index %s of %s: %v", name, collection, err)
+	}
+	return nil
+}
+
 func (mr *MUPRepo) Initialize(ctx context.Context) error {
 	db := mr.cli.Database("mupDB")
 
@@ -100,6 +221,24 @@ func (mr *MUPRepo) Initialize(ctx context.Context) error {
 		return err
 	}
 
+	// Example initial data for Mup collection
+	initialMup := Mup{
+		ID:   primitive.NewObjectID(),
+		Name: "Mup",
+		Address: Address{
+			Municipality: "Novi Sad",
+			Locality:     "Novi Sad",
+			StreetName:   "Dunavska",
+			StreetNumber: 1,
+		},
+	}
+
+	mupCollection := mr.getMupCollection("mup")
+	_, err = mupCollection.InsertOne(ctx, initialMup)
+	if err != nil {
+		return fmt.Errorf("failed to insert initial mup: %v", err)
+	}
+
 	initialVehicles := []interface{}{
 		Vehicle{
 			ID:           primitive.NewObjectID(),
@@ -109,6 +248,7 @@ func (mr *MUPRepo) Initialize(ctx context.Context) error {
 			Owner:        "1234567891111",
 			Registration: "NS123AB",
 			Plates:       "NS123AB",
+			Category:     "B",
 		},
 		Vehicle{
 			ID:           primitive.NewObjectID(),
@@ -118,6 +258,7 @@ func (mr *MUPRepo) Initialize(ctx context.Context) error {
 			Registration: "",
 			Plates:       "",
 			Owner:        "123456789",
+			Category:     "B",
 		},
 		Vehicle{
 			ID:           primitive.NewObjectID(),
@@ -127,6 +268,7 @@ func (mr *MUPRepo) Initialize(ctx context.Context) error {
 			Owner:        "1234567891111",
 			Registration: "BG456CD",
 			Plates:       "BG456CD",
+			Category:     "B",
 		},
 		Vehicle{
 			ID:           primitive.NewObjectID(),
@@ -136,6 +278,7 @@ func (mr *MUPRepo) Initialize(ctx context.Context) error {
 			Registration: "",
 			Plates:       "",
 			Owner:        "33355577799",
+			Category:     "B",
 		},
 		Vehicle{
 			ID:           primitive.NewObjectID(),
@@ -145,6 +288,7 @@ func (mr *MUPRepo) Initialize(ctx context.Context) error {
 			Owner:        "1234567891122",
 			Registration: "BG123AA",
 			Plates:       "BG123AA",
+			Category:     "B",
 		},
 		Vehicle{
 			ID:           primitive.NewObjectID(),
@@ -154,6 +298,7 @@ func (mr *MUPRepo) Initialize(ctx context.Context) error {
 			Owner:        "1234567891133",
 			Registration: "NS456BB",
 			Plates:       "NS456BB",
+			Category:     "B",
 		},
 		Vehicle{
 			ID:           primitive.NewObjectID(),
@@ -163,6 +308,7 @@ func (mr *MUPRepo) Initialize(ctx context.Context) error {
 			Owner:        "1234567891144",
 			Registration: "SU789CC",
 			Plates:       "SU789CC",
+			Category:     "B",
 		},
 		Vehicle{
 			ID:           primitive.NewObjectID(),
@@ -172,6 +318,7 @@ func (mr *MUPRepo) Initialize(ctx context.Context) error {
 			Owner:        "1234567891155",
 			Registration: "KA123DD",
 			Plates:       "KA123DD",
+			Category:     "B",
 		},
 		Vehicle{
 			ID:           primitive.NewObjectID(),
@@ -181,6 +328,7 @@ func (mr *MUPRepo) Initialize(ctx context.Context) error {
 			Owner:        "1234567891166",
 			Registration: "KA456EE",
 			Plates:       "KA456EE",
+			Category:     "B",
 		},
 		Vehicle{
 			ID:           primitive.NewObjectID(),
@@ -190,6 +338,7 @@ func (mr *MUPRepo) Initialize(ctx context.Context) error {
 			Owner:        "123456789",
 			Registration: "",
 			Plates:       "",
+			Category:     "B",
 		},
 		Vehicle{
 			ID:           primitive.NewObjectID(),
@@ -199,6 +348,7 @@ func (mr *MUPRepo) Initialize(ctx context.Context) error {
 			Owner:        "1234567891111",
 			Registration: "",
 			Plates:       "",
+			Category:     "B",
 		},
 	}
 
@@ -222,6 +372,8 @@ func (mr *MUPRepo) Initialize(ctx context.Context) error {
 			Owner:              "1234567891111",
 			Plates:             "NS123AB",
 			Approved:           true,
+			Office:             initialMup.ID,
+			Paid:               true,
 		},
 		Registration{
 			VehicleID:          initialVehicles[1].(Vehicle).ID,
@@ -231,6 +383,8 @@ func (mr *MUPRepo) Initialize(ctx context.Context) error {
 			Owner:              "1234567891111",
 			Plates:             "BG456CD",
 			Approved:           true,
+			Office:             initialMup.ID,
+			Paid:               true,
 		},
 		Registration{
 			VehicleID:          initialVehicles[4].(Vehicle).ID,
@@ -240,6 +394,8 @@ func (mr *MUPRepo) Initialize(ctx context.Context) error {
 			Owner:              "1234567891122",
 			Plates:             "BG123AA",
 			Approved:           true,
+			Office:             initialMup.ID,
+			Paid:               true,
 		},
 		Registration{
 			VehicleID:          initialVehicles[5].(Vehicle).ID,
@@ -249,6 +405,8 @@ func (mr *MUPRepo) Initialize(ctx context.Context) error {
 			Owner:              "1234567891133",
 			Plates:             "NS456BB",
 			Approved:           true,
+			Office:             initialMup.ID,
+			Paid:               true,
 		},
 		Registration{
 			VehicleID:          initialVehicles[6].(Vehicle).ID,
@@ -258,6 +416,8 @@ func (mr *MUPRepo) Initialize(ctx context.Context) error {
 			Owner:              "1234567891144",
 			Plates:             "SU789CC",
 			Approved:           true,
+			Office:             initialMup.ID,
+			Paid:               true,
 		},
 		Registration{
 			VehicleID:          initialVehicles[7].(Vehicle).ID,
@@ -267,6 +427,8 @@ func (mr *MUPRepo) Initialize(ctx context.Context) error {
 			Owner:              "1234567891155",
 			Plates:             "KA123DD",
 			Approved:           true,
+			Office:             initialMup.ID,
+			Paid:               true,
 		},
 		Registration{
 			VehicleID:          initialVehicles[8].(Vehicle).ID,
@@ -276,6 +438,8 @@ func (mr *MUPRepo) Initialize(ctx context.Context) error {
 			Owner:              "1234567891166",
 			Plates:             "KA456EE",
 			Approved:           true,
+			Office:             initialMup.ID,
+			Paid:               true,
 		},
 	}
 
@@ -295,6 +459,7 @@ func (mr *MUPRepo) Initialize(ctx context.Context) error {
 			PlateType:          "Standard", // Assuming a default plate type
 			Owner:              r.Owner,
 			VehicleID:          r.VehicleID,
+			Office:             r.Office,
 		}
 		err = mr.SavePlates(ctx, plates)
 		if err != nil {
@@ -302,36 +467,17 @@ func (mr *MUPRepo) Initialize(ctx context.Context) error {
 		}
 	}
 
-	// Example initial data for Mup collection
-	initialMup := Mup{
-		ID:   primitive.NewObjectID(),
-		Name: "Mup",
-		Address: Address{
-			Municipality: "",
-			Locality:     "Novi Sad",
-			StreetName:   "Dunavska",
-			StreetNumber: 1,
-		},
-		Vehicles:       []primitive.ObjectID{initialVehicles[0].(Vehicle).ID, initialVehicles[1].(Vehicle).ID},
-		TrafficPermits: []primitive.ObjectID{},
-		Plates:         []string{"NS123AB", "BG456CD", "BG123AA", "NS456BB", "SU789CC", "KA123DD", "KA456EE"},
-		DrivingBans:    []primitive.ObjectID{},
-		Registrations:  []string{"NS123AB", "BG456CD", "BG123AA", "NS456BB", "SU789CC", "KA123DD", "KA456EE"},
-	}
-
-	mupCollection := mr.getMupCollection("mup")
-	_, err = mupCollection.InsertOne(ctx, initialMup)
-	if err != nil {
-		return fmt.Errorf("failed to insert initial mup: %v", err)
-	}
-
 	// Initial data for DrivingBan collection
 	initialDrivingBans := []interface{}{
 		DrivingBan{
-			ID:       primitive.NewObjectID(),
-			Reason:   "Speeding",
-			Duration: time.Date(2024, 8, 31, 0, 0, 0, 0, time.UTC),
-			Person:   "1234567891111",
+			ID:        primitive.NewObjectID(),
+			Reason:    "Speeding",
+			Start:     time.Date(2024, 6, 1, 0, 0, 0, 0, time.UTC),
+			End:       time.Date(2024, 8, 31, 0, 0, 0, 0, time.UTC),
+			Person:    "1234567891111",
+			Office:    initialMup.ID,
+			Authority: BanAuthorityCourt,
+			IssuedAt:  time.Date(2024, 6, 1, 0, 0, 0, 0, time.UTC),
 		},
 	}
 
@@ -350,6 +496,9 @@ func (mr *MUPRepo) Initialize(ctx context.Context) error {
 			ExpirationDate: time.Date(2034, 3, 1, 0, 0, 0, 0, time.UTC),
 			Approved:       true,
 			Person:         "1234567891111",
+			Office:         initialMup.ID,
+			Category:       "B",
+			Paid:           true,
 		},
 	}
 
@@ -373,27 +522,25 @@ func (mr *MUPRepo) SaveVehicle(ctx context.Context, vehicle *Vehicle) error {
 		return err
 	}
 
-	err = mr.SaveVehicleIntoMup(ctx, vehicle)
-	if err != nil {
-		log.Printf("Failed to save vehicle into mup: %v", err)
-		return err
-	}
-
 	return nil
 }
 
 func (mr *MUPRepo) SaveRegistrationIntoVehicle(ctx context.Context, registration *Registration) error {
 	collection := mr.getMupCollection("vehicle")
 
-	filter := bson.D{{"_id", registration.VehicleID}}
+	filter := bson.D{{Key: "_id", Value: registration.VehicleID}, {Key: "owner", Value: registration.Owner}}
 
 	update := bson.D{{"$set", bson.D{{"registration", registration.RegistrationNumber}}}}
 
-	_, err := collection.UpdateOne(ctx, filter, update)
+	result, err := collection.UpdateOne(ctx, filter, update)
 	if err != nil {
 		return err
 	}
 
+	if result.MatchedCount == 0 {
+		return ErrVehicleNotFound
+	}
+
 	fmt.Println("Registration successfully saved into vehicle!")
 	return nil
 }
@@ -468,158 +615,143 @@ func (mr *MUPRepo) GetRegistrationByVehicleID(ctx context.Context, vehicleID pri
 	return registration, nil
 }
 
-//Mup methods
-
-func (mr *MUPRepo) SaveVehicleIntoMup(ctx context.Context, vehicle *Vehicle) error {
-	collection := mr.getMupCollection("mup")
-
-	filter := bson.D{{"name", "Mup"}}
-
-	update := bson.D{{"$push", bson.D{{"vehicles", vehicle.ID}}}}
-
-	_, err := collection.UpdateOne(ctx, filter, update)
-	if err != nil {
-		return err
-	}
-
-	fmt.Println("Vehicle successfully saved into mup!")
-	return nil
-}
-
-func (mr *MUPRepo) SavePlatesIntoMup(ctx context.Context, plates Plates) error {
-	collection := mr.getMupCollection("mup")
-
-	filter := bson.D{{"name", "Mup"}}
-
-	update := bson.D{{"$push", bson.D{{"plates", plates.PlatesNumber}}}}
-
-	_, err := collection.UpdateOne(ctx, filter, update)
-	if err != nil {
-		return err
-	}
-
-	fmt.Println("Plates successfully saved into mup!")
-	return nil
-}
-
-func (mr *MUPRepo) SaveRegistrationIntoMup(ctx context.Context, registration *Registration) error {
-	collection := mr.getMupCollection("mup")
-
-	filter := bson.D{{"name", "Mup"}}
-
-	update := bson.D{{"$push", bson.D{{"registrations", registration.RegistrationNumber}}}}
-
-	_, err := collection.UpdateOne(ctx, filter, update)
-	if err != nil {
-		return err
-	}
-
-	fmt.Println("Registration successfully saved into mup!")
-	return nil
-}
-
-func (mr *MUPRepo) SaveTrafficPermitIntoMup(ctx context.Context, trafficPermit *TrafficPermit) error {
-	collection := mr.getMupCollection("mup")
-
-	filter := bson.D{{"name", "Mup"}}
-
-	update := bson.D{{"$push", bson.D{{"trafficPermits", trafficPermit.ID}}}}
-
-	_, err := collection.UpdateOne(ctx, filter, update)
-	if err != nil {
-		return err
-	}
-
-	fmt.Println("Traffic permit successfully saved into mup!")
-	return nil
-}
-
-func (mr *MUPRepo) SaveDrivingBanIntoMup(ctx context.Context, drivingBan DrivingBan) error {
-	collection := mr.getMupCollection("mup")
+//Registration methods
 
-	filter := bson.D{{"name", "Mup"}}
-
-	update := bson.D{{"$push", bson.D{{"drivingBans", drivingBan.ID}}}}
+// Saves registration request and its payment order in a single transaction.
+// Resubmitting while a request for the same vehicle is open returns that request and its order.
+// Every submission is given a new registration number, so retries are recognized by the vehicle
+// alone: a retry arriving after the request was rejected or approved opens a new request
+func (mr *MUPRepo) SubmitRegistrationRequest(ctx context.Context, registration *Registration, order *PaymentOrder, attachments Attachments) error {
+	collection := mr.getMupCollection("registration")
 
-	_, err := collection.UpdateOne(ctx, filter, update)
-	if err != nil {
-		return err
-	}
+	return mr.withTransaction(ctx, func(sessCtx mongo.SessionContext) error {
+		filter := bson.D{
+			{Key: "vehicleID", Value: registration.VehicleID},
+			{Key: "status", Value: bson.D{{Key: "$in", Value: OpenStatuses}}},
+		}
 
-	fmt.Println("Driving ban successfully saved into mup!")
-	return nil
-}
+		var existing Registration
+		err := collection.FindOne(sessCtx, filter).Decode(&existing)
+		if err == nil {
+			*registration = existing
+			// A retried submit links only the files the request doesn't have yet
+			err = mr.saveAttachments(sessCtx, RequestTypeRegistration, existing.RegistrationNumber, existing.Office, attachments)
+			if err != nil {
+				return err
+			}
+			return mr.loadPaymentOrder(sessCtx, existing.PaymentOrder, order)
+		}
+		if !errors.Is(err, mongo.ErrNoDocuments) {
+			return err
+		}
 
-func (mr *MUPRepo) SubmitRegistrationRequest(ctx context.Context, registration *Registration) error {
-	collection := mr.getMupCollection("registration")
+		order.RequestID = registration.RegistrationNumber
+		registration.PaymentOrder = order.ID
+		registration.Paid = false
+		registration.Status = StatusSubmitted
+		registration.Timeline = Timeline{{To: StatusSubmitted, By: registration.Owner, At: time.Now()}}
 
-	_, err := collection.InsertOne(ctx, registration)
-	if err != nil {
-		log.Printf("Failed to create vehicle: %v", err)
-		return err
-	}
+		_, err = collection.InsertOne(sessCtx, registration)
+		if err != nil {
+			log.Printf("Failed to create registration: %v", err)
+			return err
+		}
 
-	err = mr.SaveRegistrationIntoMup(ctx, registration)
-	if err != nil {
-		log.Printf("Failed to save registration into mup: %v", err)
-		return err
-	}
+		_, err = mr.getMupCollection("paymentOrder").InsertOne(sessCtx, order)
+		if err != nil {
+			log.Printf("Failed to create payment order: %v", err)
+			return err
+		}
 
-	err = mr.SaveRegistrationIntoVehicle(ctx, registration)
-	if err != nil {
-		log.Printf("Failed to save registration into vehicle: %v", err)
-		return err
-	}
+		err = mr.SaveRegistrationIntoVehicle(sessCtx, registration)
+		if err != nil {
+			log.Printf("Failed to save registration into vehicle: %v", err)
+			return err
+		}
 
-	return nil
+		return mr.saveAttachments(sessCtx, RequestTypeRegistration, registration.RegistrationNumber, registration.Office, attachments)
+	})
 }
 
-func (mr *MUPRepo) ApproveRegistration(ctx context.Context, registration Registration) error {
+// Approves submitted registration and issues its plates in a single transaction.
+// Approving an already approved registration returns it with the plates issued the first time.
+// Unless office is primitive.NilObjectID, only registrations submitted to it can be approved
+func (mr *MUPRepo) ApproveRegistration(ctx context.Context, registration *Registration, plates Plates, office primitive.ObjectID, clerk string) error {
 	collection := mr.getMupCollection("registration")
 
-	filter := bson.D{{"registrationNumber", registration.RegistrationNumber}}
+	return mr.withTransaction(ctx, func(sessCtx mongo.SessionContext) error {
+		filter := bson.D{{"registrationNumber", registration.RegistrationNumber}}
 
-	update := bson.D{{"$set", bson.D{
-		{"approved", true},
-		{"expirationDate", registration.ExpirationDate},
-		{"plates", registration.Plates}}}}
+		var existing Registration
+		err := collection.FindOne(sessCtx, filter).Decode(&existing)
+		if err != nil {
+			if errors.Is(err, mongo.ErrNoDocuments) {
+				return ErrRegistrationNotFound
+			}
+			return err
+		}
 
-	_, err := collection.UpdateOne(ctx, filter, update)
-	if err != nil {
+		if !office.IsZero() && existing.Office != office {
+			return ErrWrongOffice
+		}
 
-		return err
-	}
+		if existing.Approved {
+			*registration = existing
+			return nil
+		}
 
-	fmt.Println("Traffic permit approved successfully!")
+		if existing.Status != StatusSubmitted {
+			return ErrInvalidTransition
+		}
 
-	return nil
-}
+		if !existing.Paid {
+			return ErrNotPaid
+		}
 
-func (mr *MUPRepo) DeletePendingRegistration(ctx context.Context, registrationNumber string) error {
-	collection := mr.getMupCollection("registration")
-	filter := bson.D{{"registrationNumber", registrationNumber}, {"approved", false}}
+		change := StatusChange{From: StatusSubmitted, To: StatusApproved, By: clerk, At: time.Now()}
+
+		existing.Approved = true
+		existing.ExpirationDate = registration.ExpirationDate
+		existing.Plates = plates.PlatesNumber
+		existing.ProcessedAt = change.At
+		existing.Status = change.To
+		existing.Timeline = append(existing.Timeline, change)
+
+		filter = append(filter, bson.E{Key: "status", Value: StatusSubmitted})
+
+		update := bson.D{
+			{Key: "$set", Value: bson.D{
+				{Key: "approved", Value: existing.Approved},
+				{Key: "expirationDate", Value: existing.ExpirationDate},
+				{Key: "plates", Value: existing.Plates},
+				{Key: "processedAt", Value: existing.ProcessedAt},
+				{Key: "status", Value: existing.Status}}},
+			{Key: "$push", Value: bson.D{{Key: "timeline", Value: change}}},
+		}
 
-	_, err := collection.DeleteOne(ctx, filter)
-	if err != nil {
-		return err
-	}
+		result, err := collection.UpdateOne(sessCtx, filter, update)
+		if err != nil {
+			return err
+		}
+		if result.MatchedCount == 0 {
+			return ErrInvalidTransition
+		}
 
-	fmt.Println("Pending registration request deleted successfully!")
-	return nil
-}
+		plates.RegistrationNumber = existing.RegistrationNumber
+		plates.VehicleID = existing.VehicleID
+		plates.Owner = existing.Owner
+		plates.Office = existing.Office
 
-// Delete pending traffic permit request
-func (mr *MUPRepo) DeletePendingTrafficPermit(ctx context.Context, permitID primitive.ObjectID) error {
-	collection := mr.getMupCollection("trafficPermit")
-	filter := bson.D{{"_id", permitID}, {"approved", false}}
+		err = mr.savePlates(sessCtx, plates)
+		if err != nil {
+			return err
+		}
 
-	_, err := collection.DeleteOne(ctx, filter)
-	if err != nil {
-		return err
-	}
+		*registration = existing
 
-	fmt.Println("Pending traffic permit request deleted successfully!")
-	return nil
+		fmt.Println("Registration approved successfully!")
+		return nil
+	})
 }
 
 func (mr *MUPRepo) GetRegistrationByPlate(ctx context.Context, plate string) (Registration, error) {
@@ -642,145 +774,157 @@ func (mr *MUPRepo) GetRegistrationByPlate(ctx context.Context, plate string) (Re
 
 //Driving permit methods
 
-func (mr *MUPRepo) SubmitTrafficPermitRequest(ctx context.Context, trafficPermit *TrafficPermit) error {
+// Saves traffic permit request and its payment order in a single transaction.
+// Resubmitting while a request for the same person is open returns that request and its order
+func (mr *MUPRepo) SubmitTrafficPermitRequest(ctx context.Context, trafficPermit *TrafficPermit, order *PaymentOrder, attachments Attachments) error {
 	collection := mr.getMupCollection("trafficPermit")
 
-	_, err := collection.InsertOne(ctx, trafficPermit)
-	if err != nil {
-		log.Printf("Failed to create traffic permit: %v", err)
-		return err
-	}
-
-	err = mr.SaveTrafficPermitIntoMup(ctx, trafficPermit)
-	if err != nil {
-		log.Printf("Failed to save traffic permit into mup: %v", err)
-		return err
-	}
-
-	return nil
-}
-
-func (mr *MUPRepo) ApproveTrafficPermitRequest(ctx context.Context, permitID primitive.ObjectID) error {
-	expirationDate := time.Now().AddDate(5, 0, 0)
+	return mr.withTransaction(ctx, func(sessCtx mongo.SessionContext) error {
+		filter := bson.D{
+			{Key: "person", Value: trafficPermit.Person},
+			{Key: "status", Value: bson.D{{Key: "$in", Value: OpenStatuses}}},
+		}
 
-	collection := mr.getMupCollection("trafficPermit")
+		var existing TrafficPermit
+		err := collection.FindOne(sessCtx, filter).Decode(&existing)
+		if err == nil {
+			*trafficPermit = existing
+			// A retried submit links only the files the request doesn't have yet
+			err = mr.saveAttachments(sessCtx, RequestTypeTrafficPermit, existing.ID.Hex(), existing.Office, attachments)
+			if err != nil {
+				return err
+			}
+			return mr.loadPaymentOrder(sessCtx, existing.PaymentOrder, order)
+		}
+		if !errors.Is(err, mongo.ErrNoDocuments) {
+			return err
+		}
 
-	filter := bson.D{{"_id", permitID}}
+		order.RequestID = trafficPermit.ID.Hex()
+		trafficPermit.PaymentOrder = order.ID
+		trafficPermit.Paid = false
+		trafficPermit.Status = StatusSubmitted
+		trafficPermit.Timeline = Timeline{{To: StatusSubmitted, By: trafficPermit.Person, At: time.Now()}}
 
-	update := bson.D{{"$set", bson.D{{"approved", true}, {"expirationDate", expirationDate}}}}
+		_, err = collection.InsertOne(sessCtx, trafficPermit)
+		if err != nil {
+			log.Printf("Failed to create traffic permit: %v", err)
+			return err
+		}
 
-	_, err := collection.UpdateOne(ctx, filter, update)
-	if err != nil {
-		return err
-	}
+		_, err = mr.getMupCollection("paymentOrder").InsertOne(sessCtx, order)
+		if err != nil {
+			log.Printf("Failed to create payment order: %v", err)
+			return err
+		}
 
-	fmt.Println("Traffic permit approved successfully!")
-	return nil
+		return mr.saveAttachments(sessCtx, RequestTypeTrafficPermit, trafficPermit.ID.Hex(), trafficPermit.Office, attachments)
+	})
 }
 
-//Plates methods
+// Approves submitted traffic permit request. Unless office is primitive.NilObjectID,
+// only requests submitted to it can be approved
+func (mr *MUPRepo) ApproveTrafficPermitRequest(ctx context.Context, permitID, office primitive.ObjectID, clerk string) error {
+	now := time.Now()
+	expirationDate := now.AddDate(5, 0, 0)
 
-func (mr *MUPRepo) SavePlates(ctx context.Context, plates Plates) error {
-	collection := mr.getMupCollection("plates")
+	collection := mr.getMupCollection("trafficPermit")
 
-	_, err := collection.InsertOne(ctx, plates)
+	var existing TrafficPermit
+	err := collection.FindOne(ctx, bson.D{{Key: "_id", Value: permitID}}).Decode(&existing)
 	if err != nil {
-		log.Printf("Failed to create plates: %v", err)
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			return ErrTrafficPermitNotFound
+		}
 		return err
 	}
 
-	err = mr.SavePlatesIntoMup(ctx, plates)
-	if err != nil {
-		log.Printf("Failed to save plates into mup: %v", err)
-		return err
+	if !office.IsZero() && existing.Office != office {
+		return ErrWrongOffice
 	}
 
-	err = mr.SavePlatesIntoVehicle(ctx, plates)
-	if err != nil {
-		log.Printf("Failed to save plates into vehicle: %v", err)
-		return err
+	// Repeated approvals keep the original expiration date
+	if existing.Approved {
+		return nil
 	}
 
-	return nil
-}
-
-func (mr *MUPRepo) IssuePlates(ctx context.Context, plates Plates) error {
-	collection := mr.getMupCollection("plates")
+	if existing.Status != StatusSubmitted {
+		return ErrInvalidTransition
+	}
 
-	_, err := collection.InsertOne(ctx, plates)
-	if err != nil {
-		log.Printf("Failed to create plates: %v", err)
-		return err
+	if !existing.Paid {
+		return ErrNotPaid
 	}
 
-	err = mr.SavePlatesIntoMup(ctx, plates)
-	if err != nil {
-		log.Printf("Failed to save plates into mup: %v", err)
-		return err
+	change := StatusChange{From: StatusSubmitted, To: StatusApproved, By: clerk, At: now}
+	filter := bson.D{{Key: "_id", Value: permitID}, {Key: "status", Value: StatusSubmitted}}
+
+	update := bson.D{
+		{Key: "$set", Value: bson.D{
+			{Key: "approved", Value: true},
+			{Key: "expirationDate", Value: expirationDate},
+			{Key: "processedAt", Value: now},
+			{Key: "status", Value: change.To}}},
+		{Key: "$push", Value: bson.D{{Key: "timeline", Value: change}}},
 	}
 
-	err = mr.SavePlatesIntoVehicle(ctx, plates)
+	result, err := collection.UpdateOne(ctx, filter, update)
 	if err != nil {
-		log.Printf("Failed to save plates into vehicle: %v", err)
 		return err
 	}
+	if result.MatchedCount == 0 {
+		return ErrInvalidTransition
+	}
 
+	fmt.Println("Traffic permit approved successfully!")
 	return nil
 }
 
-//Driving ban methods
+//Plates methods
 
-func (mr *MUPRepo) IssueDrivingBan(ctx context.Context, drivingBan *DrivingBan) error {
-	drivingBan.ID = primitive.NewObjectID()
+func (mr *MUPRepo) SavePlates(ctx context.Context, plates Plates) error {
+	return mr.savePlates(ctx, plates)
+}
+
+// Saves plates and their references. Plates already issued for the registration are left as they are
+func (mr *MUPRepo) savePlates(ctx context.Context, plates Plates) error {
+	collection := mr.getMupCollection("plates")
 
-	collection := mr.getMupCollection("drivingBan")
+	filter := bson.D{{Key: "registrationNumber", Value: plates.RegistrationNumber}}
 
-	_, err := collection.InsertOne(ctx, drivingBan)
+	count, err := collection.CountDocuments(ctx, filter)
 	if err != nil {
-		log.Printf("Failed to create driving ban: %v", err)
 		return err
 	}
+	if count > 0 {
+		return nil
+	}
 
-	err = mr.SaveDrivingBanIntoMup(ctx, *drivingBan)
+	_, err = collection.InsertOne(ctx, plates)
 	if err != nil {
-		log.Printf("Failed to save driving ban into mup: %v", err)
+		log.Printf("Failed to create plates: %v", err)
 		return err
 	}
 
-	return nil
-}
-
-func (mr *MUPRepo) GetDrivingBan(ctx context.Context, jmbg string) (DrivingBan, error) {
-	collection := mr.getMupCollection("drivingBan")
-
-	filter := bson.D{
-		{Key: "person", Value: jmbg},
-	}
-
-	options := options.FindOne()
-	options.SetSort(bson.D{{Key: "duration", Value: -1}}) // Sort by duration, desceding
-
-	var drivingBan DrivingBan
-
-	err := collection.FindOne(ctx, filter, options).Decode(&drivingBan)
+	err = mr.SavePlatesIntoVehicle(ctx, plates)
 	if err != nil {
-		if errors.Is(err, mongo.ErrNoDocuments) {
-			return DrivingBan{}, nil
-		}
-		return DrivingBan{}, err
+		log.Printf("Failed to save plates into vehicle: %v", err)
+		return err
 	}
 
-	return drivingBan, nil
+	return nil
 }
 
 func (mr *MUPRepo) GetDrivingPermitByJMBG(ctx context.Context, jmbg string) (TrafficPermit, error) {
 	collection := mr.getMupCollection("trafficPermit")
 
-	filter := bson.D{{"person", jmbg}}
+	// Issued permits only, the latest one when the person was issued several
+	filter := bson.D{{"person", jmbg}, {"approved", true}}
+	opts := options.FindOne().SetSort(bson.D{{Key: "issuedDate", Value: -1}})
 
 	var drivingPermit TrafficPermit
 
-	err := collection.FindOne(ctx, filter).Decode(&drivingPermit)
+	err := collection.FindOne(ctx, filter, opts).Decode(&drivingPermit)
 	if err != nil {
 		if errors.Is(err, mongo.ErrNoDocuments) {
 			return TrafficPermit{}, nil
@@ -793,34 +937,6 @@ func (mr *MUPRepo) GetDrivingPermitByJMBG(ctx context.Context, jmbg string) (Tra
 
 //Person methods
 
-func (mr *MUPRepo) CheckForPersonsDrivingBans(ctx context.Context, userID string) (DrivingBans, error) {
-	collection := mr.getMupCollection("drivingBan")
-
-	filter := bson.D{{"person", userID}}
-
-	var drivingBans DrivingBans
-
-	cursor, err := collection.Find(ctx, filter)
-	if err != nil {
-		return nil, err
-	}
-	defer cursor.Close(ctx)
-
-	for cursor.Next(ctx) {
-		var drivingBan DrivingBan
-		if err := cursor.Decode(&drivingBan); err != nil {
-			return nil, err
-		}
-		drivingBans = append(drivingBans, drivingBan)
-	}
-
-	if err := cursor.Err(); err != nil {
-		return nil, err
-	}
-
-	return drivingBans, nil
-}
-
 func (mr *MUPRepo) GetPersonsRegistrations(ctx context.Context, jmbg string) (Registrations, error) {
 	collection := mr.getMupCollection("registration")
 
@@ -906,6 +1022,25 @@ func (mr *MUPRepo) GetUserDrivingPermits(ctx context.Context, jmbg string) (Traf
 	return drivingPermits, nil
 }
 
+// Returns all traffic permit requests of the person whatever their status, newest first
+func (mr *MUPRepo) GetPersonsTrafficPermits(ctx context.Context, jmbg string) (TrafficPermits, error) {
+	collection := mr.getMupCollection("trafficPermit")
+
+	opts := options.Find().SetSort(bson.D{{Key: "issuedDate", Value: -1}})
+	cursor, err := collection.Find(ctx, bson.D{{Key: "person", Value: jmbg}}, opts)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	trafficPermits := TrafficPermits{}
+	if err = cursor.All(ctx, &trafficPermits); err != nil {
+		return nil, err
+	}
+
+	return trafficPermits, nil
+}
+
 func (mr *MUPRepo) GetPersonsVehicles(ctx context.Context, jmbg string) ([]Vehicle, error) {
 	collection := mr.getMupCollection("vehicle")
 
@@ -926,11 +1061,17 @@ func (mr *MUPRepo) GetPersonsVehicles(ctx context.Context, jmbg string) ([]Vehic
 	return vehicles, nil
 }
 
-func (mr *MUPRepo) GetPendingRegistrationRequests(ctx context.Context) (Registrations, error) {
+// Returns paid submitted requests of the given office, or of all offices for primitive.NilObjectID
+func (mr *MUPRepo) GetPendingRegistrationRequests(ctx context.Context, office primitive.ObjectID) (Registrations, error) {
 	collection := mr.getMupCollection("registration")
 
 	filter := bson.D{
-		{"approved", false},
+		{Key: "status", Value: StatusSubmitted},
+		{Key: "paid", Value: true},
+	}
+
+	if !office.IsZero() {
+		filter = append(filter, bson.E{Key: "office", Value: office})
 	}
 
 	var pendingRequests Registrations
@@ -956,21 +1097,60 @@ func (mr *MUPRepo) GetPendingRegistrationRequests(ctx context.Context) (Registra
 	return pendingRequests, nil
 }
 
+func (mr *MUPRepo) GetRegistrationByNumber(ctx context.Context, registrationNumber string) (Registration, error) {
+	collection := mr.getMupCollection("registration")
+
+	var registration Registration
+	err := collection.FindOne(ctx, bson.D{{Key: "registrationNumber", Value: registrationNumber}}).Decode(&registration)
+	if err != nil {
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			return Registration{}, ErrRegistrationNotFound
+		}
+		return Registration{}, err
+	}
+
+	return registration, nil
+}
+
+func (mr *MUPRepo) GetTrafficPermitByID(ctx context.Context, permitID primitive.ObjectID) (TrafficPermit, error) {
+	collection := mr.getMupCollection("trafficPermit")
+
+	var trafficPermit TrafficPermit
+	err := collection.FindOne(ctx, bson.D{{Key: "_id", Value: permitID}}).Decode(&trafficPermit)
+	if err != nil {
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			return TrafficPermit{}, ErrTrafficPermitNotFound
+		}
+		return TrafficPermit{}, err
+	}
+
+	return trafficPermit, nil
+}
+
 func (mr *MUPRepo) GetVehicleByID(ctx context.Context, vehicleID primitive.ObjectID) (Vehicle, error) {
 	collection := mr.getMupCollection("vehicle")
 	var vehicle Vehicle
 	err := collection.FindOne(ctx, bson.M{"_id": vehicleID}).Decode(&vehicle)
 	if err != nil {
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			return Vehicle{}, ErrVehicleNotFound
+		}
 		return Vehicle{}, err
 	}
 	return vehicle, nil
 }
 
-func (mr *MUPRepo) GetPendingTrafficPermitRequests(ctx context.Context) (TrafficPermits, error) {
+// Returns paid submitted requests of the given office, or of all offices for primitive.NilObjectID
+func (mr *MUPRepo) GetPendingTrafficPermitRequests(ctx context.Context, office primitive.ObjectID) (TrafficPermits, error) {
 	collection := mr.getMupCollection("trafficPermit")
 
 	filter := bson.D{
-		{"approved", false},
+		{Key: "status", Value: StatusSubmitted},
+		{Key: "paid", Value: true},
+	}
+
+	if !office.IsZero() {
+		filter = append(filter, bson.E{Key: "office", Value: office})
 	}
 
 	var pendingRequests TrafficPermits
@@ -996,7 +1176,7 @@ func (mr *MUPRepo) GetPendingTrafficPermitRequests(ctx context.Context) (Traffic
 	return pendingRequests, nil
 }
 
-// MUP methods
+// Creates the default office when no office exists yet
 func (mr *MUPRepo) SaveMup(ctx context.Context) error {
 	collection := mr.getMupCollection("mup")
 
@@ -1015,21 +1195,16 @@ func (mr *MUPRepo) SaveMup(ctx context.Context) error {
 	mupID := primitive.NewObjectID()
 
 	address := Address{
-		Municipality: "",
+		Municipality: "Novi Sad",
 		Locality:     "Novi Sad",
 		StreetName:   "Dunavska",
 		StreetNumber: 1,
 	}
 
 	mup := Mup{
-		ID:             mupID,
-		Name:           "Mup",
-		Address:        address,
-		Vehicles:       make([]primitive.ObjectID, 0),
-		TrafficPermits: make([]primitive.ObjectID, 0),
-		Plates:         make([]string, 0),
-		DrivingBans:    make([]primitive.ObjectID, 0),
-		Registrations:  make([]string, 0),
+		ID:      mupID,
+		Name:    "Mup",
+		Address: address,
 	}
 
 	_, err = collection.InsertOne(ctx, mup)
@@ -1042,6 +1217,284 @@ func (mr *MUPRepo) SaveMup(ctx context.Context) error {
 	return nil
 }
 
+// Saves new office. Office names are unique
+func (mr *MUPRepo) SaveOffice(ctx context.Context, mup *Mup) error {
+	collection := mr.getMupCollection("mup")
+
+	mup.ID = primitive.NewObjectID()
+
+	_, err := collection.InsertOne(ctx, mup)
+	if err != nil {
+		if mongo.IsDuplicateKeyError(err) {
+			return ErrMupAlreadyExists
+		}
+		return err
+	}
+
+	return nil
+}
+
+// Returns offices in the given municipality, or all offices for empty municipality
+func (mr *MUPRepo) GetMups(ctx context.Context, municipality string) (Mups, error) {
+	collection := mr.getMupCollection("mup")
+
+	filter := bson.M{}
+	if municipality != "" {
+		filter["address.municipality"] = municipality
+	}
+
+	cursor, err := collection.Find(ctx, filter)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	var mups Mups
+	if err = cursor.All(ctx, &mups); err != nil {
+		return nil, err
+	}
+
+	return mups, nil
+}
+
+func (mr *MUPRepo) GetMupByID(ctx context.Context, id primitive.ObjectID) (Mup, error) {
+	collection := mr.getMupCollection("mup")
+
+	var mup Mup
+	err := collection.FindOne(ctx, bson.M{"_id": id}).Decode(&mup)
+	if err != nil {
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			return Mup{}, ErrMupNotFound
+		}
+		return Mup{}, err
+	}
+
+	return mup, nil
+}
+
+// Returns queue length and average processing time of every office.
+// Processing time is measured from submission (issuedDate) to approval (processedAt)
+func (mr *MUPRepo) GetOfficeQueueStats(ctx context.Context) (OfficeQueueStatsList, error) {
+	mups, err := mr.GetMups(ctx, "")
+	if err != nil {
+		return nil, err
+	}
+
+	registrations, err := mr.aggregateQueue(ctx, "registration")
+	if err != nil {
+		return nil, err
+	}
+
+	trafficPermits, err := mr.aggregateQueue(ctx, "trafficPermit")
+	if err != nil {
+		return nil, err
+	}
+
+	stats := OfficeQueueStatsList{}
+	for _, mup := range mups {
+		registration := registrations[mup.ID]
+		trafficPermit := trafficPermits[mup.ID]
+
+		stats = append(stats, OfficeQueueStats{
+			Office:                          mup.ID,
+			Name:                            mup.Name,
+			Municipality:                    mup.Address.Municipality,
+			PendingRegistrations:            registration.Pending,
+			PendingTrafficPermits:           trafficPermit.Pending,
+			AvgRegistrationProcessingHours:  registration.AvgProcessingMs / float64(time.Hour/time.Millisecond),
+			AvgTrafficPermitProcessingHours: trafficPermit.AvgProcessingMs / float64(time.Hour/time.Millisecond),
+		})
+	}
+
+	return stats, nil
+}
+
+type queueStats struct {
+	Office          primitive.ObjectID `bson:"_id"`
+	Pending         int64              `bson:"pending"`
+	AvgProcessingMs float64            `bson:"avgProcessingMs"`
+}
+
+// Groups requests of a collection by office, counting paid submitted requests as the queue.
+// Requests not yet processed have no processedAt, so their subtraction yields null and $avg skips them
+func (mr *MUPRepo) aggregateQueue(ctx context.Context, nameOfCollection string) (map[primitive.ObjectID]queueStats, error) {
+	collection := mr.getMupCollection(nameOfCollection)
+
+	pipeline := mongo.Pipeline{
+		{{Key: "$group", Value: bson.D{
+			{Key: "_id", Value: "$office"},
+			{Key: "pending", Value: bson.D{{Key: "$sum", Value: bson.D{
+				{Key: "$cond", Value: bson.A{bson.D{{Key: "$and", Value: bson.A{
+					bson.D{{Key: "$eq", Value: bson.A{"$status", StatusSubmitted}}},
+					bson.D{{Key: "$eq", Value: bson.A{"$paid", true}}}}}}, 1, 0}}}}}},
+			{Key: "avgProcessingMs", Value: bson.D{{Key: "$avg", Value: bson.D{
+				{Key: "$subtract", Value: bson.A{"$processedAt", "$issuedDate"}}}}}},
+		}}},
+	}
+
+	cursor, err := collection.Aggregate(ctx, pipeline)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	var results []queueStats
+	if err = cursor.All(ctx, &results); err != nil {
+		return nil, err
+	}
+
+	stats := make(map[primitive.ObjectID]queueStats, len(results))
+	for _, result := range results {
+		stats[result.Office] = result
+	}
+
+	return stats, nil
+}
+
+// Returns the office records are assigned to when no other office is given
+func (mr *MUPRepo) GetDefaultMup(ctx context.Context) (Mup, error) {
+	collection := mr.getMupCollection("mup")
+
+	var mup Mup
+	err := collection.FindOne(ctx, bson.M{"name": "Mup"}).Decode(&mup)
+	if err != nil {
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			return Mup{}, ErrMupNotFound
+		}
+		return Mup{}, err
+	}
+
+	return mup, nil
+}
+
+// Moves references kept in the legacy ID arrays of Mup documents onto the
+// referenced records and removes the arrays. Records no office claims are
+// assigned to the default office. Safe to run on every start
+func (mr *MUPRepo) MigrateMupReferences(ctx context.Context) error {
+	collection := mr.getMupCollection("mup")
+
+	type legacyMup struct {
+		ID             primitive.ObjectID   `bson:"_id"`
+		TrafficPermits []primitive.ObjectID `bson:"trafficPermits"`
+		Plates         []string             `bson:"plates"`
+		DrivingBans    []primitive.ObjectID `bson:"drivingBans"`
+		Registrations  []string             `bson:"registrations"`
+	}
+
+	legacyFields := []string{"vehicles", "trafficPermits", "plates", "drivingBans", "registrations"}
+
+	var hasLegacyField bson.A
+	for _, field := range legacyFields {
+		hasLegacyField = append(hasLegacyField, bson.M{field: bson.M{"$exists": true}})
+	}
+
+	cursor, err := collection.Find(ctx, bson.M{"$or": hasLegacyField})
+	if err != nil {
+		return err
+	}
+	defer cursor.Close(ctx)
+
+	for cursor.Next(ctx) {
+		var legacy legacyMup
+		if err := cursor.Decode(&legacy); err != nil {
+			return err
+		}
+
+		references := []struct {
+			collection string
+			field      string
+			values     []interface{}
+		}{
+			{"registration", "registrationNumber", toInterfaces(legacy.Registrations)},
+			{"plates", "platesNumber", toInterfaces(legacy.Plates)},
+			{"trafficPermit", "_id", toInterfaces(legacy.TrafficPermits)},
+			{"drivingBan", "_id", toInterfaces(legacy.DrivingBans)},
+		}
+
+		// Arrays can be close to the document size limit, so they are matched in batches
+		const batchSize = 1000
+		for _, ref := range references {
+			for start := 0; start < len(ref.values); start += batchSize {
+				end := min(start+batchSize, len(ref.values))
+
+				filter := bson.M{ref.field: bson.M{"$in": ref.values[start:end]}, "office": bson.M{"$exists": false}}
+				update := bson.M{"$set": bson.M{"office": legacy.ID}}
+
+				_, err := mr.getMupCollection(ref.collection).UpdateMany(ctx, filter, update)
+				if err != nil {
+					return fmt.Errorf("failed to migrate %s of mup %s: %v", ref.collection, legacy.ID.Hex(), err)
+				}
+			}
+		}
+
+		// Arrays are removed last, so an interrupted migration is picked up again on the next start
+		unset := bson.M{}
+		for _, field := range legacyFields {
+			unset[field] = ""
+		}
+
+		_, err = collection.UpdateOne(ctx, bson.M{"_id": legacy.ID}, bson.M{"$unset": unset})
+		if err != nil {
+			return fmt.Errorf("failed to migrate mup %s: %v", legacy.ID.Hex(), err)
+		}
+
+		mr.logger.Printf("Migrated references of mup %s", legacy.ID.Hex())
+	}
+
+	if err := cursor.Err(); err != nil {
+		return err
+	}
+
+	defaultMup, err := mr.GetDefaultMup(ctx)
+	if err != nil {
+		return err
+	}
+
+	for _, name := range []string{"registration", "plates", "trafficPermit", "drivingBan"} {
+		filter := bson.M{"office": bson.M{"$exists": false}}
+		update := bson.M{"$set": bson.M{"office": defaultMup.ID}}
+
+		_, err := mr.getMupCollection(name).UpdateMany(ctx, filter, update)
+		if err != nil {
+			return fmt.Errorf("failed to assign default mup to %s: %v", name, err)
+		}
+	}
+
+	return nil
+}
+
+// Runs fn inside a multi-document transaction. The driver retries fn on transient errors.
+// A duplicate key means a concurrent retry of the same request committed first,
+// so fn is run once more to pick up its result
+func (mr *MUPRepo) withTransaction(ctx context.Context, fn func(sessCtx mongo.SessionContext) error) error {
+	run := func() error {
+		session, err := mr.cli.StartSession()
+		if err != nil {
+			return err
+		}
+		defer session.EndSession(ctx)
+
+		_, err = session.WithTransaction(ctx, func(sessCtx mongo.SessionContext) (interface{}, error) {
+			return nil, fn(sessCtx)
+		})
+		return err
+	}
+
+	err := run()
+	if mongo.IsDuplicateKeyError(err) {
+		return run()
+	}
+	return err
+}
+
+func toInterfaces[T any](values []T) []interface{} {
+	result := make([]interface{}, len(values))
+	for i, value := range values {
+		result[i] = value
+	}
+	return result
+}
+
 // Get collection method
 func (mr *MUPRepo) getMupCollection(nameOfCollection string) *mongo.Collection {
 	mupDatabase := mr.cli.Database("mupDB")
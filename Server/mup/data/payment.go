@@ -0,0 +1,74 @@
+package data
+
+import (
+	"encoding/json"
+	"io"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+const (
+	PaymentStatusPending = "PENDING"
+	PaymentStatusPaid    = "PAID"
+)
+
+// Fee of a request type. Fee with empty vehicle category applies to
+// categories that have no fee of their own
+type Fee struct {
+	ID              primitive.ObjectID `bson:"_id,omitempty" json:"id"`
+	RequestType     string             `bson:"requestType" json:"requestType"`
+	VehicleCategory string             `bson:"vehicleCategory" json:"vehicleCategory"`
+	Amount          float64            `bson:"amount" json:"amount"`
+	Currency        string             `bson:"currency" json:"currency"`
+	Description     string             `bson:"description" json:"description"`
+}
+
+type Fees []Fee
+
+// Payment order (uplatnica) issued for a request. Payments are matched
+// by the model 97 reference ("poziv na broj")
+type PaymentOrder struct {
+	ID               primitive.ObjectID `bson:"_id,omitempty" json:"id"`
+	RequestType      string             `bson:"requestType" json:"requestType"`
+	RequestID        string             `bson:"requestID" json:"requestID"`
+	Payer            string             `bson:"payer" json:"payer"`
+	Purpose          string             `bson:"purpose" json:"purpose"`
+	Recipient        string             `bson:"recipient" json:"recipient"`
+	RecipientAccount string             `bson:"recipientAccount" json:"recipientAccount"`
+	Amount           float64            `bson:"amount" json:"amount"`
+	Currency         string             `bson:"currency" json:"currency"`
+	Model            string             `bson:"model" json:"model"`
+	ReferenceNumber  string             `bson:"referenceNumber" json:"referenceNumber"`
+	Status           string             `bson:"status" json:"status"`
+	TransactionID    string             `bson:"transactionID,omitempty" json:"transactionID,omitempty"`
+	CreatedAt        time.Time          `bson:"createdAt" json:"createdAt"`
+	PaidAt           time.Time          `bson:"paidAt,omitempty" json:"paidAt"`
+}
+
+type PaymentOrders []PaymentOrder
+
+func (f *Fee) ToJSON(w io.Writer) error {
+	e := json.NewEncoder(w)
+	return e.Encode(f)
+}
+
+func (f *Fee) FromJSON(r io.Reader) error {
+	d := json.NewDecoder(r)
+	return d.Decode(f)
+}
+
+func (f *Fees) ToJSON(w io.Writer) error {
+	e := json.NewEncoder(w)
+	return e.Encode(f)
+}
+
+func (po *PaymentOrder) ToJSON(w io.Writer) error {
+	e := json.NewEncoder(w)
+	return e.Encode(po)
+}
+
+func (po *PaymentOrders) ToJSON(w io.Writer) error {
+	e := json.NewEncoder(w)
+	return e.Encode(po)
+}
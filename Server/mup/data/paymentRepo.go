@@ -0,0 +1,223 @@
+package data
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+var (
+	ErrFeeNotFound          = errors.New("fee not found")
+	ErrPaymentOrderNotFound = errors.New("payment order not found")
+	ErrNotPaid              = errors.New("request is not paid")
+)
+
+//Fee methods
+
+func (mr *MUPRepo) GetFees(ctx context.Context) (Fees, error) {
+	collection := mr.getMupCollection("fee")
+
+	opts := options.Find().SetSort(bson.D{{Key: "requestType", Value: 1}, {Key: "vehicleCategory", Value: 1}})
+	cursor, err := collection.Find(ctx, bson.D{}, opts)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	fees := Fees{}
+	if err = cursor.All(ctx, &fees); err != nil {
+		return nil, err
+	}
+
+	return fees, nil
+}
+
+// Returns fee of the request type for the vehicle category, falling back
+// to the fee of the request type without category
+func (mr *MUPRepo) GetFee(ctx context.Context, requestType, vehicleCategory string) (Fee, error) {
+	collection := mr.getMupCollection("fee")
+
+	filter := bson.D{
+		{Key: "requestType", Value: requestType},
+		{Key: "vehicleCategory", Value: bson.D{{Key: "$in", Value: bson.A{vehicleCategory, ""}}}},
+	}
+	// Specific category sorts after the empty one
+	opts := options.FindOne().SetSort(bson.D{{Key: "vehicleCategory", Value: -1}})
+
+	var fee Fee
+	err := collection.FindOne(ctx, filter, opts).Decode(&fee)
+	if err != nil {
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			return Fee{}, ErrFeeNotFound
+		}
+		return Fee{}, err
+	}
+
+	return fee, nil
+}
+
+// Creates or replaces fee of the request type and vehicle category
+func (mr *MUPRepo) SaveFee(ctx context.Context, fee *Fee) error {
+	collection := mr.getMupCollection("fee")
+
+	filter := bson.D{{Key: "requestType", Value: fee.RequestType}, {Key: "vehicleCategory", Value: fee.VehicleCategory}}
+	update := bson.D{{Key: "$set", Value: bson.D{
+		{Key: "amount", Value: fee.Amount},
+		{Key: "currency", Value: fee.Currency},
+		{Key: "description", Value: fee.Description}}}}
+
+	opts := options.FindOneAndUpdate().SetUpsert(true).SetReturnDocument(options.After)
+	return collection.FindOneAndUpdate(ctx, filter, update, opts).Decode(fee)
+}
+
+// Adds fees of the default schedule that are missing, keeping amounts changed since
+func (mr *MUPRepo) SaveDefaultFees(ctx context.Context) error {
+	collection := mr.getMupCollection("fee")
+
+	fees := []Fee{
+		{RequestType: RequestTypeRegistration, VehicleCategory: "", Amount: 8000, Currency: "RSD", Description: "Vehicle registration"},
+		{RequestType: RequestTypeRegistration, VehicleCategory: "A", Amount: 4500, Currency: "RSD", Description: "Motorcycle registration"},
+		{RequestType: RequestTypeRegistration, VehicleCategory: "C", Amount: 15000, Currency: "RSD", Description: "Truck registration"},
+		{RequestType: RequestTypeRegistration, VehicleCategory: "D", Amount: 15000, Currency: "RSD", Description: "Bus registration"},
+		{RequestType: RequestTypeTrafficPermit, VehicleCategory: "", Amount: 3500, Currency: "RSD", Description: "Driving permit"},
+		{RequestType: RequestTypePermitConversion, VehicleCategory: "", Amount: 3500, Currency: "RSD", Description: "Foreign driving permit conversion"},
+	}
+
+	// Only missing fees are added, amounts changed through SaveFee are kept
+	for _, fee := range fees {
+		filter := bson.D{{Key: "requestType", Value: fee.RequestType}, {Key: "vehicleCategory", Value: fee.VehicleCategory}}
+		update := bson.D{{Key: "$setOnInsert", Value: fee}}
+
+		_, err := collection.UpdateOne(ctx, filter, update, options.Update().SetUpsert(true))
+		if err != nil && !mongo.IsDuplicateKeyError(err) {
+			return err
+		}
+	}
+
+	return nil
+}
+
+//Payment order methods
+
+func (mr *MUPRepo) GetPaymentOrder(ctx context.Context, id primitive.ObjectID) (PaymentOrder, error) {
+	collection := mr.getMupCollection("paymentOrder")
+
+	var order PaymentOrder
+	err := collection.FindOne(ctx, bson.D{{Key: "_id", Value: id}}).Decode(&order)
+	if err != nil {
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			return PaymentOrder{}, ErrPaymentOrderNotFound
+		}
+		return PaymentOrder{}, err
+	}
+
+	return order, nil
+}
+
+// Returns payment order paid with the model and reference
+func (mr *MUPRepo) GetPaymentOrderByReference(ctx context.Context, model, reference string) (PaymentOrder, error) {
+	collection := mr.getMupCollection("paymentOrder")
+
+	var order PaymentOrder
+	err := collection.FindOne(ctx, bson.D{{Key: "model", Value: model}, {Key: "referenceNumber", Value: reference}}).Decode(&order)
+	if err != nil {
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			return PaymentOrder{}, ErrPaymentOrderNotFound
+		}
+		return PaymentOrder{}, err
+	}
+
+	return order, nil
+}
+
+func (mr *MUPRepo) GetPersonsPaymentOrders(ctx context.Context, jmbg string) (PaymentOrders, error) {
+	collection := mr.getMupCollection("paymentOrder")
+
+	opts := options.Find().SetSort(bson.D{{Key: "createdAt", Value: -1}})
+	cursor, err := collection.Find(ctx, bson.D{{Key: "payer", Value: jmbg}}, opts)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	orders := PaymentOrders{}
+	if err = cursor.All(ctx, &orders); err != nil {
+		return nil, err
+	}
+
+	return orders, nil
+}
+
+// Loads payment order of a request resubmitted while pending. Requests
+// submitted before fees were introduced have none
+func (mr *MUPRepo) loadPaymentOrder(ctx context.Context, id primitive.ObjectID, order *PaymentOrder) error {
+	if id.IsZero() {
+		return nil
+	}
+
+	err := mr.getMupCollection("paymentOrder").FindOne(ctx, bson.D{{Key: "_id", Value: id}}).Decode(order)
+	if err != nil && !errors.Is(err, mongo.ErrNoDocuments) {
+		return err
+	}
+
+	return nil
+}
+
+// Marks payment order as paid and lets its request into the approval queue,
+// in a single transaction. Confirming a paid order again changes nothing
+func (mr *MUPRepo) ConfirmPayment(ctx context.Context, order *PaymentOrder, transactionID string, paidAt time.Time) error {
+	orders := mr.getMupCollection("paymentOrder")
+
+	return mr.withTransaction(ctx, func(sessCtx mongo.SessionContext) error {
+		filter := bson.D{{Key: "_id", Value: order.ID}, {Key: "status", Value: PaymentStatusPending}}
+		update := bson.D{{Key: "$set", Value: bson.D{
+			{Key: "status", Value: PaymentStatusPaid},
+			{Key: "transactionID", Value: transactionID},
+			{Key: "paidAt", Value: paidAt}}}}
+
+		result, err := orders.UpdateOne(sessCtx, filter, update)
+		if err != nil {
+			return err
+		}
+		if result.MatchedCount == 0 {
+			return mr.loadPaymentOrder(sessCtx, order.ID, order)
+		}
+
+		requests, requestFilter, err := mr.requestFilter(order.RequestType, order.RequestID)
+		if err != nil {
+			return fmt.Errorf("unknown request %s %s: %v", order.RequestType, order.RequestID, err)
+		}
+
+		_, err = requests.UpdateOne(sessCtx, requestFilter, bson.D{{Key: "$set", Value: bson.D{{Key: "paid", Value: true}}}})
+		if err != nil {
+			return err
+		}
+
+		order.Status = PaymentStatusPaid
+		order.TransactionID = transactionID
+		order.PaidAt = paidAt
+		return nil
+	})
+}
+
+// Requests submitted before fees were introduced were free, so they are
+// marked as paid. Safe to run on every start
+func (mr *MUPRepo) MigratePayments(ctx context.Context) error {
+	filter := bson.D{{Key: "paid", Value: bson.D{{Key: "$exists", Value: false}}}}
+	update := bson.D{{Key: "$set", Value: bson.D{{Key: "paid", Value: true}}}}
+
+	for _, collection := range []string{"registration", "trafficPermit", "permitConversion"} {
+		_, err := mr.getMupCollection(collection).UpdateMany(ctx, filter, update)
+		if err != nil {
+			return fmt.Errorf("failed to migrate payments of %s: %v", collection, err)
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,65 @@
+package data
+
+import (
+	"encoding/json"
+	"io"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// Request to exchange a foreign driving licence for a domestic permit. Holders of
+// foreign licences keep driving on them until the conversion is approved, when a
+// traffic permit is issued for every converted category
+type PermitConversion struct {
+	ID                     primitive.ObjectID   `bson:"_id,omitempty" json:"id"`
+	Person                 string               `bson:"person" json:"person"`
+	Office                 primitive.ObjectID   `bson:"office" json:"office"`
+	Country                string               `bson:"country" json:"country"`
+	OriginalNumber         string               `bson:"originalNumber" json:"originalNumber"`
+	Categories             []string             `bson:"categories" json:"categories"`
+	OriginalIssuedDate     time.Time            `bson:"originalIssuedDate" json:"originalIssuedDate"`
+	OriginalExpirationDate time.Time            `bson:"originalExpirationDate" json:"originalExpirationDate"`
+	SubmittedAt            time.Time            `bson:"submittedAt" json:"submittedAt"`
+	ProcessedAt            time.Time            `bson:"processedAt,omitempty" json:"processedAt"`
+	PaymentOrder           primitive.ObjectID   `bson:"paymentOrder,omitempty" json:"paymentOrder"`
+	Paid                   bool                 `bson:"paid" json:"paid"`
+	Status                 string               `bson:"status" json:"status"`
+	Timeline               Timeline             `bson:"timeline" json:"timeline"`
+	Permits                []primitive.ObjectID `bson:"permits,omitempty" json:"permits,omitempty"`
+}
+
+type PermitConversions []PermitConversion
+
+type PermitConversionDetails struct {
+	PermitConversion
+	FirstName   string      `json:"firstName"`
+	LastName    string      `json:"lastName"`
+	Citizenship string      `json:"citizenship"`
+	Attachments Attachments `json:"attachments"`
+	// Converted categories need no exams, only a valid medical certificate
+	MedicalCertificateValid bool      `json:"medicalCertificateValid"`
+	MedicalValidUntil       time.Time `json:"medicalValidUntil,omitempty"`
+}
+
+type PermitConversionDetailsList []PermitConversionDetails
+
+func (pc *PermitConversion) ToJSON(w io.Writer) error {
+	e := json.NewEncoder(w)
+	return e.Encode(pc)
+}
+
+func (pc *PermitConversion) FromJSON(r io.Reader) error {
+	d := json.NewDecoder(r)
+	return d.Decode(pc)
+}
+
+func (pc *PermitConversions) ToJSON(w io.Writer) error {
+	e := json.NewEncoder(w)
+	return e.Encode(pc)
+}
+
+func (pcdl *PermitConversionDetailsList) ToJSON(w io.Writer) error {
+	e := json.NewEncoder(w)
+	return e.Encode(pcdl)
+}
@@ -0,0 +1,221 @@
+package data
+
+import (
+	"context"
+	"errors"
+	"log"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+var ErrPermitConversionNotFound = errors.New("permit conversion request not found")
+
+//Permit conversion methods
+
+// Saves conversion request and its payment order in a single transaction. Resubmitting
+// while a request for the same foreign licence is open returns that request and its order
+func (mr *MUPRepo) SubmitPermitConversion(ctx context.Context, conversion *PermitConversion, order *PaymentOrder, attachments Attachments) error {
+	collection := mr.getMupCollection("permitConversion")
+
+	return mr.withTransaction(ctx, func(sessCtx mongo.SessionContext) error {
+		filter := bson.D{
+			{Key: "person", Value: conversion.Person},
+			{Key: "country", Value: conversion.Country},
+			{Key: "originalNumber", Value: conversion.OriginalNumber},
+			{Key: "status", Value: bson.D{{Key: "$in", Value: OpenStatuses}}},
+		}
+
+		var existing PermitConversion
+		err := collection.FindOne(sessCtx, filter).Decode(&existing)
+		if err == nil {
+			*conversion = existing
+			err = mr.saveAttachments(sessCtx, RequestTypePermitConversion, existing.ID.Hex(), existing.Office, attachments)
+			if err != nil {
+				return err
+			}
+			return mr.loadPaymentOrder(sessCtx, existing.PaymentOrder, order)
+		}
+		if !errors.Is(err, mongo.ErrNoDocuments) {
+			return err
+		}
+
+		order.RequestID = conversion.ID.Hex()
+		conversion.PaymentOrder = order.ID
+		conversion.Paid = false
+		conversion.Status = StatusSubmitted
+		conversion.Timeline = Timeline{{To: StatusSubmitted, By: conversion.Person, At: conversion.SubmittedAt}}
+
+		_, err = collection.InsertOne(sessCtx, conversion)
+		if err != nil {
+			log.Printf("Failed to create permit conversion: %v", err)
+			return err
+		}
+
+		_, err = mr.getMupCollection("paymentOrder").InsertOne(sessCtx, order)
+		if err != nil {
+			log.Printf("Failed to create payment order: %v", err)
+			return err
+		}
+
+		return mr.saveAttachments(sessCtx, RequestTypePermitConversion, conversion.ID.Hex(), conversion.Office, attachments)
+	})
+}
+
+func (mr *MUPRepo) GetPermitConversion(ctx context.Context, id primitive.ObjectID) (PermitConversion, error) {
+	collection := mr.getMupCollection("permitConversion")
+
+	var conversion PermitConversion
+	err := collection.FindOne(ctx, bson.D{{Key: "_id", Value: id}}).Decode(&conversion)
+	if err != nil {
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			return PermitConversion{}, ErrPermitConversionNotFound
+		}
+		return PermitConversion{}, err
+	}
+
+	return conversion, nil
+}
+
+// Returns all conversion requests of the person, newest first
+func (mr *MUPRepo) GetPersonsPermitConversions(ctx context.Context, jmbg string) (PermitConversions, error) {
+	collection := mr.getMupCollection("permitConversion")
+
+	opts := options.Find().SetSort(bson.D{{Key: "submittedAt", Value: -1}})
+	cursor, err := collection.Find(ctx, bson.D{{Key: "person", Value: jmbg}}, opts)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	conversions := PermitConversions{}
+	if err = cursor.All(ctx, &conversions); err != nil {
+		return nil, err
+	}
+
+	return conversions, nil
+}
+
+// Returns paid submitted conversions of the given office, or of all offices for primitive.NilObjectID
+func (mr *MUPRepo) GetPendingPermitConversions(ctx context.Context, office primitive.ObjectID) (PermitConversions, error) {
+	collection := mr.getMupCollection("permitConversion")
+
+	filter := bson.D{
+		{Key: "status", Value: StatusSubmitted},
+		{Key: "paid", Value: true},
+	}
+
+	if !office.IsZero() {
+		filter = append(filter, bson.E{Key: "office", Value: office})
+	}
+
+	opts := options.Find().SetSort(bson.D{{Key: "submittedAt", Value: 1}})
+	cursor, err := collection.Find(ctx, filter, opts)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	conversions := PermitConversions{}
+	if err = cursor.All(ctx, &conversions); err != nil {
+		return nil, err
+	}
+
+	return conversions, nil
+}
+
+// Approves submitted conversion and issues a domestic permit for every converted
+// category, all under the given number. Unless office is primitive.NilObjectID,
+// only conversions submitted to it can be approved. Permits of a banned person
+// are suspended right away
+func (mr *MUPRepo) ApprovePermitConversion(ctx context.Context, id, office primitive.ObjectID, number, clerk string) (PermitConversion, error) {
+	collection := mr.getMupCollection("permitConversion")
+
+	var conversion PermitConversion
+	err := mr.withTransaction(ctx, func(sessCtx mongo.SessionContext) error {
+		var err error
+		conversion, err = mr.GetPermitConversion(sessCtx, id)
+		if err != nil {
+			return err
+		}
+
+		if !office.IsZero() && conversion.Office != office {
+			return ErrWrongOffice
+		}
+
+		// Repeated approvals return the permits issued the first time
+		if conversion.Status == StatusApproved {
+			return nil
+		}
+
+		if conversion.Status != StatusSubmitted {
+			return ErrInvalidTransition
+		}
+
+		if !conversion.Paid {
+			return ErrNotPaid
+		}
+
+		now := time.Now()
+		change := StatusChange{From: StatusSubmitted, To: StatusApproved, By: clerk, At: now}
+
+		var permits []interface{}
+		var permitIDs []primitive.ObjectID
+		for _, category := range conversion.Categories {
+			permit := TrafficPermit{
+				ID:             primitive.NewObjectID(),
+				Number:         number,
+				IssuedDate:     now,
+				ExpirationDate: now.AddDate(5, 0, 0),
+				Approved:       true,
+				Person:         conversion.Person,
+				Office:         conversion.Office,
+				ProcessedAt:    now,
+				Category:       category,
+				Paid:           true,
+				Status:         StatusApproved,
+				Timeline:       Timeline{change},
+				Conversion:     conversion.ID,
+			}
+			permits = append(permits, permit)
+			permitIDs = append(permitIDs, permit.ID)
+		}
+
+		_, err = mr.getMupCollection("trafficPermit").InsertMany(sessCtx, permits)
+		if err != nil {
+			return err
+		}
+
+		filter := bson.D{{Key: "_id", Value: id}, {Key: "status", Value: StatusSubmitted}}
+		update := bson.D{
+			{Key: "$set", Value: bson.D{
+				{Key: "status", Value: change.To},
+				{Key: "processedAt", Value: now},
+				{Key: "permits", Value: permitIDs}}},
+			{Key: "$push", Value: bson.D{{Key: "timeline", Value: change}}},
+		}
+
+		result, err := collection.UpdateOne(sessCtx, filter, update)
+		if err != nil {
+			return err
+		}
+		if result.MatchedCount == 0 {
+			return ErrInvalidTransition
+		}
+
+		conversion.Status = change.To
+		conversion.ProcessedAt = now
+		conversion.Permits = permitIDs
+		conversion.Timeline = append(conversion.Timeline, change)
+
+		return mr.reconcilePersonsPermits(sessCtx, conversion.Person, clerk, "")
+	})
+	if err != nil {
+		return PermitConversion{}, err
+	}
+
+	return conversion, nil
+}
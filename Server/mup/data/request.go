@@ -0,0 +1,47 @@
+package data
+
+import (
+	"encoding/json"
+	"io"
+	"time"
+)
+
+// States of registration and traffic permit requests
+const (
+	StatusSubmitted = "SUBMITTED"
+	StatusNeedsInfo = "NEEDS_INFO"
+	StatusApproved  = "APPROVED"
+	StatusRejected  = "REJECTED"
+	StatusWithdrawn = "WITHDRAWN"
+)
+
+// States in which a request still waits on the clerk or the citizen
+var OpenStatuses = []string{StatusSubmitted, StatusNeedsInfo}
+
+// Single transition of a request. By is the JMBG of the clerk or citizen who made it,
+// or the authority that banned the permit holder. It is empty for transitions
+// recorded before the history was kept and for bans that ran out
+type StatusChange struct {
+	From   string    `bson:"from,omitempty" json:"from,omitempty"`
+	To     string    `bson:"to" json:"to"`
+	By     string    `bson:"by,omitempty" json:"by,omitempty"`
+	At     time.Time `bson:"at" json:"at"`
+	Reason string    `bson:"reason,omitempty" json:"reason,omitempty"`
+}
+
+type Timeline []StatusChange
+
+type StatusChangeRequest struct {
+	Status string `json:"status"`
+	Reason string `json:"reason"`
+}
+
+func (sc *StatusChange) ToJSON(w io.Writer) error {
+	e := json.NewEncoder(w)
+	return e.Encode(sc)
+}
+
+func (scr *StatusChangeRequest) FromJSON(r io.Reader) error {
+	d := json.NewDecoder(r)
+	return d.Decode(scr)
+}
@@ -0,0 +1,138 @@
+package data
+
+import (
+	"context"
+	"errors"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+//Request status methods
+
+// Moves the request from change.From to change.To and appends the change to its timeline.
+// Returns ErrInvalidTransition if the request moved meanwhile. Closing a request cancels
+// its appointment, freeing the slot
+func (mr *MUPRepo) ChangeRequestStatus(ctx context.Context, requestType, requestID string, change StatusChange) error {
+	collection, filter, err := mr.requestFilter(requestType, requestID)
+	if err != nil {
+		return err
+	}
+
+	filter = append(filter, bson.E{Key: "status", Value: change.From})
+
+	set := bson.D{{Key: "status", Value: change.To}}
+	if change.To == StatusRejected {
+		set = append(set, bson.E{Key: "processedAt", Value: change.At})
+	}
+
+	update := bson.D{
+		{Key: "$set", Value: set},
+		{Key: "$push", Value: bson.D{{Key: "timeline", Value: change}}},
+	}
+
+	return mr.withTransaction(ctx, func(sessCtx mongo.SessionContext) error {
+		result, err := collection.UpdateOne(sessCtx, filter, update)
+		if err != nil {
+			return err
+		}
+		if result.MatchedCount == 0 {
+			return ErrInvalidTransition
+		}
+
+		if change.To == StatusRejected || change.To == StatusWithdrawn {
+			return mr.cancelRequestAppointment(sessCtx, requestType, requestID)
+		}
+
+		return nil
+	})
+}
+
+// Sets status and timeline of requests created before requests had a status
+func (mr *MUPRepo) MigrateRequestStatuses(ctx context.Context) error {
+	for nameOfCollection, ownerField := range map[string]string{"registration": "$owner", "trafficPermit": "$person"} {
+		collection := mr.getMupCollection(nameOfCollection)
+
+		submitted := bson.D{{Key: "to", Value: StatusSubmitted}, {Key: "by", Value: ownerField}, {Key: "at", Value: "$issuedDate"}}
+		approved := bson.D{
+			{Key: "from", Value: StatusSubmitted},
+			{Key: "to", Value: StatusApproved},
+			{Key: "at", Value: bson.D{{Key: "$ifNull", Value: bson.A{"$processedAt", "$issuedDate"}}}},
+		}
+
+		migrations := []struct {
+			approved bool
+			status   string
+			timeline bson.A
+		}{
+			{approved: true, status: StatusApproved, timeline: bson.A{submitted, approved}},
+			{approved: false, status: StatusSubmitted, timeline: bson.A{submitted}},
+		}
+
+		for _, migration := range migrations {
+			filter := bson.D{
+				{Key: "status", Value: bson.D{{Key: "$exists", Value: false}}},
+				{Key: "approved", Value: migration.approved},
+			}
+			// Pipeline update, so the timeline can be built from the request's own fields
+			update := mongo.Pipeline{{{Key: "$set", Value: bson.D{
+				{Key: "status", Value: migration.status},
+				{Key: "timeline", Value: migration.timeline},
+			}}}}
+
+			_, err := collection.UpdateMany(ctx, filter, update)
+			if err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+// Returns collection of the request type and filter selecting the request
+func (mr *MUPRepo) requestFilter(requestType, requestID string) (*mongo.Collection, bson.D, error) {
+	switch requestType {
+	case RequestTypeRegistration:
+		return mr.getMupCollection("registration"), bson.D{{Key: "registrationNumber", Value: requestID}}, nil
+	case RequestTypeTrafficPermit:
+		permitID, err := primitive.ObjectIDFromHex(requestID)
+		if err != nil {
+			return nil, nil, ErrRequestNotFound
+		}
+		return mr.getMupCollection("trafficPermit"), bson.D{{Key: "_id", Value: permitID}}, nil
+	case RequestTypePermitConversion:
+		conversionID, err := primitive.ObjectIDFromHex(requestID)
+		if err != nil {
+			return nil, nil, ErrRequestNotFound
+		}
+		return mr.getMupCollection("permitConversion"), bson.D{{Key: "_id", Value: conversionID}}, nil
+	default:
+		return nil, nil, ErrRequestNotFound
+	}
+}
+
+// Cancels active appointment of the request, if it has one, and frees its slot
+func (mr *MUPRepo) cancelRequestAppointment(sessCtx mongo.SessionContext, requestType, requestID string) error {
+	filter := bson.D{
+		{Key: "requestType", Value: requestType},
+		{Key: "requestID", Value: requestID},
+		{Key: "cancelled", Value: false},
+	}
+	update := bson.D{{Key: "$set", Value: bson.D{{Key: "cancelled", Value: true}}}}
+
+	var appointment Appointment
+	err := mr.getMupCollection("appointment").FindOneAndUpdate(sessCtx, filter, update).Decode(&appointment)
+	if err != nil {
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			return nil
+		}
+		return err
+	}
+
+	slotID := AppointmentSlotID{Office: appointment.Office, Start: appointment.Start}
+	slotFilter := bson.D{{Key: "_id", Value: slotID}, {Key: "booked", Value: bson.D{{Key: "$gt", Value: 0}}}}
+	_, err = mr.getMupCollection("appointmentSlot").UpdateOne(sessCtx, slotFilter, bson.D{{Key: "$inc", Value: bson.D{{Key: "booked", Value: -1}}}})
+	return err
+}
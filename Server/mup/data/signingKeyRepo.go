@@ -0,0 +1,50 @@
+package data
+
+import (
+	"context"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+//Signing key methods
+
+// Returns all signing keys, newest first
+func (mr *MUPRepo) GetSigningKeys(ctx context.Context) (SigningKeys, error) {
+	collection := mr.getMupCollection("signingKey")
+
+	opts := options.Find().SetSort(bson.D{{Key: "createdAt", Value: -1}})
+	cursor, err := collection.Find(ctx, bson.D{}, opts)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	keys := SigningKeys{}
+	if err = cursor.All(ctx, &keys); err != nil {
+		return nil, err
+	}
+
+	return keys, nil
+}
+
+// Retires the active signing key, dropping its private part, and stores the new active key
+func (mr *MUPRepo) RotateSigningKey(ctx context.Context, key SigningKey) error {
+	collection := mr.getMupCollection("signingKey")
+
+	return mr.withTransaction(ctx, func(sessCtx mongo.SessionContext) error {
+		retire := bson.D{
+			{Key: "$set", Value: bson.D{{Key: "active", Value: false}, {Key: "retiredAt", Value: time.Now()}}},
+			{Key: "$unset", Value: bson.D{{Key: "privateKey", Value: ""}}},
+		}
+		_, err := collection.UpdateMany(sessCtx, bson.D{{Key: "active", Value: true}}, retire)
+		if err != nil {
+			return err
+		}
+
+		_, err = collection.InsertOne(sessCtx, key)
+		return err
+	})
+}
@@ -14,6 +14,7 @@ type Vehicle struct {
 	Registration string             `bson:"registration" json:"registration"`
 	Plates       string             `bson:"plates" json:"plates"`
 	Owner        string             `bson:"owner" json:"owner"`
+	Category     string             `bson:"category" json:"category"`
 }
 
 type Vehicles []Vehicle
@@ -26,6 +27,12 @@ type Registration struct {
 	Owner              string             `bson:"owner" json:"owner"`
 	Plates             string             `bson:"plates" json:"plates"`
 	Approved           bool               `bson:"approved" json:"approved"`
+	Office             primitive.ObjectID `bson:"office" json:"office"`
+	ProcessedAt        time.Time          `bson:"processedAt,omitempty" json:"processedAt"`
+	PaymentOrder       primitive.ObjectID `bson:"paymentOrder,omitempty" json:"paymentOrder"`
+	Paid               bool               `bson:"paid" json:"paid"`
+	Status             string             `bson:"status" json:"status"`
+	Timeline           Timeline           `bson:"timeline" json:"timeline"`
 }
 
 type Registrations []Registration
@@ -38,10 +45,14 @@ type RegistrationDetails struct {
 	Owner              string             `json:"owner"`
 	Plates             string             `json:"plates"`
 	Approved           bool               `json:"approved"`
+	Office             primitive.ObjectID `json:"office"`
 	FirstName          string             `json:"firstName"`
 	LastName           string             `json:"lastName"`
 	VehicleBrand       string             `json:"vehicleBrand"`
 	VehicleModel       string             `json:"vehicleModel"`
+	Status             string             `json:"status"`
+	Timeline           Timeline           `json:"timeline"`
+	Attachments        Attachments        `json:"attachments"`
 }
 
 type RegistrationDetailsList []RegistrationDetails
@@ -52,6 +63,7 @@ type Plates struct {
 	PlateType          string             `bson:"plateType" json:"plateType"`
 	Owner              string             `bson:"owner" json:"owner"`
 	VehicleID          primitive.ObjectID `bson:"vehicleID" json:"vehicleID"`
+	Office             primitive.ObjectID `bson:"office" json:"office"`
 }
 
 type ListOfPlates []Plates
@@ -0,0 +1,141 @@
+package documents
+
+import (
+	"fmt"
+	"io"
+	"mup/data"
+	"strconv"
+	"strings"
+)
+
+const issuer = "Ministarstvo unutrašnjih poslova"
+
+// Writes registration certificate (saobraćajna dozvola) of an approved registration
+func RegistrationCertificate(w io.Writer, registration data.Registration, vehicle data.Vehicle, owner data.Person, office data.Mup, verifyURL string) error {
+	doc := newDocument(issuer+" - "+office.Name, "Saobraćajna dozvola")
+
+	doc.section("Registracija")
+	doc.field("Registarski broj", registration.RegistrationNumber)
+	doc.field("Registarske tablice", registration.Plates)
+	doc.field("Datum izdavanja", formatDate(registration.IssuedDate))
+	doc.field("Važi do", formatDate(registration.ExpirationDate))
+	doc.field("Izdao", office.Name+", "+formatAddress(office.Address))
+
+	doc.section("Vozilo")
+	doc.field("Marka", vehicle.Brand)
+	doc.field("Model", vehicle.Model)
+	doc.field("Godina proizvodnje", strconv.Itoa(vehicle.Year))
+	doc.field("Kategorija", vehicle.Category)
+
+	doc.section("Vlasnik")
+	doc.field("Ime i prezime", owner.FirstName+" "+owner.LastName)
+	doc.field("JMBG", owner.JMBG)
+	doc.field("Adresa", formatAddress(owner.Address))
+
+	if err := doc.verificationCode(verifyURL); err != nil {
+		return err
+	}
+
+	return doc.write(w)
+}
+
+// Writes summary of a driving permit together with the holder's driving bans
+func DrivingPermitSummary(w io.Writer, permit data.TrafficPermit, holder data.Person, office data.Mup, bans data.DrivingBans, verifyURL string) error {
+	doc := newDocument(issuer+" - "+office.Name, "Vozačka dozvola - izvod")
+
+	doc.section("Vozačka dozvola")
+	doc.field("Broj dozvole", permit.Number)
+	doc.field("Kategorija", permit.Category)
+	doc.field("Datum izdavanja", formatDate(permit.IssuedDate))
+	doc.field("Važi do", formatDate(permit.ExpirationDate))
+	doc.field("Izdao", office.Name+", "+formatAddress(office.Address))
+
+	doc.section("Vozač")
+	doc.field("Ime i prezime", holder.FirstName+" "+holder.LastName)
+	doc.field("JMBG", holder.JMBG)
+	doc.field("Datum rođenja", holder.DOB)
+	doc.field("Adresa", formatAddress(holder.Address))
+
+	doc.section("Zabrane upravljanja vozilom")
+	if len(bans) == 0 {
+		doc.paragraph("Nema izrečenih zabrana.")
+	}
+	for _, ban := range bans {
+		doc.field(ban.Reason, formatDate(ban.Start)+" - "+formatDate(ban.End)+" ("+banStatuses[ban.Status]+")")
+	}
+
+	if err := doc.verificationCode(verifyURL); err != nil {
+		return err
+	}
+
+	return doc.write(w)
+}
+
+// Convention titles as printed on the cover of the international permit
+var conventionTitles = map[string]string{
+	data.Convention1949: "International Driving Permit - Convention on Road Traffic of 19 September 1949",
+	data.Convention1968: "International Driving Permit - Convention on Road Traffic of 8 November 1968",
+}
+
+// Lays out the international permit for printing as the convention requires it
+func InternationalPermitPrintout(permit data.InternationalPermit, domestic data.TrafficPermit, holder data.Person, office data.Mup) data.PermitPrintout {
+	return data.PermitPrintout{
+		Title:            conventionTitles[permit.Convention],
+		IssuingCountry:   "Republic of Serbia",
+		DistinguishSign:  "SRB",
+		Authority:        issuer + " - " + office.Name,
+		Place:            office.Address.Locality,
+		IssuedDate:       formatDate(permit.IssuedDate),
+		ValidUntil:       formatDate(permit.ExpirationDate),
+		DomesticNumber:   domestic.Number,
+		Surname:          holder.LastName,
+		OtherNames:       holder.FirstName,
+		DateOfBirth:      holder.DOB,
+		PermanentAddress: formatAddress(holder.Address),
+		Categories:       permit.Categories,
+	}
+}
+
+// Writes international driving permit from its stored printout
+func InternationalDrivingPermit(w io.Writer, permit data.InternationalPermit) error {
+	printout := permit.Printout
+	doc := newDocument(printout.Authority, "Međunarodna vozačka dozvola")
+
+	doc.paragraph(printout.Title)
+
+	doc.section("International Driving Permit")
+	doc.field("No.", permit.Number)
+	doc.field("Issuing country", printout.IssuingCountry+" ("+printout.DistinguishSign+")")
+	doc.field("Issued by", printout.Authority)
+	doc.field("Place", printout.Place)
+	doc.field("Date", printout.IssuedDate)
+	doc.field("Valid until", printout.ValidUntil)
+	doc.field("Domestic permit No.", printout.DomesticNumber)
+
+	doc.section("Holder")
+	doc.field("Surname", printout.Surname)
+	doc.field("Other names", printout.OtherNames)
+	doc.field("Date of birth", printout.DateOfBirth)
+	doc.field("Permanent place of residence", printout.PermanentAddress)
+
+	doc.section("Categories")
+	doc.field("Valid for", strings.Join(printout.Categories, ", "))
+	doc.paragraph("This permit is not valid for driving in the territory of the issuing country " +
+		"and only together with the domestic driving permit.")
+
+	return doc.write(w)
+}
+
+var banStatuses = map[string]string{
+	data.BanStatusUpcoming: "predstoji",
+	data.BanStatusActive:   "na snazi",
+	data.BanStatusExpired:  "istekla",
+	data.BanStatusLifted:   "ukinuta",
+}
+
+func formatAddress(address data.Address) string {
+	if address.StreetName == "" {
+		return address.Locality
+	}
+	return fmt.Sprintf("%s %d, %s", address.StreetName, address.StreetNumber, address.Locality)
+}
@@ -0,0 +1,100 @@
+package documents
+
+import (
+	"bytes"
+	_ "embed"
+	"io"
+	"time"
+
+	"github.com/go-pdf/fpdf"
+	"github.com/skip2/go-qrcode"
+)
+
+const dateFormat = "02.01.2006"
+
+// Core PDF fonts are cp1252, which has no glyphs for č, ć and đ, so a
+// UTF-8 font is embedded to print names as they are written
+const fontFamily = "DejaVu"
+
+var (
+	//go:embed fonts/DejaVuSansCondensed.ttf
+	fontRegular []byte
+	//go:embed fonts/DejaVuSansCondensed-Bold.ttf
+	fontBold []byte
+)
+
+// Document being written
+type document struct {
+	pdf *fpdf.Fpdf
+}
+
+func newDocument(issuer, title string) *document {
+	pdf := fpdf.New("P", "mm", "A4", "")
+	pdf.SetTitle(title, true)
+	pdf.SetCreator(issuer, true)
+	pdf.AddUTF8FontFromBytes(fontFamily, "", fontRegular)
+	pdf.AddUTF8FontFromBytes(fontFamily, "B", fontBold)
+	pdf.AddPage()
+
+	doc := &document{pdf: pdf}
+
+	pdf.SetFont(fontFamily, "", 10)
+	pdf.CellFormat(0, 6, "Republika Srbija", "", 1, "L", false, 0, "")
+	pdf.CellFormat(0, 6, issuer, "", 1, "L", false, 0, "")
+	pdf.Ln(8)
+
+	pdf.SetFont(fontFamily, "B", 16)
+	pdf.CellFormat(0, 10, title, "", 1, "C", false, 0, "")
+	pdf.Ln(6)
+
+	return doc
+}
+
+func (d *document) section(title string) {
+	d.pdf.Ln(4)
+	d.pdf.SetFont(fontFamily, "B", 12)
+	d.pdf.CellFormat(0, 8, title, "B", 1, "L", false, 0, "")
+	d.pdf.Ln(2)
+}
+
+func (d *document) field(label, value string) {
+	d.pdf.SetFont(fontFamily, "B", 10)
+	d.pdf.CellFormat(60, 7, label, "", 0, "L", false, 0, "")
+	d.pdf.SetFont(fontFamily, "", 10)
+	d.pdf.MultiCell(0, 7, value, "", "L", false)
+}
+
+func (d *document) paragraph(text string) {
+	d.pdf.SetFont(fontFamily, "", 10)
+	d.pdf.MultiCell(0, 6, text, "", "L", false)
+}
+
+// Draws QR code linking to the verification URL at the bottom of the page
+func (d *document) verificationCode(verifyURL string) error {
+	png, err := qrcode.Encode(verifyURL, qrcode.Medium, 256)
+	if err != nil {
+		return err
+	}
+
+	d.pdf.RegisterImageOptionsReader("verification", fpdf.ImageOptions{ImageType: "PNG"}, bytes.NewReader(png))
+
+	_, pageHeight := d.pdf.GetPageSize()
+	d.pdf.ImageOptions("verification", 15, pageHeight-60, 40, 40, false, fpdf.ImageOptions{ImageType: "PNG"}, 0, verifyURL)
+
+	d.pdf.SetXY(60, pageHeight-50)
+	d.pdf.SetFont(fontFamily, "", 8)
+	d.pdf.MultiCell(0, 4, "Autentičnost dokumenta proverite skeniranjem QR koda ili na adresi:\n"+verifyURL, "", "L", false)
+
+	return nil
+}
+
+func (d *document) write(w io.Writer) error {
+	return d.pdf.Output(w)
+}
+
+func formatDate(t time.Time) string {
+	if t.IsZero() {
+		return "-"
+	}
+	return t.Format(dateFormat)
+}
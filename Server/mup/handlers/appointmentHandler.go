@@ -0,0 +1,228 @@
+package handlers
+
+import (
+	"errors"
+	"log"
+	"mup/data"
+	"mup/services"
+	"net/http"
+
+	"github.com/gorilla/mux"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// GET METHODS
+
+func (mh *MupHandler) GetOfficeSchedule(rw http.ResponseWriter, r *http.Request) {
+	office, err := primitive.ObjectIDFromHex(mux.Vars(r)["office"])
+	if err != nil {
+		http.Error(rw, "Invalid office ID", http.StatusBadRequest)
+		return
+	}
+
+	schedule, err := mh.service.GetOfficeSchedule(r.Context(), office)
+	if err != nil {
+		if errors.Is(err, data.ErrScheduleNotFound) {
+			http.Error(rw, "Office does not take appointments", http.StatusNotFound)
+			return
+		}
+		http.Error(rw, "Failed to retrieve office schedule", http.StatusInternalServerError)
+		return
+	}
+
+	rw.Header().Set(ContentType, ApplicationJson)
+	rw.WriteHeader(http.StatusOK)
+	if err := schedule.ToJSON(rw); err != nil {
+		http.Error(rw, "Failed to encode office schedule", http.StatusInternalServerError)
+	}
+}
+
+func (mh *MupHandler) GetFreeSlots(rw http.ResponseWriter, r *http.Request) {
+	office, err := primitive.ObjectIDFromHex(mux.Vars(r)["office"])
+	if err != nil {
+		http.Error(rw, "Invalid office ID", http.StatusBadRequest)
+		return
+	}
+
+	slots, err := mh.service.GetFreeSlots(r.Context(), office, r.URL.Query().Get("date"))
+	if err != nil {
+		if errors.Is(err, services.ErrInvalidSlot) {
+			http.Error(rw, "Invalid date, expected YYYY-MM-DD", http.StatusBadRequest)
+			return
+		}
+		if errors.Is(err, data.ErrScheduleNotFound) {
+			http.Error(rw, "Office does not take appointments", http.StatusNotFound)
+			return
+		}
+		log.Printf("Failed to retrieve free slots: %v", err)
+		http.Error(rw, "Failed to retrieve free slots", http.StatusInternalServerError)
+		return
+	}
+
+	rw.Header().Set(ContentType, ApplicationJson)
+	rw.WriteHeader(http.StatusOK)
+	if err := slots.ToJSON(rw); err != nil {
+		http.Error(rw, "Failed to encode free slots", http.StatusInternalServerError)
+	}
+}
+
+// Clerk view of the day's appointments
+func (mh *MupHandler) GetOfficeAppointments(rw http.ResponseWriter, r *http.Request) {
+	tokenStr := mh.extractTokenFromHeader(r)
+	office, err := mh.getQueueOffice(r, tokenStr)
+	if err != nil {
+		if errors.Is(err, data.ErrWrongOffice) {
+			http.Error(rw, "Appointments of another office requested", http.StatusForbidden)
+			return
+		}
+		http.Error(rw, "Invalid office ID", http.StatusBadRequest)
+		return
+	}
+
+	appointments, err := mh.service.GetOfficeAppointments(r.Context(), office, r.URL.Query().Get("date"))
+	if err != nil {
+		if errors.Is(err, services.ErrInvalidSlot) {
+			http.Error(rw, "Invalid date, expected YYYY-MM-DD", http.StatusBadRequest)
+			return
+		}
+		log.Printf("Failed to retrieve appointments: %v", err)
+		http.Error(rw, "Failed to retrieve appointments", http.StatusInternalServerError)
+		return
+	}
+
+	rw.Header().Set(ContentType, ApplicationJson)
+	rw.WriteHeader(http.StatusOK)
+	if err := appointments.ToJSON(rw); err != nil {
+		http.Error(rw, "Failed to encode appointments", http.StatusInternalServerError)
+	}
+}
+
+// POST METHODS
+
+func (mh *MupHandler) BookAppointment(rw http.ResponseWriter, r *http.Request) {
+	var appointment data.Appointment
+
+	jmbg, err := mh.getJMBGFromToken(mh.extractTokenFromHeader(r))
+	if err != nil || jmbg == "" {
+		http.Error(rw, "Failed to read JMBG from token", http.StatusBadRequest)
+		return
+	}
+
+	if err := appointment.FromJSON(r.Body); err != nil {
+		http.Error(rw, FailedToDecodeRequestBody, http.StatusBadRequest)
+		log.Printf("Failed to decode request body: %v", err)
+		return
+	}
+
+	if err := mh.service.BookAppointment(r.Context(), &appointment, jmbg); err != nil {
+		log.Printf("Failed to book appointment: %v", err)
+		switch {
+		case errors.Is(err, data.ErrRequestNotFound):
+			http.Error(rw, "Pending request not found", http.StatusNotFound)
+		case errors.Is(err, data.ErrScheduleNotFound):
+			http.Error(rw, "Office does not take appointments", http.StatusNotFound)
+		case errors.Is(err, services.ErrInvalidSlot):
+			http.Error(rw, "Requested time is not a free appointment slot", http.StatusBadRequest)
+		case errors.Is(err, data.ErrSlotFull):
+			http.Error(rw, "Appointment slot is full", http.StatusConflict)
+		case errors.Is(err, data.ErrAppointmentExists):
+			http.Error(rw, "Request already has an appointment", http.StatusConflict)
+		default:
+			http.Error(rw, "Failed to book appointment", http.StatusInternalServerError)
+		}
+		return
+	}
+
+	rw.Header().Set(ContentType, ApplicationJson)
+	rw.WriteHeader(http.StatusCreated)
+	if err := appointment.ToJSON(rw); err != nil {
+		http.Error(rw, "Failed to encode appointment", http.StatusInternalServerError)
+	}
+
+	log.Printf("Successfully booked appointment with id '%s'", appointment.ID.Hex())
+}
+
+// PUT METHODS
+
+func (mh *MupHandler) SaveOfficeSchedule(rw http.ResponseWriter, r *http.Request) {
+	var schedule data.OfficeSchedule
+
+	office, err := primitive.ObjectIDFromHex(mux.Vars(r)["office"])
+	if err != nil {
+		http.Error(rw, "Invalid office ID", http.StatusBadRequest)
+		return
+	}
+
+	clerkOffice, err := mh.getOfficeFromToken(mh.extractTokenFromHeader(r))
+	if err != nil {
+		http.Error(rw, "Invalid office in token", http.StatusBadRequest)
+		return
+	}
+	if !clerkOffice.IsZero() && clerkOffice != office {
+		http.Error(rw, "Schedule of another office requested", http.StatusForbidden)
+		return
+	}
+
+	if err := schedule.FromJSON(r.Body); err != nil {
+		http.Error(rw, FailedToDecodeRequestBody, http.StatusBadRequest)
+		log.Printf("Failed to decode request body: %v", err)
+		return
+	}
+
+	schedule.Office = office
+
+	if err := mh.service.SaveOfficeSchedule(r.Context(), &schedule); err != nil {
+		log.Printf("Failed to save office schedule: %v", err)
+		if errors.Is(err, data.ErrMupNotFound) {
+			http.Error(rw, "Office not found", http.StatusNotFound)
+			return
+		}
+		if errors.Is(err, services.ErrInvalidSchedule) {
+			http.Error(rw, "Invalid office schedule", http.StatusBadRequest)
+			return
+		}
+		http.Error(rw, "Failed to save office schedule", http.StatusInternalServerError)
+		return
+	}
+
+	rw.Header().Set(ContentType, ApplicationJson)
+	rw.WriteHeader(http.StatusOK)
+	if err := schedule.ToJSON(rw); err != nil {
+		http.Error(rw, "Failed to encode office schedule", http.StatusInternalServerError)
+	}
+}
+
+// DELETE METHODS
+
+func (mh *MupHandler) CancelAppointment(rw http.ResponseWriter, r *http.Request) {
+	jmbg, err := mh.getJMBGFromToken(mh.extractTokenFromHeader(r))
+	if err != nil || jmbg == "" {
+		http.Error(rw, "Failed to read JMBG from token", http.StatusBadRequest)
+		return
+	}
+
+	appointmentID, err := primitive.ObjectIDFromHex(mux.Vars(r)["id"])
+	if err != nil {
+		http.Error(rw, "Invalid appointment ID", http.StatusBadRequest)
+		return
+	}
+
+	appointment, err := mh.service.CancelAppointment(r.Context(), appointmentID, jmbg)
+	if err != nil {
+		if errors.Is(err, data.ErrAppointmentNotFound) {
+			http.Error(rw, "Appointment not found", http.StatusNotFound)
+			return
+		}
+		log.Printf("Failed to cancel appointment: %v", err)
+		http.Error(rw, "Failed to cancel appointment", http.StatusInternalServerError)
+		return
+	}
+
+	rw.Header().Set(ContentType, ApplicationJson)
+	rw.WriteHeader(http.StatusOK)
+	if err := appointment.ToJSON(rw); err != nil {
+		http.Error(rw, "Failed to encode appointment", http.StatusInternalServerError)
+	}
+
+	log.Printf("Successfully cancelled appointment with id '%s'", appointmentID.Hex())
+}
@@ -0,0 +1,164 @@
+package handlers
+
+import (
+	"common/storage"
+	"encoding/json"
+	"errors"
+	"io"
+	"log"
+	"mime"
+	"mup/data"
+	"mup/services"
+	"net/http"
+	"strconv"
+
+	"github.com/gorilla/mux"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+const (
+	MultipartFormData = "multipart/form-data"
+	// Room for the request JSON and multipart headers on top of the attachments
+	maxSubmissionSize = services.MaxAttachments*services.MaxAttachmentSize + 1<<20
+	// Parts above this size are buffered on disk while the form is parsed
+	maxSubmissionMemory = 1 << 20
+)
+
+// GET METHODS
+
+func (mh *MupHandler) GetRequestAttachments(rw http.ResponseWriter, r *http.Request) {
+	office, err := mh.getOfficeFromToken(mh.extractTokenFromHeader(r))
+	if err != nil {
+		http.Error(rw, "Invalid office in token", http.StatusBadRequest)
+		return
+	}
+
+	requestType := r.URL.Query().Get("requestType")
+	requestID := r.URL.Query().Get("requestID")
+
+	attachments, err := mh.service.GetRequestAttachments(r.Context(), requestType, requestID, office)
+	if err != nil {
+		if errors.Is(err, data.ErrRequestNotFound) {
+			http.Error(rw, "Unknown request type", http.StatusBadRequest)
+			return
+		}
+		if errors.Is(err, data.ErrWrongOffice) {
+			http.Error(rw, "Request belongs to another office", http.StatusForbidden)
+			return
+		}
+		http.Error(rw, "Failed to retrieve attachments", http.StatusInternalServerError)
+		return
+	}
+
+	rw.Header().Set(ContentType, ApplicationJson)
+	rw.WriteHeader(http.StatusOK)
+	if err := attachments.ToJSON(rw); err != nil {
+		http.Error(rw, "Failed to encode attachments", http.StatusInternalServerError)
+	}
+}
+
+// Streams the attachment content to the clerk, the checksum lets them confirm the file is intact
+func (mh *MupHandler) DownloadAttachment(rw http.ResponseWriter, r *http.Request) {
+	office, err := mh.getOfficeFromToken(mh.extractTokenFromHeader(r))
+	if err != nil {
+		http.Error(rw, "Invalid office in token", http.StatusBadRequest)
+		return
+	}
+
+	id, err := primitive.ObjectIDFromHex(mux.Vars(r)["id"])
+	if err != nil {
+		http.Error(rw, "Invalid attachment ID", http.StatusBadRequest)
+		return
+	}
+
+	attachment, content, err := mh.service.OpenAttachment(r.Context(), id, office)
+	if err != nil {
+		if errors.Is(err, data.ErrAttachmentNotFound) || errors.Is(err, storage.ErrBlobNotFound) {
+			http.Error(rw, "Attachment not found", http.StatusNotFound)
+			return
+		}
+		if errors.Is(err, data.ErrWrongOffice) {
+			http.Error(rw, "Request belongs to another office", http.StatusForbidden)
+			return
+		}
+		log.Printf("Failed to open attachment: %v", err)
+		http.Error(rw, "Failed to retrieve attachment", http.StatusInternalServerError)
+		return
+	}
+	defer content.Close()
+
+	rw.Header().Set(ContentType, attachment.ContentType)
+	rw.Header().Set("Content-Length", strconv.FormatInt(attachment.Size, 10))
+	rw.Header().Set("Content-Disposition", mime.FormatMediaType("attachment", map[string]string{"filename": attachment.FileName}))
+	rw.Header().Set("X-Checksum-SHA256", attachment.SHA256)
+	rw.WriteHeader(http.StatusOK)
+	if _, err := io.Copy(rw, content); err != nil {
+		log.Printf("Failed to write attachment '%s': %v", attachment.ID.Hex(), err)
+	}
+}
+
+// Decodes request body into v. JSON bodies carry only the request, multipart bodies
+// carry it in the 'request' field with files keyed by their attachment kind.
+// The returned cleanup removes files buffered while parsing
+func decodeSubmission(rw http.ResponseWriter, r *http.Request, v interface{}) ([]services.AttachmentUpload, func(), error) {
+	mediaType, _, _ := mime.ParseMediaType(r.Header.Get(ContentType))
+	if mediaType != MultipartFormData {
+		return nil, func() {}, json.NewDecoder(r.Body).Decode(v)
+	}
+
+	r.Body = http.MaxBytesReader(rw, r.Body, maxSubmissionSize)
+	if err := r.ParseMultipartForm(maxSubmissionMemory); err != nil {
+		var maxBytesErr *http.MaxBytesError
+		if errors.As(err, &maxBytesErr) {
+			return nil, func() {}, services.ErrAttachmentTooLarge
+		}
+		return nil, func() {}, err
+	}
+
+	cleanup := func() {
+		if err := r.MultipartForm.RemoveAll(); err != nil {
+			log.Printf("Failed to remove uploaded files: %v", err)
+		}
+	}
+
+	if err := json.Unmarshal([]byte(r.FormValue("request")), v); err != nil {
+		return nil, cleanup, err
+	}
+
+	var uploads []services.AttachmentUpload
+	for kind, headers := range r.MultipartForm.File {
+		for _, header := range headers {
+			file, err := header.Open()
+			if err != nil {
+				return nil, cleanup, err
+			}
+			closeFile := file.Close
+			prev := cleanup
+			cleanup = func() {
+				closeFile()
+				prev()
+			}
+
+			uploads = append(uploads, services.AttachmentUpload{Kind: kind, FileName: header.Filename, Content: file})
+		}
+	}
+
+	return uploads, cleanup, nil
+}
+
+// Writes response for attachment validation errors, reports whether err was one of them
+func writeAttachmentError(rw http.ResponseWriter, err error) bool {
+	switch {
+	case errors.Is(err, services.ErrAttachmentTooLarge):
+		http.Error(rw, "Attachment exceeds the size limit", http.StatusRequestEntityTooLarge)
+	case errors.Is(err, services.ErrUnsupportedMediaType):
+		http.Error(rw, "Attachment must be a PDF, JPEG or PNG file", http.StatusUnsupportedMediaType)
+	case errors.Is(err, services.ErrInvalidAttachmentKind):
+		http.Error(rw, "Attachment kind is not accepted for the request", http.StatusBadRequest)
+	case errors.Is(err, services.ErrTooManyAttachments):
+		http.Error(rw, "Too many attachments", http.StatusBadRequest)
+	default:
+		return false
+	}
+	return true
+}
@@ -0,0 +1,194 @@
+package handlers
+
+import (
+	"errors"
+	"log"
+	"mup/data"
+	"net/http"
+
+	"github.com/gorilla/mux"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+const ApplicationPdf = "application/pdf"
+
+// GET METHODS
+
+func (mh *MupHandler) GetRegistrationCertificate(rw http.ResponseWriter, r *http.Request) {
+	tokenStr := mh.extractTokenFromHeader(r)
+	jmbg, err := mh.getJMBGFromToken(tokenStr)
+	if err != nil || jmbg == "" {
+		http.Error(rw, "Failed to read JMBG from token", http.StatusBadRequest)
+		return
+	}
+
+	registrationNumber := mux.Vars(r)["registrationNumber"]
+
+	pdf, err := mh.service.RegistrationCertificate(r.Context(), registrationNumber, jmbg, tokenStr, mh.verifyBaseURL())
+	if err != nil {
+		if errors.Is(err, data.ErrRegistrationNotFound) {
+			http.Error(rw, "Approved registration not found", http.StatusNotFound)
+			return
+		}
+		log.Printf("Failed to create registration certificate: %v", err)
+		http.Error(rw, "Failed to create registration certificate", http.StatusInternalServerError)
+		return
+	}
+
+	writePdf(rw, "saobracajna-dozvola-"+registrationNumber+".pdf", pdf)
+}
+
+func (mh *MupHandler) GetDrivingPermitSummary(rw http.ResponseWriter, r *http.Request) {
+	tokenStr := mh.extractTokenFromHeader(r)
+	jmbg, err := mh.getJMBGFromToken(tokenStr)
+	if err != nil || jmbg == "" {
+		http.Error(rw, "Failed to read JMBG from token", http.StatusBadRequest)
+		return
+	}
+
+	permitID, err := primitive.ObjectIDFromHex(mux.Vars(r)["id"])
+	if err != nil {
+		http.Error(rw, "Invalid permit ID", http.StatusBadRequest)
+		return
+	}
+
+	pdf, err := mh.service.DrivingPermitSummary(r.Context(), permitID, jmbg, tokenStr, mh.verifyBaseURL())
+	if err != nil {
+		if errors.Is(err, data.ErrTrafficPermitNotFound) {
+			http.Error(rw, "Approved driving permit not found", http.StatusNotFound)
+			return
+		}
+		log.Printf("Failed to create driving permit summary: %v", err)
+		http.Error(rw, "Failed to create driving permit summary", http.StatusInternalServerError)
+		return
+	}
+
+	writePdf(rw, "vozacka-dozvola-"+permitID.Hex()+".pdf", pdf)
+}
+
+func (mh *MupHandler) GetRegistrationToken(rw http.ResponseWriter, r *http.Request) {
+	tokenStr := mh.extractTokenFromHeader(r)
+	jmbg, err := mh.getJMBGFromToken(tokenStr)
+	if err != nil || jmbg == "" {
+		http.Error(rw, "Failed to read JMBG from token", http.StatusBadRequest)
+		return
+	}
+
+	token, err := mh.service.RegistrationToken(r.Context(), mux.Vars(r)["registrationNumber"], jmbg, tokenStr)
+	if err != nil {
+		if errors.Is(err, data.ErrRegistrationNotFound) {
+			http.Error(rw, "Approved registration not found", http.StatusNotFound)
+			return
+		}
+		log.Printf("Failed to sign registration: %v", err)
+		http.Error(rw, "Failed to sign registration", http.StatusInternalServerError)
+		return
+	}
+
+	mh.writeSignedDocument(rw, token)
+}
+
+func (mh *MupHandler) GetDrivingPermitToken(rw http.ResponseWriter, r *http.Request) {
+	tokenStr := mh.extractTokenFromHeader(r)
+	jmbg, err := mh.getJMBGFromToken(tokenStr)
+	if err != nil || jmbg == "" {
+		http.Error(rw, "Failed to read JMBG from token", http.StatusBadRequest)
+		return
+	}
+
+	permitID, err := primitive.ObjectIDFromHex(mux.Vars(r)["id"])
+	if err != nil {
+		http.Error(rw, "Invalid permit ID", http.StatusBadRequest)
+		return
+	}
+
+	token, err := mh.service.DrivingPermitToken(r.Context(), permitID, jmbg, tokenStr)
+	if err != nil {
+		if errors.Is(err, data.ErrTrafficPermitNotFound) {
+			http.Error(rw, "Approved driving permit not found", http.StatusNotFound)
+			return
+		}
+		log.Printf("Failed to sign driving permit: %v", err)
+		http.Error(rw, "Failed to sign driving permit", http.StatusInternalServerError)
+		return
+	}
+
+	mh.writeSignedDocument(rw, token)
+}
+
+// Public endpoint the QR codes link to, reports whether the signed document token is genuine
+func (mh *MupHandler) VerifyDocument(rw http.ResponseWriter, r *http.Request) {
+	token := r.URL.Query().Get("token")
+	if token == "" {
+		http.Error(rw, "Missing token", http.StatusBadRequest)
+		return
+	}
+
+	verification, err := mh.service.VerifyDocument(r.Context(), token)
+	if err != nil {
+		log.Printf("Failed to verify document: %v", err)
+		http.Error(rw, "Failed to verify document", http.StatusInternalServerError)
+		return
+	}
+
+	rw.Header().Set(ContentType, ApplicationJson)
+	rw.WriteHeader(http.StatusOK)
+	if err := verification.ToJSON(rw); err != nil {
+		http.Error(rw, "Failed to encode verification", http.StatusInternalServerError)
+	}
+}
+
+// Public keys for verifying document tokens offline
+func (mh *MupHandler) GetSigningKeys(rw http.ResponseWriter, r *http.Request) {
+	keys, err := mh.service.GetSigningKeys(r.Context())
+	if err != nil {
+		http.Error(rw, "Failed to retrieve signing keys", http.StatusInternalServerError)
+		return
+	}
+
+	rw.Header().Set(ContentType, ApplicationJson)
+	rw.WriteHeader(http.StatusOK)
+	if err := keys.ToJSON(rw); err != nil {
+		http.Error(rw, "Failed to encode signing keys", http.StatusInternalServerError)
+	}
+}
+
+// POST METHODS
+
+func (mh *MupHandler) RotateSigningKey(rw http.ResponseWriter, r *http.Request) {
+	key, err := mh.service.RotateSigningKey(r.Context())
+	if err != nil {
+		log.Printf("Failed to rotate signing key: %v", err)
+		http.Error(rw, "Failed to rotate signing key", http.StatusInternalServerError)
+		return
+	}
+
+	rw.Header().Set(ContentType, ApplicationJson)
+	rw.WriteHeader(http.StatusCreated)
+	if err := key.ToJSON(rw); err != nil {
+		http.Error(rw, "Failed to encode signing key", http.StatusInternalServerError)
+	}
+}
+
+func (mh *MupHandler) verifyBaseURL() string {
+	return mh.publicURL + "/api/v1/verify?token="
+}
+
+func (mh *MupHandler) writeSignedDocument(rw http.ResponseWriter, token string) {
+	signed := data.SignedDocument{Token: token, VerifyURL: mh.verifyBaseURL() + token}
+
+	rw.Header().Set(ContentType, ApplicationJson)
+	rw.WriteHeader(http.StatusOK)
+	if err := signed.ToJSON(rw); err != nil {
+		http.Error(rw, "Failed to encode signed document", http.StatusInternalServerError)
+	}
+}
+
+func writePdf(rw http.ResponseWriter, filename string, pdf []byte) {
+	rw.Header().Set(ContentType, ApplicationPdf)
+	rw.Header().Set("Content-Disposition", "inline; filename=\""+filename+"\"")
+	rw.WriteHeader(http.StatusOK)
+	if _, err := rw.Write(pdf); err != nil {
+		log.Printf("Failed to write %s: %v", filename, err)
+	}
+}
@@ -0,0 +1,159 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"log"
+	"mup/data"
+	"mup/services"
+	"net/http"
+
+	"github.com/gorilla/mux"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// GET METHODS
+
+// Returns every ban of the person in effect right now, for roadside checks
+func (mh *MupHandler) GetActiveDrivingBans(rw http.ResponseWriter, r *http.Request) {
+	var request data.JMBGRequest
+	if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+		http.Error(rw, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	drivingBans, err := mh.service.GetActiveDrivingBans(r.Context(), request.JMBG)
+	if err != nil {
+		http.Error(rw, "Failed to retrieve driving bans", http.StatusInternalServerError)
+		return
+	}
+
+	rw.Header().Set(ContentType, ApplicationJson)
+	rw.WriteHeader(http.StatusOK)
+	if err := drivingBans.ToJSON(rw); err != nil {
+		http.Error(rw, FailedToEncodeDrivingBans, http.StatusInternalServerError)
+	}
+}
+
+// PUT METHODS
+
+func (mh *MupHandler) LiftDrivingBan(rw http.ResponseWriter, r *http.Request) {
+	clerk, err := mh.getJMBGFromToken(mh.extractTokenFromHeader(r))
+	if err != nil {
+		http.Error(rw, "Failed to read JMBG from token", http.StatusBadRequest)
+		return
+	}
+
+	id, err := primitive.ObjectIDFromHex(mux.Vars(r)["id"])
+	if err != nil {
+		http.Error(rw, "Invalid driving ban ID", http.StatusBadRequest)
+		return
+	}
+
+	var request data.LiftBanRequest
+	if err := request.FromJSON(r.Body); err != nil {
+		http.Error(rw, FailedToDecodeRequestBody, http.StatusBadRequest)
+		log.Printf("Failed to decode request body: %v", err)
+		return
+	}
+
+	drivingBan, err := mh.service.LiftDrivingBan(r.Context(), id, clerk, request.Reason)
+	if err != nil {
+		switch {
+		case errors.Is(err, services.ErrReasonRequired):
+			http.Error(rw, "Reason is required", http.StatusBadRequest)
+		case errors.Is(err, data.ErrDrivingBanNotFound):
+			http.Error(rw, "Driving ban not found", http.StatusNotFound)
+		case errors.Is(err, data.ErrBanAlreadyLifted):
+			http.Error(rw, "Driving ban is already lifted", http.StatusConflict)
+		default:
+			log.Printf("Failed to lift driving ban: %v", err)
+			http.Error(rw, "Failed to lift driving ban", http.StatusInternalServerError)
+		}
+		return
+	}
+
+	rw.Header().Set(ContentType, ApplicationJson)
+	rw.WriteHeader(http.StatusOK)
+	if err := drivingBan.ToJSON(rw); err != nil {
+		http.Error(rw, "Failed to encode driving ban", http.StatusInternalServerError)
+	}
+}
+
+func (mh *MupHandler) SetDrivingBanAppeal(rw http.ResponseWriter, r *http.Request) {
+	id, err := primitive.ObjectIDFromHex(mux.Vars(r)["id"])
+	if err != nil {
+		http.Error(rw, "Invalid driving ban ID", http.StatusBadRequest)
+		return
+	}
+
+	var appeal data.BanAppeal
+	if err := appeal.FromJSON(r.Body); err != nil {
+		http.Error(rw, FailedToDecodeRequestBody, http.StatusBadRequest)
+		log.Printf("Failed to decode request body: %v", err)
+		return
+	}
+
+	drivingBan, err := mh.service.SetDrivingBanAppeal(r.Context(), id, appeal)
+	if err != nil {
+		switch {
+		case errors.Is(err, services.ErrInvalidAppeal):
+			http.Error(rw, err.Error(), http.StatusBadRequest)
+		case errors.Is(err, data.ErrDrivingBanNotFound):
+			http.Error(rw, "Driving ban not found", http.StatusNotFound)
+		default:
+			log.Printf("Failed to record appeal: %v", err)
+			http.Error(rw, "Failed to record appeal", http.StatusInternalServerError)
+		}
+		return
+	}
+
+	rw.Header().Set(ContentType, ApplicationJson)
+	rw.WriteHeader(http.StatusOK)
+	if err := drivingBan.ToJSON(rw); err != nil {
+		http.Error(rw, "Failed to encode driving ban", http.StatusInternalServerError)
+	}
+}
+
+func (mh *MupHandler) RevokeTrafficPermit(rw http.ResponseWriter, r *http.Request) {
+	clerk, err := mh.getJMBGFromToken(mh.extractTokenFromHeader(r))
+	if err != nil {
+		http.Error(rw, "Failed to read JMBG from token", http.StatusBadRequest)
+		return
+	}
+
+	permitID, err := primitive.ObjectIDFromHex(mux.Vars(r)["id"])
+	if err != nil {
+		http.Error(rw, "Invalid permit ID", http.StatusBadRequest)
+		return
+	}
+
+	var request data.RevokePermitRequest
+	if err := request.FromJSON(r.Body); err != nil {
+		http.Error(rw, FailedToDecodeRequestBody, http.StatusBadRequest)
+		log.Printf("Failed to decode request body: %v", err)
+		return
+	}
+
+	permit, err := mh.service.RevokeTrafficPermit(r.Context(), permitID, clerk, request.Reason)
+	if err != nil {
+		switch {
+		case errors.Is(err, services.ErrReasonRequired):
+			http.Error(rw, "Reason is required", http.StatusBadRequest)
+		case errors.Is(err, data.ErrTrafficPermitNotFound):
+			http.Error(rw, "Traffic permit not found", http.StatusNotFound)
+		case errors.Is(err, data.ErrInvalidTransition):
+			http.Error(rw, "Only issued permits can be revoked", http.StatusConflict)
+		default:
+			log.Printf("Failed to revoke traffic permit: %v", err)
+			http.Error(rw, "Failed to revoke traffic permit", http.StatusInternalServerError)
+		}
+		return
+	}
+
+	rw.Header().Set(ContentType, ApplicationJson)
+	rw.WriteHeader(http.StatusOK)
+	if err := permit.ToJSON(rw); err != nil {
+		http.Error(rw, "Failed to encode traffic permit", http.StatusInternalServerError)
+	}
+}
@@ -0,0 +1,259 @@
+package handlers
+
+import (
+	"errors"
+	"log"
+	"mup/data"
+	"mup/services"
+	"net/http"
+
+	"github.com/gorilla/mux"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// GET METHODS
+
+func (mh *MupHandler) GetDrivingSchools(rw http.ResponseWriter, r *http.Request) {
+	schools, err := mh.service.GetDrivingSchools(r.Context())
+	if err != nil {
+		http.Error(rw, "Failed to retrieve driving schools", http.StatusInternalServerError)
+		return
+	}
+
+	rw.Header().Set(ContentType, ApplicationJson)
+	rw.WriteHeader(http.StatusOK)
+	if err := schools.ToJSON(rw); err != nil {
+		http.Error(rw, "Failed to encode driving schools", http.StatusInternalServerError)
+	}
+}
+
+func (mh *MupHandler) GetSchoolCandidates(rw http.ResponseWriter, r *http.Request) {
+	school, err := primitive.ObjectIDFromHex(mux.Vars(r)["id"])
+	if err != nil {
+		http.Error(rw, "Invalid driving school ID", http.StatusBadRequest)
+		return
+	}
+
+	candidates, err := mh.service.GetSchoolCandidates(r.Context(), school)
+	if err != nil {
+		if errors.Is(err, data.ErrDrivingSchoolNotFound) {
+			http.Error(rw, "Driving school not found", http.StatusNotFound)
+			return
+		}
+		http.Error(rw, "Failed to retrieve candidates", http.StatusInternalServerError)
+		return
+	}
+
+	rw.Header().Set(ContentType, ApplicationJson)
+	rw.WriteHeader(http.StatusOK)
+	if err := candidates.ToJSON(rw); err != nil {
+		http.Error(rw, "Failed to encode candidates", http.StatusInternalServerError)
+	}
+}
+
+func (mh *MupHandler) GetPersonsExamResults(rw http.ResponseWriter, r *http.Request) {
+	jmbg, err := mh.getJMBGFromToken(mh.extractTokenFromHeader(r))
+	if err != nil || jmbg == "" {
+		http.Error(rw, "Failed to read JMBG from token", http.StatusBadRequest)
+		return
+	}
+
+	results, err := mh.service.GetPersonsExamResults(r.Context(), jmbg)
+	if err != nil {
+		http.Error(rw, "Failed to retrieve exam results", http.StatusInternalServerError)
+		return
+	}
+
+	rw.Header().Set(ContentType, ApplicationJson)
+	rw.WriteHeader(http.StatusOK)
+	if err := results.ToJSON(rw); err != nil {
+		http.Error(rw, "Failed to encode exam results", http.StatusInternalServerError)
+	}
+}
+
+func (mh *MupHandler) GetPersonsMedicalCertificates(rw http.ResponseWriter, r *http.Request) {
+	jmbg, err := mh.getJMBGFromToken(mh.extractTokenFromHeader(r))
+	if err != nil || jmbg == "" {
+		http.Error(rw, "Failed to read JMBG from token", http.StatusBadRequest)
+		return
+	}
+
+	certificates, err := mh.service.GetPersonsMedicalCertificates(r.Context(), jmbg)
+	if err != nil {
+		http.Error(rw, "Failed to retrieve medical certificates", http.StatusInternalServerError)
+		return
+	}
+
+	rw.Header().Set(ContentType, ApplicationJson)
+	rw.WriteHeader(http.StatusOK)
+	if err := certificates.ToJSON(rw); err != nil {
+		http.Error(rw, "Failed to encode medical certificates", http.StatusInternalServerError)
+	}
+}
+
+// Lets citizens check whether they can be issued a permit of the category
+func (mh *MupHandler) GetPersonsQualification(rw http.ResponseWriter, r *http.Request) {
+	jmbg, err := mh.getJMBGFromToken(mh.extractTokenFromHeader(r))
+	if err != nil || jmbg == "" {
+		http.Error(rw, "Failed to read JMBG from token", http.StatusBadRequest)
+		return
+	}
+
+	category := r.URL.Query().Get("category")
+	if category == "" {
+		http.Error(rw, "Missing category", http.StatusBadRequest)
+		return
+	}
+
+	qualification, err := mh.service.GetQualification(r.Context(), jmbg, category)
+	if err != nil {
+		http.Error(rw, "Failed to retrieve qualification", http.StatusInternalServerError)
+		return
+	}
+
+	rw.Header().Set(ContentType, ApplicationJson)
+	rw.WriteHeader(http.StatusOK)
+	if err := qualification.ToJSON(rw); err != nil {
+		http.Error(rw, "Failed to encode qualification", http.StatusInternalServerError)
+	}
+}
+
+// POST METHODS
+
+func (mh *MupHandler) CreateDrivingSchool(rw http.ResponseWriter, r *http.Request) {
+	var school data.DrivingSchool
+	if err := school.FromJSON(r.Body); err != nil {
+		http.Error(rw, FailedToDecodeRequestBody, http.StatusBadRequest)
+		log.Printf("Failed to decode request body: %v", err)
+		return
+	}
+
+	if err := mh.service.CreateDrivingSchool(r.Context(), &school); err != nil {
+		if errors.Is(err, services.ErrInvalidDrivingSchool) {
+			http.Error(rw, err.Error(), http.StatusBadRequest)
+			return
+		}
+		if errors.Is(err, data.ErrDrivingSchoolExists) {
+			http.Error(rw, "Driving school with the accreditation number already exists", http.StatusConflict)
+			return
+		}
+		log.Printf("Failed to create driving school: %v", err)
+		http.Error(rw, "Failed to create driving school", http.StatusInternalServerError)
+		return
+	}
+
+	rw.Header().Set(ContentType, ApplicationJson)
+	rw.WriteHeader(http.StatusCreated)
+	if err := school.ToJSON(rw); err != nil {
+		http.Error(rw, "Failed to encode driving school", http.StatusInternalServerError)
+	}
+
+	log.Printf("Successfully created driving school with id '%s'", school.ID.Hex())
+}
+
+func (mh *MupHandler) EnrollCandidate(rw http.ResponseWriter, r *http.Request) {
+	school, err := primitive.ObjectIDFromHex(mux.Vars(r)["id"])
+	if err != nil {
+		http.Error(rw, "Invalid driving school ID", http.StatusBadRequest)
+		return
+	}
+
+	var candidate data.Candidate
+	if err := candidate.FromJSON(r.Body); err != nil {
+		http.Error(rw, FailedToDecodeRequestBody, http.StatusBadRequest)
+		log.Printf("Failed to decode request body: %v", err)
+		return
+	}
+	if candidate.Person == "" || candidate.Category == "" {
+		http.Error(rw, "Candidate needs a person and a category", http.StatusBadRequest)
+		return
+	}
+	candidate.School = school
+
+	if err := mh.service.EnrollCandidate(r.Context(), &candidate); err != nil {
+		writeDrivingSchoolError(rw, err, "Failed to enroll candidate")
+		return
+	}
+
+	rw.Header().Set(ContentType, ApplicationJson)
+	rw.WriteHeader(http.StatusCreated)
+	if err := candidate.ToJSON(rw); err != nil {
+		http.Error(rw, "Failed to encode candidate", http.StatusInternalServerError)
+	}
+}
+
+// Examiner records outcome of the candidate's exam
+func (mh *MupHandler) RecordExamResult(rw http.ResponseWriter, r *http.Request) {
+	examiner, err := mh.getJMBGFromToken(mh.extractTokenFromHeader(r))
+	if err != nil || examiner == "" {
+		http.Error(rw, "Failed to read JMBG from token", http.StatusBadRequest)
+		return
+	}
+
+	candidateID, err := primitive.ObjectIDFromHex(mux.Vars(r)["id"])
+	if err != nil {
+		http.Error(rw, "Invalid candidate ID", http.StatusBadRequest)
+		return
+	}
+
+	var result data.ExamResult
+	if err := result.FromJSON(r.Body); err != nil {
+		http.Error(rw, FailedToDecodeRequestBody, http.StatusBadRequest)
+		log.Printf("Failed to decode request body: %v", err)
+		return
+	}
+
+	if err := mh.service.RecordExamResult(r.Context(), candidateID, &result, examiner); err != nil {
+		writeDrivingSchoolError(rw, err, "Failed to record exam result")
+		return
+	}
+
+	rw.Header().Set(ContentType, ApplicationJson)
+	rw.WriteHeader(http.StatusCreated)
+	if err := result.ToJSON(rw); err != nil {
+		http.Error(rw, "Failed to encode exam result", http.StatusInternalServerError)
+	}
+}
+
+func (mh *MupHandler) SaveMedicalCertificate(rw http.ResponseWriter, r *http.Request) {
+	var certificate data.MedicalCertificate
+	if err := certificate.FromJSON(r.Body); err != nil {
+		http.Error(rw, FailedToDecodeRequestBody, http.StatusBadRequest)
+		log.Printf("Failed to decode request body: %v", err)
+		return
+	}
+
+	if err := mh.service.SaveMedicalCertificate(r.Context(), &certificate); err != nil {
+		if errors.Is(err, services.ErrInvalidMedicalCertificate) {
+			http.Error(rw, err.Error(), http.StatusBadRequest)
+			return
+		}
+		log.Printf("Failed to save medical certificate: %v", err)
+		http.Error(rw, "Failed to save medical certificate", http.StatusInternalServerError)
+		return
+	}
+
+	rw.Header().Set(ContentType, ApplicationJson)
+	rw.WriteHeader(http.StatusCreated)
+	if err := certificate.ToJSON(rw); err != nil {
+		http.Error(rw, "Failed to encode medical certificate", http.StatusInternalServerError)
+	}
+}
+
+func writeDrivingSchoolError(rw http.ResponseWriter, err error, message string) {
+	switch {
+	case errors.Is(err, data.ErrDrivingSchoolNotFound):
+		http.Error(rw, "Driving school not found", http.StatusNotFound)
+	case errors.Is(err, data.ErrCandidateNotFound):
+		http.Error(rw, "Candidate not found", http.StatusNotFound)
+	case errors.Is(err, data.ErrCandidateExists):
+		http.Error(rw, "Person is already a candidate for the category at the school", http.StatusConflict)
+	case errors.Is(err, services.ErrNotAccredited), errors.Is(err, services.ErrCategoryNotTaught), errors.Is(err, services.ErrTheoryNotPassed):
+		http.Error(rw, err.Error(), http.StatusConflict)
+	case errors.Is(err, services.ErrInvalidExam):
+		http.Error(rw, "Exam type must be THEORY or PRACTICAL and taken in the past", http.StatusBadRequest)
+	default:
+		log.Printf("%s: %v", message, err)
+		http.Error(rw, message, http.StatusInternalServerError)
+	}
+}
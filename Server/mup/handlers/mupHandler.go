@@ -2,6 +2,7 @@ package handlers
 
 import (
 	"encoding/json"
+	"errors"
 	"fmt"
 	"log"
 	"mup/data"
@@ -25,12 +26,16 @@ type KeyProduct struct{}
 var secretKey = []byte("eUpravaT2")
 
 type MupHandler struct {
-	service *services.MupService
-	logger  *log.Logger
+	service   *services.MupService
+	logger    *log.Logger
+	publicURL string
+	noticeKey []byte
 }
 
-func NewMupHandler(service *services.MupService, logger *log.Logger) *MupHandler {
-	return &MupHandler{service: service, logger: logger}
+// publicURL is the address the service is reachable at from outside, used in verification links.
+// Payment notices are accepted only when signed with noticeKey
+func NewMupHandler(service *services.MupService, logger *log.Logger, publicURL string, noticeKey []byte) *MupHandler {
+	return &MupHandler{service: service, logger: logger, publicURL: publicURL, noticeKey: noticeKey}
 }
 
 // Ping
@@ -158,7 +163,17 @@ func (mh *MupHandler) GetPendingRegistrationRequests(rw http.ResponseWriter, r *
 	ctx := r.Context()
 
 	tokenStr := mh.extractTokenFromHeader(r)
-	pendingRequests, err := mh.service.GetPendingRegistrationRequests(ctx, tokenStr)
+	office, err := mh.getQueueOffice(r, tokenStr)
+	if err != nil {
+		if errors.Is(err, data.ErrWrongOffice) {
+			http.Error(rw, "Queue of another office requested", http.StatusForbidden)
+			return
+		}
+		http.Error(rw, "Invalid office ID", http.StatusBadRequest)
+		return
+	}
+
+	pendingRequests, err := mh.service.GetPendingRegistrationRequests(ctx, office, tokenStr)
 	if err != nil {
 		http.Error(rw, "Failed to retrieve pending registration requests", http.StatusInternalServerError)
 		return
@@ -197,7 +212,17 @@ func (mh *MupHandler) GetPendingTrafficPermitRequests(rw http.ResponseWriter, r
 	ctx := r.Context()
 
 	tokenStr := mh.extractTokenFromHeader(r)
-	pendingRequests, err := mh.service.GetPendingTrafficPermitRequests(ctx, tokenStr)
+	office, err := mh.getQueueOffice(r, tokenStr)
+	if err != nil {
+		if errors.Is(err, data.ErrWrongOffice) {
+			http.Error(rw, "Queue of another office requested", http.StatusForbidden)
+			return
+		}
+		http.Error(rw, "Invalid office ID", http.StatusBadRequest)
+		return
+	}
+
+	pendingRequests, err := mh.service.GetPendingTrafficPermitRequests(ctx, office, tokenStr)
 	if err != nil {
 		http.Error(rw, "Failed to retrieve pending traffic permit requests", http.StatusInternalServerError)
 		return
@@ -304,7 +329,71 @@ func (mh *MupHandler) GetVehiclesDTOByJMBG(rw http.ResponseWriter, r *http.Reque
 	}
 }
 
+func (mh *MupHandler) GetMups(rw http.ResponseWriter, r *http.Request) {
+	municipality := r.URL.Query().Get("municipality")
+
+	mups, err := mh.service.GetMups(r.Context(), municipality)
+	if err != nil {
+		http.Error(rw, "Failed to retrieve offices", http.StatusInternalServerError)
+		return
+	}
+
+	rw.Header().Set(ContentType, ApplicationJson)
+	rw.WriteHeader(http.StatusOK)
+	if err := mups.ToJSON(rw); err != nil {
+		http.Error(rw, "Failed to encode offices", http.StatusInternalServerError)
+	}
+}
+
+func (mh *MupHandler) GetOfficeQueueStats(rw http.ResponseWriter, r *http.Request) {
+	stats, err := mh.service.GetOfficeQueueStats(r.Context())
+	if err != nil {
+		log.Printf("Failed to retrieve office queue stats: %v", err)
+		http.Error(rw, "Failed to retrieve office queue stats", http.StatusInternalServerError)
+		return
+	}
+
+	rw.Header().Set(ContentType, ApplicationJson)
+	rw.WriteHeader(http.StatusOK)
+	if err := stats.ToJSON(rw); err != nil {
+		http.Error(rw, "Failed to encode office queue stats", http.StatusInternalServerError)
+	}
+}
+
 // POST METHODS
+func (mh *MupHandler) CreateOffice(rw http.ResponseWriter, r *http.Request) {
+	var mup data.Mup
+
+	if err := mup.FromJSON(r.Body); err != nil {
+		http.Error(rw, FailedToDecodeRequestBody, http.StatusBadRequest)
+		log.Printf("Failed to decode request body: %v", err)
+		return
+	}
+
+	if mup.Name == "" || mup.Address.Municipality == "" {
+		http.Error(rw, "Office name and municipality are required", http.StatusBadRequest)
+		return
+	}
+
+	if err := mh.service.CreateOffice(r.Context(), &mup); err != nil {
+		log.Printf("Failed to create office: %v", err)
+		if errors.Is(err, data.ErrMupAlreadyExists) {
+			http.Error(rw, "Office with that name already exists", http.StatusConflict)
+			return
+		}
+		http.Error(rw, "Failed to create office", http.StatusInternalServerError)
+		return
+	}
+
+	rw.Header().Set(ContentType, ApplicationJson)
+	rw.WriteHeader(http.StatusCreated)
+	if err := mup.ToJSON(rw); err != nil {
+		http.Error(rw, "Failed to encode office", http.StatusInternalServerError)
+	}
+
+	log.Printf("Successfully created office with id '%s'", mup.ID.Hex())
+}
+
 func (mh *MupHandler) SubmitRegistrationRequest(rw http.ResponseWriter, r *http.Request) {
 	var registration data.Registration
 
@@ -315,7 +404,12 @@ func (mh *MupHandler) SubmitRegistrationRequest(rw http.ResponseWriter, r *http.
 		return
 	}
 
-	if err := json.NewDecoder(r.Body).Decode(&registration); err != nil {
+	uploads, cleanup, err := decodeSubmission(rw, r, &registration)
+	defer cleanup()
+	if err != nil {
+		if writeAttachmentError(rw, err) {
+			return
+		}
 		http.Error(rw, FailedToDecodeRequestBody, http.StatusBadRequest)
 		log.Printf("Failed to decode request body: %v", err)
 		return
@@ -323,8 +417,23 @@ func (mh *MupHandler) SubmitRegistrationRequest(rw http.ResponseWriter, r *http.
 
 	registration.Owner = jmbg
 
-	if err := mh.service.SubmitRegistrationRequest(r.Context(), &registration); err != nil {
+	if err := mh.service.SubmitRegistrationRequest(r.Context(), &registration, uploads); err != nil {
 		log.Printf("Failed to submit registration request: %v", err)
+		if writeAttachmentError(rw, err) {
+			return
+		}
+		if errors.Is(err, data.ErrVehicleNotFound) {
+			http.Error(rw, "Vehicle not found", http.StatusNotFound)
+			return
+		}
+		if errors.Is(err, data.ErrMupNotFound) {
+			http.Error(rw, "Office not found", http.StatusBadRequest)
+			return
+		}
+		if errors.Is(err, data.ErrFeeNotFound) {
+			http.Error(rw, "No fee defined for the request", http.StatusUnprocessableEntity)
+			return
+		}
 		http.Error(rw, "Failed to submit registration request", http.StatusInternalServerError)
 		return
 	}
@@ -352,7 +461,12 @@ func (mh *MupHandler) SubmitTrafficPermitRequest(rw http.ResponseWriter, r *http
 		return
 	}
 
-	if err := json.NewDecoder(r.Body).Decode(&trafficPermit); err != nil {
+	uploads, cleanup, err := decodeSubmission(rw, r, &trafficPermit)
+	defer cleanup()
+	if err != nil {
+		if writeAttachmentError(rw, err) {
+			return
+		}
 		http.Error(rw, FailedToDecodeRequestBody, http.StatusBadRequest)
 		log.Printf("Failed to decode request body: %v", err)
 		return
@@ -360,8 +474,19 @@ func (mh *MupHandler) SubmitTrafficPermitRequest(rw http.ResponseWriter, r *http
 
 	trafficPermit.Person = jmbg
 
-	if err := mh.service.SubmitTrafficPermitRequest(ctx, &trafficPermit, jmbg, tokenStr); err != nil {
+	if err := mh.service.SubmitTrafficPermitRequest(ctx, &trafficPermit, jmbg, tokenStr, uploads); err != nil {
 		log.Printf("Failed to submit traffic permit request: %v", err)
+		if writeAttachmentError(rw, err) {
+			return
+		}
+		if errors.Is(err, data.ErrMupNotFound) {
+			http.Error(rw, "Office not found", http.StatusBadRequest)
+			return
+		}
+		if errors.Is(err, data.ErrFeeNotFound) {
+			http.Error(rw, "No fee defined for the request", http.StatusUnprocessableEntity)
+			return
+		}
 		http.Error(rw, "Failed to submit traffic permit request", http.StatusInternalServerError)
 		return
 	}
@@ -422,6 +547,10 @@ func (mh *MupHandler) IssueDrivingBan(rw http.ResponseWriter, r *http.Request) {
 
 	if err := mh.service.IssueDrivingBan(r.Context(), &drivingBan); err != nil {
 		log.Printf("Failed to issue driving ban: %v", err)
+		if errors.Is(err, services.ErrInvalidDrivingBan) {
+			http.Error(rw, err.Error(), http.StatusBadRequest)
+			return
+		}
 		http.Error(rw, "Failed to issue driving ban", http.StatusInternalServerError)
 		return
 	}
@@ -439,20 +568,47 @@ func (mh *MupHandler) IssueDrivingBan(rw http.ResponseWriter, r *http.Request) {
 func (mh *MupHandler) ApproveRegistration(rw http.ResponseWriter, r *http.Request) {
 	var registration data.Registration
 
+	tokenStr := mh.extractTokenFromHeader(r)
+	office, err := mh.getOfficeFromToken(tokenStr)
+	if err != nil {
+		http.Error(rw, "Invalid office in token", http.StatusBadRequest)
+		return
+	}
+
+	clerk, err := mh.getJMBGFromToken(tokenStr)
+	if err != nil {
+		http.Error(rw, "Failed to read JMBG from token", http.StatusBadRequest)
+		return
+	}
+
 	if err := json.NewDecoder(r.Body).Decode(&registration); err != nil {
 		http.Error(rw, FailedToDecodeRequestBody, http.StatusBadRequest)
 		log.Printf("Failed to decode request body: %v", err)
 		return
 	}
 
-	if err := mh.service.ApproveRegistration(r.Context(), registration); err != nil {
+	if err := mh.service.ApproveRegistration(r.Context(), &registration, office, clerk); err != nil {
 		log.Printf("Failed to approve registration: %v", err)
+		if errors.Is(err, data.ErrRegistrationNotFound) {
+			http.Error(rw, "Registration not found", http.StatusNotFound)
+			return
+		}
+		if errors.Is(err, data.ErrWrongOffice) {
+			http.Error(rw, "Registration was submitted to another office", http.StatusForbidden)
+			return
+		}
+		if errors.Is(err, data.ErrNotPaid) {
+			http.Error(rw, "Registration is not paid", http.StatusConflict)
+			return
+		}
+		if errors.Is(err, data.ErrInvalidTransition) {
+			http.Error(rw, "Only submitted registrations can be approved", http.StatusConflict)
+			return
+		}
 		http.Error(rw, "Failed to approve registration", http.StatusInternalServerError)
 		return
 	}
 
-	registration.Approved = true
-
 	rw.Header().Set(ContentType, ApplicationJson)
 	rw.WriteHeader(http.StatusOK)
 	if err := json.NewEncoder(rw).Encode(registration); err != nil {
@@ -465,6 +621,19 @@ func (mh *MupHandler) ApproveRegistration(rw http.ResponseWriter, r *http.Reques
 func (mh *MupHandler) ApproveTrafficPermitRequest(rw http.ResponseWriter, r *http.Request) {
 	var trafficPermit data.TrafficPermit
 
+	tokenStr := mh.extractTokenFromHeader(r)
+	office, err := mh.getOfficeFromToken(tokenStr)
+	if err != nil {
+		http.Error(rw, "Invalid office in token", http.StatusBadRequest)
+		return
+	}
+
+	clerk, err := mh.getJMBGFromToken(tokenStr)
+	if err != nil {
+		http.Error(rw, "Failed to read JMBG from token", http.StatusBadRequest)
+		return
+	}
+
 	if err := json.NewDecoder(r.Body).Decode(&trafficPermit); err != nil {
 		http.Error(rw, FailedToDecodeRequestBody, http.StatusBadRequest)
 		log.Printf("Failed to decode request body: %v", err)
@@ -473,8 +642,32 @@ func (mh *MupHandler) ApproveTrafficPermitRequest(rw http.ResponseWriter, r *htt
 
 	trafficPermit.Approved = true
 
-	if err := mh.service.ApproveTrafficPermitRequest(r.Context(), trafficPermit.ID); err != nil {
+	if err := mh.service.ApproveTrafficPermitRequest(r.Context(), trafficPermit.ID, office, clerk); err != nil {
 		log.Printf("Failed to approve traffic permit: %v", err)
+		if errors.Is(err, data.ErrTrafficPermitNotFound) {
+			http.Error(rw, "Traffic permit not found", http.StatusNotFound)
+			return
+		}
+		if errors.Is(err, data.ErrWrongOffice) {
+			http.Error(rw, "Traffic permit was submitted to another office", http.StatusForbidden)
+			return
+		}
+		if errors.Is(err, data.ErrNotPaid) {
+			http.Error(rw, "Traffic permit is not paid", http.StatusConflict)
+			return
+		}
+		if errors.Is(err, data.ErrInvalidTransition) {
+			http.Error(rw, "Only submitted traffic permits can be approved", http.StatusConflict)
+			return
+		}
+		if errors.Is(err, services.ErrExamsNotPassed) {
+			http.Error(rw, "Candidate has not passed both exams for the category", http.StatusConflict)
+			return
+		}
+		if errors.Is(err, services.ErrMedicalCertificateInvalid) {
+			http.Error(rw, "Candidate has no valid medical certificate", http.StatusConflict)
+			return
+		}
 		http.Error(rw, "Failed to approve traffic permit", http.StatusInternalServerError)
 		return
 	}
@@ -488,52 +681,6 @@ func (mh *MupHandler) ApproveTrafficPermitRequest(rw http.ResponseWriter, r *htt
 	log.Printf("Successfully updated traffic permit '%s'", trafficPermit.ID.Hex())
 }
 
-// DELETE METHODS
-
-func (mh *MupHandler) DeletePendingRegistration(rw http.ResponseWriter, r *http.Request) {
-	vars := mux.Vars(r)
-	request := vars["request"]
-
-	if request == "" {
-		http.Error(rw, "registration number is required", http.StatusBadRequest)
-		return
-	}
-
-	ctx := r.Context()
-	err := mh.service.DeletePendingRegistration(ctx, request)
-	if err != nil {
-		http.Error(rw, err.Error(), http.StatusInternalServerError)
-		return
-	}
-
-	rw.WriteHeader(http.StatusOK)
-}
-
-func (mh *MupHandler) DeletePendingTrafficPermit(rw http.ResponseWriter, r *http.Request) {
-	vars := mux.Vars(r)
-	request := vars["request"]
-
-	if request == "" {
-		http.Error(rw, "permit ID is required", http.StatusBadRequest)
-		return
-	}
-
-	permitID, err := primitive.ObjectIDFromHex(request)
-	if err != nil {
-		http.Error(rw, "Invalid permit ID", http.StatusBadRequest)
-		return
-	}
-
-	ctx := r.Context()
-	err = mh.service.DeletePendingTrafficPermit(ctx, permitID)
-	if err != nil {
-		http.Error(rw, err.Error(), http.StatusInternalServerError)
-		return
-	}
-
-	rw.WriteHeader(http.StatusOK)
-}
-
 // JWT middleware
 func (mh *MupHandler) AuthorizeRoles(allowedRoles ...string) mux.MiddlewareFunc {
 	return func(next http.Handler) http.Handler {
@@ -581,6 +728,58 @@ func (mh *MupHandler) extractTokenFromHeader(rr *http.Request) string {
 	return ""
 }
 
+// Returns office ID from 'office' query parameter, primitive.NilObjectID if it is not set
+func (mh *MupHandler) getOfficeFromQuery(r *http.Request) (primitive.ObjectID, error) {
+	office := r.URL.Query().Get("office")
+	if office == "" {
+		return primitive.NilObjectID, nil
+	}
+	return primitive.ObjectIDFromHex(office)
+}
+
+// Returns office the clerk is bound to through the 'office' claim,
+// primitive.NilObjectID if the token carries none
+func (mh *MupHandler) getOfficeFromToken(tokenString string) (primitive.ObjectID, error) {
+	claims := jwt.MapClaims{}
+	token, err := jwt.ParseWithClaims(tokenString, claims, func(token *jwt.Token) (interface{}, error) {
+		return secretKey, nil
+	})
+
+	if err != nil || !token.Valid {
+		return primitive.NilObjectID, err
+	}
+
+	office, ok := claims["office"].(string)
+	if !ok || office == "" {
+		return primitive.NilObjectID, nil
+	}
+
+	return primitive.ObjectIDFromHex(office)
+}
+
+// Returns office whose queue is requested. Clerks bound to an office only get
+// its queue, others pick one through the 'office' query parameter
+func (mh *MupHandler) getQueueOffice(r *http.Request, tokenString string) (primitive.ObjectID, error) {
+	office, err := mh.getOfficeFromQuery(r)
+	if err != nil {
+		return primitive.NilObjectID, err
+	}
+
+	clerkOffice, err := mh.getOfficeFromToken(tokenString)
+	if err != nil {
+		return primitive.NilObjectID, err
+	}
+
+	if clerkOffice.IsZero() {
+		return office, nil
+	}
+	if !office.IsZero() && office != clerkOffice {
+		return primitive.NilObjectID, data.ErrWrongOffice
+	}
+
+	return clerkOffice, nil
+}
+
 func (mh *MupHandler) getJMBGFromToken(tokenString string) (string, error) {
 	claims := jwt.MapClaims{}
 	token, err := jwt.ParseWithClaims(tokenString, claims, func(token *jwt.Token) (interface{}, error) {
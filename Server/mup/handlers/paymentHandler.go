@@ -0,0 +1,195 @@
+package handlers
+
+import (
+	"common/payments"
+	"encoding/json"
+	"errors"
+	"io"
+	"log"
+	"mup/data"
+	"mup/services"
+	"net/http"
+
+	"github.com/gorilla/mux"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// Payment notices are small, anything larger is not from the gateway
+const maxPaymentNoticeSize = 64 << 10
+
+// GET METHODS
+
+func (mh *MupHandler) GetFees(rw http.ResponseWriter, r *http.Request) {
+	fees, err := mh.service.GetFees(r.Context())
+	if err != nil {
+		http.Error(rw, "Failed to retrieve fees", http.StatusInternalServerError)
+		return
+	}
+
+	rw.Header().Set(ContentType, ApplicationJson)
+	rw.WriteHeader(http.StatusOK)
+	if err := fees.ToJSON(rw); err != nil {
+		http.Error(rw, "Failed to encode fees", http.StatusInternalServerError)
+	}
+}
+
+func (mh *MupHandler) GetPaymentOrder(rw http.ResponseWriter, r *http.Request) {
+	jmbg, err := mh.getJMBGFromToken(mh.extractTokenFromHeader(r))
+	if err != nil || jmbg == "" {
+		http.Error(rw, "Failed to read JMBG from token", http.StatusBadRequest)
+		return
+	}
+
+	orderID, err := primitive.ObjectIDFromHex(mux.Vars(r)["id"])
+	if err != nil {
+		http.Error(rw, "Invalid payment order ID", http.StatusBadRequest)
+		return
+	}
+
+	order, err := mh.service.GetPaymentOrder(r.Context(), orderID, jmbg)
+	if err != nil {
+		if errors.Is(err, data.ErrPaymentOrderNotFound) {
+			http.Error(rw, "Payment order not found", http.StatusNotFound)
+			return
+		}
+		http.Error(rw, "Failed to retrieve payment order", http.StatusInternalServerError)
+		return
+	}
+
+	rw.Header().Set(ContentType, ApplicationJson)
+	rw.WriteHeader(http.StatusOK)
+	if err := order.ToJSON(rw); err != nil {
+		http.Error(rw, "Failed to encode payment order", http.StatusInternalServerError)
+	}
+}
+
+func (mh *MupHandler) GetPersonsPaymentOrders(rw http.ResponseWriter, r *http.Request) {
+	jmbg, err := mh.getJMBGFromToken(mh.extractTokenFromHeader(r))
+	if err != nil || jmbg == "" {
+		http.Error(rw, "Failed to read JMBG from token", http.StatusBadRequest)
+		return
+	}
+
+	orders, err := mh.service.GetPersonsPaymentOrders(r.Context(), jmbg)
+	if err != nil {
+		http.Error(rw, "Failed to retrieve payment orders", http.StatusInternalServerError)
+		return
+	}
+
+	rw.Header().Set(ContentType, ApplicationJson)
+	rw.WriteHeader(http.StatusOK)
+	if err := orders.ToJSON(rw); err != nil {
+		http.Error(rw, "Failed to encode payment orders", http.StatusInternalServerError)
+	}
+}
+
+// POST METHODS
+
+func (mh *MupHandler) ConfirmPayment(rw http.ResponseWriter, r *http.Request) {
+	jmbg, err := mh.getJMBGFromToken(mh.extractTokenFromHeader(r))
+	if err != nil || jmbg == "" {
+		http.Error(rw, "Failed to read JMBG from token", http.StatusBadRequest)
+		return
+	}
+
+	orderID, err := primitive.ObjectIDFromHex(mux.Vars(r)["id"])
+	if err != nil {
+		http.Error(rw, "Invalid payment order ID", http.StatusBadRequest)
+		return
+	}
+
+	order, err := mh.service.ConfirmPayment(r.Context(), orderID, jmbg)
+	if err != nil {
+		log.Printf("Failed to confirm payment: %v", err)
+		switch {
+		case errors.Is(err, data.ErrPaymentOrderNotFound):
+			http.Error(rw, "Payment order not found", http.StatusNotFound)
+		case errors.Is(err, services.ErrPaymentNotReceived):
+			http.Error(rw, "Payment not received yet", http.StatusConflict)
+		case errors.Is(err, services.ErrUnderpaid):
+			http.Error(rw, err.Error(), http.StatusConflict)
+		default:
+			http.Error(rw, "Failed to confirm payment", http.StatusBadGateway)
+		}
+		return
+	}
+
+	rw.Header().Set(ContentType, ApplicationJson)
+	rw.WriteHeader(http.StatusOK)
+	if err := order.ToJSON(rw); err != nil {
+		http.Error(rw, "Failed to encode payment order", http.StatusInternalServerError)
+	}
+
+	log.Printf("Payment order '%s' confirmed as paid", order.ID.Hex())
+}
+
+// Records the payment of an order the payment gateway notifies the service of. The body
+// must be signed by the gateway, a notice of a payment already recorded is accepted again
+func (mh *MupHandler) ReceivePaymentNotice(rw http.ResponseWriter, r *http.Request) {
+	body, err := io.ReadAll(http.MaxBytesReader(rw, r.Body, maxPaymentNoticeSize))
+	if err != nil {
+		http.Error(rw, "Failed to read request body", http.StatusBadRequest)
+		return
+	}
+	if !payments.VerifyNotice(mh.noticeKey, body, r.Header.Get(payments.SignatureHeader)) {
+		http.Error(rw, "Invalid payment notice signature", http.StatusUnauthorized)
+		return
+	}
+
+	var notice payments.Notice
+	if err := json.Unmarshal(body, &notice); err != nil {
+		http.Error(rw, FailedToDecodeRequestBody, http.StatusBadRequest)
+		log.Printf("Failed to decode payment notice: %v", err)
+		return
+	}
+
+	order, err := mh.service.RecordPayment(r.Context(), notice)
+	if err != nil {
+		switch {
+		case errors.Is(err, data.ErrPaymentOrderNotFound):
+			http.Error(rw, "Payment order not found", http.StatusNotFound)
+		case errors.Is(err, services.ErrUnderpaid), errors.Is(err, services.ErrAlreadyPaid):
+			http.Error(rw, err.Error(), http.StatusConflict)
+		default:
+			http.Error(rw, "Failed to record payment", http.StatusInternalServerError)
+			log.Printf("Failed to record payment of order %s %s: %v", notice.Model, notice.ReferenceNumber, err)
+		}
+		return
+	}
+
+	rw.Header().Set(ContentType, ApplicationJson)
+	rw.WriteHeader(http.StatusOK)
+	if err := order.ToJSON(rw); err != nil {
+		http.Error(rw, "Failed to encode payment order", http.StatusInternalServerError)
+	}
+
+	log.Printf("Payment order '%s' paid, transaction '%s'", order.ID.Hex(), notice.TransactionID)
+}
+
+// PUT METHODS
+
+func (mh *MupHandler) SaveFee(rw http.ResponseWriter, r *http.Request) {
+	var fee data.Fee
+
+	if err := fee.FromJSON(r.Body); err != nil {
+		http.Error(rw, FailedToDecodeRequestBody, http.StatusBadRequest)
+		log.Printf("Failed to decode request body: %v", err)
+		return
+	}
+
+	if err := mh.service.SaveFee(r.Context(), &fee); err != nil {
+		if errors.Is(err, services.ErrInvalidFee) {
+			http.Error(rw, "Invalid fee", http.StatusBadRequest)
+			return
+		}
+		log.Printf("Failed to save fee: %v", err)
+		http.Error(rw, "Failed to save fee", http.StatusInternalServerError)
+		return
+	}
+
+	rw.Header().Set(ContentType, ApplicationJson)
+	rw.WriteHeader(http.StatusOK)
+	if err := fee.ToJSON(rw); err != nil {
+		http.Error(rw, "Failed to encode fee", http.StatusInternalServerError)
+	}
+}
@@ -0,0 +1,299 @@
+package handlers
+
+import (
+	"errors"
+	"log"
+	"mup/data"
+	"mup/services"
+	"net/http"
+
+	"github.com/gorilla/mux"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// GET METHODS
+
+// Returns all conversion requests of the citizen with their status and timeline
+func (mh *MupHandler) GetPersonsPermitConversions(rw http.ResponseWriter, r *http.Request) {
+	jmbg, err := mh.getJMBGFromToken(mh.extractTokenFromHeader(r))
+	if err != nil || jmbg == "" {
+		http.Error(rw, "Failed to read JMBG from token", http.StatusBadRequest)
+		return
+	}
+
+	conversions, err := mh.service.GetPersonsPermitConversions(r.Context(), jmbg)
+	if err != nil {
+		http.Error(rw, "Failed to retrieve permit conversion requests", http.StatusInternalServerError)
+		return
+	}
+
+	rw.Header().Set(ContentType, ApplicationJson)
+	rw.WriteHeader(http.StatusOK)
+	if err := conversions.ToJSON(rw); err != nil {
+		http.Error(rw, "Failed to encode permit conversion requests", http.StatusInternalServerError)
+	}
+}
+
+func (mh *MupHandler) GetPendingPermitConversions(rw http.ResponseWriter, r *http.Request) {
+	tokenStr := mh.extractTokenFromHeader(r)
+	office, err := mh.getQueueOffice(r, tokenStr)
+	if err != nil {
+		if errors.Is(err, data.ErrWrongOffice) {
+			http.Error(rw, "Queue of another office requested", http.StatusForbidden)
+			return
+		}
+		http.Error(rw, "Invalid office ID", http.StatusBadRequest)
+		return
+	}
+
+	pending, err := mh.service.GetPendingPermitConversions(r.Context(), office, tokenStr)
+	if err != nil {
+		http.Error(rw, "Failed to retrieve pending permit conversion requests", http.StatusInternalServerError)
+		return
+	}
+
+	rw.Header().Set(ContentType, ApplicationJson)
+	rw.WriteHeader(http.StatusOK)
+	if err := pending.ToJSON(rw); err != nil {
+		http.Error(rw, "Failed to encode pending requests", http.StatusInternalServerError)
+	}
+}
+
+func (mh *MupHandler) GetPersonsInternationalPermits(rw http.ResponseWriter, r *http.Request) {
+	jmbg, err := mh.getJMBGFromToken(mh.extractTokenFromHeader(r))
+	if err != nil || jmbg == "" {
+		http.Error(rw, "Failed to read JMBG from token", http.StatusBadRequest)
+		return
+	}
+
+	permits, err := mh.service.GetPersonsInternationalPermits(r.Context(), jmbg)
+	if err != nil {
+		http.Error(rw, "Failed to retrieve international permits", http.StatusInternalServerError)
+		return
+	}
+
+	rw.Header().Set(ContentType, ApplicationJson)
+	rw.WriteHeader(http.StatusOK)
+	if err := permits.ToJSON(rw); err != nil {
+		http.Error(rw, "Failed to encode international permits", http.StatusInternalServerError)
+	}
+}
+
+func (mh *MupHandler) GetInternationalPermitDocument(rw http.ResponseWriter, r *http.Request) {
+	jmbg, err := mh.getJMBGFromToken(mh.extractTokenFromHeader(r))
+	if err != nil || jmbg == "" {
+		http.Error(rw, "Failed to read JMBG from token", http.StatusBadRequest)
+		return
+	}
+
+	id, err := primitive.ObjectIDFromHex(mux.Vars(r)["id"])
+	if err != nil {
+		http.Error(rw, "Invalid international permit ID", http.StatusBadRequest)
+		return
+	}
+
+	pdf, err := mh.service.InternationalPermitDocument(r.Context(), id, jmbg)
+	if err != nil {
+		if errors.Is(err, data.ErrInternationalPermitNotFound) {
+			http.Error(rw, "International permit not found", http.StatusNotFound)
+			return
+		}
+		log.Printf("Failed to create international permit document: %v", err)
+		http.Error(rw, "Failed to create international permit document", http.StatusInternalServerError)
+		return
+	}
+
+	writePdf(rw, "medjunarodna-vozacka-dozvola-"+id.Hex()+".pdf", pdf)
+}
+
+// POST METHODS
+
+func (mh *MupHandler) SubmitPermitConversion(rw http.ResponseWriter, r *http.Request) {
+	tokenStr := mh.extractTokenFromHeader(r)
+	jmbg, err := mh.getJMBGFromToken(tokenStr)
+	if err != nil || jmbg == "" {
+		http.Error(rw, FailedToReadUsernameFromToken, http.StatusBadRequest)
+		return
+	}
+
+	var conversion data.PermitConversion
+	uploads, cleanup, err := decodeSubmission(rw, r, &conversion)
+	defer cleanup()
+	if err != nil {
+		if writeAttachmentError(rw, err) {
+			return
+		}
+		http.Error(rw, FailedToDecodeRequestBody, http.StatusBadRequest)
+		log.Printf("Failed to decode request body: %v", err)
+		return
+	}
+
+	if err := mh.service.SubmitPermitConversion(r.Context(), &conversion, jmbg, tokenStr, uploads); err != nil {
+		if writeAttachmentError(rw, err) {
+			return
+		}
+		switch {
+		case errors.Is(err, services.ErrInvalidPermitConversion), errors.Is(err, services.ErrForeignPermitExpired):
+			http.Error(rw, err.Error(), http.StatusBadRequest)
+		case errors.Is(err, services.ErrOnWarrantList):
+			http.Error(rw, "Person is on the warrant list", http.StatusForbidden)
+		case errors.Is(err, data.ErrMupNotFound):
+			http.Error(rw, "Office not found", http.StatusBadRequest)
+		case errors.Is(err, data.ErrFeeNotFound):
+			http.Error(rw, "No fee defined for the request", http.StatusUnprocessableEntity)
+		default:
+			log.Printf("Failed to submit permit conversion request: %v", err)
+			http.Error(rw, "Failed to submit permit conversion request", http.StatusInternalServerError)
+		}
+		return
+	}
+
+	rw.Header().Set(ContentType, ApplicationJson)
+	rw.WriteHeader(http.StatusCreated)
+	if err := conversion.ToJSON(rw); err != nil {
+		http.Error(rw, "Failed to encode permit conversion request", http.StatusInternalServerError)
+	}
+
+	log.Printf("Successfully created permit conversion request with id '%s'", conversion.ID.Hex())
+}
+
+// Clerk converts the foreign licence, issuing a domestic permit for each of its categories
+func (mh *MupHandler) ApprovePermitConversion(rw http.ResponseWriter, r *http.Request) {
+	tokenStr := mh.extractTokenFromHeader(r)
+	office, err := mh.getOfficeFromToken(tokenStr)
+	if err != nil {
+		http.Error(rw, "Invalid office in token", http.StatusBadRequest)
+		return
+	}
+
+	clerk, err := mh.getJMBGFromToken(tokenStr)
+	if err != nil {
+		http.Error(rw, "Failed to read JMBG from token", http.StatusBadRequest)
+		return
+	}
+
+	id, err := primitive.ObjectIDFromHex(mux.Vars(r)["id"])
+	if err != nil {
+		http.Error(rw, "Invalid permit conversion ID", http.StatusBadRequest)
+		return
+	}
+
+	conversion, err := mh.service.ApprovePermitConversion(r.Context(), id, office, clerk)
+	if err != nil {
+		switch {
+		case errors.Is(err, data.ErrPermitConversionNotFound):
+			http.Error(rw, "Permit conversion request not found", http.StatusNotFound)
+		case errors.Is(err, data.ErrWrongOffice):
+			http.Error(rw, "Permit conversion was submitted to another office", http.StatusForbidden)
+		case errors.Is(err, data.ErrNotPaid):
+			http.Error(rw, "Permit conversion is not paid", http.StatusConflict)
+		case errors.Is(err, data.ErrInvalidTransition):
+			http.Error(rw, "Only submitted permit conversions can be approved", http.StatusConflict)
+		case errors.Is(err, services.ErrMedicalCertificateInvalid):
+			http.Error(rw, "Applicant has no valid medical certificate", http.StatusConflict)
+		default:
+			log.Printf("Failed to approve permit conversion: %v", err)
+			http.Error(rw, "Failed to approve permit conversion", http.StatusInternalServerError)
+		}
+		return
+	}
+
+	rw.Header().Set(ContentType, ApplicationJson)
+	rw.WriteHeader(http.StatusOK)
+	if err := conversion.ToJSON(rw); err != nil {
+		http.Error(rw, "Failed to encode permit conversion", http.StatusInternalServerError)
+	}
+
+	log.Printf("Permit conversion '%s' approved", conversion.ID.Hex())
+}
+
+func (mh *MupHandler) IssueInternationalPermit(rw http.ResponseWriter, r *http.Request) {
+	tokenStr := mh.extractTokenFromHeader(r)
+	office, err := mh.getOfficeFromToken(tokenStr)
+	if err != nil {
+		http.Error(rw, "Invalid office in token", http.StatusBadRequest)
+		return
+	}
+
+	clerk, err := mh.getJMBGFromToken(tokenStr)
+	if err != nil {
+		http.Error(rw, "Failed to read JMBG from token", http.StatusBadRequest)
+		return
+	}
+
+	var request data.InternationalPermitRequest
+	if err := request.FromJSON(r.Body); err != nil {
+		http.Error(rw, FailedToDecodeRequestBody, http.StatusBadRequest)
+		log.Printf("Failed to decode request body: %v", err)
+		return
+	}
+
+	permit, err := mh.service.IssueInternationalPermit(r.Context(), request, office, clerk, tokenStr)
+	if err != nil {
+		switch {
+		case errors.Is(err, services.ErrInvalidConvention):
+			http.Error(rw, err.Error(), http.StatusBadRequest)
+		case errors.Is(err, data.ErrTrafficPermitNotFound):
+			http.Error(rw, "Traffic permit not found", http.StatusNotFound)
+		case errors.Is(err, services.ErrPermitNotValid), errors.Is(err, services.ErrNoConventionCategories):
+			http.Error(rw, err.Error(), http.StatusConflict)
+		default:
+			log.Printf("Failed to issue international permit: %v", err)
+			http.Error(rw, "Failed to issue international permit", http.StatusInternalServerError)
+		}
+		return
+	}
+
+	rw.Header().Set(ContentType, ApplicationJson)
+	rw.WriteHeader(http.StatusCreated)
+	if err := permit.ToJSON(rw); err != nil {
+		http.Error(rw, "Failed to encode international permit", http.StatusInternalServerError)
+	}
+}
+
+// PUT METHODS
+
+// Clerk asks for more information about a conversion request or rejects it
+func (mh *MupHandler) ChangePermitConversionStatus(rw http.ResponseWriter, r *http.Request) {
+	mh.changePermitConversionStatus(rw, r, true)
+}
+
+// Citizen answers a request for more information or withdraws the conversion request
+func (mh *MupHandler) ChangePersonsPermitConversionStatus(rw http.ResponseWriter, r *http.Request) {
+	mh.changePermitConversionStatus(rw, r, false)
+}
+
+func (mh *MupHandler) changePermitConversionStatus(rw http.ResponseWriter, r *http.Request, clerk bool) {
+	actor, err := mh.getActor(r, clerk)
+	if err != nil {
+		http.Error(rw, "Invalid token", http.StatusBadRequest)
+		return
+	}
+
+	id, err := primitive.ObjectIDFromHex(mux.Vars(r)["id"])
+	if err != nil {
+		http.Error(rw, "Invalid permit conversion ID", http.StatusBadRequest)
+		return
+	}
+
+	var request data.StatusChangeRequest
+	if err := request.FromJSON(r.Body); err != nil {
+		http.Error(rw, FailedToDecodeRequestBody, http.StatusBadRequest)
+		log.Printf("Failed to decode request body: %v", err)
+		return
+	}
+
+	conversion, err := mh.service.ChangePermitConversionStatus(r.Context(), id, actor, request.Status, request.Reason)
+	if err != nil {
+		writeStatusChangeError(rw, err)
+		return
+	}
+
+	rw.Header().Set(ContentType, ApplicationJson)
+	rw.WriteHeader(http.StatusOK)
+	if err := conversion.ToJSON(rw); err != nil {
+		http.Error(rw, "Failed to encode permit conversion", http.StatusInternalServerError)
+	}
+
+	log.Printf("Permit conversion '%s' moved to %s", conversion.ID.Hex(), conversion.Status)
+}
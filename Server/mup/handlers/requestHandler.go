@@ -0,0 +1,210 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"log"
+	"mup/data"
+	"mup/services"
+	"net/http"
+
+	"github.com/gorilla/mux"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// Reason recorded by the former delete endpoints when the clerk gives none
+const declinedWithoutReason = "Declined without stated reason"
+
+// GET METHODS
+
+// Returns all traffic permit requests of the citizen with their status and timeline
+func (mh *MupHandler) GetPersonsTrafficPermitRequests(rw http.ResponseWriter, r *http.Request) {
+	jmbg, err := mh.getJMBGFromToken(mh.extractTokenFromHeader(r))
+	if err != nil || jmbg == "" {
+		http.Error(rw, "Failed to read JMBG from token", http.StatusBadRequest)
+		return
+	}
+
+	permits, err := mh.service.GetPersonsTrafficPermitRequests(r.Context(), jmbg)
+	if err != nil {
+		http.Error(rw, "Failed to retrieve traffic permit requests", http.StatusInternalServerError)
+		return
+	}
+
+	rw.Header().Set(ContentType, ApplicationJson)
+	rw.WriteHeader(http.StatusOK)
+	if err := permits.ToJSON(rw); err != nil {
+		http.Error(rw, "Failed to encode traffic permit requests", http.StatusInternalServerError)
+	}
+}
+
+// PUT METHODS
+
+// Clerk asks for more information about a registration request or rejects it
+func (mh *MupHandler) ChangeRegistrationStatus(rw http.ResponseWriter, r *http.Request) {
+	mh.changeRegistrationStatus(rw, r, true)
+}
+
+// Citizen answers a request for more information or withdraws the registration request
+func (mh *MupHandler) ChangePersonsRegistrationStatus(rw http.ResponseWriter, r *http.Request) {
+	mh.changeRegistrationStatus(rw, r, false)
+}
+
+// Clerk asks for more information about a traffic permit request or rejects it
+func (mh *MupHandler) ChangeTrafficPermitStatus(rw http.ResponseWriter, r *http.Request) {
+	mh.changeTrafficPermitStatus(rw, r, true)
+}
+
+// Citizen answers a request for more information or withdraws the traffic permit request
+func (mh *MupHandler) ChangePersonsTrafficPermitStatus(rw http.ResponseWriter, r *http.Request) {
+	mh.changeTrafficPermitStatus(rw, r, false)
+}
+
+// DELETE METHODS
+
+// Rejects the registration request, kept for clients of the former delete endpoint
+func (mh *MupHandler) RejectPendingRegistration(rw http.ResponseWriter, r *http.Request) {
+	actor, err := mh.getActor(r, true)
+	if err != nil {
+		http.Error(rw, "Invalid token", http.StatusBadRequest)
+		return
+	}
+
+	_, err = mh.service.ChangeRegistrationStatus(r.Context(), mux.Vars(r)["request"], actor, data.StatusRejected, legacyReason(r))
+	if err != nil {
+		writeStatusChangeError(rw, err)
+		return
+	}
+
+	rw.WriteHeader(http.StatusOK)
+}
+
+// Rejects the traffic permit request, kept for clients of the former delete endpoint
+func (mh *MupHandler) RejectPendingTrafficPermit(rw http.ResponseWriter, r *http.Request) {
+	actor, err := mh.getActor(r, true)
+	if err != nil {
+		http.Error(rw, "Invalid token", http.StatusBadRequest)
+		return
+	}
+
+	permitID, err := primitive.ObjectIDFromHex(mux.Vars(r)["request"])
+	if err != nil {
+		http.Error(rw, "Invalid permit ID", http.StatusBadRequest)
+		return
+	}
+
+	_, err = mh.service.ChangeTrafficPermitStatus(r.Context(), permitID, actor, data.StatusRejected, legacyReason(r))
+	if err != nil {
+		writeStatusChangeError(rw, err)
+		return
+	}
+
+	rw.WriteHeader(http.StatusOK)
+}
+
+func (mh *MupHandler) changeRegistrationStatus(rw http.ResponseWriter, r *http.Request, clerk bool) {
+	actor, err := mh.getActor(r, clerk)
+	if err != nil {
+		http.Error(rw, "Invalid token", http.StatusBadRequest)
+		return
+	}
+
+	var request data.StatusChangeRequest
+	if err := request.FromJSON(r.Body); err != nil {
+		http.Error(rw, FailedToDecodeRequestBody, http.StatusBadRequest)
+		log.Printf("Failed to decode request body: %v", err)
+		return
+	}
+
+	registration, err := mh.service.ChangeRegistrationStatus(r.Context(), mux.Vars(r)["registrationNumber"], actor, request.Status, request.Reason)
+	if err != nil {
+		writeStatusChangeError(rw, err)
+		return
+	}
+
+	rw.Header().Set(ContentType, ApplicationJson)
+	rw.WriteHeader(http.StatusOK)
+	if err := json.NewEncoder(rw).Encode(registration); err != nil {
+		http.Error(rw, "Failed to encode registration", http.StatusInternalServerError)
+	}
+
+	log.Printf("Registration '%s' moved to %s", registration.RegistrationNumber, registration.Status)
+}
+
+func (mh *MupHandler) changeTrafficPermitStatus(rw http.ResponseWriter, r *http.Request, clerk bool) {
+	actor, err := mh.getActor(r, clerk)
+	if err != nil {
+		http.Error(rw, "Invalid token", http.StatusBadRequest)
+		return
+	}
+
+	permitID, err := primitive.ObjectIDFromHex(mux.Vars(r)["id"])
+	if err != nil {
+		http.Error(rw, "Invalid permit ID", http.StatusBadRequest)
+		return
+	}
+
+	var request data.StatusChangeRequest
+	if err := request.FromJSON(r.Body); err != nil {
+		http.Error(rw, FailedToDecodeRequestBody, http.StatusBadRequest)
+		log.Printf("Failed to decode request body: %v", err)
+		return
+	}
+
+	permit, err := mh.service.ChangeTrafficPermitStatus(r.Context(), permitID, actor, request.Status, request.Reason)
+	if err != nil {
+		writeStatusChangeError(rw, err)
+		return
+	}
+
+	rw.Header().Set(ContentType, ApplicationJson)
+	rw.WriteHeader(http.StatusOK)
+	if err := permit.ToJSON(rw); err != nil {
+		http.Error(rw, "Failed to encode traffic permit", http.StatusInternalServerError)
+	}
+
+	log.Printf("Traffic permit '%s' moved to %s", permit.ID.Hex(), permit.Status)
+}
+
+// Returns the token owner as actor. Clerks bound to an office carry it in the token
+func (mh *MupHandler) getActor(r *http.Request, clerk bool) (services.Actor, error) {
+	tokenStr := mh.extractTokenFromHeader(r)
+
+	jmbg, err := mh.getJMBGFromToken(tokenStr)
+	if err != nil || jmbg == "" {
+		return services.Actor{}, errors.New("failed to read JMBG from token")
+	}
+
+	actor := services.Actor{JMBG: jmbg, Clerk: clerk}
+	if clerk {
+		actor.Office, err = mh.getOfficeFromToken(tokenStr)
+		if err != nil {
+			return services.Actor{}, err
+		}
+	}
+
+	return actor, nil
+}
+
+func legacyReason(r *http.Request) string {
+	if reason := r.URL.Query().Get("reason"); reason != "" {
+		return reason
+	}
+	return declinedWithoutReason
+}
+
+func writeStatusChangeError(rw http.ResponseWriter, err error) {
+	switch {
+	case errors.Is(err, data.ErrRegistrationNotFound), errors.Is(err, data.ErrTrafficPermitNotFound), errors.Is(err, data.ErrPermitConversionNotFound):
+		http.Error(rw, "Request not found", http.StatusNotFound)
+	case errors.Is(err, data.ErrWrongOffice):
+		http.Error(rw, "Request was submitted to another office", http.StatusForbidden)
+	case errors.Is(err, data.ErrInvalidTransition):
+		http.Error(rw, "Request can't move to the requested status", http.StatusConflict)
+	case errors.Is(err, services.ErrReasonRequired):
+		http.Error(rw, "Reason is required", http.StatusBadRequest)
+	default:
+		log.Printf("Failed to change request status: %v", err)
+		http.Error(rw, "Failed to change request status", http.StatusInternalServerError)
+	}
+}
@@ -1,6 +1,9 @@
 package main
 
 import (
+	"common/payments"
+	"common/signing"
+	"common/storage"
 	"context"
 	"log"
 	"mup/clients"
@@ -47,6 +50,17 @@ func main() {
 		}
 	}
 
+	// Legacy bans have to be converted before the index over their dates is created
+	err = store.MigrateDrivingBans(context.Background())
+	if err != nil {
+		logger.Fatalf("Failed to migrate driving bans: %s", err.Error())
+	}
+
+	err = store.EnsureIndexes(context.Background())
+	if err != nil {
+		logger.Fatalf("Failed to create DB indexes: %s", err.Error())
+	}
+
 	courtClient := &http.Client{
 		Transport: &http.Transport{
 			MaxIdleConns:        10,
@@ -66,8 +80,35 @@ func main() {
 	court := clients.NewCourtClient(courtClient, os.Getenv("COURT_SERVICE_URI"))
 	sso := clients.NewSSOClient(ssoClient, os.Getenv("SSO_SERVICE_URI"))
 
-	mupService := services.NewMupService(store, storeLogger, sso, court)
-	mupHandler := handlers.NewMupHandler(mupService, storeLogger)
+	// Fake gateway confirms every payment and is for local development only. Otherwise
+	// payments are recorded only from notices the bank gateway signs with the shared secret
+	var paymentGateway payments.Gateway
+	if os.Getenv("FAKE_PAYMENT_GATEWAY") == "true" {
+		logger.Println("Fake payment gateway in use, every payment order can be confirmed as paid")
+		paymentGateway = payments.NewFakeGateway()
+	}
+	noticeKey := []byte(os.Getenv("PAYMENT_NOTICE_SECRET"))
+	if len(noticeKey) == 0 {
+		logger.Println("PAYMENT_NOTICE_SECRET is not set, payment notices will be rejected")
+	}
+
+	signer, err := signing.NewSigner(context.Background(), store, "mup")
+	if err != nil {
+		logger.Fatalf("Failed to load signing keys: %s", err.Error())
+	}
+
+	blobs, err := newBlobStore(context.Background())
+	if err != nil {
+		logger.Fatalf("Failed to open attachment storage: %s", err.Error())
+	}
+
+	mupService := services.NewMupService(store, storeLogger, sso, court, paymentGateway, signer, blobs)
+	publicURL := os.Getenv("PUBLIC_URL")
+	if publicURL == "" {
+		publicURL = "http://localhost:" + port
+	}
+
+	mupHandler := handlers.NewMupHandler(mupService, storeLogger, publicURL, noticeKey)
 
 	router := mux.NewRouter()
 
@@ -76,25 +117,82 @@ func main() {
 	router.HandleFunc("/api/v1/driving-bans", mupHandler.CheckForPersonsDrivingBans).Methods("GET")
 	router.HandleFunc("/api/v1/persons-registrations", mupHandler.GetPersonsRegistrations).Methods("GET")
 	router.HandleFunc("/api/v1/persons-driving-permit", mupHandler.GetUserDrivingPermitDetails).Methods("GET")
+	router.HandleFunc("/api/v1/persons-traffic-permit-requests", mupHandler.GetPersonsTrafficPermitRequests).Methods("GET")
+	router.HandleFunc("/api/v1/offices", mupHandler.GetMups).Methods("GET")
+	router.HandleFunc("/api/v1/offices/stats", mupHandler.GetOfficeQueueStats).Methods("GET")
+	router.HandleFunc("/api/v1/offices/{office}/schedule", mupHandler.GetOfficeSchedule).Methods("GET")
+	router.HandleFunc("/api/v1/offices/{office}/slots", mupHandler.GetFreeSlots).Methods("GET")
+	router.HandleFunc("/api/v1/fees", mupHandler.GetFees).Methods("GET")
+	router.HandleFunc("/api/v1/payment-orders/{id}", mupHandler.GetPaymentOrder).Methods("GET")
+	router.HandleFunc("/api/v1/persons-payment-orders", mupHandler.GetPersonsPaymentOrders).Methods("GET")
+	router.HandleFunc("/api/v1/registrations/{registrationNumber}/certificate", mupHandler.GetRegistrationCertificate).Methods("GET")
+	router.HandleFunc("/api/v1/driving-permits/{id}/summary", mupHandler.GetDrivingPermitSummary).Methods("GET")
+	router.HandleFunc("/api/v1/registrations/{registrationNumber}/token", mupHandler.GetRegistrationToken).Methods("GET")
+	router.HandleFunc("/api/v1/driving-permits/{id}/token", mupHandler.GetDrivingPermitToken).Methods("GET")
+	router.HandleFunc("/api/v1/verify", mupHandler.VerifyDocument).Methods("GET")
+	router.HandleFunc("/api/v1/verify/keys", mupHandler.GetSigningKeys).Methods("GET")
+	router.HandleFunc("/api/v1/driving-schools", mupHandler.GetDrivingSchools).Methods("GET")
+	router.HandleFunc("/api/v1/persons-exam-results", mupHandler.GetPersonsExamResults).Methods("GET")
+	router.HandleFunc("/api/v1/persons-medical-certificates", mupHandler.GetPersonsMedicalCertificates).Methods("GET")
+	router.HandleFunc("/api/v1/persons-qualification", mupHandler.GetPersonsQualification).Methods("GET")
+	router.HandleFunc("/api/v1/persons-permit-conversion-requests", mupHandler.GetPersonsPermitConversions).Methods("GET")
+	router.HandleFunc("/api/v1/persons-international-permits", mupHandler.GetPersonsInternationalPermits).Methods("GET")
+	router.HandleFunc("/api/v1/international-permits/{id}/document", mupHandler.GetInternationalPermitDocument).Methods("GET")
 
 	//POST
 	router.HandleFunc("/api/v1/vehicle", mupHandler.SaveVehicle).Methods("POST")
 	router.HandleFunc("/api/v1/registration-request", mupHandler.SubmitRegistrationRequest).Methods("POST")
 	router.HandleFunc("/api/v1/traffic-permit-request", mupHandler.SubmitTrafficPermitRequest).Methods("POST")
+	router.HandleFunc("/api/v1/permit-conversion-request", mupHandler.SubmitPermitConversion).Methods("POST")
+	router.HandleFunc("/api/v1/appointments", mupHandler.BookAppointment).Methods("POST")
+	if paymentGateway != nil {
+		router.HandleFunc("/api/v1/payment-orders/{id}/confirm", mupHandler.ConfirmPayment).Methods("POST")
+	}
+	router.HandleFunc("/api/v1/payments/notice", mupHandler.ReceivePaymentNotice).Methods("POST")
+
+	//PUT
+	router.HandleFunc("/api/v1/persons-registrations/{registrationNumber}/status", mupHandler.ChangePersonsRegistrationStatus).Methods("PUT")
+	router.HandleFunc("/api/v1/persons-traffic-permit-requests/{id}/status", mupHandler.ChangePersonsTrafficPermitStatus).Methods("PUT")
+	router.HandleFunc("/api/v1/persons-permit-conversion-requests/{id}/status", mupHandler.ChangePersonsPermitConversionStatus).Methods("PUT")
 
-	authorizedRouter := router.Methods("GET", "POST", "DELETE").Subrouter()
+	//DELETE
+	router.HandleFunc("/api/v1/appointments/{id}", mupHandler.CancelAppointment).Methods("DELETE")
+
+	authorizedRouter := router.Methods("GET", "POST", "PUT", "DELETE").Subrouter()
+	authorizedRouter.HandleFunc("/api/v1/offices", mupHandler.CreateOffice).Methods("POST")
+	authorizedRouter.HandleFunc("/api/v1/offices/{office}/schedule", mupHandler.SaveOfficeSchedule).Methods("PUT")
+	authorizedRouter.HandleFunc("/api/v1/appointments", mupHandler.GetOfficeAppointments).Methods("GET")
+	authorizedRouter.HandleFunc("/api/v1/fees", mupHandler.SaveFee).Methods("PUT")
+	authorizedRouter.HandleFunc("/api/v1/signing-keys/rotate", mupHandler.RotateSigningKey).Methods("POST")
 	authorizedRouter.HandleFunc("/api/v1/pending-registration-requests", mupHandler.GetPendingRegistrationRequests).Methods("GET")
 	authorizedRouter.HandleFunc("/api/v1/pending-traffic-permit-requests", mupHandler.GetPendingTrafficPermitRequests).Methods("GET")
 	authorizedRouter.HandleFunc("/api/v1/approve-registration-request", mupHandler.ApproveRegistration).Methods("POST")
 	authorizedRouter.HandleFunc("/api/v1/approve-traffic-permit-request", mupHandler.ApproveTrafficPermitRequest).Methods("POST")
-	authorizedRouter.HandleFunc("/api/v1/delete-pending-registration-request/{request}", mupHandler.DeletePendingRegistration).Methods("DELETE")
-	authorizedRouter.HandleFunc("/api/v1/delete-pending-traffic-permit-request/{request}", mupHandler.DeletePendingTrafficPermit).Methods("DELETE")
+	authorizedRouter.HandleFunc("/api/v1/registration-requests/{registrationNumber}/status", mupHandler.ChangeRegistrationStatus).Methods("PUT")
+	authorizedRouter.HandleFunc("/api/v1/traffic-permit-requests/{id}/status", mupHandler.ChangeTrafficPermitStatus).Methods("PUT")
+	authorizedRouter.HandleFunc("/api/v1/delete-pending-registration-request/{request}", mupHandler.RejectPendingRegistration).Methods("DELETE")
+	authorizedRouter.HandleFunc("/api/v1/delete-pending-traffic-permit-request/{request}", mupHandler.RejectPendingTrafficPermit).Methods("DELETE")
+	authorizedRouter.HandleFunc("/api/v1/attachments", mupHandler.GetRequestAttachments).Methods("GET")
+	authorizedRouter.HandleFunc("/api/v1/attachments/{id}", mupHandler.DownloadAttachment).Methods("GET")
+	authorizedRouter.HandleFunc("/api/v1/driving-schools", mupHandler.CreateDrivingSchool).Methods("POST")
+	authorizedRouter.HandleFunc("/api/v1/driving-schools/{id}/candidates", mupHandler.GetSchoolCandidates).Methods("GET")
+	authorizedRouter.HandleFunc("/api/v1/driving-schools/{id}/candidates", mupHandler.EnrollCandidate).Methods("POST")
+	authorizedRouter.HandleFunc("/api/v1/candidates/{id}/exam-results", mupHandler.RecordExamResult).Methods("POST")
+	authorizedRouter.HandleFunc("/api/v1/medical-certificates", mupHandler.SaveMedicalCertificate).Methods("POST")
+	authorizedRouter.HandleFunc("/api/v1/pending-permit-conversion-requests", mupHandler.GetPendingPermitConversions).Methods("GET")
+	authorizedRouter.HandleFunc("/api/v1/permit-conversion-requests/{id}/status", mupHandler.ChangePermitConversionStatus).Methods("PUT")
+	authorizedRouter.HandleFunc("/api/v1/permit-conversion-requests/{id}/approve", mupHandler.ApprovePermitConversion).Methods("POST")
+	authorizedRouter.HandleFunc("/api/v1/international-permits", mupHandler.IssueInternationalPermit).Methods("POST")
 
 	// For clients
 	router.HandleFunc("/api/v1/registered-vehicles", mupHandler.CheckForRegisteredVehicles).Methods("GET")
 	authorizedRouter.HandleFunc("/api/v1/driving-ban", mupHandler.IssueDrivingBan).Methods("POST")
 	authorizedRouter.HandleFunc("/api/v1/registration-by-plate", mupHandler.GetRegistrationByPlate).Methods("GET")
 	authorizedRouter.HandleFunc("/api/v1/check-persons-driving-ban", mupHandler.GetDrivingBan).Methods("GET")
+	authorizedRouter.HandleFunc("/api/v1/check-persons-active-driving-bans", mupHandler.GetActiveDrivingBans).Methods("GET")
+	authorizedRouter.HandleFunc("/api/v1/driving-bans/{id}/lift", mupHandler.LiftDrivingBan).Methods("PUT")
+	authorizedRouter.HandleFunc("/api/v1/driving-bans/{id}/appeal", mupHandler.SetDrivingBanAppeal).Methods("PUT")
+	authorizedRouter.HandleFunc("/api/v1/driving-permits/{id}/revoke", mupHandler.RevokeTrafficPermit).Methods("PUT")
 	authorizedRouter.HandleFunc("/api/v1/check-persons-driving-permit", mupHandler.GetDrivingPermitByJMBG).Methods("GET")
 	authorizedRouter.Use(mupHandler.AuthorizeRoles("ADMIN"))
 
@@ -111,13 +209,39 @@ func main() {
 
 	mupService.SaveMup()
 
+	err = store.MigrateMupReferences(context.Background())
+	if err != nil {
+		logger.Fatalf("Failed to migrate mup references: %s", err.Error())
+	}
+
+	err = store.MigratePayments(context.Background())
+	if err != nil {
+		logger.Fatalf("Failed to migrate payments: %s", err.Error())
+	}
+
+	err = store.MigrateRequestStatuses(context.Background())
+	if err != nil {
+		logger.Fatalf("Failed to migrate request statuses: %s", err.Error())
+	}
+
+	err = store.SaveDefaultFees(context.Background())
+	if err != nil {
+		logger.Fatalf("Failed to create default fees: %s", err.Error())
+	}
+
+	// Bans that start later or run out change permit states without a request
+	reconcileContext, stopReconcile := context.WithCancel(context.Background())
+	defer stopReconcile()
+	go mupService.ReconcilePermitStatuses(reconcileContext, time.Hour)
+
 	// Initialize the server
 	server := http.Server{
-		Addr:         ":" + port,
-		Handler:      cors(router),
-		IdleTimeout:  120 * time.Second,
-		ReadTimeout:  5 * time.Second,
-		WriteTimeout: 5 * time.Second,
+		Addr:        ":" + port,
+		Handler:     cors(router),
+		IdleTimeout: 120 * time.Second,
+		// Long enough for requests carrying attachments
+		ReadTimeout:  60 * time.Second,
+		WriteTimeout: 60 * time.Second,
 	}
 	logger.Printf("Server listening on port: %s\n", port)
 
@@ -134,6 +258,7 @@ func main() {
 
 	sig := <-sigCh
 	logger.Printf("Recieved terminate, starting gracefull shutdown: %v\n", sig)
+	stopReconcile()
 
 	// Gracefull shutdown
 	if server.Shutdown(timeoutContext) != nil {
@@ -141,3 +266,17 @@ func main() {
 	}
 	logger.Println("Server gracefully stopped")
 }
+
+// Attachments go to an S3-compatible store when S3_ENDPOINT is set, otherwise to the filesystem
+func newBlobStore(ctx context.Context) (storage.BlobStore, error) {
+	endpoint := os.Getenv("S3_ENDPOINT")
+	if endpoint != "" {
+		return storage.NewS3Store(ctx, endpoint, os.Getenv("S3_ACCESS_KEY"), os.Getenv("S3_SECRET_KEY"), os.Getenv("S3_BUCKET"), os.Getenv("S3_USE_SSL") == "true")
+	}
+
+	root := os.Getenv("ATTACHMENTS_PATH")
+	if root == "" {
+		root = "attachments"
+	}
+	return storage.NewFileSystemStore(root)
+}
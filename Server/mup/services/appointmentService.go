@@ -0,0 +1,206 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"log"
+	"mup/data"
+	"slices"
+	"time"
+	_ "time/tzdata"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+const dateLayout = "2006-01-02"
+const hoursLayout = "15:04"
+
+var (
+	ErrInvalidSchedule = errors.New("invalid office schedule")
+	ErrInvalidSlot     = errors.New("requested time is not an appointment slot")
+)
+
+// Working hours and holidays of offices are given in local time
+var officeLocation = loadOfficeLocation()
+
+func loadOfficeLocation() *time.Location {
+	location, err := time.LoadLocation("Europe/Belgrade")
+	if err != nil {
+		log.Printf("Failed to load office time zone, using UTC: %v", err)
+		return time.UTC
+	}
+	return location
+}
+
+func (ms *MupService) SaveOfficeSchedule(ctx context.Context, schedule *data.OfficeSchedule) error {
+	if _, err := ms.repo.GetMupByID(ctx, schedule.Office); err != nil {
+		return err
+	}
+
+	if schedule.SlotMinutes <= 0 || schedule.Capacity <= 0 {
+		return ErrInvalidSchedule
+	}
+
+	for _, hours := range schedule.WorkingHours {
+		open, err := time.Parse(hoursLayout, hours.Start)
+		if err != nil {
+			return ErrInvalidSchedule
+		}
+		closing, err := time.Parse(hoursLayout, hours.End)
+		if err != nil || !open.Before(closing) || hours.Weekday < time.Sunday || hours.Weekday > time.Saturday {
+			return ErrInvalidSchedule
+		}
+	}
+
+	for _, holiday := range schedule.Holidays {
+		if _, err := time.Parse(dateLayout, holiday); err != nil {
+			return ErrInvalidSchedule
+		}
+	}
+
+	if schedule.Holidays == nil {
+		schedule.Holidays = []string{}
+	}
+
+	return ms.repo.SaveOfficeSchedule(ctx, schedule)
+}
+
+func (ms *MupService) GetOfficeSchedule(ctx context.Context, office primitive.ObjectID) (data.OfficeSchedule, error) {
+	return ms.repo.GetOfficeSchedule(ctx, office)
+}
+
+// Returns slots of the office on the given date that can still be booked
+func (ms *MupService) GetFreeSlots(ctx context.Context, office primitive.ObjectID, date string) (data.Slots, error) {
+	day, err := time.ParseInLocation(dateLayout, date, officeLocation)
+	if err != nil {
+		return nil, ErrInvalidSlot
+	}
+
+	schedule, err := ms.repo.GetOfficeSchedule(ctx, office)
+	if err != nil {
+		return nil, err
+	}
+
+	booked, err := ms.repo.GetBookedSlots(ctx, office, day, day.AddDate(0, 0, 1))
+	if err != nil {
+		return nil, err
+	}
+
+	bookedByStart := make(map[int64]int, len(booked))
+	for _, slot := range booked {
+		bookedByStart[slot.ID.Start.Unix()] = slot.Booked
+	}
+
+	now := time.Now()
+	freeSlots := data.Slots{}
+	for _, slot := range daySlots(schedule, day) {
+		if !slot.Start.After(now) {
+			continue
+		}
+
+		slot.Free = schedule.Capacity - bookedByStart[slot.Start.Unix()]
+		if slot.Free > 0 {
+			freeSlots = append(freeSlots, slot)
+		}
+	}
+
+	return freeSlots, nil
+}
+
+// Books appointment at the office the person's pending request was submitted to
+func (ms *MupService) BookAppointment(ctx context.Context, appointment *data.Appointment, person string) error {
+	office, err := ms.repo.GetPendingRequestOffice(ctx, appointment.RequestType, appointment.RequestID, person)
+	if err != nil {
+		return err
+	}
+
+	schedule, err := ms.repo.GetOfficeSchedule(ctx, office)
+	if err != nil {
+		return err
+	}
+
+	start := appointment.Start.In(officeLocation)
+	if !start.After(time.Now()) {
+		return ErrInvalidSlot
+	}
+
+	end, ok := findSlot(schedule, start)
+	if !ok {
+		return ErrInvalidSlot
+	}
+
+	appointment.Office = office
+	appointment.Person = person
+	appointment.Start = start
+	appointment.End = end
+
+	return ms.repo.BookAppointment(ctx, appointment, schedule.Capacity)
+}
+
+func (ms *MupService) CancelAppointment(ctx context.Context, appointmentID primitive.ObjectID, person string) (data.Appointment, error) {
+	return ms.repo.CancelAppointment(ctx, appointmentID, person)
+}
+
+// Returns the day's appointments of the office, or of all offices for primitive.NilObjectID
+func (ms *MupService) GetOfficeAppointments(ctx context.Context, office primitive.ObjectID, date string) (data.Appointments, error) {
+	day, err := time.ParseInLocation(dateLayout, date, officeLocation)
+	if err != nil {
+		return nil, ErrInvalidSlot
+	}
+
+	return ms.repo.GetOfficeAppointments(ctx, office, day, day.AddDate(0, 0, 1))
+}
+
+// Generates all slots of the day in office working hours
+func daySlots(schedule data.OfficeSchedule, day time.Time) data.Slots {
+	slots := data.Slots{}
+	if slices.Contains(schedule.Holidays, day.Format(dateLayout)) {
+		return slots
+	}
+
+	length := time.Duration(schedule.SlotMinutes) * time.Minute
+	for _, hours := range schedule.WorkingHours {
+		if hours.Weekday != day.Weekday() {
+			continue
+		}
+
+		open, closing, ok := workingHoursOn(hours, day)
+		if !ok {
+			continue
+		}
+
+		for start := open; !start.Add(length).After(closing); start = start.Add(length) {
+			slots = append(slots, data.Slot{Start: start, End: start.Add(length)})
+		}
+	}
+
+	return slots
+}
+
+// Returns end of the slot starting at start, false if no slot starts then
+func findSlot(schedule data.OfficeSchedule, start time.Time) (time.Time, bool) {
+	day := time.Date(start.Year(), start.Month(), start.Day(), 0, 0, 0, 0, officeLocation)
+
+	for _, slot := range daySlots(schedule, day) {
+		if slot.Start.Equal(start) {
+			return slot.End, true
+		}
+	}
+
+	return time.Time{}, false
+}
+
+func workingHoursOn(hours data.WorkingHours, day time.Time) (time.Time, time.Time, bool) {
+	open, err := time.Parse(hoursLayout, hours.Start)
+	if err != nil {
+		return time.Time{}, time.Time{}, false
+	}
+	closing, err := time.Parse(hoursLayout, hours.End)
+	if err != nil {
+		return time.Time{}, time.Time{}, false
+	}
+
+	return time.Date(day.Year(), day.Month(), day.Day(), open.Hour(), open.Minute(), 0, 0, officeLocation),
+		time.Date(day.Year(), day.Month(), day.Day(), closing.Hour(), closing.Minute(), 0, 0, officeLocation),
+		true
+}
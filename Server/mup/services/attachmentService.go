@@ -0,0 +1,133 @@
+package services
+
+import (
+	"common/storage"
+	"context"
+	"errors"
+	"io"
+	"mup/data"
+	"path/filepath"
+	"slices"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+const (
+	MaxAttachmentSize = 10 << 20
+	MaxAttachments    = 5
+)
+
+var (
+	ErrAttachmentTooLarge     = errors.New("attachment exceeds the size limit")
+	ErrTooManyAttachments     = errors.New("too many attachments")
+	ErrUnsupportedMediaType   = errors.New("attachment must be a PDF, JPEG or PNG file")
+	ErrInvalidAttachmentKind  = errors.New("attachment kind is not accepted for the request")
+	allowedAttachmentContents = []string{"application/pdf", "image/jpeg", "image/png"}
+)
+
+// File uploaded together with a request, Kind is one of data.AttachmentKinds
+type AttachmentUpload struct {
+	Kind     string
+	FileName string
+	Content  io.Reader
+}
+
+// Returns attachments of the request. Clerks bound to an office only get
+// attachments of requests submitted there
+func (ms *MupService) GetRequestAttachments(ctx context.Context, requestType, requestID string, office primitive.ObjectID) (data.Attachments, error) {
+	if _, ok := data.AttachmentKinds[requestType]; !ok {
+		return nil, data.ErrRequestNotFound
+	}
+
+	attachments, err := ms.repo.GetRequestAttachments(ctx, requestType, requestID)
+	if err != nil {
+		return nil, err
+	}
+	if len(attachments) > 0 && !office.IsZero() && attachments[0].Office != office {
+		return nil, data.ErrWrongOffice
+	}
+
+	return attachments, nil
+}
+
+// Returns the attachment with a reader of its content, which the caller closes.
+// Clerks bound to an office only get attachments of requests submitted there
+func (ms *MupService) OpenAttachment(ctx context.Context, id, office primitive.ObjectID) (data.Attachment, io.ReadCloser, error) {
+	attachment, err := ms.repo.GetAttachment(ctx, id)
+	if err != nil {
+		return data.Attachment{}, nil, err
+	}
+	if !office.IsZero() && attachment.Office != office {
+		return data.Attachment{}, nil, data.ErrWrongOffice
+	}
+
+	content, err := ms.blobs.Get(ctx, attachment.BlobKey)
+	if err != nil {
+		return data.Attachment{}, nil, err
+	}
+
+	return attachment, content, nil
+}
+
+// Validates the uploads and stores their content in the blob store. The content type is
+// sniffed from the content itself, the one declared by the client is ignored. Content
+// stored before an upload is rejected is discarded
+func (ms *MupService) storeAttachments(ctx context.Context, requestType, uploadedBy string, uploads []AttachmentUpload) (data.Attachments, error) {
+	if len(uploads) > MaxAttachments {
+		return nil, ErrTooManyAttachments
+	}
+
+	attachments := data.Attachments{}
+	for _, upload := range uploads {
+		if !slices.Contains(data.AttachmentKinds[requestType], upload.Kind) {
+			ms.discardAttachments(ctx, attachments)
+			return nil, ErrInvalidAttachmentKind
+		}
+
+		stored, err := storage.StoreContent(ctx, ms.blobs, "attachments/", upload.Content, MaxAttachmentSize, allowedAttachmentContents)
+		if err != nil {
+			ms.discardAttachments(ctx, attachments)
+		}
+		switch {
+		case errors.Is(err, storage.ErrContentTooLarge):
+			return nil, ErrAttachmentTooLarge
+		case errors.Is(err, storage.ErrUnsupportedContent):
+			return nil, ErrUnsupportedMediaType
+		case err != nil:
+			return nil, err
+		}
+
+		attachment := data.Attachment{
+			ID:          primitive.NewObjectID(),
+			Kind:        upload.Kind,
+			FileName:    filepath.Base(upload.FileName),
+			ContentType: stored.ContentType,
+			Size:        stored.Size,
+			SHA256:      stored.SHA256,
+			BlobKey:     stored.Key,
+			UploadedBy:  uploadedBy,
+			UploadedAt:  time.Now(),
+		}
+
+		attachments = append(attachments, attachment)
+	}
+
+	return attachments, nil
+}
+
+// Removes content of attachments that weren't linked to a request, unless other
+// attachments keep the same content
+func (ms *MupService) discardAttachments(ctx context.Context, attachments data.Attachments) {
+	if len(attachments) == 0 {
+		return
+	}
+
+	keys := make([]string, len(attachments))
+	for i, attachment := range attachments {
+		keys[i] = attachment.BlobKey
+	}
+	if err := storage.DiscardContent(ctx, ms.blobs, keys, ms.repo.AttachmentBlobInUse); err != nil {
+		ms.logger.Printf("Failed to discard attachment content: %v", err)
+	}
+}
@@ -0,0 +1,213 @@
+package services
+
+import (
+	"bytes"
+	"common/signing"
+	"context"
+	"errors"
+	"mup/data"
+	"mup/documents"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// Returns signed token of the owner's approved registration
+func (ms *MupService) RegistrationToken(ctx context.Context, registrationNumber, owner, tokenStr string) (string, error) {
+	registration, err := ms.approvedRegistration(ctx, registrationNumber, owner)
+	if err != nil {
+		return "", err
+	}
+
+	person, err := ms.ssoc.GetUserByJMBG(ctx, owner, tokenStr)
+	if err != nil {
+		return "", err
+	}
+
+	return ms.signRegistration(registration, person)
+}
+
+// Returns signed token of the holder's approved driving permit
+func (ms *MupService) DrivingPermitToken(ctx context.Context, permitID primitive.ObjectID, holder, tokenStr string) (string, error) {
+	permit, err := ms.approvedTrafficPermit(ctx, permitID, holder)
+	if err != nil {
+		return "", err
+	}
+
+	person, err := ms.ssoc.GetUserByJMBG(ctx, holder, tokenStr)
+	if err != nil {
+		return "", err
+	}
+
+	return ms.signTrafficPermit(permit, person)
+}
+
+// Returns PDF registration certificate of the owner's approved registration. The QR code
+// links to verifyBaseURL followed by the signed token of the registration
+func (ms *MupService) RegistrationCertificate(ctx context.Context, registrationNumber, owner, tokenStr, verifyBaseURL string) ([]byte, error) {
+	registration, err := ms.approvedRegistration(ctx, registrationNumber, owner)
+	if err != nil {
+		return nil, err
+	}
+
+	vehicle, err := ms.repo.GetVehicleByID(ctx, registration.VehicleID)
+	if err != nil {
+		return nil, err
+	}
+
+	person, err := ms.ssoc.GetUserByJMBG(ctx, owner, tokenStr)
+	if err != nil {
+		return nil, err
+	}
+
+	office, err := ms.repo.GetMupByID(ctx, registration.Office)
+	if err != nil {
+		return nil, err
+	}
+
+	token, err := ms.signRegistration(registration, person)
+	if err != nil {
+		return nil, err
+	}
+
+	var buf bytes.Buffer
+	err = documents.RegistrationCertificate(&buf, registration, vehicle, person, office, verifyBaseURL+token)
+	if err != nil {
+		return nil, err
+	}
+
+	return buf.Bytes(), nil
+}
+
+// Returns PDF summary of the holder's approved driving permit. The QR code
+// links to verifyBaseURL followed by the signed token of the permit
+func (ms *MupService) DrivingPermitSummary(ctx context.Context, permitID primitive.ObjectID, holder, tokenStr, verifyBaseURL string) ([]byte, error) {
+	permit, err := ms.approvedTrafficPermit(ctx, permitID, holder)
+	if err != nil {
+		return nil, err
+	}
+
+	person, err := ms.ssoc.GetUserByJMBG(ctx, holder, tokenStr)
+	if err != nil {
+		return nil, err
+	}
+
+	office, err := ms.repo.GetMupByID(ctx, permit.Office)
+	if err != nil {
+		return nil, err
+	}
+
+	bans, err := ms.repo.CheckForPersonsDrivingBans(ctx, holder)
+	if err != nil {
+		return nil, err
+	}
+	bans.SetStatuses(time.Now())
+
+	token, err := ms.signTrafficPermit(permit, person)
+	if err != nil {
+		return nil, err
+	}
+
+	var buf bytes.Buffer
+	err = documents.DrivingPermitSummary(&buf, permit, person, office, bans, verifyBaseURL+token)
+	if err != nil {
+		return nil, err
+	}
+
+	return buf.Bytes(), nil
+}
+
+// Validates the signed document token and checks that the document is still valid.
+// Tokens that are not genuine only disclose that they are invalid
+func (ms *MupService) VerifyDocument(ctx context.Context, token string) (data.DocumentVerification, error) {
+	claims, err := ms.signer.Verify(ctx, token)
+	if errors.Is(err, signing.ErrInvalidToken) {
+		return data.DocumentVerification{Valid: false}, nil
+	}
+	if err != nil && !errors.Is(err, signing.ErrTokenExpired) {
+		return data.DocumentVerification{}, err
+	}
+
+	verification := data.DocumentVerification{
+		Document:       claims.Document,
+		Holder:         claims.Holder,
+		ExpirationDate: time.Unix(claims.ExpiresAt, 0),
+		Expired:        errors.Is(err, signing.ErrTokenExpired),
+	}
+
+	switch claims.Document {
+	case data.DocumentRegistration:
+		registration, err := ms.repo.GetRegistrationByNumber(ctx, claims.Subject)
+		if err != nil && !errors.Is(err, data.ErrRegistrationNotFound) {
+			return data.DocumentVerification{}, err
+		}
+		verification.Number = claims.Subject
+		verification.Valid = err == nil && registration.Approved
+	case data.DocumentDrivingPermit:
+		permitID, err := primitive.ObjectIDFromHex(claims.Subject)
+		if err != nil {
+			return data.DocumentVerification{Valid: false}, nil
+		}
+		permit, err := ms.repo.GetTrafficPermitByID(ctx, permitID)
+		if err != nil && !errors.Is(err, data.ErrTrafficPermitNotFound) {
+			return data.DocumentVerification{}, err
+		}
+		verification.Number = permit.Number
+		verification.Valid = err == nil && permit.Approved && permit.Status != data.StatusSuspended && permit.Status != data.StatusRevoked
+	default:
+		return data.DocumentVerification{Valid: false}, nil
+	}
+
+	verification.Valid = verification.Valid && !verification.Expired
+	return verification, nil
+}
+
+// Returns all signing keys without their private parts
+func (ms *MupService) GetSigningKeys(ctx context.Context) (data.SigningKeys, error) {
+	return ms.signer.PublicKeys(ctx)
+}
+
+// Replaces the active signing key, tokens signed with the old key stay valid
+func (ms *MupService) RotateSigningKey(ctx context.Context) (data.SigningKey, error) {
+	key, err := ms.signer.Rotate(ctx)
+	if err != nil {
+		return data.SigningKey{}, err
+	}
+
+	ms.logger.Printf("Signing key rotated, new key is '%s'", key.ID)
+	return key, nil
+}
+
+func (ms *MupService) approvedRegistration(ctx context.Context, registrationNumber, owner string) (data.Registration, error) {
+	registration, err := ms.repo.GetRegistrationByNumber(ctx, registrationNumber)
+	if err != nil {
+		return data.Registration{}, err
+	}
+	if registration.Owner != owner || !registration.Approved {
+		return data.Registration{}, data.ErrRegistrationNotFound
+	}
+
+	return registration, nil
+}
+
+func (ms *MupService) approvedTrafficPermit(ctx context.Context, permitID primitive.ObjectID, holder string) (data.TrafficPermit, error) {
+	permit, err := ms.repo.GetTrafficPermitByID(ctx, permitID)
+	if err != nil {
+		return data.TrafficPermit{}, err
+	}
+	if permit.Person != holder || !permit.Approved {
+		return data.TrafficPermit{}, data.ErrTrafficPermitNotFound
+	}
+
+	return permit, nil
+}
+
+func (ms *MupService) signRegistration(registration data.Registration, owner data.Person) (string, error) {
+	holder := signing.MaskName(owner.FirstName + " " + owner.LastName)
+	return ms.signer.Sign(data.DocumentRegistration, registration.RegistrationNumber, holder, registration.ExpirationDate)
+}
+
+func (ms *MupService) signTrafficPermit(permit data.TrafficPermit, holder data.Person) (string, error) {
+	name := signing.MaskName(holder.FirstName + " " + holder.LastName)
+	return ms.signer.Sign(data.DocumentDrivingPermit, permit.ID.Hex(), name, permit.ExpirationDate)
+}
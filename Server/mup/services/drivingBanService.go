@@ -0,0 +1,141 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"mup/data"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+var (
+	ErrInvalidDrivingBan = errors.New("driving ban needs a person, an end after its start and a known authority")
+	ErrInvalidAppeal     = errors.New("appeal needs the case it was filed in")
+)
+
+// Issues ban starting now unless a start is given. Callers that predate the
+// issuing authority are courts, so it defaults to the court
+func (ms *MupService) IssueDrivingBan(ctx context.Context, drivingBan *data.DrivingBan) error {
+	if drivingBan.Start.IsZero() {
+		drivingBan.Start = time.Now()
+	}
+	if drivingBan.Authority == "" {
+		drivingBan.Authority = data.BanAuthorityCourt
+	}
+
+	if drivingBan.Person == "" || !drivingBan.End.After(drivingBan.Start) {
+		return ErrInvalidDrivingBan
+	}
+	if drivingBan.Authority != data.BanAuthorityCourt && drivingBan.Authority != data.BanAuthorityPoints {
+		return ErrInvalidDrivingBan
+	}
+
+	office, err := ms.repo.GetDefaultMup(ctx)
+	if err != nil {
+		return err
+	}
+	drivingBan.Office = office.ID
+	drivingBan.Lifted = nil
+
+	if err := ms.repo.IssueDrivingBan(ctx, drivingBan); err != nil {
+		return err
+	}
+
+	drivingBan.Status = drivingBan.StatusAt(time.Now())
+	return nil
+}
+
+// Returns the person's ban history, latest first
+func (ms *MupService) CheckForPersonsDrivingBans(ctx context.Context, jmbg string) (data.DrivingBans, error) {
+	drivingBans, err := ms.repo.CheckForPersonsDrivingBans(ctx, jmbg)
+	if err != nil {
+		return nil, err
+	}
+
+	drivingBans.SetStatuses(time.Now())
+	return drivingBans, nil
+}
+
+func (ms *MupService) GetDrivingBan(ctx context.Context, jmbg string) (data.DrivingBan, error) {
+	drivingBan, err := ms.repo.GetDrivingBan(ctx, jmbg)
+	if err != nil {
+		return data.DrivingBan{}, err
+	}
+
+	if !drivingBan.ID.IsZero() {
+		drivingBan.Status = drivingBan.StatusAt(time.Now())
+	}
+	return drivingBan, nil
+}
+
+// Returns every ban of the person in effect right now
+func (ms *MupService) GetActiveDrivingBans(ctx context.Context, jmbg string) (data.DrivingBans, error) {
+	now := time.Now()
+
+	drivingBans, err := ms.repo.GetActiveDrivingBans(ctx, jmbg, now)
+	if err != nil {
+		return nil, err
+	}
+
+	drivingBans.SetStatuses(now)
+	return drivingBans, nil
+}
+
+func (ms *MupService) LiftDrivingBan(ctx context.Context, id primitive.ObjectID, by, reason string) (data.DrivingBan, error) {
+	if reason == "" {
+		return data.DrivingBan{}, ErrReasonRequired
+	}
+
+	lifting := data.BanLifting{At: time.Now(), By: by, Reason: reason}
+	drivingBan, err := ms.repo.LiftDrivingBan(ctx, id, lifting)
+	if err != nil {
+		return data.DrivingBan{}, err
+	}
+
+	drivingBan.Status = drivingBan.StatusAt(lifting.At)
+	ms.logger.Printf("Driving ban '%s' lifted by '%s'", id.Hex(), by)
+	return drivingBan, nil
+}
+
+func (ms *MupService) SetDrivingBanAppeal(ctx context.Context, id primitive.ObjectID, appeal data.BanAppeal) (data.DrivingBan, error) {
+	if appeal.CaseID == "" {
+		return data.DrivingBan{}, ErrInvalidAppeal
+	}
+	if appeal.FiledAt.IsZero() {
+		appeal.FiledAt = time.Now()
+	}
+
+	drivingBan, err := ms.repo.SetDrivingBanAppeal(ctx, id, appeal)
+	if err != nil {
+		return data.DrivingBan{}, err
+	}
+
+	drivingBan.Status = drivingBan.StatusAt(time.Now())
+	return drivingBan, nil
+}
+
+func (ms *MupService) RevokeTrafficPermit(ctx context.Context, permitID primitive.ObjectID, by, reason string) (data.TrafficPermit, error) {
+	if reason == "" {
+		return data.TrafficPermit{}, ErrReasonRequired
+	}
+	return ms.repo.RevokeTrafficPermit(ctx, permitID, by, reason)
+}
+
+// Suspends and reinstates permits as bans start and run out, until ctx is done
+func (ms *MupService) ReconcilePermitStatuses(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		if err := ms.repo.ReconcilePermitStatuses(ctx); err != nil {
+			ms.logger.Printf("Failed to reconcile permit statuses: %v", err)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
@@ -0,0 +1,149 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"mup/data"
+	"slices"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+var (
+	ErrInvalidDrivingSchool      = errors.New("driving school needs a name, accreditation number and categories")
+	ErrNotAccredited             = errors.New("driving school accreditation has expired")
+	ErrCategoryNotTaught         = errors.New("driving school is not accredited for the category")
+	ErrInvalidExam               = errors.New("invalid exam result")
+	ErrTheoryNotPassed           = errors.New("practical exam requires a passed theory exam")
+	ErrInvalidMedicalCertificate = errors.New("medical certificate needs a number and a validity period")
+	ErrExamsNotPassed            = errors.New("candidate has not passed both exams for the category")
+	ErrMedicalCertificateInvalid = errors.New("candidate has no valid medical certificate")
+)
+
+func (ms *MupService) CreateDrivingSchool(ctx context.Context, school *data.DrivingSchool) error {
+	if school.Name == "" || school.AccreditationNumber == "" || len(school.Categories) == 0 {
+		return ErrInvalidDrivingSchool
+	}
+	return ms.repo.CreateDrivingSchool(ctx, school)
+}
+
+func (ms *MupService) GetDrivingSchools(ctx context.Context) (data.DrivingSchools, error) {
+	return ms.repo.GetDrivingSchools(ctx)
+}
+
+// Enrolls the person at the school, which has to be accredited for the category
+func (ms *MupService) EnrollCandidate(ctx context.Context, candidate *data.Candidate) error {
+	school, err := ms.repo.GetDrivingSchool(ctx, candidate.School)
+	if err != nil {
+		return err
+	}
+
+	candidate.EnrolledAt = time.Now()
+	if err := checkAccreditation(school, candidate.Category, candidate.EnrolledAt); err != nil {
+		return err
+	}
+
+	return ms.repo.EnrollCandidate(ctx, candidate)
+}
+
+func (ms *MupService) GetSchoolCandidates(ctx context.Context, school primitive.ObjectID) (data.Candidates, error) {
+	if _, err := ms.repo.GetDrivingSchool(ctx, school); err != nil {
+		return nil, err
+	}
+	return ms.repo.GetSchoolCandidates(ctx, school)
+}
+
+// Records exam taken by the candidate. The practical exam can only be taken after
+// the theory exam is passed, and the school has to be accredited on the exam day
+func (ms *MupService) RecordExamResult(ctx context.Context, candidateID primitive.ObjectID, result *data.ExamResult, examiner string) error {
+	if result.Type != data.ExamTheory && result.Type != data.ExamPractical {
+		return ErrInvalidExam
+	}
+
+	now := time.Now()
+	if result.TakenAt.IsZero() {
+		result.TakenAt = now
+	}
+	if result.TakenAt.After(now) {
+		return ErrInvalidExam
+	}
+
+	candidate, err := ms.repo.GetCandidate(ctx, candidateID)
+	if err != nil {
+		return err
+	}
+
+	school, err := ms.repo.GetDrivingSchool(ctx, candidate.School)
+	if err != nil {
+		return err
+	}
+	if err := checkAccreditation(school, candidate.Category, result.TakenAt); err != nil {
+		return err
+	}
+
+	if result.Type == data.ExamPractical {
+		passed, err := ms.repo.HasPassedExam(ctx, candidate.Person, candidate.Category, data.ExamTheory)
+		if err != nil {
+			return err
+		}
+		if !passed {
+			return ErrTheoryNotPassed
+		}
+	}
+
+	result.Candidate = candidate.ID
+	result.Person = candidate.Person
+	result.School = candidate.School
+	result.Category = candidate.Category
+	result.Examiner = examiner
+	result.RecordedAt = now
+
+	return ms.repo.SaveExamResult(ctx, result)
+}
+
+func (ms *MupService) GetPersonsExamResults(ctx context.Context, jmbg string) (data.ExamResults, error) {
+	return ms.repo.GetPersonsExamResults(ctx, jmbg)
+}
+
+func (ms *MupService) SaveMedicalCertificate(ctx context.Context, certificate *data.MedicalCertificate) error {
+	if certificate.Person == "" || certificate.Number == "" || certificate.IssuedDate.IsZero() || !certificate.ValidUntil.After(certificate.IssuedDate) {
+		return ErrInvalidMedicalCertificate
+	}
+	return ms.repo.SaveMedicalCertificate(ctx, certificate)
+}
+
+func (ms *MupService) GetPersonsMedicalCertificates(ctx context.Context, jmbg string) (data.MedicalCertificates, error) {
+	return ms.repo.GetPersonsMedicalCertificates(ctx, jmbg)
+}
+
+func (ms *MupService) GetQualification(ctx context.Context, jmbg, category string) (data.Qualification, error) {
+	return ms.repo.GetQualification(ctx, jmbg, category, time.Now())
+}
+
+// Returns error explaining why the person can't be issued a permit of the category
+func (ms *MupService) checkQualification(ctx context.Context, jmbg, category string) error {
+	qualification, err := ms.repo.GetQualification(ctx, jmbg, category, time.Now())
+	if err != nil {
+		return err
+	}
+
+	if !qualification.TheoryPassed || !qualification.PracticalPassed {
+		return ErrExamsNotPassed
+	}
+	if !qualification.MedicalCertificateValid {
+		return ErrMedicalCertificateInvalid
+	}
+
+	return nil
+}
+
+func checkAccreditation(school data.DrivingSchool, category string, at time.Time) error {
+	if at.After(school.AccreditedUntil) {
+		return ErrNotAccredited
+	}
+	if !slices.Contains(school.Categories, category) {
+		return ErrCategoryNotTaught
+	}
+	return nil
+}
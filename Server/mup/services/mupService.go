@@ -1,6 +1,9 @@
 package services
 
 import (
+	"common/payments"
+	"common/signing"
+	"common/storage"
 	"context"
 	"fmt"
 	"log"
@@ -17,14 +20,13 @@ type MupService struct {
 	logger *log.Logger
 	ssoc   clients.SSOClient
 	cc     clients.CourtClient
+	pg     payments.Gateway
+	signer *signing.Signer
+	blobs  storage.BlobStore
 }
 
-func NewMupService(r *data.MUPRepo, log *log.Logger, ssoc clients.SSOClient, cc clients.CourtClient) *MupService {
-	return &MupService{repo: r, logger: log, ssoc: ssoc, cc: cc}
-}
-
-func (ms *MupService) CheckForPersonsDrivingBans(ctx context.Context, jmbg string) (data.DrivingBans, error) {
-	return ms.repo.CheckForPersonsDrivingBans(ctx, jmbg)
+func NewMupService(r *data.MUPRepo, log *log.Logger, ssoc clients.SSOClient, cc clients.CourtClient, pg payments.Gateway, signer *signing.Signer, blobs storage.BlobStore) *MupService {
+	return &MupService{repo: r, logger: log, ssoc: ssoc, cc: cc, pg: pg, signer: signer, blobs: blobs}
 }
 
 func (ms *MupService) GetPersonsRegistrations(ctx context.Context, jmbg string) (data.Registrations, error) {
@@ -57,6 +59,8 @@ func (ms *MupService) GetUserDrivingPermitDetails(ctx context.Context, jmbg, tok
 			Person:         permit.Person,
 			FirstName:      user.FirstName,
 			LastName:       user.LastName,
+			Status:         permit.Status,
+			Timeline:       permit.Timeline,
 		}
 
 		drivingPermitDetailsList = append(drivingPermitDetailsList, drivingPermitDetails)
@@ -65,8 +69,8 @@ func (ms *MupService) GetUserDrivingPermitDetails(ctx context.Context, jmbg, tok
 	return drivingPermitDetailsList, nil
 }
 
-func (ms *MupService) GetPendingRegistrationRequests(ctx context.Context, tokenStr string) (data.RegistrationDetailsList, error) {
-	pendingRequests, err := ms.repo.GetPendingRegistrationRequests(ctx)
+func (ms *MupService) GetPendingRegistrationRequests(ctx context.Context, office primitive.ObjectID, tokenStr string) (data.RegistrationDetailsList, error) {
+	pendingRequests, err := ms.repo.GetPendingRegistrationRequests(ctx, office)
 	if err != nil {
 		return nil, err
 	}
@@ -83,6 +87,11 @@ func (ms *MupService) GetPendingRegistrationRequests(ctx context.Context, tokenS
 			return nil, err
 		}
 
+		attachments, err := ms.repo.GetRequestAttachments(ctx, data.RequestTypeRegistration, reg.RegistrationNumber)
+		if err != nil {
+			return nil, err
+		}
+
 		registrationDetails := data.RegistrationDetails{
 			RegistrationNumber: reg.RegistrationNumber,
 			IssuedDate:         reg.IssuedDate,
@@ -91,10 +100,14 @@ func (ms *MupService) GetPendingRegistrationRequests(ctx context.Context, tokenS
 			Owner:              reg.Owner,
 			Plates:             reg.Plates,
 			Approved:           reg.Approved,
+			Office:             reg.Office,
 			FirstName:          user.FirstName,
 			LastName:           user.LastName,
 			VehicleBrand:       vehicle.Brand,
 			VehicleModel:       vehicle.Model,
+			Status:             reg.Status,
+			Timeline:           reg.Timeline,
+			Attachments:        attachments,
 		}
 
 		registrationDetailsList = append(registrationDetailsList, registrationDetails)
@@ -103,8 +116,8 @@ func (ms *MupService) GetPendingRegistrationRequests(ctx context.Context, tokenS
 	return registrationDetailsList, nil
 }
 
-func (ms *MupService) GetPendingTrafficPermitRequests(ctx context.Context, tokenStr string) (data.TrafficPermitDetailsList, error) {
-	pendingRequests, err := ms.repo.GetPendingTrafficPermitRequests(ctx)
+func (ms *MupService) GetPendingTrafficPermitRequests(ctx context.Context, office primitive.ObjectID, tokenStr string) (data.TrafficPermitDetailsList, error) {
+	pendingRequests, err := ms.repo.GetPendingTrafficPermitRequests(ctx, office)
 	if err != nil {
 		return nil, err
 	}
@@ -116,6 +129,16 @@ func (ms *MupService) GetPendingTrafficPermitRequests(ctx context.Context, token
 			return nil, err
 		}
 
+		attachments, err := ms.repo.GetRequestAttachments(ctx, data.RequestTypeTrafficPermit, permit.ID.Hex())
+		if err != nil {
+			return nil, err
+		}
+
+		qualification, err := ms.repo.GetQualification(ctx, permit.Person, permit.Category, time.Now())
+		if err != nil {
+			return nil, err
+		}
+
 		trafficPermitDetails := data.TrafficPermitDetails{
 			ID:             permit.ID,
 			Number:         permit.Number,
@@ -123,8 +146,13 @@ func (ms *MupService) GetPendingTrafficPermitRequests(ctx context.Context, token
 			ExpirationDate: permit.ExpirationDate,
 			Approved:       permit.Approved,
 			Person:         permit.Person,
+			Office:         permit.Office,
 			FirstName:      user.FirstName,
 			LastName:       user.LastName,
+			Status:         permit.Status,
+			Timeline:       permit.Timeline,
+			Attachments:    attachments,
+			Qualification:  qualification,
 		}
 
 		trafficPermitDetailsList = append(trafficPermitDetailsList, trafficPermitDetails)
@@ -137,27 +165,47 @@ func (ms *MupService) RetrieveRegisteredVehicles(ctx context.Context) (data.Vehi
 	return ms.repo.RetrieveRegisteredVehicles(ctx)
 }
 
-func (ms *MupService) SubmitRegistrationRequest(ctx context.Context, registration *data.Registration) error {
+func (ms *MupService) SubmitRegistrationRequest(ctx context.Context, registration *data.Registration, uploads []AttachmentUpload) error {
 	registration.Approved = false
 	registration.IssuedDate = time.Now()
 	registration.ExpirationDate = registration.IssuedDate
 	registration.RegistrationNumber = utils.GenerateRegistration()
 	registration.Plates = ""
 
-	err := ms.repo.SubmitRegistrationRequest(ctx, registration)
+	office, err := ms.resolveOffice(ctx, registration.Office)
+	if err != nil {
+		return err
+	}
+	registration.Office = office
+
+	vehicle, err := ms.repo.GetVehicleByID(ctx, registration.VehicleID)
 	if err != nil {
 		return err
 	}
+	if vehicle.Owner != registration.Owner {
+		return data.ErrVehicleNotFound
+	}
 
-	err = ms.repo.SaveRegistrationIntoVehicle(ctx, registration)
+	order, err := ms.newPaymentOrder(ctx, data.RequestTypeRegistration, vehicle.Category, registration.Owner)
 	if err != nil {
 		return err
 	}
 
+	attachments, err := ms.storeAttachments(ctx, data.RequestTypeRegistration, registration.Owner, uploads)
+	if err != nil {
+		return err
+	}
+
+	err = ms.repo.SubmitRegistrationRequest(ctx, registration, &order, attachments)
+	if err != nil {
+		ms.discardAttachments(ctx, attachments)
+		return err
+	}
+
 	return nil
 }
 
-func (ms *MupService) SubmitTrafficPermitRequest(ctx context.Context, trafficPermit *data.TrafficPermit, jmbg, tokenStr string) error {
+func (ms *MupService) SubmitTrafficPermitRequest(ctx context.Context, trafficPermit *data.TrafficPermit, jmbg, tokenStr string, uploads []AttachmentUpload) error {
 	user, err := ms.ssoc.GetUserByJMBG(ctx, jmbg, tokenStr)
 	if err != nil {
 		return err
@@ -178,7 +226,29 @@ func (ms *MupService) SubmitTrafficPermitRequest(ctx context.Context, trafficPer
 	trafficPermit.IssuedDate = time.Now()
 	trafficPermit.Number = utils.GenerateRegistration()
 
-	return ms.repo.SubmitTrafficPermitRequest(ctx, trafficPermit)
+	office, err := ms.resolveOffice(ctx, trafficPermit.Office)
+	if err != nil {
+		return err
+	}
+	trafficPermit.Office = office
+
+	order, err := ms.newPaymentOrder(ctx, data.RequestTypeTrafficPermit, trafficPermit.Category, trafficPermit.Person)
+	if err != nil {
+		return err
+	}
+
+	attachments, err := ms.storeAttachments(ctx, data.RequestTypeTrafficPermit, trafficPermit.Person, uploads)
+	if err != nil {
+		return err
+	}
+
+	err = ms.repo.SubmitTrafficPermitRequest(ctx, trafficPermit, &order, attachments)
+	if err != nil {
+		ms.discardAttachments(ctx, attachments)
+		return err
+	}
+
+	return nil
 }
 
 func (ms *MupService) GetPersonsVehicles(ctx context.Context, jmbg string) ([]data.Vehicle, error) {
@@ -192,41 +262,16 @@ func (ms *MupService) SaveVehicle(ctx context.Context, vehicle *data.Vehicle) er
 	return ms.repo.SaveVehicle(ctx, vehicle)
 }
 
-func (ms *MupService) IssueDrivingBan(ctx context.Context, drivingBan *data.DrivingBan) error {
-	return ms.repo.IssueDrivingBan(ctx, drivingBan)
-}
-
-func (ms *MupService) ApproveRegistration(ctx context.Context, registration data.Registration) error {
-	expirationDate := time.Now().AddDate(5, 0, 0)
-	registration.Approved = true
-	registration.ExpirationDate = expirationDate
-
-	platesNumber := utils.GeneratePlates()
+func (ms *MupService) ApproveRegistration(ctx context.Context, registration *data.Registration, office primitive.ObjectID, clerk string) error {
+	registration.ExpirationDate = time.Now().AddDate(5, 0, 0)
 
 	plates := data.Plates{
 		RegistrationNumber: registration.RegistrationNumber,
-		PlatesNumber:       platesNumber,
+		PlatesNumber:       utils.GeneratePlates(),
 		PlateType:          "vehicle plates",
-		VehicleID:          registration.VehicleID,
-		Owner:              registration.Owner,
-	}
-
-	registration.Plates = platesNumber
-
-	err := ms.repo.ApproveRegistration(ctx, registration)
-	if err != nil {
-		return err
 	}
 
-	return ms.repo.IssuePlates(ctx, plates)
-}
-
-func (ms *MupService) DeletePendingRegistration(ctx context.Context, registrationNumber string) error {
-	return ms.repo.DeletePendingRegistration(ctx, registrationNumber)
-}
-
-func (ms *MupService) DeletePendingTrafficPermit(ctx context.Context, permitID primitive.ObjectID) error {
-	return ms.repo.DeletePendingTrafficPermit(ctx, permitID)
+	return ms.repo.ApproveRegistration(ctx, registration, plates, office, clerk)
 }
 
 func (ms *MupService) GetVehiclesDTOByJMBG(ctx context.Context, jmbg string) (data.VehiclesDTO, error) {
@@ -262,21 +307,60 @@ func (ms *MupService) GetVehiclesDTOByJMBG(ctx context.Context, jmbg string) (da
 	return vehicleDTOs, nil
 }
 
-func (ms *MupService) ApproveTrafficPermitRequest(ctx context.Context, permitID primitive.ObjectID) error {
-	return ms.repo.ApproveTrafficPermitRequest(ctx, permitID)
+// Approves the permit once the candidate has passed both exams for the category
+// and holds a valid medical certificate
+func (ms *MupService) ApproveTrafficPermitRequest(ctx context.Context, permitID, office primitive.ObjectID, clerk string) error {
+	permit, err := ms.repo.GetTrafficPermitByID(ctx, permitID)
+	if err != nil {
+		return err
+	}
+
+	if !permit.Approved {
+		if err := ms.checkQualification(ctx, permit.Person, permit.Category); err != nil {
+			return err
+		}
+	}
+
+	return ms.repo.ApproveTrafficPermitRequest(ctx, permitID, office, clerk)
 }
 
 func (ms *MupService) GetRegistrationByPlate(ctx context.Context, plate string) (data.Registration, error) {
 	return ms.repo.GetRegistrationByPlate(ctx, plate)
 }
-func (ms *MupService) GetDrivingBan(ctx context.Context, jmbg string) (data.DrivingBan, error) {
-	return ms.repo.GetDrivingBan(ctx, jmbg)
-}
 
 func (ms *MupService) GetDrivingPermitByJMBG(ctx context.Context, jmbg string) (data.TrafficPermit, error) {
 	return ms.repo.GetDrivingPermitByJMBG(ctx, jmbg)
 }
 
+func (ms *MupService) GetMups(ctx context.Context, municipality string) (data.Mups, error) {
+	return ms.repo.GetMups(ctx, municipality)
+}
+
+func (ms *MupService) CreateOffice(ctx context.Context, mup *data.Mup) error {
+	return ms.repo.SaveOffice(ctx, mup)
+}
+
+func (ms *MupService) GetOfficeQueueStats(ctx context.Context) (data.OfficeQueueStatsList, error) {
+	return ms.repo.GetOfficeQueueStats(ctx)
+}
+
+// Returns the office picked by citizen, or the default office if none was picked
+func (ms *MupService) resolveOffice(ctx context.Context, office primitive.ObjectID) (primitive.ObjectID, error) {
+	if office.IsZero() {
+		mup, err := ms.repo.GetDefaultMup(ctx)
+		if err != nil {
+			return primitive.NilObjectID, err
+		}
+		return mup.ID, nil
+	}
+
+	mup, err := ms.repo.GetMupByID(ctx, office)
+	if err != nil {
+		return primitive.NilObjectID, err
+	}
+	return mup.ID, nil
+}
+
 func (ms *MupService) SaveMup() error {
 	err := ms.repo.SaveMup(context.Background())
 	if err != nil {
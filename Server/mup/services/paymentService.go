@@ -0,0 +1,146 @@
+package services
+
+import (
+	"common/payments"
+	"context"
+	"errors"
+	"fmt"
+	"mup/data"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// Administrative fees are paid to the republic budget account
+const paymentRecipient = "Republička administrativna taksa"
+const paymentRecipientAccount = "840-742221843-57"
+const paymentModel = "97"
+
+var (
+	ErrInvalidFee         = errors.New("invalid fee")
+	ErrPaymentNotReceived = errors.New("payment not received")
+	ErrUnderpaid          = errors.New("amount paid does not settle the payment order")
+	ErrAlreadyPaid        = errors.New("payment order already paid")
+)
+
+func (ms *MupService) GetFees(ctx context.Context) (data.Fees, error) {
+	return ms.repo.GetFees(ctx)
+}
+
+func (ms *MupService) SaveFee(ctx context.Context, fee *data.Fee) error {
+	switch fee.RequestType {
+	case data.RequestTypeRegistration, data.RequestTypeTrafficPermit, data.RequestTypePermitConversion:
+	default:
+		return ErrInvalidFee
+	}
+	if fee.Amount < 0 {
+		return ErrInvalidFee
+	}
+	if fee.Currency == "" {
+		fee.Currency = "RSD"
+	}
+
+	return ms.repo.SaveFee(ctx, fee)
+}
+
+// Returns payment order of the payer
+func (ms *MupService) GetPaymentOrder(ctx context.Context, id primitive.ObjectID, payer string) (data.PaymentOrder, error) {
+	order, err := ms.repo.GetPaymentOrder(ctx, id)
+	if err != nil {
+		return data.PaymentOrder{}, err
+	}
+	if order.Payer != payer {
+		return data.PaymentOrder{}, data.ErrPaymentOrderNotFound
+	}
+
+	return order, nil
+}
+
+func (ms *MupService) GetPersonsPaymentOrders(ctx context.Context, jmbg string) (data.PaymentOrders, error) {
+	return ms.repo.GetPersonsPaymentOrders(ctx, jmbg)
+}
+
+// Checks payment of the order with the payment gateway. Once paid, the
+// request the order was issued for enters the approval queue
+func (ms *MupService) ConfirmPayment(ctx context.Context, id primitive.ObjectID, payer string) (data.PaymentOrder, error) {
+	order, err := ms.GetPaymentOrder(ctx, id, payer)
+	if err != nil {
+		return data.PaymentOrder{}, err
+	}
+
+	if order.Status == data.PaymentStatusPaid {
+		return order, nil
+	}
+
+	confirmation, err := ms.pg.ConfirmPayment(ctx, payments.Order{
+		Model:           order.Model,
+		ReferenceNumber: order.ReferenceNumber,
+		Amount:          order.Amount,
+	})
+	if err != nil {
+		return data.PaymentOrder{}, err
+	}
+
+	return ms.settlePayment(ctx, order, confirmation)
+}
+
+// Records the payment the payment gateway notifies of. A notice of a payment
+// already recorded is accepted again, another payment of a paid order is not
+func (ms *MupService) RecordPayment(ctx context.Context, notice payments.Notice) (data.PaymentOrder, error) {
+	order, err := ms.repo.GetPaymentOrderByReference(ctx, notice.Model, notice.ReferenceNumber)
+	if err != nil {
+		return data.PaymentOrder{}, err
+	}
+
+	if order.Status != data.PaymentStatusPaid {
+		order, err = ms.settlePayment(ctx, order, notice.Confirmation())
+		if err != nil {
+			return order, err
+		}
+	}
+	if order.TransactionID != notice.TransactionID {
+		return order, ErrAlreadyPaid
+	}
+
+	return order, nil
+}
+
+// Marks the order paid if the payment settles it
+func (ms *MupService) settlePayment(ctx context.Context, order data.PaymentOrder, confirmation payments.Confirmation) (data.PaymentOrder, error) {
+	if !confirmation.Paid {
+		return order, ErrPaymentNotReceived
+	}
+	if confirmation.Amount < order.Amount {
+		return order, fmt.Errorf("%w: paid %.2f of %.2f %s", ErrUnderpaid, confirmation.Amount, order.Amount, order.Currency)
+	}
+
+	err := ms.repo.ConfirmPayment(ctx, &order, confirmation.TransactionID, confirmation.PaidAt)
+	if err != nil {
+		return data.PaymentOrder{}, err
+	}
+
+	return order, nil
+}
+
+// Creates payment order for the fee of the request type and vehicle category
+func (ms *MupService) newPaymentOrder(ctx context.Context, requestType, vehicleCategory, payer string) (data.PaymentOrder, error) {
+	fee, err := ms.repo.GetFee(ctx, requestType, vehicleCategory)
+	if err != nil {
+		return data.PaymentOrder{}, err
+	}
+
+	return data.PaymentOrder{
+		ID:               primitive.NewObjectID(),
+		RequestType:      requestType,
+		Payer:            payer,
+		Purpose:          fee.Description,
+		Recipient:        paymentRecipient,
+		RecipientAccount: paymentRecipientAccount,
+		Amount:           fee.Amount,
+		Currency:         fee.Currency,
+		Model:            paymentModel,
+		ReferenceNumber:  payments.GenerateReference97(),
+		Status:           data.PaymentStatusPending,
+		CreatedAt:        time.Now(),
+	}, nil
+}
@@ -0,0 +1,318 @@
+package services
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"mup/data"
+	"mup/documents"
+	"mup/utils"
+	"regexp"
+	"slices"
+	"strings"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+var (
+	ErrInvalidPermitConversion = errors.New("conversion needs a foreign issuing country, licence number and categories")
+	ErrForeignPermitExpired    = errors.New("foreign licence has expired")
+	ErrOnWarrantList           = errors.New("person is on the warrant list")
+	ErrInvalidConvention       = errors.New("convention must be 1949 or 1968")
+	ErrPermitNotValid          = errors.New("domestic permit is not in force")
+	ErrNoConventionCategories  = errors.New("permit has no categories recognised by the convention")
+)
+
+// Validity of an international permit in years, never past the domestic permit
+var conventionValidity = map[string]int{
+	data.Convention1949: 1,
+	data.Convention1968: 3,
+}
+
+// Domestic categories as the 1949 convention knows them, its keys are all categories
+// a foreign licence can be converted to. The 1968 convention uses most domestic
+// categories as they are
+var convention1949Categories = map[string]string{
+	"AM": "A", "A1": "A", "A2": "A", "A": "A",
+	"B1": "B", "B": "B",
+	"C1": "C", "C": "C",
+	"D1": "D", "D": "D",
+	"BE": "E", "C1E": "E", "CE": "E", "D1E": "E", "DE": "E",
+}
+
+var convention1968Categories = []string{"A", "A1", "B", "B1", "C", "C1", "D", "D1", "BE", "C1E", "CE", "D1E", "DE"}
+
+var countryCode = regexp.MustCompile(`^[A-Z]{2}$`)
+
+// Domestic licences are issued as traffic permits, only foreign ones are converted
+const domesticCountry = "RS"
+
+func (ms *MupService) SubmitPermitConversion(ctx context.Context, conversion *data.PermitConversion, jmbg, tokenStr string, uploads []AttachmentUpload) error {
+	conversion.Country = strings.ToUpper(strings.TrimSpace(conversion.Country))
+	conversion.OriginalNumber = strings.TrimSpace(conversion.OriginalNumber)
+
+	if !countryCode.MatchString(conversion.Country) || conversion.Country == domesticCountry ||
+		conversion.OriginalNumber == "" || len(conversion.Categories) == 0 {
+		return ErrInvalidPermitConversion
+	}
+
+	var categories []string
+	for _, category := range conversion.Categories {
+		category = strings.ToUpper(strings.TrimSpace(category))
+		if _, ok := convention1949Categories[category]; !ok {
+			return ErrInvalidPermitConversion
+		}
+		if !slices.Contains(categories, category) {
+			categories = append(categories, category)
+		}
+	}
+	conversion.Categories = categories
+
+	now := time.Now()
+	if !conversion.OriginalExpirationDate.IsZero() && conversion.OriginalExpirationDate.Before(now) {
+		return ErrForeignPermitExpired
+	}
+
+	user, err := ms.ssoc.GetUserByJMBG(ctx, jmbg, tokenStr)
+	if err != nil {
+		return err
+	}
+
+	warrants, err := ms.cc.CheckForPersonsWarrant(ctx, user.JMBG, tokenStr)
+	if err != nil {
+		return err
+	}
+	if len(warrants) != 0 {
+		return ErrOnWarrantList
+	}
+
+	conversion.ID = primitive.NewObjectID()
+	conversion.Person = user.JMBG
+	conversion.SubmittedAt = now
+	conversion.ProcessedAt = time.Time{}
+	conversion.Permits = nil
+
+	office, err := ms.resolveOffice(ctx, conversion.Office)
+	if err != nil {
+		return err
+	}
+	conversion.Office = office
+
+	order, err := ms.newPaymentOrder(ctx, data.RequestTypePermitConversion, "", conversion.Person)
+	if err != nil {
+		return err
+	}
+
+	attachments, err := ms.storeAttachments(ctx, data.RequestTypePermitConversion, conversion.Person, uploads)
+	if err != nil {
+		return err
+	}
+
+	err = ms.repo.SubmitPermitConversion(ctx, conversion, &order, attachments)
+	if err != nil {
+		ms.discardAttachments(ctx, attachments)
+		return err
+	}
+
+	return nil
+}
+
+// Returns all conversion requests of the person with their status and timeline
+func (ms *MupService) GetPersonsPermitConversions(ctx context.Context, jmbg string) (data.PermitConversions, error) {
+	return ms.repo.GetPersonsPermitConversions(ctx, jmbg)
+}
+
+func (ms *MupService) GetPendingPermitConversions(ctx context.Context, office primitive.ObjectID, tokenStr string) (data.PermitConversionDetailsList, error) {
+	pending, err := ms.repo.GetPendingPermitConversions(ctx, office)
+	if err != nil {
+		return nil, err
+	}
+
+	detailsList := data.PermitConversionDetailsList{}
+	for _, conversion := range pending {
+		user, err := ms.ssoc.GetUserByJMBG(ctx, conversion.Person, tokenStr)
+		if err != nil {
+			return nil, err
+		}
+
+		attachments, err := ms.repo.GetRequestAttachments(ctx, data.RequestTypePermitConversion, conversion.ID.Hex())
+		if err != nil {
+			return nil, err
+		}
+
+		qualification, err := ms.repo.GetQualification(ctx, conversion.Person, "", time.Now())
+		if err != nil {
+			return nil, err
+		}
+
+		detailsList = append(detailsList, data.PermitConversionDetails{
+			PermitConversion:        conversion,
+			FirstName:               user.FirstName,
+			LastName:                user.LastName,
+			Citizenship:             user.Citizenship,
+			Attachments:             attachments,
+			MedicalCertificateValid: qualification.MedicalCertificateValid,
+			MedicalValidUntil:       qualification.MedicalValidUntil,
+		})
+	}
+
+	return detailsList, nil
+}
+
+func (ms *MupService) ChangePermitConversionStatus(ctx context.Context, id primitive.ObjectID, actor Actor, status, reason string) (data.PermitConversion, error) {
+	conversion, err := ms.repo.GetPermitConversion(ctx, id)
+	if err != nil {
+		return data.PermitConversion{}, err
+	}
+
+	if !actor.Clerk && conversion.Person != actor.JMBG {
+		return data.PermitConversion{}, data.ErrPermitConversionNotFound
+	}
+
+	change, err := newStatusChange(actor, conversion.Office, conversion.Status, status, reason)
+	if err != nil {
+		return data.PermitConversion{}, err
+	}
+
+	err = ms.repo.ChangeRequestStatus(ctx, data.RequestTypePermitConversion, id.Hex(), change)
+	if err != nil {
+		return data.PermitConversion{}, err
+	}
+
+	return ms.repo.GetPermitConversion(ctx, id)
+}
+
+// Converted categories need no exams, but the holder must be medically fit to drive
+func (ms *MupService) ApprovePermitConversion(ctx context.Context, id, office primitive.ObjectID, clerk string) (data.PermitConversion, error) {
+	conversion, err := ms.repo.GetPermitConversion(ctx, id)
+	if err != nil {
+		return data.PermitConversion{}, err
+	}
+
+	if conversion.Status != data.StatusApproved {
+		qualification, err := ms.repo.GetQualification(ctx, conversion.Person, "", time.Now())
+		if err != nil {
+			return data.PermitConversion{}, err
+		}
+		if !qualification.MedicalCertificateValid {
+			return data.PermitConversion{}, ErrMedicalCertificateInvalid
+		}
+	}
+
+	return ms.repo.ApprovePermitConversion(ctx, id, office, utils.GenerateRegistration(), clerk)
+}
+
+// Issues international permit for the domestic permit in force. The holder's other
+// permits in force are listed too, in the categories the convention recognises
+func (ms *MupService) IssueInternationalPermit(ctx context.Context, request data.InternationalPermitRequest, office primitive.ObjectID, clerk, tokenStr string) (data.InternationalPermit, error) {
+	years, ok := conventionValidity[request.Convention]
+	if !ok {
+		return data.InternationalPermit{}, ErrInvalidConvention
+	}
+
+	now := time.Now()
+
+	permit, err := ms.repo.GetTrafficPermitByID(ctx, request.Permit)
+	if err != nil {
+		return data.InternationalPermit{}, err
+	}
+	if permit.Status != data.StatusApproved || !permit.ExpirationDate.After(now) {
+		return data.InternationalPermit{}, ErrPermitNotValid
+	}
+
+	permits, err := ms.repo.GetUserDrivingPermits(ctx, permit.Person)
+	if err != nil {
+		return data.InternationalPermit{}, err
+	}
+
+	var domestic []string
+	for _, p := range permits {
+		if p.Status == data.StatusApproved && p.ExpirationDate.After(now) {
+			domestic = append(domestic, p.Category)
+		}
+	}
+
+	categories := conventionCategories(request.Convention, domestic)
+	if len(categories) == 0 {
+		return data.InternationalPermit{}, ErrNoConventionCategories
+	}
+
+	if office.IsZero() {
+		office = permit.Office
+	}
+	mup, err := ms.repo.GetMupByID(ctx, office)
+	if err != nil {
+		return data.InternationalPermit{}, err
+	}
+
+	holder, err := ms.ssoc.GetUserByJMBG(ctx, permit.Person, tokenStr)
+	if err != nil {
+		return data.InternationalPermit{}, err
+	}
+
+	expirationDate := now.AddDate(years, 0, 0)
+	if permit.ExpirationDate.Before(expirationDate) {
+		expirationDate = permit.ExpirationDate
+	}
+
+	international := data.InternationalPermit{
+		Number:         "IDP-" + utils.GenerateRegistration(),
+		Person:         permit.Person,
+		Permit:         permit.ID,
+		Convention:     request.Convention,
+		Categories:     categories,
+		IssuedDate:     now,
+		ExpirationDate: expirationDate,
+		Office:         mup.ID,
+		IssuedBy:       clerk,
+	}
+	international.Printout = documents.InternationalPermitPrintout(international, permit, holder, mup)
+
+	if err := ms.repo.IssueInternationalPermit(ctx, &international); err != nil {
+		return data.InternationalPermit{}, err
+	}
+
+	return international, nil
+}
+
+func (ms *MupService) GetPersonsInternationalPermits(ctx context.Context, jmbg string) (data.InternationalPermits, error) {
+	return ms.repo.GetPersonsInternationalPermits(ctx, jmbg)
+}
+
+// Prints the international permit as stored when it was issued
+func (ms *MupService) InternationalPermitDocument(ctx context.Context, id primitive.ObjectID, holder string) ([]byte, error) {
+	permit, err := ms.repo.GetInternationalPermit(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if permit.Person != holder {
+		return nil, data.ErrInternationalPermitNotFound
+	}
+
+	var buf bytes.Buffer
+	if err := documents.InternationalDrivingPermit(&buf, permit); err != nil {
+		return nil, err
+	}
+
+	return buf.Bytes(), nil
+}
+
+// Translates domestic categories to those of the convention, without duplicates
+func conventionCategories(convention string, domestic []string) []string {
+	var categories []string
+	for _, category := range domestic {
+		if convention == data.Convention1949 {
+			category = convention1949Categories[category]
+		} else if !slices.Contains(convention1968Categories, category) {
+			category = ""
+		}
+
+		if category != "" && !slices.Contains(categories, category) {
+			categories = append(categories, category)
+		}
+	}
+
+	slices.Sort(categories)
+	return categories
+}
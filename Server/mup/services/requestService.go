@@ -0,0 +1,114 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"mup/data"
+	"slices"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+var ErrReasonRequired = errors.New("status change requires a reason")
+
+// Target statuses a clerk can move a request to, with the statuses it has to be in.
+// Approval issues documents, so it goes through ApproveRegistration and ApproveTrafficPermitRequest
+var clerkTransitions = map[string][]string{
+	data.StatusNeedsInfo: {data.StatusSubmitted},
+	data.StatusRejected:  {data.StatusSubmitted, data.StatusNeedsInfo},
+}
+
+// Citizens answer requests for more information by resubmitting, and can withdraw open requests
+var citizenTransitions = map[string][]string{
+	data.StatusSubmitted: {data.StatusNeedsInfo},
+	data.StatusWithdrawn: {data.StatusSubmitted, data.StatusNeedsInfo},
+}
+
+// Who changes the status of a request. Clerks bound to an office only handle its
+// requests, citizens only their own
+type Actor struct {
+	JMBG   string
+	Clerk  bool
+	Office primitive.ObjectID
+}
+
+func (ms *MupService) ChangeRegistrationStatus(ctx context.Context, registrationNumber string, actor Actor, status, reason string) (data.Registration, error) {
+	registration, err := ms.repo.GetRegistrationByNumber(ctx, registrationNumber)
+	if err != nil {
+		return data.Registration{}, err
+	}
+
+	if !actor.Clerk && registration.Owner != actor.JMBG {
+		return data.Registration{}, data.ErrRegistrationNotFound
+	}
+
+	change, err := newStatusChange(actor, registration.Office, registration.Status, status, reason)
+	if err != nil {
+		return data.Registration{}, err
+	}
+
+	err = ms.repo.ChangeRequestStatus(ctx, data.RequestTypeRegistration, registrationNumber, change)
+	if err != nil {
+		return data.Registration{}, err
+	}
+
+	return ms.repo.GetRegistrationByNumber(ctx, registrationNumber)
+}
+
+func (ms *MupService) ChangeTrafficPermitStatus(ctx context.Context, permitID primitive.ObjectID, actor Actor, status, reason string) (data.TrafficPermit, error) {
+	permit, err := ms.repo.GetTrafficPermitByID(ctx, permitID)
+	if err != nil {
+		return data.TrafficPermit{}, err
+	}
+
+	if !actor.Clerk && permit.Person != actor.JMBG {
+		return data.TrafficPermit{}, data.ErrTrafficPermitNotFound
+	}
+
+	change, err := newStatusChange(actor, permit.Office, permit.Status, status, reason)
+	if err != nil {
+		return data.TrafficPermit{}, err
+	}
+
+	err = ms.repo.ChangeRequestStatus(ctx, data.RequestTypeTrafficPermit, permitID.Hex(), change)
+	if err != nil {
+		return data.TrafficPermit{}, err
+	}
+
+	return ms.repo.GetTrafficPermitByID(ctx, permitID)
+}
+
+// Returns all traffic permit requests of the person, newest first
+func (ms *MupService) GetPersonsTrafficPermitRequests(ctx context.Context, jmbg string) (data.TrafficPermits, error) {
+	return ms.repo.GetPersonsTrafficPermits(ctx, jmbg)
+}
+
+// Checks that the actor may move the request from its current status and returns the change
+func newStatusChange(actor Actor, office primitive.ObjectID, current, status, reason string) (data.StatusChange, error) {
+	transitions := citizenTransitions
+	if actor.Clerk {
+		transitions = clerkTransitions
+		if !actor.Office.IsZero() && office != actor.Office {
+			return data.StatusChange{}, data.ErrWrongOffice
+		}
+	}
+
+	from, ok := transitions[status]
+	if !ok || !slices.Contains(from, current) {
+		return data.StatusChange{}, data.ErrInvalidTransition
+	}
+
+	// Withdrawing is the citizen's own decision and needs no explanation
+	if reason == "" && status != data.StatusWithdrawn {
+		return data.StatusChange{}, ErrReasonRequired
+	}
+
+	return data.StatusChange{
+		From:   current,
+		To:     status,
+		By:     actor.JMBG,
+		At:     time.Now(),
+		Reason: reason,
+	}, nil
+}
@@ -21,6 +21,7 @@ func NewCourtClient(client *http.Client, address string) CourtClient {
 	}
 }
 
+// Reports the violation to the court. Reporting the same violation again doesn't schedule another hearing
 func (cc CourtClient) CreateCrimeReport(ctx context.Context, violation data.TrafficViolation, token string) error {
 	requestBody, err := json.Marshal(violation)
 	if err != nil {
@@ -37,13 +38,119 @@ func (cc CourtClient) CreateCrimeReport(ctx context.Context, violation data.Traf
 
 	resp, err := cc.client.Do(req)
 	if err != nil {
-		return err
+		return handleHttpReqErr(err, req.URL.String(), req.Method, requestTimeout(ctx))
 	}
 	defer resp.Body.Close()
 
 	if resp.StatusCode != http.StatusOK {
-		return errors.New("unexpected status code: " + resp.Status)
+		return handleHttpRespErr(resp)
 	}
 
 	return nil
 }
+
+// Sends the unpaid fine to the court and returns the hearing scheduled for it. Sending
+// the same fine again returns the hearing already scheduled
+func (cc CourtClient) CreateFineCase(ctx context.Context, fine data.UnpaidFine, token string) (string, error) {
+	return cc.createCase(ctx, "/unpaid-fines", fine, token)
+}
+
+// Sends the contested violation to the court and returns the hearing scheduled for it.
+// Sending the same fine again returns the hearing already scheduled
+func (cc CourtClient) CreateObjectionCase(ctx context.Context, contested data.ContestedViolation, token string) (string, error) {
+	return cc.createCase(ctx, "/contested-violations", contested, token)
+}
+
+func (cc CourtClient) createCase(ctx context.Context, path string, body interface{}, token string) (string, error) {
+	requestBody, err := json.Marshal(body)
+	if err != nil {
+		return "", err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, cc.address+path, bytes.NewBuffer(requestBody))
+	if err != nil {
+		return "", err
+	}
+
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Authorization", "Bearer "+token)
+
+	resp, err := cc.client.Do(req)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK && resp.StatusCode != http.StatusCreated {
+		return "", errors.New("unexpected status code: " + resp.Status)
+	}
+
+	var hearing struct {
+		ID string `json:"id"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&hearing); err != nil {
+		return "", err
+	}
+
+	return hearing.ID, nil
+}
+
+// Marks the warrant executed in the court and returns it
+func (cc CourtClient) ExecuteWarrant(ctx context.Context, id string, execution data.WarrantExecution, token string) (data.Warrant, error) {
+	requestBody, err := json.Marshal(execution)
+	if err != nil {
+		return data.Warrant{}, err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, cc.address+"/warrants/"+id+"/execute", bytes.NewBuffer(requestBody))
+	if err != nil {
+		return data.Warrant{}, err
+	}
+
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Authorization", "Bearer "+token)
+
+	resp, err := cc.client.Do(req)
+	if err != nil {
+		return data.Warrant{}, handleHttpReqErr(err, req.URL.String(), req.Method, requestTimeout(ctx))
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return data.Warrant{}, handleHttpRespErr(resp)
+	}
+
+	var warrant data.Warrant
+	if err := json.NewDecoder(resp.Body).Decode(&warrant); err != nil {
+		return data.Warrant{}, err
+	}
+
+	return warrant, nil
+}
+
+// Returns warrants the court issued for the person, active and executed
+func (cc CourtClient) GetWarrants(ctx context.Context, jmbg, token string) ([]data.Warrant, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, cc.address+"/warrants/"+jmbg, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	req.Header.Set("Authorization", "Bearer "+token)
+
+	resp, err := cc.client.Do(req)
+	if err != nil {
+		return nil, handleHttpReqErr(err, req.URL.String(), req.Method, requestTimeout(ctx))
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, handleHttpRespErr(resp)
+	}
+
+	warrants := []data.Warrant{}
+	if err := json.NewDecoder(resp.Body).Decode(&warrants); err != nil {
+		return nil, err
+	}
+
+	return warrants, nil
+}
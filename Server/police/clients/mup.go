@@ -4,11 +4,8 @@ import (
 	"bytes"
 	"context"
 	"encoding/json"
-	"errors"
 	"net/http"
 	"police/data"
-
-	"go.mongodb.org/mongo-driver/mongo"
 )
 
 type MupClient struct {
@@ -39,13 +36,13 @@ func (mc MupClient) GetRegistrationByPlate(ctx context.Context, plates data.Plat
 
 	resp, err := mc.client.Do(req)
 	if err != nil {
-		return data.Registration{}, err
+		return data.Registration{}, handleHttpReqErr(err, req.URL.String(), req.Method, requestTimeout(ctx))
 	}
 
 	defer resp.Body.Close()
 
 	if resp.StatusCode != http.StatusOK {
-		return data.Registration{}, errors.New("unexpected status code: " + resp.Status)
+		return data.Registration{}, handleHttpRespErr(resp)
 	}
 
 	var registration data.Registration
@@ -56,13 +53,14 @@ func (mc MupClient) GetRegistrationByPlate(ctx context.Context, plates data.Plat
 	return registration, nil
 }
 
-func (mc MupClient) CheckDrivingBan(ctx context.Context, jmbg data.JMBGRequest, token string) (*data.DrivingBan, error) {
+// Returns every driving ban of the person in effect right now
+func (mc MupClient) GetActiveDrivingBans(ctx context.Context, jmbg data.JMBGRequest, token string) ([]data.DrivingBan, error) {
 	requestBody, err := json.Marshal(jmbg)
 	if err != nil {
 		return nil, err
 	}
 
-	req, err := http.NewRequestWithContext(ctx, http.MethodGet, mc.address+"/check-persons-driving-ban", bytes.NewBuffer(requestBody))
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, mc.address+"/check-persons-active-driving-bans", bytes.NewBuffer(requestBody))
 	if err != nil {
 		return nil, err
 	}
@@ -72,24 +70,20 @@ func (mc MupClient) CheckDrivingBan(ctx context.Context, jmbg data.JMBGRequest,
 
 	resp, err := mc.client.Do(req)
 	if err != nil {
-		return nil, err
+		return nil, handleHttpReqErr(err, req.URL.String(), req.Method, requestTimeout(ctx))
 	}
 	defer resp.Body.Close()
 
 	if resp.StatusCode != http.StatusOK {
-		return nil, errors.New("unexpected status code: " + resp.Status)
+		return nil, handleHttpRespErr(resp)
 	}
 
-	var drivingBan data.DrivingBan
-	err = json.NewDecoder(resp.Body).Decode(&drivingBan)
-	if err != nil {
-		if err == mongo.ErrNoDocuments {
-			return nil, nil
-		}
+	var drivingBans []data.DrivingBan
+	if err := json.NewDecoder(resp.Body).Decode(&drivingBans); err != nil {
 		return nil, err
 	}
 
-	return &drivingBan, nil
+	return drivingBans, nil
 }
 
 func (mc MupClient) GetDrivingPermitByJMBG(ctx context.Context, jmbg data.JMBGRequest, token string) (data.TrafficPermit, error) {
@@ -110,12 +104,12 @@ func (mc MupClient) GetDrivingPermitByJMBG(ctx context.Context, jmbg data.JMBGRe
 
 	resp, err := mc.client.Do(req)
 	if err != nil {
-		return permit, err
+		return permit, handleHttpReqErr(err, req.URL.String(), req.Method, requestTimeout(ctx))
 	}
 	defer resp.Body.Close()
 
 	if resp.StatusCode != http.StatusOK {
-		return permit, errors.New("unexpected status code: " + resp.Status)
+		return permit, handleHttpRespErr(resp)
 	}
 
 	if err := json.NewDecoder(resp.Body).Decode(&permit); err != nil {
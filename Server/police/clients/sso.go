@@ -6,7 +6,6 @@ import (
 	"fmt"
 	"net/http"
 	"police/data"
-	"police/domain"
 	"time"
 )
 
@@ -45,11 +44,7 @@ func (sc *SSOClient) GetPersonByJMBG(ctx context.Context, jmbg, token string) (d
 	}
 
 	if resp.StatusCode != http.StatusOK {
-		return data.Person{}, domain.ErrResp{
-			URL:        resp.Request.URL.String(),
-			Method:     resp.Request.Method,
-			StatusCode: resp.StatusCode,
-		}
+		return data.Person{}, handleHttpRespErr(resp)
 	}
 
 	var person data.Person
@@ -1,11 +1,21 @@
 package clients
 
 import (
+	"context"
+	"errors"
+	"net/http"
 	"net/url"
 	"police/domain"
 	"time"
 )
 
+const (
+	// Pause before the first retry of a call, doubled for every one after it
+	retryBackoff = 200 * time.Millisecond
+	// Least time an attempt should have left before the deadline for a retry to be made
+	minRetryTime = 500 * time.Millisecond
+)
+
 func handleHttpReqErr(err error, reqUrl string, method string, timeout time.Duration) error {
 	urlErr, ok := err.(*url.Error)
 	if !ok {
@@ -24,3 +34,64 @@ func handleHttpReqErr(err error, reqUrl string, method string, timeout time.Dura
 		Err: urlErr,
 	}
 }
+
+// Error for the status the service answered with. Statuses of a service that is
+// overloaded or restarting are temporary, the same request may succeed later
+func handleHttpRespErr(resp *http.Response) error {
+	switch resp.StatusCode {
+	case http.StatusRequestTimeout, http.StatusTooManyRequests, http.StatusBadGateway, http.StatusServiceUnavailable, http.StatusGatewayTimeout:
+		return domain.ErrRespTmp{
+			URL:        resp.Request.URL.String(),
+			Method:     resp.Request.Method,
+			StatusCode: resp.StatusCode,
+		}
+	}
+	return domain.ErrResp{
+		URL:        resp.Request.URL.String(),
+		Method:     resp.Request.Method,
+		StatusCode: resp.StatusCode,
+	}
+}
+
+// Time left until the deadline of the request, zero if it has none
+func requestTimeout(ctx context.Context) time.Duration {
+	deadline, ok := ctx.Deadline()
+	if !ok {
+		return 0
+	}
+	return time.Until(deadline)
+}
+
+// Whether the call failed in a way that may not happen again, so it's worth retrying
+func IsTemporary(err error) bool {
+	var connErr domain.ErrConnecting
+	return errors.Is(err, domain.ErrRespTmp{}) || errors.As(err, &connErr)
+}
+
+// Calls fn with the timeout for every attempt and calls it again, at most attempts
+// times in all, while it fails with a temporary error. The deadline of ctx bounds the
+// calls with their retries together, an attempt never runs past it and no retry is
+// made that wouldn't have minRetryTime left after the pause before it
+func CallWithRetry(ctx context.Context, timeout time.Duration, attempts int, fn func(ctx context.Context) error) error {
+	var err error
+	backoff := retryBackoff
+	for attempt := 1; ; attempt++ {
+		callCtx, cancel := context.WithTimeout(ctx, timeout)
+		err = fn(callCtx)
+		cancel()
+
+		if err == nil || attempt >= attempts || !IsTemporary(err) {
+			return err
+		}
+		if deadline, ok := ctx.Deadline(); ok && time.Until(deadline) < backoff+minRetryTime {
+			return err
+		}
+
+		select {
+		case <-ctx.Done():
+			return err
+		case <-time.After(backoff):
+		}
+		backoff *= 2
+	}
+}
@@ -0,0 +1,287 @@
+package data
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"strings"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// Road conditions at the time of the accident
+const (
+	RoadDry   = "DRY"
+	RoadWet   = "WET"
+	RoadSnow  = "SNOW"
+	RoadIce   = "ICE"
+	RoadMud   = "MUD"
+	RoadOther = "OTHER"
+)
+
+// Roles of people involved in an accident
+const (
+	RoleDriver     = "DRIVER"
+	RolePassenger  = "PASSENGER"
+	RolePedestrian = "PEDESTRIAN"
+)
+
+// Injuries of people involved in an accident, from the lightest
+const (
+	InjuryNone    = "NONE"
+	InjuryMinor   = "MINOR"
+	InjurySerious = "SERIOUS"
+	InjuryFatal   = "FATAL"
+)
+
+// Severity of an accident, set by the worst injury in it
+const (
+	SeverityDamageOnly = "DAMAGE_ONLY"
+	SeverityMinor      = "MINOR_INJURY"
+	SeveritySerious    = "SERIOUS_INJURY"
+	SeverityFatal      = "FATAL"
+)
+
+var (
+	ErrAccidentNotFound        = errors.New("accident not found")
+	ErrAccidentLocationMissing = errors.New("accident needs a location")
+	ErrInvalidRoadCondition    = errors.New("road condition must be DRY, WET, SNOW, ICE, MUD or OTHER")
+	ErrAccidentVehiclesMissing = errors.New("accident needs at least one vehicle with its plates")
+	ErrAccidentVehicleTwice    = errors.New("vehicle is listed in the accident more than once")
+	ErrAccidentPersonMissing   = errors.New("people involved in the accident need their JMBG")
+	ErrAccidentPersonTwice     = errors.New("person is listed in the accident more than once")
+	ErrInvalidAccidentRole     = errors.New("role must be DRIVER, PASSENGER or PEDESTRIAN")
+	ErrInvalidInjury           = errors.New("injury must be NONE, MINOR, SERIOUS or FATAL")
+	ErrAccidentPersonVehicle   = errors.New("drivers and passengers need the plates of a vehicle in the accident, pedestrians none")
+	ErrAccidentDriverTwice     = errors.New("vehicle has more than one driver")
+	ErrResponsibleNotInvolved  = errors.New("responsible party must be one of the people involved")
+	ErrAccidentViolator        = errors.New("violations of an accident need the JMBG of an involved person and at least one offence")
+)
+
+var injuryRanks = map[string]int{InjuryNone: 0, InjuryMinor: 1, InjurySerious: 2, InjuryFatal: 3}
+
+var injurySeverities = map[string]string{
+	InjuryNone:    SeverityDamageOnly,
+	InjuryMinor:   SeverityMinor,
+	InjurySerious: SeveritySerious,
+	InjuryFatal:   SeverityFatal,
+}
+
+// Report of a traffic accident. Violations found to have caused it are recorded with
+// the accident and linked both ways
+type Accident struct {
+	ID            primitive.ObjectID `bson:"_id" json:"id"`
+	Time          time.Time          `bson:"time" json:"time"`
+	Location      string             `bson:"location" json:"location"`
+	Place         `bson:",inline"`
+	Municipality  string               `bson:"municipality,omitempty" json:"municipality,omitempty"`
+	RoadCondition string               `bson:"roadCondition" json:"roadCondition"`
+	Vehicles      []AccidentVehicle    `bson:"vehicles" json:"vehicles"`
+	Persons       []AccidentPerson     `bson:"persons" json:"persons"`
+	Severity      string               `bson:"severity" json:"severity"`
+	Damage        string               `bson:"damage" json:"damage"`
+	Description   string               `bson:"description,omitempty" json:"description,omitempty"`
+	Responsible   string               `bson:"responsible,omitempty" json:"responsible,omitempty"`
+	Violations    []primitive.ObjectID `bson:"violations" json:"violations"`
+	RecordedBy    Stamp                `bson:"recordedBy" json:"recordedBy"`
+	RecordedAt    time.Time            `bson:"recordedAt" json:"recordedAt"`
+}
+
+type Accidents []Accident
+
+type AccidentVehicle struct {
+	Plates      string `bson:"plates" json:"plates"`
+	VehicleType string `bson:"vehicleType,omitempty" json:"vehicleType,omitempty"`
+}
+
+// Person involved in the accident. Vehicle is the plates of the vehicle a driver or
+// passenger was in
+type AccidentPerson struct {
+	JMBG    string `bson:"jmbg" json:"jmbg"`
+	Role    string `bson:"role" json:"role"`
+	Injury  string `bson:"injury" json:"injury"`
+	Vehicle string `bson:"vehicle,omitempty" json:"vehicle,omitempty"`
+}
+
+// What the officer reports an accident with. Time is when it happened, now if empty.
+// Municipality is worked out from the position when left out. Responsible is the JMBG
+// of the party found responsible, if known
+type NewAccident struct {
+	Time          time.Time           `json:"time"`
+	Location      string              `json:"location"`
+	Municipality  string              `json:"municipality"`
+	RoadCondition string              `json:"roadCondition"`
+	Vehicles      []AccidentVehicle   `json:"vehicles"`
+	Persons       []AccidentPerson    `json:"persons"`
+	Damage        string              `json:"damage"`
+	Description   string              `json:"description"`
+	Responsible   string              `json:"responsible"`
+	Violations    []AccidentViolation `json:"violations"`
+	Place
+}
+
+// Violation of a person involved in the accident
+type AccidentViolation struct {
+	JMBG        string       `json:"jmbg"`
+	Offences    OffenceItems `json:"offences"`
+	Description string       `json:"description"`
+}
+
+type AccidentViolations []AccidentViolation
+
+// Accidents of a month in a municipality with the same severity
+type AccidentCount struct {
+	Month        string `bson:"month" json:"month"`
+	Municipality string `bson:"municipality" json:"municipality"`
+	Severity     string `bson:"severity" json:"severity"`
+	Count        int    `bson:"count" json:"count"`
+}
+
+type AccidentCounts []AccidentCount
+
+// Accident with the violations recorded for it
+type AccidentReport struct {
+	Accident   Accident           `json:"accident"`
+	Violations []TrafficViolation `json:"violations"`
+}
+
+func ValidRoadCondition(condition string) bool {
+	switch condition {
+	case RoadDry, RoadWet, RoadSnow, RoadIce, RoadMud, RoadOther:
+		return true
+	}
+	return false
+}
+
+func ValidAccidentRole(role string) bool {
+	return role == RoleDriver || role == RolePassenger || role == RolePedestrian
+}
+
+func ValidInjury(injury string) bool {
+	_, ok := injuryRanks[injury]
+	return ok
+}
+
+// Validates the report, normalizing plates and filling in missing injuries as none
+func (na *NewAccident) Validate() error {
+	na.Location = strings.TrimSpace(na.Location)
+	if na.Location == "" {
+		return ErrAccidentLocationMissing
+	}
+	if !ValidRoadCondition(na.RoadCondition) {
+		return ErrInvalidRoadCondition
+	}
+	if err := na.Place.Validate(); err != nil {
+		return err
+	}
+
+	if len(na.Vehicles) == 0 {
+		return ErrAccidentVehiclesMissing
+	}
+	vehicles := map[string]bool{}
+	for i := range na.Vehicles {
+		vehicle := &na.Vehicles[i]
+		vehicle.Plates = NormalizePlate(vehicle.Plates)
+		if vehicle.Plates == "" {
+			return ErrAccidentVehiclesMissing
+		}
+		if vehicles[vehicle.Plates] {
+			return ErrAccidentVehicleTwice
+		}
+		if vehicle.VehicleType != "" && !ValidVehicleType(vehicle.VehicleType) {
+			return ErrInvalidVehicleType
+		}
+		vehicles[vehicle.Plates] = true
+	}
+
+	persons := map[string]bool{}
+	drivers := map[string]bool{}
+	for i := range na.Persons {
+		person := &na.Persons[i]
+		person.JMBG = strings.TrimSpace(person.JMBG)
+		person.Vehicle = NormalizePlate(person.Vehicle)
+		if person.Injury == "" {
+			person.Injury = InjuryNone
+		}
+
+		switch {
+		case person.JMBG == "":
+			return ErrAccidentPersonMissing
+		case persons[person.JMBG]:
+			return ErrAccidentPersonTwice
+		case !ValidAccidentRole(person.Role):
+			return ErrInvalidAccidentRole
+		case !ValidInjury(person.Injury):
+			return ErrInvalidInjury
+		case person.Role == RolePedestrian && person.Vehicle != "",
+			person.Role != RolePedestrian && !vehicles[person.Vehicle]:
+			return ErrAccidentPersonVehicle
+		case person.Role == RoleDriver && drivers[person.Vehicle]:
+			return ErrAccidentDriverTwice
+		}
+
+		persons[person.JMBG] = true
+		if person.Role == RoleDriver {
+			drivers[person.Vehicle] = true
+		}
+	}
+
+	na.Responsible = strings.TrimSpace(na.Responsible)
+	if na.Responsible != "" && !persons[na.Responsible] {
+		return ErrResponsibleNotInvolved
+	}
+
+	return nil
+}
+
+// Severity of the accident by the worst injury of the people involved in it
+func AccidentSeverity(persons []AccidentPerson) string {
+	worst := InjuryNone
+	for _, person := range persons {
+		if injuryRanks[person.Injury] > injuryRanks[worst] {
+			worst = person.Injury
+		}
+	}
+	return injurySeverities[worst]
+}
+
+// Whether the person is one of the people involved in the accident
+func (a *Accident) Involves(jmbg string) bool {
+	for _, person := range a.Persons {
+		if person.JMBG == jmbg {
+			return true
+		}
+	}
+	return false
+}
+
+func (a *Accident) ToJSON(w io.Writer) error {
+	e := json.NewEncoder(w)
+	return e.Encode(a)
+}
+
+func (a *Accidents) ToJSON(w io.Writer) error {
+	e := json.NewEncoder(w)
+	return e.Encode(a)
+}
+
+func (na *NewAccident) FromJSON(r io.Reader) error {
+	d := json.NewDecoder(r)
+	return d.Decode(na)
+}
+
+func (av *AccidentViolations) FromJSON(r io.Reader) error {
+	d := json.NewDecoder(r)
+	return d.Decode(av)
+}
+
+func (ac *AccidentCounts) ToJSON(w io.Writer) error {
+	e := json.NewEncoder(w)
+	return e.Encode(ac)
+}
+
+func (ar *AccidentReport) ToJSON(w io.Writer) error {
+	e := json.NewEncoder(w)
+	return e.Encode(ar)
+}
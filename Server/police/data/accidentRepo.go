@@ -0,0 +1,125 @@
+package data
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+//Accident methods
+
+func (pr *PoliceRepo) CreateAccident(ctx context.Context, accident *Accident) error {
+	_, err := pr.getPoliceCollection("accidents").InsertOne(ctx, accident)
+	return err
+}
+
+// Removes the accident, when the violations it was reported with couldn't be saved
+func (pr *PoliceRepo) DeleteAccident(ctx context.Context, id primitive.ObjectID) error {
+	_, err := pr.getPoliceCollection("accidents").DeleteOne(ctx, bson.D{{Key: "_id", Value: id}})
+	return err
+}
+
+func (pr *PoliceRepo) GetAccident(ctx context.Context, id primitive.ObjectID) (Accident, error) {
+	var accident Accident
+	err := pr.getPoliceCollection("accidents").FindOne(ctx, bson.D{{Key: "_id", Value: id}}).Decode(&accident)
+	if err != nil {
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			return Accident{}, ErrAccidentNotFound
+		}
+		return Accident{}, err
+	}
+
+	return accident, nil
+}
+
+// Returns accidents in the municipality and of the severity given, or all of them, latest first
+func (pr *PoliceRepo) GetAccidents(ctx context.Context, municipality, severity string) (Accidents, error) {
+	filter := bson.D{}
+	if municipality != "" {
+		filter = append(filter, bson.E{Key: "municipality", Value: municipality})
+	}
+	if severity != "" {
+		filter = append(filter, bson.E{Key: "severity", Value: severity})
+	}
+
+	opts := options.Find().SetSort(bson.D{{Key: "time", Value: -1}})
+	cursor, err := pr.getPoliceCollection("accidents").Find(ctx, filter, opts)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	accidents := Accidents{}
+	if err = cursor.All(ctx, &accidents); err != nil {
+		return nil, err
+	}
+
+	return accidents, nil
+}
+
+// Links the violations to the accident
+func (pr *PoliceRepo) AddAccidentViolations(ctx context.Context, id primitive.ObjectID, violations []primitive.ObjectID) (Accident, error) {
+	update := bson.D{{Key: "$push", Value: bson.D{{Key: "violations", Value: bson.D{{Key: "$each", Value: violations}}}}}}
+	opts := options.FindOneAndUpdate().SetReturnDocument(options.After)
+
+	var accident Accident
+	err := pr.getPoliceCollection("accidents").FindOneAndUpdate(ctx, bson.D{{Key: "_id", Value: id}}, update, opts).Decode(&accident)
+	if err != nil {
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			return Accident{}, ErrAccidentNotFound
+		}
+		return Accident{}, err
+	}
+
+	return accident, nil
+}
+
+// Unlinks the violations from the accident, when they couldn't be saved
+func (pr *PoliceRepo) RemoveAccidentViolations(ctx context.Context, id primitive.ObjectID, violations []primitive.ObjectID) error {
+	update := bson.D{{Key: "$pull", Value: bson.D{{Key: "violations", Value: bson.D{{Key: "$in", Value: violations}}}}}}
+
+	_, err := pr.getPoliceCollection("accidents").UpdateOne(ctx, bson.D{{Key: "_id", Value: id}}, update)
+	return err
+}
+
+// Counts accidents that happened in the period by month, municipality and severity.
+// Months are in UTC
+func (pr *PoliceRepo) CountAccidents(ctx context.Context, from, to time.Time) (AccidentCounts, error) {
+	pipeline := mongo.Pipeline{
+		{{Key: "$match", Value: bson.D{{Key: "time", Value: bson.D{{Key: "$gte", Value: from}, {Key: "$lt", Value: to}}}}}},
+		{{Key: "$group", Value: bson.D{
+			{Key: "_id", Value: bson.D{
+				{Key: "month", Value: bson.D{{Key: "$dateToString", Value: bson.D{{Key: "format", Value: "%Y-%m"}, {Key: "date", Value: "$time"}}}}},
+				{Key: "municipality", Value: bson.D{{Key: "$ifNull", Value: bson.A{"$municipality", ""}}}},
+				{Key: "severity", Value: "$severity"},
+			}},
+			{Key: "count", Value: bson.D{{Key: "$sum", Value: 1}}},
+		}}},
+		{{Key: "$project", Value: bson.D{
+			{Key: "_id", Value: 0},
+			{Key: "month", Value: "$_id.month"},
+			{Key: "municipality", Value: "$_id.municipality"},
+			{Key: "severity", Value: "$_id.severity"},
+			{Key: "count", Value: 1},
+		}}},
+		{{Key: "$sort", Value: bson.D{{Key: "month", Value: 1}, {Key: "municipality", Value: 1}, {Key: "severity", Value: 1}}}},
+	}
+
+	cursor, err := pr.getPoliceCollection("accidents").Aggregate(ctx, pipeline)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	counts := AccidentCounts{}
+	if err = cursor.All(ctx, &counts); err != nil {
+		return nil, err
+	}
+
+	return counts, nil
+}
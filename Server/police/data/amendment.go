@@ -0,0 +1,180 @@
+package data
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"reflect"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// States of a violation besides the one it is recorded in, which is left empty
+const (
+	ViolationVoidRequested = "VOID_REQUESTED"
+	ViolationVoided        = "VOIDED"
+)
+
+// Kinds of entries in the history of a violation
+const (
+	AmendmentChanged       = "CHANGED"
+	AmendmentVoidRequested = "VOID_REQUESTED"
+	AmendmentVoidRejected  = "VOID_REJECTED"
+	AmendmentVoided        = "VOIDED"
+)
+
+// Fields of a violation that can be amended, by their JSON names
+const (
+	FieldViolator    = "violatorJMBG"
+	FieldReason      = "reason"
+	FieldDescription = "description"
+	FieldTime        = "time"
+	FieldLocation    = "location"
+	FieldOffences    = "offences"
+	FieldPosition    = "position"
+	FieldRoad        = "road"
+)
+
+var (
+	ErrViolationNotFound    = errors.New("traffic violation not found")
+	ErrViolationVoided      = errors.New("traffic violation is voided")
+	ErrViolationVoidPending = errors.New("traffic violation is waiting for its voiding to be approved")
+	ErrNoVoidRequest        = errors.New("voiding of the traffic violation wasn't requested")
+	ErrViolationChanged     = errors.New("traffic violation was changed in the meantime, read it again")
+	ErrAmendmentReason      = errors.New("changes to a traffic violation need a reason")
+	ErrAmendmentEmpty       = errors.New("amendment doesn't change the traffic violation")
+	ErrOwnVoidRequest       = errors.New("voiding must be approved by a supervisor other than the officer who requested it")
+	ErrViolationFined       = errors.New("violator and offences of a fined traffic violation can't be changed, void it and record it again")
+	ErrViolationInCourt     = errors.New("fine of the traffic violation is before the court, it can only be voided there")
+)
+
+// Officer who changed a violation or approved the change
+type OfficerRef struct {
+	Officer     primitive.ObjectID `bson:"officer" json:"officer"`
+	BadgeNumber string             `bson:"badgeNumber" json:"badgeNumber"`
+}
+
+// Voiding of a violation, requested by an officer and approved by a supervisor
+type VoidRequest struct {
+	Reason      string      `bson:"reason" json:"reason"`
+	RequestedBy OfficerRef  `bson:"requestedBy" json:"requestedBy"`
+	RequestedAt time.Time   `bson:"requestedAt" json:"requestedAt"`
+	ApprovedBy  *OfficerRef `bson:"approvedBy,omitempty" json:"approvedBy,omitempty"`
+	ApprovedAt  *time.Time  `bson:"approvedAt,omitempty" json:"approvedAt,omitempty"`
+}
+
+// Values of the fields an amendment changed. Fields left empty before or after the
+// change are left out, Fields of the amendment lists every field it changed
+type ViolationFields struct {
+	ViolatorJMBG string         `bson:"violatorJMBG,omitempty" json:"violatorJMBG,omitempty"`
+	Reason       string         `bson:"reason,omitempty" json:"reason,omitempty"`
+	Description  string         `bson:"description,omitempty" json:"description,omitempty"`
+	Time         *time.Time     `bson:"time,omitempty" json:"time,omitempty"`
+	Location     string         `bson:"location,omitempty" json:"location,omitempty"`
+	Offences     OffenceItems   `bson:"offences,omitempty" json:"offences,omitempty"`
+	Position     *GeoPoint      `bson:"position,omitempty" json:"position,omitempty"`
+	Road         *RoadReference `bson:"road,omitempty" json:"road,omitempty"`
+}
+
+// Entry in the history of a violation, kept in the violation itself. Entries are only
+// ever added, Version is the version of the violation the entry made
+type ViolationAmendment struct {
+	ID        primitive.ObjectID `bson:"_id" json:"id"`
+	Violation primitive.ObjectID `bson:"violation" json:"violation"`
+	Version   int                `bson:"version" json:"version"`
+	Kind      string             `bson:"kind" json:"kind"`
+	Fields    []string           `bson:"fields,omitempty" json:"fields,omitempty"`
+	Old       *ViolationFields   `bson:"old,omitempty" json:"old,omitempty"`
+	New       *ViolationFields   `bson:"new,omitempty" json:"new,omitempty"`
+	Reason    string             `bson:"reason" json:"reason"`
+	AmendedBy OfficerRef         `bson:"amendedBy" json:"amendedBy"`
+	AmendedAt time.Time          `bson:"amendedAt" json:"amendedAt"`
+}
+
+type ViolationAmendments []ViolationAmendment
+
+// Changes the officer makes to a violation and why. Fields left out of the changes are kept
+type AmendmentRequest struct {
+	Changes TrafficViolation `json:"changes"`
+	Reason  string           `json:"reason"`
+}
+
+// Why a voiding is requested or rejected
+type VoidDecision struct {
+	Reason string `json:"reason"`
+}
+
+// Violation as it is now with every change made to it, oldest first
+type ViolationHistory struct {
+	Violation  TrafficViolation    `json:"violation"`
+	Amendments ViolationAmendments `json:"amendments"`
+}
+
+// Compares the amendable fields of the violation before and after the change and
+// returns the ones that changed with their values
+func DiffViolation(before, after TrafficViolation) ([]string, ViolationFields, ViolationFields) {
+	fields := []string{}
+	var old, changed ViolationFields
+
+	if before.ViolatorJMBG != after.ViolatorJMBG {
+		fields = append(fields, FieldViolator)
+		old.ViolatorJMBG, changed.ViolatorJMBG = before.ViolatorJMBG, after.ViolatorJMBG
+	}
+	if before.Reason != after.Reason {
+		fields = append(fields, FieldReason)
+		old.Reason, changed.Reason = before.Reason, after.Reason
+	}
+	if before.Description != after.Description {
+		fields = append(fields, FieldDescription)
+		old.Description, changed.Description = before.Description, after.Description
+	}
+	if !before.Time.Equal(after.Time) {
+		fields = append(fields, FieldTime)
+		old.Time, changed.Time = &before.Time, &after.Time
+	}
+	if before.Location != after.Location {
+		fields = append(fields, FieldLocation)
+		old.Location, changed.Location = before.Location, after.Location
+	}
+	if !reflect.DeepEqual(before.Offences, after.Offences) {
+		fields = append(fields, FieldOffences)
+		old.Offences, changed.Offences = before.Offences, after.Offences
+	}
+	if !reflect.DeepEqual(before.Position, after.Position) {
+		fields = append(fields, FieldPosition)
+		old.Position, changed.Position = before.Position, after.Position
+	}
+	if !reflect.DeepEqual(before.Road, after.Road) {
+		fields = append(fields, FieldRoad)
+		old.Road, changed.Road = before.Road, after.Road
+	}
+
+	return fields, old, changed
+}
+
+// Error for a violation that can't be changed in the state it is in
+func ViolationStateError(status string) error {
+	switch status {
+	case ViolationVoided:
+		return ErrViolationVoided
+	case ViolationVoidRequested:
+		return ErrViolationVoidPending
+	}
+	return nil
+}
+
+func (ar *AmendmentRequest) FromJSON(r io.Reader) error {
+	d := json.NewDecoder(r)
+	return d.Decode(ar)
+}
+
+func (vd *VoidDecision) FromJSON(r io.Reader) error {
+	d := json.NewDecoder(r)
+	return d.Decode(vd)
+}
+
+func (vh *ViolationHistory) ToJSON(w io.Writer) error {
+	e := json.NewEncoder(w)
+	return e.Encode(vh)
+}
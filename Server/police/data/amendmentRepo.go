@@ -0,0 +1,89 @@
+package data
+
+import (
+	"context"
+	"errors"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+//Amendment methods
+
+// Moves the violation from the state given and the version before the amendment to the
+// version of the amendment with the update, adding the amendment to its history in the
+// same write. Fails when the violation was changed since that version was read
+func (pr *PoliceRepo) AmendTrafficViolation(ctx context.Context, amendment ViolationAmendment, status string, update bson.D) (TrafficViolation, error) {
+	id, version := amendment.Violation, amendment.Version-1
+	filter := bson.D{
+		{Key: "_id", Value: id},
+		{Key: "version", Value: valueOrMissing(version, version == 0)},
+		{Key: "status", Value: valueOrMissing(status, status == "")},
+	}
+	update = append(update,
+		bson.E{Key: "$inc", Value: bson.D{{Key: "version", Value: 1}}},
+		bson.E{Key: "$push", Value: bson.D{{Key: "history", Value: amendment}}},
+	)
+	opts := options.FindOneAndUpdate().SetReturnDocument(options.After)
+
+	var violation TrafficViolation
+	err := pr.getPoliceCollection("traffic_violations").FindOneAndUpdate(ctx, filter, update, opts).Decode(&violation)
+	if err != nil {
+		if !errors.Is(err, mongo.ErrNoDocuments) {
+			return TrafficViolation{}, err
+		}
+		if _, err := pr.GetTrafficViolationByID(ctx, id); err != nil {
+			if errors.Is(err, mongo.ErrNoDocuments) {
+				return TrafficViolation{}, ErrViolationNotFound
+			}
+			return TrafficViolation{}, err
+		}
+		return TrafficViolation{}, ErrViolationChanged
+	}
+
+	return violation, nil
+}
+
+// Fields left empty are left out of violations, so they are matched by not existing
+func valueOrMissing(value interface{}, empty bool) interface{} {
+	if empty {
+		return bson.D{{Key: "$exists", Value: false}}
+	}
+	return value
+}
+
+// Moves entries kept in the history collection used before onto their violations and
+// removes the collection. Entries of changes the violation never got are left out and
+// entries already moved are skipped, so it is safe to run on every start
+func (pr *PoliceRepo) MigrateViolationAmendments(ctx context.Context) (int, error) {
+	collection := pr.getPoliceCollection("violation_amendments")
+	opts := options.Find().SetSort(bson.D{{Key: "violation", Value: 1}, {Key: "version", Value: 1}})
+	cursor, err := collection.Find(ctx, bson.D{}, opts)
+	if err != nil {
+		return 0, err
+	}
+
+	amendments := ViolationAmendments{}
+	if err = cursor.All(ctx, &amendments); err != nil {
+		return 0, err
+	}
+
+	migrated := 0
+	for _, amendment := range amendments {
+		filter := bson.D{
+			{Key: "_id", Value: amendment.Violation},
+			{Key: "version", Value: bson.D{{Key: "$gte", Value: amendment.Version}}},
+			{Key: "history._id", Value: bson.D{{Key: "$ne", Value: amendment.ID}}},
+		}
+		update := bson.D{{Key: "$push", Value: bson.D{{Key: "history", Value: amendment}}}}
+
+		result, err := pr.getPoliceCollection("traffic_violations").UpdateOne(ctx, filter, update)
+		if err != nil {
+			return migrated, err
+		}
+		migrated += int(result.ModifiedCount)
+	}
+
+	return migrated, collection.Drop(ctx)
+}
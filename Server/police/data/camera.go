@@ -0,0 +1,349 @@
+package data
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// Types of events cameras report
+const (
+	CameraEventSpeed    = "SPEED"
+	CameraEventRedLight = "RED_LIGHT"
+)
+
+// States of an event in the review queue
+const (
+	ReviewPending   = "PENDING"
+	ReviewResolved  = "RESOLVED"
+	ReviewDismissed = "DISMISSED"
+)
+
+// Reasons events are sent to the review queue instead of becoming violations
+const (
+	ReviewInvalidEvent  = "INVALID_EVENT"
+	ReviewUnknownCamera = "UNKNOWN_CAMERA"
+	ReviewEventTooOld   = "EVENT_TOO_OLD"
+	ReviewUnknownPlate  = "UNKNOWN_PLATE"
+	ReviewLookupFailed  = "LOOKUP_FAILED"
+)
+
+// Tolerance deducted from the measured speed for the error of the device: 3 km/h up to
+// 100 km/h and 3% above it. Only the speed left over the limit is a violation
+const (
+	SpeedToleranceKmh     = 3.0
+	SpeedTolerancePercent = 3.0
+)
+
+// Events of the same plate on the same camera within the window are one pass of the
+// vehicle, recorded once as the earliest of them
+const CameraBurstWindow = 2 * time.Minute
+
+// Oldest event an offline batch may bring in, older ones are reviewed by hand
+const CameraEventMaxAge = 30 * 24 * time.Hour
+
+// Columns of the CSV format, in this order. The type column may be left out, in which
+// case the events are SPEED events
+var CameraCSVColumns = []string{"camera_id", "timestamp", "plate", "measured_speed", "speed_limit", "type"}
+
+var (
+	ErrCameraIDMissing      = errors.New("camera ID is required")
+	ErrCameraNotFound       = errors.New("camera not found")
+	ErrCameraLocation       = errors.New("camera needs a location")
+	ErrCameraReviewNotFound = errors.New("event in review not found")
+	ErrCameraReviewClosed   = errors.New("event in review is already resolved or dismissed")
+	ErrInvalidCameraType    = errors.New("camera event type must be SPEED or RED_LIGHT")
+	ErrCameraEventTime      = errors.New("camera event needs a timestamp in RFC3339")
+	ErrCameraEventPlate     = errors.New("camera event needs a plate")
+	ErrCameraEventSpeed     = errors.New("speed events need a positive measured speed and limit")
+	ErrCameraCSVHeader      = errors.New("CSV header must be camera_id,timestamp,plate,measured_speed,speed_limit[,type]")
+	ErrCameraEventInFuture  = errors.New("camera event is in the future")
+)
+
+// Fixed camera the service takes events from. Violations are recorded at its location
+type Camera struct {
+	ID       string `bson:"_id" json:"id"`
+	Location string `bson:"location" json:"location"`
+	Active   bool   `bson:"active" json:"active"`
+	Place    `bson:",inline"`
+	SavedAt  time.Time `bson:"savedAt" json:"savedAt"`
+	SavedBy  string    `bson:"savedBy" json:"savedBy"`
+}
+
+type Cameras []Camera
+
+// Event a camera reports. Speeds are in km/h, a red light event has none. Frame is an
+// optional reference to the picture in the camera system, attached to the violation
+type CameraEvent struct {
+	CameraID      string    `bson:"cameraId" json:"cameraId"`
+	Type          string    `bson:"type" json:"type"`
+	Timestamp     time.Time `bson:"timestamp" json:"timestamp"`
+	Plate         string    `bson:"plate" json:"plate"`
+	MeasuredSpeed float64   `bson:"measuredSpeed,omitempty" json:"measuredSpeed,omitempty"`
+	SpeedLimit    float64   `bson:"speedLimit,omitempty" json:"speedLimit,omitempty"`
+	Frame         string    `bson:"frame,omitempty" json:"frame,omitempty"`
+}
+
+type CameraEvents []CameraEvent
+
+// Pass of a vehicle a violation was recorded for, kept to recognise the same pass
+// coming in again in a burst or a later batch
+type CameraPass struct {
+	ID        primitive.ObjectID `bson:"_id" json:"id"`
+	CameraID  string             `bson:"cameraId" json:"cameraId"`
+	Plate     string             `bson:"plate" json:"plate"`
+	Timestamp time.Time          `bson:"timestamp" json:"timestamp"`
+	Violation primitive.ObjectID `bson:"violation" json:"violation"`
+}
+
+// Event that could not be turned into a violation on its own. Raw is the input of an
+// event that could not be read at all
+type CameraReview struct {
+	ID         primitive.ObjectID `bson:"_id" json:"id"`
+	Event      *CameraEvent       `bson:"event,omitempty" json:"event,omitempty"`
+	Raw        string             `bson:"raw,omitempty" json:"raw,omitempty"`
+	Source     string             `bson:"source" json:"source"`
+	Reason     string             `bson:"reason" json:"reason"`
+	Detail     string             `bson:"detail,omitempty" json:"detail,omitempty"`
+	Status     string             `bson:"status" json:"status"`
+	ReceivedAt time.Time          `bson:"receivedAt" json:"receivedAt"`
+	ResolvedBy string             `bson:"resolvedBy,omitempty" json:"resolvedBy,omitempty"`
+	ResolvedAt *time.Time         `bson:"resolvedAt,omitempty" json:"resolvedAt,omitempty"`
+	Violation  primitive.ObjectID `bson:"violation,omitempty" json:"violation,omitempty"`
+	Note       string             `bson:"note,omitempty" json:"note,omitempty"`
+}
+
+type CameraReviews []CameraReview
+
+// Outcome of ingesting a batch of events
+type CameraIngestResult struct {
+	Received        int      `json:"received"`
+	Violations      int      `json:"violations"`
+	WithinTolerance int      `json:"withinTolerance"`
+	Duplicates      int      `json:"duplicates"`
+	Queued          int      `json:"queued"`
+	ViolationIDs    []string `json:"violationIds"`
+}
+
+// Event of a batch that could not be read, with the input it was read from
+type UnreadableCameraEvent struct {
+	Raw string
+	Err error
+}
+
+func (c *Camera) Validate() error {
+	if strings.TrimSpace(c.ID) == "" {
+		return ErrCameraIDMissing
+	}
+	if strings.TrimSpace(c.Location) == "" {
+		return ErrCameraLocation
+	}
+	return c.Place.Validate()
+}
+
+// Checks the event has what its type needs and normalises the plate and the type
+func (ce *CameraEvent) Validate() error {
+	ce.Plate = NormalizePlate(ce.Plate)
+	ce.Type = strings.ToUpper(strings.TrimSpace(ce.Type))
+	if ce.Type == "" {
+		ce.Type = CameraEventSpeed
+	}
+
+	if strings.TrimSpace(ce.CameraID) == "" {
+		return ErrCameraIDMissing
+	}
+	if ce.Timestamp.IsZero() {
+		return ErrCameraEventTime
+	}
+	if ce.Plate == "" {
+		return ErrCameraEventPlate
+	}
+	switch ce.Type {
+	case CameraEventSpeed:
+		if ce.MeasuredSpeed <= 0 || ce.SpeedLimit <= 0 {
+			return ErrCameraEventSpeed
+		}
+	case CameraEventRedLight:
+	default:
+		return ErrInvalidCameraType
+	}
+	return nil
+}
+
+// Speed the violation is recorded with, the measured speed less the tolerance
+func (ce *CameraEvent) RecordedSpeed() float64 {
+	tolerance := SpeedToleranceKmh
+	if percent := ce.MeasuredSpeed * SpeedTolerancePercent / 100; percent > tolerance {
+		tolerance = percent
+	}
+	return math.Floor(ce.MeasuredSpeed - tolerance)
+}
+
+// Whether the event is a violation once the tolerance is deducted
+func (ce *CameraEvent) IsViolation() bool {
+	if ce.Type == CameraEventRedLight {
+		return true
+	}
+	return ce.RecordedSpeed() > ce.SpeedLimit
+}
+
+// Whether the events are the same pass of a vehicle by a camera
+func (ce *CameraEvent) SamePass(other CameraEvent) bool {
+	if ce.CameraID != other.CameraID || ce.Plate != other.Plate {
+		return false
+	}
+	gap := ce.Timestamp.Sub(other.Timestamp)
+	return gap < CameraBurstWindow && gap > -CameraBurstWindow
+}
+
+// Plates are looked up in upper case, without the space around them
+func NormalizePlate(plate string) string {
+	return strings.ToUpper(strings.TrimSpace(plate))
+}
+
+// Reads a JSON array of events, or a single event. Events that can't be decoded are
+// returned apart, so one bad event doesn't reject the batch
+func ReadCameraEventsJSON(r io.Reader) (CameraEvents, []UnreadableCameraEvent, error) {
+	var raw json.RawMessage
+	if err := json.NewDecoder(r).Decode(&raw); err != nil {
+		return nil, nil, err
+	}
+
+	items := []json.RawMessage{}
+	if trimmed := strings.TrimSpace(string(raw)); strings.HasPrefix(trimmed, "[") {
+		if err := json.Unmarshal(raw, &items); err != nil {
+			return nil, nil, err
+		}
+	} else {
+		items = append(items, raw)
+	}
+
+	events := CameraEvents{}
+	unreadable := []UnreadableCameraEvent{}
+	for _, item := range items {
+		var event CameraEvent
+		if err := json.Unmarshal(item, &event); err != nil {
+			unreadable = append(unreadable, UnreadableCameraEvent{Raw: string(item), Err: err})
+			continue
+		}
+		events = append(events, event)
+	}
+	return events, unreadable, nil
+}
+
+// Reads events from CSV with the header in CameraCSVColumns. Timestamps are in RFC3339
+// and speeds in km/h. Rows that can't be read are returned apart
+func ReadCameraEventsCSV(r io.Reader) (CameraEvents, []UnreadableCameraEvent, error) {
+	reader := csv.NewReader(r)
+	reader.FieldsPerRecord = -1
+	reader.TrimLeadingSpace = true
+
+	header, err := reader.Read()
+	if err != nil {
+		return nil, nil, err
+	}
+	if len(header) < len(CameraCSVColumns)-1 || len(header) > len(CameraCSVColumns) {
+		return nil, nil, ErrCameraCSVHeader
+	}
+	for i, column := range header {
+		if strings.ToLower(strings.TrimSpace(column)) != CameraCSVColumns[i] {
+			return nil, nil, ErrCameraCSVHeader
+		}
+	}
+
+	events := CameraEvents{}
+	unreadable := []UnreadableCameraEvent{}
+	for {
+		record, err := reader.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			var parseErr *csv.ParseError
+			if errors.As(err, &parseErr) {
+				unreadable = append(unreadable, UnreadableCameraEvent{Raw: fmt.Sprintf("line %d", parseErr.StartLine), Err: err})
+				continue
+			}
+			return nil, nil, err
+		}
+
+		event, err := cameraEventFromRecord(record, len(header))
+		if err != nil {
+			unreadable = append(unreadable, UnreadableCameraEvent{Raw: strings.Join(record, ","), Err: err})
+			continue
+		}
+		events = append(events, event)
+	}
+	return events, unreadable, nil
+}
+
+func cameraEventFromRecord(record []string, columns int) (CameraEvent, error) {
+	if len(record) != columns {
+		return CameraEvent{}, fmt.Errorf("row has %d columns, header has %d", len(record), columns)
+	}
+
+	timestamp, err := time.Parse(time.RFC3339, strings.TrimSpace(record[1]))
+	if err != nil {
+		return CameraEvent{}, ErrCameraEventTime
+	}
+
+	event := CameraEvent{
+		CameraID:  strings.TrimSpace(record[0]),
+		Timestamp: timestamp,
+		Plate:     record[2],
+	}
+	if columns == len(CameraCSVColumns) {
+		event.Type = record[5]
+	}
+
+	// Red light events leave the speeds empty
+	for i, speed := range []*float64{&event.MeasuredSpeed, &event.SpeedLimit} {
+		value := strings.TrimSpace(record[3+i])
+		if value == "" {
+			continue
+		}
+		*speed, err = strconv.ParseFloat(value, 64)
+		if err != nil {
+			return CameraEvent{}, ErrCameraEventSpeed
+		}
+	}
+	return event, nil
+}
+
+func (c *Camera) ToJSON(w io.Writer) error {
+	e := json.NewEncoder(w)
+	return e.Encode(c)
+}
+
+func (c *Camera) FromJSON(r io.Reader) error {
+	d := json.NewDecoder(r)
+	return d.Decode(c)
+}
+
+func (c *Cameras) ToJSON(w io.Writer) error {
+	e := json.NewEncoder(w)
+	return e.Encode(c)
+}
+
+func (cr *CameraReview) ToJSON(w io.Writer) error {
+	e := json.NewEncoder(w)
+	return e.Encode(cr)
+}
+
+func (cr *CameraReviews) ToJSON(w io.Writer) error {
+	e := json.NewEncoder(w)
+	return e.Encode(cr)
+}
+
+func (cir *CameraIngestResult) ToJSON(w io.Writer) error {
+	e := json.NewEncoder(w)
+	return e.Encode(cir)
+}
@@ -0,0 +1,217 @@
+package data
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+//Camera methods
+
+// Saves the camera, replacing the one saved before under its ID
+func (pr *PoliceRepo) SaveCamera(ctx context.Context, camera Camera) error {
+	filter := bson.D{{Key: "_id", Value: camera.ID}}
+	opts := options.Replace().SetUpsert(true)
+
+	_, err := pr.getPoliceCollection("cameras").ReplaceOne(ctx, filter, camera, opts)
+	return err
+}
+
+func (pr *PoliceRepo) GetCamera(ctx context.Context, id string) (Camera, error) {
+	var camera Camera
+	err := pr.getPoliceCollection("cameras").FindOne(ctx, bson.D{{Key: "_id", Value: id}}).Decode(&camera)
+	if err != nil {
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			return Camera{}, ErrCameraNotFound
+		}
+		return Camera{}, err
+	}
+
+	return camera, nil
+}
+
+func (pr *PoliceRepo) GetCameras(ctx context.Context) (Cameras, error) {
+	opts := options.Find().SetSort(bson.D{{Key: "_id", Value: 1}})
+	cursor, err := pr.getPoliceCollection("cameras").Find(ctx, bson.D{}, opts)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	cameras := Cameras{}
+	if err = cursor.All(ctx, &cameras); err != nil {
+		return nil, err
+	}
+
+	return cameras, nil
+}
+
+// Whether a violation was already recorded for the pass of the vehicle the event belongs to
+func (pr *PoliceRepo) CameraPassRecorded(ctx context.Context, event CameraEvent) (bool, error) {
+	filter := bson.D{
+		{Key: "cameraId", Value: event.CameraID},
+		{Key: "plate", Value: event.Plate},
+		{Key: "timestamp", Value: bson.D{
+			{Key: "$gt", Value: event.Timestamp.Add(-CameraBurstWindow)},
+			{Key: "$lt", Value: event.Timestamp.Add(CameraBurstWindow)},
+		}},
+	}
+
+	count, err := pr.getPoliceCollection("camera_passes").CountDocuments(ctx, filter, options.Count().SetLimit(1))
+	if err != nil {
+		return false, err
+	}
+	return count > 0, nil
+}
+
+// Saves the violations cameras recorded along with the passes they were recorded for.
+// Passes are saved first and are unique, so a violation whose pass is already recorded,
+// as when a batch is sent again, is left out. Returns the violations saved
+func (pr *PoliceRepo) CreateCameraViolations(ctx context.Context, violations []TrafficViolation, passes []CameraPass) ([]TrafficViolation, error) {
+	if len(violations) == 0 {
+		return violations, nil
+	}
+
+	collection := pr.getPoliceCollection("camera_passes")
+	documents := make([]interface{}, len(passes))
+	for i := range passes {
+		documents[i] = passes[i]
+	}
+
+	recorded := map[int]bool{}
+	_, err := collection.InsertMany(ctx, documents, options.InsertMany().SetOrdered(false))
+	var bulkErr mongo.BulkWriteException
+	switch {
+	case errors.As(err, &bulkErr) && bulkErr.WriteConcernError == nil:
+		for _, writeErr := range bulkErr.WriteErrors {
+			if !mongo.IsDuplicateKeyError(writeErr) {
+				return nil, err
+			}
+			recorded[writeErr.Index] = true
+		}
+	case err != nil:
+		return nil, err
+	}
+
+	saved := []TrafficViolation{}
+	savedPasses := bson.A{}
+	for i := range violations {
+		if !recorded[i] {
+			saved = append(saved, violations[i])
+			savedPasses = append(savedPasses, passes[i].ID)
+		}
+	}
+
+	if err := pr.CreateTrafficViolations(ctx, saved); err != nil {
+		// Frees the passes, so the events can be recorded again
+		filter := bson.D{{Key: "_id", Value: bson.D{{Key: "$in", Value: savedPasses}}}}
+		if _, err := collection.DeleteMany(ctx, filter); err != nil {
+			pr.logger.Printf("Failed to remove camera passes: %v\n", err)
+		}
+		return nil, err
+	}
+
+	return saved, nil
+}
+
+//Camera review methods
+
+func (pr *PoliceRepo) CreateCameraReviews(ctx context.Context, reviews CameraReviews) error {
+	if len(reviews) == 0 {
+		return nil
+	}
+
+	documents := make([]interface{}, len(reviews))
+	for i := range reviews {
+		documents[i] = reviews[i]
+	}
+	_, err := pr.getPoliceCollection("camera_reviews").InsertMany(ctx, documents)
+	return err
+}
+
+// Returns events in review in the state given, or all of them, oldest first
+func (pr *PoliceRepo) GetCameraReviews(ctx context.Context, status string) (CameraReviews, error) {
+	filter := bson.D{}
+	if status != "" {
+		filter = append(filter, bson.E{Key: "status", Value: status})
+	}
+
+	opts := options.Find().SetSort(bson.D{{Key: "receivedAt", Value: 1}})
+	cursor, err := pr.getPoliceCollection("camera_reviews").Find(ctx, filter, opts)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	reviews := CameraReviews{}
+	if err = cursor.All(ctx, &reviews); err != nil {
+		return nil, err
+	}
+
+	return reviews, nil
+}
+
+func (pr *PoliceRepo) GetCameraReview(ctx context.Context, id primitive.ObjectID) (CameraReview, error) {
+	var review CameraReview
+	err := pr.getPoliceCollection("camera_reviews").FindOne(ctx, bson.D{{Key: "_id", Value: id}}).Decode(&review)
+	if err != nil {
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			return CameraReview{}, ErrCameraReviewNotFound
+		}
+		return CameraReview{}, err
+	}
+
+	return review, nil
+}
+
+// Closes the pending event in review as resolved with the violation, or as dismissed
+// when violation is nil
+func (pr *PoliceRepo) CloseCameraReview(ctx context.Context, id primitive.ObjectID, status, by, note string, violation primitive.ObjectID, at time.Time) (CameraReview, error) {
+	set := bson.D{
+		{Key: "status", Value: status},
+		{Key: "resolvedBy", Value: by},
+		{Key: "resolvedAt", Value: at},
+		{Key: "note", Value: note},
+	}
+	if !violation.IsZero() {
+		set = append(set, bson.E{Key: "violation", Value: violation})
+	}
+
+	filter := bson.D{{Key: "_id", Value: id}, {Key: "status", Value: ReviewPending}}
+	opts := options.FindOneAndUpdate().SetReturnDocument(options.After)
+
+	var review CameraReview
+	err := pr.getPoliceCollection("camera_reviews").FindOneAndUpdate(ctx, filter, bson.D{{Key: "$set", Value: set}}, opts).Decode(&review)
+	if err != nil {
+		if !errors.Is(err, mongo.ErrNoDocuments) {
+			return CameraReview{}, err
+		}
+		if _, err := pr.GetCameraReview(ctx, id); err != nil {
+			return CameraReview{}, err
+		}
+		return CameraReview{}, ErrCameraReviewClosed
+	}
+
+	return review, nil
+}
+
+// Puts the event back in review, when the violation it was resolved with couldn't be saved
+func (pr *PoliceRepo) ReopenCameraReview(ctx context.Context, id primitive.ObjectID) error {
+	update := bson.D{
+		{Key: "$set", Value: bson.D{{Key: "status", Value: ReviewPending}}},
+		{Key: "$unset", Value: bson.D{
+			{Key: "resolvedBy", Value: ""},
+			{Key: "resolvedAt", Value: ""},
+			{Key: "violation", Value: ""},
+			{Key: "note", Value: ""},
+		}},
+	}
+
+	_, err := pr.getPoliceCollection("camera_reviews").UpdateOne(ctx, bson.D{{Key: "_id", Value: id}}, update)
+	return err
+}
@@ -0,0 +1,180 @@
+package data
+
+import (
+	"encoding/json"
+	"io"
+	"regexp"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+// Severity classes of offences
+const (
+	SeverityMisdemeanor = "PREKRSAJ"
+	SeverityCriminal    = "KRIVICNO_DELO"
+)
+
+// Codes of offences found by roadside checks
+const (
+	OffenceDrunkDriving        = "DRUNK_DRIVING"
+	OffenceImproperTires       = "IMPROPER_TIRES"
+	OffenceDrivingWhileBanned  = "DRIVING_WHILE_BANNED"
+	OffencePermitSuspended     = "PERMIT_SUSPENDED"
+	OffencePermitRevoked       = "PERMIT_REVOKED"
+	OffencePermitExpired       = "PERMIT_EXPIRED"
+	OffenceRegistrationExpired = "REGISTRATION_EXPIRED"
+	OffenceSpeeding            = "SPEEDING"
+	OffenceRunningRedLight     = "RUNNING_RED_LIGHT"
+	OffenceUninsuredVehicle    = "UNINSURED_VEHICLE"
+	OffenceEndangeringTraffic  = "ENDANGERING_TRAFFIC"
+	// Free-text reasons of violations recorded before the catalogue that no code matched
+	OffenceUnclassified = "UNCLASSIFIED"
+)
+
+// Catalogue entry of an offence with the sanctions the law prescribes for it
+type Offence struct {
+	Code         string  `bson:"code" json:"code"`
+	Title        string  `bson:"title" json:"title"`
+	LegalArticle string  `bson:"legalArticle" json:"legalArticle"`
+	Severity     string  `bson:"severity" json:"severity"`
+	FineMin      float64 `bson:"fineMin" json:"fineMin"`
+	FineMax      float64 `bson:"fineMax" json:"fineMax"`
+	Points       int     `bson:"points" json:"points"`
+	Unit         string  `bson:"unit,omitempty" json:"unit,omitempty"`
+	Active       bool    `bson:"active" json:"active"`
+}
+
+type Offences []Offence
+
+// Offences by code
+type Catalogue map[string]Offence
+
+// Single offence of a violation. The catalogue entry is copied as it was when the
+// violation was recorded, so later changes of the catalogue don't rewrite it
+type OffenceItem struct {
+	Code          string   `bson:"code" json:"code"`
+	Title         string   `bson:"title" json:"title"`
+	LegalArticle  string   `bson:"legalArticle" json:"legalArticle"`
+	Severity      string   `bson:"severity" json:"severity"`
+	FineMin       float64  `bson:"fineMin" json:"fineMin"`
+	FineMax       float64  `bson:"fineMax" json:"fineMax"`
+	Points        int      `bson:"points" json:"points"`
+	MeasuredValue *float64 `bson:"measuredValue,omitempty" json:"measuredValue,omitempty"`
+	Unit          string   `bson:"unit,omitempty" json:"unit,omitempty"`
+	Detail        string   `bson:"detail,omitempty" json:"detail,omitempty"`
+}
+
+type OffenceItems []OffenceItem
+
+// Offences the catalogue starts with. Fines are in dinars
+var DefaultOffences = Offences{
+	{Code: OffenceDrunkDriving, Title: "Upravljanje vozilom pod dejstvom alkohola", LegalArticle: "ZOBS čl. 187", Severity: SeverityMisdemeanor, FineMin: 10000, FineMax: 120000, Points: 8, Unit: "‰"},
+	{Code: OffenceImproperTires, Title: "Neodgovarajući pneumatici za godišnje doba", LegalArticle: "ZOBS čl. 30", Severity: SeverityMisdemeanor, FineMin: 5000, FineMax: 20000, Points: 0},
+	{Code: OffenceDrivingWhileBanned, Title: "Upravljanje vozilom za vreme zabrane", LegalArticle: "ZOBS čl. 179", Severity: SeverityMisdemeanor, FineMin: 100000, FineMax: 120000, Points: 15},
+	{Code: OffencePermitSuspended, Title: "Upravljanje vozilom sa suspendovanom vozačkom dozvolom", LegalArticle: "ZOBS čl. 179", Severity: SeverityMisdemeanor, FineMin: 100000, FineMax: 120000, Points: 15},
+	{Code: OffencePermitRevoked, Title: "Upravljanje vozilom sa oduzetom vozačkom dozvolom", LegalArticle: "ZOBS čl. 179", Severity: SeverityMisdemeanor, FineMin: 100000, FineMax: 120000, Points: 15},
+	{Code: OffencePermitExpired, Title: "Upravljanje vozilom sa isteklom vozačkom dozvolom", LegalArticle: "ZOBS čl. 176", Severity: SeverityMisdemeanor, FineMin: 5000, FineMax: 5000, Points: 0},
+	{Code: OffenceRegistrationExpired, Title: "Upravljanje neregistrovanim vozilom", LegalArticle: "ZOBS čl. 296", Severity: SeverityMisdemeanor, FineMin: 10000, FineMax: 20000, Points: 3},
+	{Code: OffenceSpeeding, Title: "Prekoračenje dozvoljene brzine", LegalArticle: "ZOBS čl. 43", Severity: SeverityMisdemeanor, FineMin: 3000, FineMax: 120000, Points: 3, Unit: "km/h"},
+	{Code: OffenceRunningRedLight, Title: "Prolazak kroz crveno svetlo", LegalArticle: "ZOBS čl. 331", Severity: SeverityMisdemeanor, FineMin: 5000, FineMax: 20000, Points: 3},
+	{Code: OffenceUninsuredVehicle, Title: "Upravljanje vozilom bez obaveznog osiguranja", LegalArticle: "ZOOS čl. 105", Severity: SeverityMisdemeanor, FineMin: 10000, FineMax: 150000, Points: 0},
+	{Code: OffenceEndangeringTraffic, Title: "Ugrožavanje javnog saobraćaja", LegalArticle: "KZ čl. 289", Severity: SeverityCriminal, FineMin: 0, FineMax: 0, Points: 0},
+	{Code: OffenceUnclassified, Title: "Neklasifikovan prekršaj", LegalArticle: "", Severity: SeverityMisdemeanor, FineMin: 0, FineMax: 0, Points: 0},
+}
+
+// Returns the offence as an item of a violation. Codes missing from the catalogue
+// keep only the code, so the violation is still recorded
+func (c Catalogue) Item(code string, measured *float64, detail string) OffenceItem {
+	offence, ok := c[code]
+	if !ok {
+		return OffenceItem{Code: code, Title: code, Severity: SeverityMisdemeanor, MeasuredValue: measured, Detail: detail}
+	}
+
+	return OffenceItem{
+		Code:          offence.Code,
+		Title:         offence.Title,
+		LegalArticle:  offence.LegalArticle,
+		Severity:      offence.Severity,
+		FineMin:       offence.FineMin,
+		FineMax:       offence.FineMax,
+		Points:        offence.Points,
+		MeasuredValue: measured,
+		Unit:          offence.Unit,
+		Detail:        detail,
+	}
+}
+
+// Adds the offence to the violation and to its human readable reason
+func (tv *TrafficViolation) AddOffence(item OffenceItem) {
+	tv.Offences = append(tv.Offences, item)
+
+	titles := make([]string, 0, len(tv.Offences))
+	for _, offence := range tv.Offences {
+		titles = append(titles, offence.Title)
+	}
+	tv.Reason = strings.Join(titles, "; ")
+}
+
+// Patterns of the free-text reasons checks used to write before the catalogue
+var legacyReasons = []struct {
+	code    string
+	pattern *regexp.Regexp
+}{
+	{OffenceDrunkDriving, regexp.MustCompile(`(?i)drunk driving(?::\s*([0-9]+(?:\.[0-9]+)?))?`)},
+	{OffenceImproperTires, regexp.MustCompile(`(?i)improper tire usage:\s*(SUMMER|WINTER) tires[^.\n]*`)},
+	{OffenceDrivingWhileBanned, regexp.MustCompile(`(?i)driving ban is in effect`)},
+	{OffencePermitSuspended, regexp.MustCompile(`(?i)driving permit suspended`)},
+	{OffencePermitRevoked, regexp.MustCompile(`(?i)driving permit revoked`)},
+	{OffencePermitExpired, regexp.MustCompile(`(?i)driving permit expired`)},
+	{OffenceRegistrationExpired, regexp.MustCompile(`(?i)vehicle registration expired`)},
+	{OffenceSpeeding, regexp.MustCompile(`(?i)speeding`)},
+}
+
+// Splits a free-text reason into offences. Text no pattern matches is kept as an
+// unclassified offence, so nothing the officer wrote is lost
+func (c Catalogue) ParseReason(reason string) OffenceItems {
+	items := OffenceItems{}
+	rest := reason
+
+	for _, legacy := range legacyReasons {
+		for _, match := range legacy.pattern.FindAllStringSubmatch(rest, -1) {
+			var measured *float64
+			detail := ""
+			switch legacy.code {
+			case OffenceDrunkDriving:
+				if value, err := strconv.ParseFloat(match[1], 64); err == nil {
+					measured = &value
+				}
+			case OffenceImproperTires:
+				detail = strings.ToUpper(match[1])
+			}
+			items = append(items, c.Item(legacy.code, measured, detail))
+		}
+		rest = legacy.pattern.ReplaceAllString(rest, "")
+	}
+
+	rest = strings.Join(strings.FieldsFunc(rest, func(r rune) bool {
+		return unicode.IsSpace(r) || r == '.' || r == ';'
+	}), " ")
+	if strings.IndexFunc(rest, unicode.IsLetter) >= 0 || len(items) == 0 {
+		items = append(items, c.Item(OffenceUnclassified, nil, rest))
+	}
+
+	return items
+}
+
+func (o *Offence) ToJSON(w io.Writer) error {
+	e := json.NewEncoder(w)
+	return e.Encode(o)
+}
+
+func (o *Offence) FromJSON(r io.Reader) error {
+	d := json.NewDecoder(r)
+	return d.Decode(o)
+}
+
+func (o *Offences) ToJSON(w io.Writer) error {
+	e := json.NewEncoder(w)
+	return e.Encode(o)
+}
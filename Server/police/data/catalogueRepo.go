@@ -0,0 +1,119 @@
+package data
+
+import (
+	"context"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+//Catalogue methods
+
+// Adds default offences missing from the catalogue. Offences an administrator changed are left as they are
+func (pr *PoliceRepo) SeedCatalogue(ctx context.Context) error {
+	collection := pr.getPoliceCollection("offences")
+
+	for _, offence := range DefaultOffences {
+		offence.Active = true
+		_, err := collection.UpdateOne(ctx,
+			bson.D{{Key: "code", Value: offence.Code}},
+			bson.D{{Key: "$setOnInsert", Value: offence}},
+			options.Update().SetUpsert(true))
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// Returns offences of the catalogue ordered by code, only those in use when activeOnly is set
+func (pr *PoliceRepo) GetOffences(ctx context.Context, activeOnly bool) (Offences, error) {
+	filter := bson.D{}
+	if activeOnly {
+		filter = append(filter, bson.E{Key: "active", Value: true})
+	}
+
+	opts := options.Find().SetSort(bson.D{{Key: "code", Value: 1}})
+	cursor, err := pr.getPoliceCollection("offences").Find(ctx, filter, opts)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	offences := Offences{}
+	if err = cursor.All(ctx, &offences); err != nil {
+		return nil, err
+	}
+
+	return offences, nil
+}
+
+// Returns offences in use by code, for recording violations
+func (pr *PoliceRepo) GetCatalogue(ctx context.Context) (Catalogue, error) {
+	offences, err := pr.GetOffences(ctx, true)
+	if err != nil {
+		return nil, err
+	}
+
+	return offences.catalogue(), nil
+}
+
+// Creates the offence or replaces the one with the same code. Violations keep the
+// copy of the offence they were recorded with
+func (pr *PoliceRepo) SaveOffence(ctx context.Context, offence Offence) error {
+	_, err := pr.getPoliceCollection("offences").ReplaceOne(ctx,
+		bson.D{{Key: "code", Value: offence.Code}},
+		offence,
+		options.Replace().SetUpsert(true))
+	return err
+}
+
+// Splits reasons of violations recorded before the catalogue into offences. Violations
+// that already have offences are skipped, so it is safe to run on every start
+func (pr *PoliceRepo) MigrateViolationReasons(ctx context.Context) (int, error) {
+	offences, err := pr.GetOffences(ctx, false)
+	if err != nil {
+		return 0, err
+	}
+	catalogue := offences.catalogue()
+
+	collection := pr.getPoliceCollection("traffic_violations")
+	filter := bson.D{{Key: "$or", Value: bson.A{
+		bson.D{{Key: "offences", Value: bson.D{{Key: "$exists", Value: false}}}},
+		bson.D{{Key: "offences", Value: nil}},
+	}}}
+
+	cursor, err := collection.Find(ctx, filter)
+	if err != nil {
+		return 0, err
+	}
+	defer cursor.Close(ctx)
+
+	migrated := 0
+	for cursor.Next(ctx) {
+		var violation TrafficViolation
+		if err := cursor.Decode(&violation); err != nil {
+			return migrated, err
+		}
+
+		items := catalogue.ParseReason(violation.Reason)
+		_, err := collection.UpdateOne(ctx,
+			bson.D{{Key: "_id", Value: violation.ID}},
+			bson.D{{Key: "$set", Value: bson.D{{Key: "offences", Value: items}}}})
+		if err != nil {
+			return migrated, err
+		}
+		migrated++
+	}
+
+	return migrated, cursor.Err()
+}
+
+func (o Offences) catalogue() Catalogue {
+	catalogue := make(Catalogue, len(o))
+	for _, offence := range o {
+		catalogue[offence.Code] = offence
+	}
+	return catalogue
+}
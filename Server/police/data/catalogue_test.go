@@ -0,0 +1,194 @@
+package data
+
+import (
+	"context"
+	"testing"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo/integration/mtest"
+)
+
+func TestParseReason(t *testing.T) {
+	catalogue := DefaultOffences.catalogue()
+
+	tests := []struct {
+		name   string
+		reason string
+		want   OffenceItems
+	}{
+		{
+			name:   "alcohol level is kept as measured",
+			reason: "Drunk driving: 1.20. ",
+			want:   OffenceItems{catalogue.Item(OffenceDrunkDriving, measured(1.2), "")},
+		},
+		{
+			name:   "alcohol level left out",
+			reason: "drunk driving \n",
+			want:   OffenceItems{catalogue.Item(OffenceDrunkDriving, nil, "")},
+		},
+		{
+			name:   "tire type is kept as detail",
+			reason: "Improper tire usage: summer tires during winter period. ",
+			want:   OffenceItems{catalogue.Item(OffenceImproperTires, nil, TireSummer)},
+		},
+		{
+			name:   "every check of a stop",
+			reason: "Drunk driving: 0.50. Improper tire usage: WINTER tires during summer period. Driving ban is in effect \nDriving permit expired. Vehicle registration expired. ",
+			want: OffenceItems{
+				catalogue.Item(OffenceDrunkDriving, measured(0.5), ""),
+				catalogue.Item(OffenceImproperTires, nil, TireWinter),
+				catalogue.Item(OffenceDrivingWhileBanned, nil, ""),
+				catalogue.Item(OffencePermitExpired, nil, ""),
+				catalogue.Item(OffenceRegistrationExpired, nil, ""),
+			},
+		},
+		{
+			name:   "unmatched text is kept unclassified",
+			reason: "Speeding; parked on the sidewalk.",
+			want: OffenceItems{
+				catalogue.Item(OffenceSpeeding, nil, ""),
+				catalogue.Item(OffenceUnclassified, nil, "parked on the sidewalk"),
+			},
+		},
+		{
+			name:   "no known offence",
+			reason: "Parked on the sidewalk",
+			want:   OffenceItems{catalogue.Item(OffenceUnclassified, nil, "Parked on the sidewalk")},
+		},
+		{
+			name:   "empty reason",
+			reason: "",
+			want:   OffenceItems{catalogue.Item(OffenceUnclassified, nil, "")},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := catalogue.ParseReason(tt.reason)
+			if !sameOffences(got, tt.want) {
+				t.Errorf("ParseReason(%q) = %+v, want %+v", tt.reason, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestMigrateViolationReasons(t *testing.T) {
+	mt := mtest.New(t, mtest.NewOptions().ClientType(mtest.Mock))
+	catalogue := DefaultOffences.catalogue()
+
+	tests := []struct {
+		name    string
+		reasons []string
+		want    []OffenceItems
+	}{
+		{
+			name:    "reasons are split into offences",
+			reasons: []string{"Drunk driving: 0.80. ", "Driving permit expired. Vehicle registration expired. "},
+			want: []OffenceItems{
+				{catalogue.Item(OffenceDrunkDriving, measured(0.8), "")},
+				{catalogue.Item(OffencePermitExpired, nil, ""), catalogue.Item(OffenceRegistrationExpired, nil, "")},
+			},
+		},
+		{
+			name: "nothing to migrate",
+		},
+	}
+
+	for _, tt := range tests {
+		mt.Run(tt.name, func(mt *mtest.T) {
+			offences := []bson.D{}
+			for _, offence := range DefaultOffences {
+				offences = append(offences, toDocument(mt, offence))
+			}
+			violations := []bson.D{}
+			for _, reason := range tt.reasons {
+				violations = append(violations, toDocument(mt, TrafficViolation{ID: primitive.NewObjectID(), Reason: reason}))
+			}
+
+			mt.AddMockResponses(
+				mtest.CreateCursorResponse(0, "policeDB.offences", mtest.FirstBatch, offences...),
+				mtest.CreateCursorResponse(0, "policeDB.traffic_violations", mtest.FirstBatch, violations...),
+			)
+			for range tt.reasons {
+				mt.AddMockResponses(mtest.CreateSuccessResponse(bson.E{Key: "n", Value: 1}, bson.E{Key: "nModified", Value: 1}))
+			}
+
+			pr := &PoliceRepo{cli: mt.Client}
+			migrated, err := pr.MigrateViolationReasons(context.Background())
+			if err != nil {
+				mt.Fatalf("MigrateViolationReasons() error = %v", err)
+			}
+			if migrated != len(tt.want) {
+				mt.Errorf("MigrateViolationReasons() = %d, want %d", migrated, len(tt.want))
+			}
+
+			// Only violations without offences are read
+			mt.GetStartedEvent()
+			find := mt.GetStartedEvent()
+			if _, err := find.Command.LookupErr("filter", "$or"); err != nil {
+				mt.Errorf("violations are read with filter %v, want those without offences", find.Command.Lookup("filter"))
+			}
+
+			for i, want := range tt.want {
+				update := mt.GetStartedEvent()
+				if update == nil || update.CommandName != "update" {
+					mt.Fatalf("violation %d wasn't updated", i)
+				}
+
+				var got struct {
+					Updates []struct {
+						U struct {
+							Set struct {
+								Offences OffenceItems `bson:"offences"`
+							} `bson:"$set"`
+						} `bson:"u"`
+					} `bson:"updates"`
+				}
+				if err := bson.Unmarshal(update.Command, &got); err != nil || len(got.Updates) != 1 {
+					mt.Fatalf("update %v can't be read: %v", update.Command, err)
+				}
+				if !sameOffences(got.Updates[0].U.Set.Offences, want) {
+					mt.Errorf("violation %d got offences %+v, want %+v", i, got.Updates[0].U.Set.Offences, want)
+				}
+			}
+		})
+	}
+}
+
+func measured(value float64) *float64 {
+	return &value
+}
+
+// Offences match by code, measured value and detail, the rest is copied from the catalogue
+func sameOffences(got, want OffenceItems) bool {
+	if len(got) != len(want) {
+		return false
+	}
+	for i := range got {
+		if got[i].Code != want[i].Code || got[i].Detail != want[i].Detail || got[i].FineMin != want[i].FineMin {
+			return false
+		}
+		if (got[i].MeasuredValue == nil) != (want[i].MeasuredValue == nil) {
+			return false
+		}
+		if got[i].MeasuredValue != nil && *got[i].MeasuredValue != *want[i].MeasuredValue {
+			return false
+		}
+	}
+	return true
+}
+
+// Document of the value as the database would return it
+func toDocument(mt *mtest.T, value interface{}) bson.D {
+	raw, err := bson.Marshal(value)
+	if err != nil {
+		mt.Fatalf("failed to marshal %v: %v", value, err)
+	}
+
+	var document bson.D
+	if err := bson.Unmarshal(raw, &document); err != nil {
+		mt.Fatalf("failed to unmarshal %v: %v", value, err)
+	}
+	return document
+}
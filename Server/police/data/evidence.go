@@ -0,0 +1,98 @@
+package data
+
+import (
+	"encoding/json"
+	"io"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// Kinds of evidence attached to a violation
+const (
+	EvidenceObjection    = "OBJECTION"
+	EvidenceBreathalyser = "BREATHALYSER_PRINTOUT"
+	EvidencePhoto        = "PHOTO"
+	EvidenceSpeedCamera  = "SPEED_CAMERA_FRAME"
+	EvidenceBodycamClip  = "BODYCAM_CLIP"
+)
+
+// Kinds of evidence officers attach. Bodycam clips stay in the bodycam system and
+// are attached as a reference to the clip there
+var OfficerEvidenceKinds = []string{EvidenceBreathalyser, EvidencePhoto, EvidenceSpeedCamera, EvidenceBodycamClip}
+
+// Actions recorded in the chain of custody of evidence
+const (
+	CustodyUploaded        = "UPLOADED"
+	CustodyViewed          = "VIEWED"
+	CustodyDownloaded      = "DOWNLOADED"
+	CustodyIntegrityFailed = "INTEGRITY_FAILED"
+)
+
+// File attached to a violation. The content is kept in the blob store under BlobKey,
+// which is derived from the checksum, so identical files share their content.
+// Evidence kept elsewhere, such as bodycam clips, has a Reference instead of content
+type Evidence struct {
+	ID          primitive.ObjectID `bson:"_id" json:"id"`
+	Violation   primitive.ObjectID `bson:"violation" json:"violation"`
+	Kind        string             `bson:"kind" json:"kind"`
+	Description string             `bson:"description,omitempty" json:"description,omitempty"`
+	FileName    string             `bson:"fileName,omitempty" json:"fileName,omitempty"`
+	ContentType string             `bson:"contentType,omitempty" json:"contentType,omitempty"`
+	Size        int64              `bson:"size" json:"size"`
+	SHA256      string             `bson:"sha256,omitempty" json:"sha256,omitempty"`
+	BlobKey     string             `bson:"blobKey,omitempty" json:"-"`
+	Reference   string             `bson:"reference,omitempty" json:"reference,omitempty"`
+	UploadedBy  string             `bson:"uploadedBy" json:"uploadedBy"`
+	UploadedAt  time.Time          `bson:"uploadedAt" json:"uploadedAt"`
+}
+
+type EvidenceFiles []Evidence
+
+// Entry of the chain of custody, one for every time evidence is stored or accessed
+type CustodyEntry struct {
+	ID        primitive.ObjectID `bson:"_id" json:"id"`
+	Evidence  primitive.ObjectID `bson:"evidence" json:"evidence"`
+	Violation primitive.ObjectID `bson:"violation" json:"violation"`
+	Action    string             `bson:"action" json:"action"`
+	Actor     string             `bson:"actor" json:"actor"`
+	Role      string             `bson:"role" json:"role"`
+	SHA256    string             `bson:"sha256,omitempty" json:"sha256,omitempty"`
+	Detail    string             `bson:"detail,omitempty" json:"detail,omitempty"`
+	At        time.Time          `bson:"at" json:"at"`
+}
+
+type CustodyLog []CustodyEntry
+
+// Evidence as the court gets it, with the address its content is downloaded from
+type EvidenceRef struct {
+	ID          string `json:"id"`
+	Kind        string `json:"kind"`
+	Description string `json:"description,omitempty"`
+	FileName    string `json:"fileName"`
+	ContentType string `json:"contentType"`
+	Size        int64  `json:"size"`
+	SHA256      string `json:"sha256"`
+	Reference   string `json:"reference,omitempty"`
+	URL         string `json:"url,omitempty"`
+}
+
+// Whether the evidence has content in the blob store, rather than being a reference
+func (e *Evidence) HasContent() bool {
+	return e.BlobKey != ""
+}
+
+func (e *Evidence) ToJSON(w io.Writer) error {
+	en := json.NewEncoder(w)
+	return en.Encode(e)
+}
+
+func (ef *EvidenceFiles) ToJSON(w io.Writer) error {
+	e := json.NewEncoder(w)
+	return e.Encode(ef)
+}
+
+func (cl *CustodyLog) ToJSON(w io.Writer) error {
+	e := json.NewEncoder(w)
+	return e.Encode(cl)
+}
@@ -0,0 +1,115 @@
+package data
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+var ErrEvidenceNotFound = errors.New("evidence not found")
+
+//Evidence methods
+
+// Saves the evidence and starts its chain of custody with the upload
+func (pr *PoliceRepo) CreateEvidence(ctx context.Context, evidence EvidenceFiles, role string) error {
+	if len(evidence) == 0 {
+		return nil
+	}
+
+	documents := make([]interface{}, len(evidence))
+	for i := range evidence {
+		documents[i] = evidence[i]
+	}
+
+	_, err := pr.getPoliceCollection("evidence").InsertMany(ctx, documents)
+	if err != nil {
+		return err
+	}
+
+	for _, e := range evidence {
+		err := pr.RecordCustody(ctx, e, CustodyUploaded, e.UploadedBy, role, "")
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Whether any evidence keeps its content under the blob key
+func (pr *PoliceRepo) EvidenceBlobInUse(ctx context.Context, key string) (bool, error) {
+	count, err := pr.getPoliceCollection("evidence").CountDocuments(ctx, bson.D{{Key: "blobKey", Value: key}}, options.Count().SetLimit(1))
+	if err != nil {
+		return false, err
+	}
+	return count > 0, nil
+}
+
+func (pr *PoliceRepo) GetEvidence(ctx context.Context, id primitive.ObjectID) (Evidence, error) {
+	var evidence Evidence
+	err := pr.getPoliceCollection("evidence").FindOne(ctx, bson.D{{Key: "_id", Value: id}}).Decode(&evidence)
+	if err != nil {
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			return Evidence{}, ErrEvidenceNotFound
+		}
+		return Evidence{}, err
+	}
+
+	return evidence, nil
+}
+
+// Returns evidence attached to the violation, in the order it was uploaded
+func (pr *PoliceRepo) GetViolationEvidence(ctx context.Context, violation primitive.ObjectID) (EvidenceFiles, error) {
+	opts := options.Find().SetSort(bson.D{{Key: "uploadedAt", Value: 1}})
+	cursor, err := pr.getPoliceCollection("evidence").Find(ctx, bson.D{{Key: "violation", Value: violation}}, opts)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	evidence := EvidenceFiles{}
+	if err = cursor.All(ctx, &evidence); err != nil {
+		return nil, err
+	}
+
+	return evidence, nil
+}
+
+// Appends an entry to the chain of custody of the evidence. Entries are never changed or removed
+func (pr *PoliceRepo) RecordCustody(ctx context.Context, evidence Evidence, action, actor, role, detail string) error {
+	entry := CustodyEntry{
+		ID:        primitive.NewObjectID(),
+		Evidence:  evidence.ID,
+		Violation: evidence.Violation,
+		Action:    action,
+		Actor:     actor,
+		Role:      role,
+		SHA256:    evidence.SHA256,
+		Detail:    detail,
+		At:        time.Now(),
+	}
+
+	_, err := pr.getPoliceCollection("evidence_custody").InsertOne(ctx, entry)
+	return err
+}
+
+// Returns the chain of custody of the evidence, oldest entry first
+func (pr *PoliceRepo) GetCustodyLog(ctx context.Context, evidence primitive.ObjectID) (CustodyLog, error) {
+	opts := options.Find().SetSort(bson.D{{Key: "at", Value: 1}, {Key: "_id", Value: 1}})
+	cursor, err := pr.getPoliceCollection("evidence_custody").Find(ctx, bson.D{{Key: "evidence", Value: evidence}}, opts)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	entries := CustodyLog{}
+	if err = cursor.All(ctx, &entries); err != nil {
+		return nil, err
+	}
+
+	return entries, nil
+}
@@ -0,0 +1,157 @@
+package data
+
+import (
+	"encoding/json"
+	"io"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// States of a fine
+const (
+	FineUnpaid    = "UNPAID"
+	FinePaid      = "PAID"
+	FineEscalated = "ESCALATED"
+	FineContested = "CONTESTED"
+	FineCancelled = "CANCELLED"
+)
+
+// Fines are paid to the budget account for traffic fines with a model 97 reference
+const (
+	FineRecipient        = "Novčane kazne za saobraćajne prekršaje"
+	FineRecipientAccount = "840-745111843-36"
+	FineModel            = "97"
+	FineCurrency         = "RSD"
+)
+
+// Days the offender has to pay half of the fine or object to it, and to pay it at all
+// before the court takes over
+const (
+	FineDiscountDays  = 8
+	FineObjectionDays = 8
+	FinePaymentDays   = 30
+)
+
+// Fine of a misdemeanour warrant (prekršajni nalog) issued for a violation. Half of it
+// settles the fine if paid within eight days of issuing
+type Fine struct {
+	ID               primitive.ObjectID `bson:"_id,omitempty" json:"id"`
+	Violation        primitive.ObjectID `bson:"violation" json:"violation"`
+	Offender         string             `bson:"offender" json:"offender"`
+	Offences         []string           `bson:"offences" json:"offences"`
+	Amount           float64            `bson:"amount" json:"amount"`
+	DiscountedAmount float64            `bson:"discountedAmount" json:"discountedAmount"`
+	Currency         string             `bson:"currency" json:"currency"`
+	IssuedAt         time.Time          `bson:"issuedAt" json:"issuedAt"`
+	DiscountUntil    time.Time          `bson:"discountUntil" json:"discountUntil"`
+	DueDate          time.Time          `bson:"dueDate" json:"dueDate"`
+	ObjectionUntil   time.Time          `bson:"objectionUntil" json:"objectionUntil"`
+	Recipient        string             `bson:"recipient" json:"recipient"`
+	RecipientAccount string             `bson:"recipientAccount" json:"recipientAccount"`
+	Model            string             `bson:"model" json:"model"`
+	ReferenceNumber  string             `bson:"referenceNumber" json:"referenceNumber"`
+	Status           string             `bson:"status" json:"status"`
+	PaidAmount       float64            `bson:"paidAmount,omitempty" json:"paidAmount,omitempty"`
+	PaidAt           time.Time          `bson:"paidAt,omitempty" json:"paidAt,omitempty"`
+	TransactionID    string             `bson:"transactionID,omitempty" json:"transactionID,omitempty"`
+	EscalatedAt      time.Time          `bson:"escalatedAt,omitempty" json:"escalatedAt,omitempty"`
+	CourtCase        string             `bson:"courtCase,omitempty" json:"courtCase,omitempty"`
+	CancelledAt      time.Time          `bson:"cancelledAt,omitempty" json:"cancelledAt,omitempty"`
+	Objection        *Objection         `bson:"objection,omitempty" json:"objection,omitempty"`
+	// Amount that settles the fine when paid now, set when the fine is read
+	AmountDue float64 `bson:"-" json:"amountDue"`
+}
+
+type Fines []Fine
+
+// Objection of the offender to the fine, which takes the violation to court
+type Objection struct {
+	Statement   string               `bson:"statement" json:"statement"`
+	Evidence    []primitive.ObjectID `bson:"evidence" json:"evidence"`
+	SubmittedAt time.Time            `bson:"submittedAt" json:"submittedAt"`
+}
+
+// Contested violation sent to the court, with the violation record and the evidence attached
+type ContestedViolation struct {
+	Fine            string           `json:"fine"`
+	ReferenceNumber string           `json:"referenceNumber"`
+	Amount          float64          `json:"amount"`
+	Currency        string           `json:"currency"`
+	Statement       string           `json:"statement"`
+	SubmittedAt     time.Time        `json:"submittedAt"`
+	Violation       TrafficViolation `json:"violation"`
+	Evidence        []EvidenceRef    `json:"evidence"`
+}
+
+// Fine case sent to the court for a fine that wasn't paid in time
+type UnpaidFine struct {
+	Fine            string    `json:"fine"`
+	Violation       string    `json:"violation"`
+	ViolatorJMBG    string    `json:"violatorJMBG"`
+	Reason          string    `json:"reason"`
+	Amount          float64   `json:"amount"`
+	Currency        string    `json:"currency"`
+	ReferenceNumber string    `json:"referenceNumber"`
+	DueDate         time.Time `json:"dueDate"`
+}
+
+// Issues the fine for the violation, at the lowest fine the catalogue sets for each of
+// its offences. Violations with a criminal offence or nothing to pay get no fine
+func NewFine(violation TrafficViolation, issuedAt time.Time) (Fine, bool) {
+	fine := Fine{
+		Violation: violation.ID,
+		Offender:  violation.ViolatorJMBG,
+		Offences:  []string{},
+		Currency:  FineCurrency,
+		IssuedAt:  issuedAt,
+		Status:    FineUnpaid,
+	}
+
+	for _, offence := range violation.Offences {
+		if offence.Severity == SeverityCriminal {
+			return Fine{}, false
+		}
+		fine.Amount += offence.FineMin
+		fine.Offences = append(fine.Offences, offence.Code)
+	}
+	if fine.Amount <= 0 {
+		return Fine{}, false
+	}
+
+	fine.DiscountedAmount = fine.Amount / 2
+	fine.DiscountUntil = endOfDay(issuedAt.AddDate(0, 0, FineDiscountDays))
+	fine.DueDate = endOfDay(issuedAt.AddDate(0, 0, FinePaymentDays))
+	fine.ObjectionUntil = endOfDay(issuedAt.AddDate(0, 0, FineObjectionDays))
+	fine.Recipient = FineRecipient
+	fine.RecipientAccount = FineRecipientAccount
+	fine.Model = FineModel
+
+	return fine, true
+}
+
+// Returns the amount that settles the fine when paid at the time
+func (f *Fine) AmountDueAt(at time.Time) float64 {
+	if f.Status != FineUnpaid {
+		return 0
+	}
+	if !at.After(f.DiscountUntil) {
+		return f.DiscountedAmount
+	}
+	return f.Amount
+}
+
+func endOfDay(t time.Time) time.Time {
+	year, month, day := t.Date()
+	return time.Date(year, month, day, 23, 59, 59, 0, t.Location())
+}
+
+func (f *Fine) ToJSON(w io.Writer) error {
+	e := json.NewEncoder(w)
+	return e.Encode(f)
+}
+
+func (f *Fines) ToJSON(w io.Writer) error {
+	e := json.NewEncoder(w)
+	return e.Encode(f)
+}
@@ -0,0 +1,179 @@
+package data
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+var (
+	ErrFineNotFound  = errors.New("fine not found")
+	ErrFineNotUnpaid = errors.New("fine is already paid, contested or sent to court")
+)
+
+//Fine methods
+
+// Saves the fine unless the violation already has one
+func (pr *PoliceRepo) CreateFine(ctx context.Context, fine *Fine) error {
+	fine.ID = primitive.NewObjectID()
+
+	_, err := pr.getPoliceCollection("fines").InsertOne(ctx, fine)
+	if mongo.IsDuplicateKeyError(err) {
+		existing, err := pr.GetFineByViolation(ctx, fine.Violation)
+		if err != nil {
+			return err
+		}
+		*fine = existing
+		return nil
+	}
+	return err
+}
+
+func (pr *PoliceRepo) GetFineByID(ctx context.Context, id primitive.ObjectID) (Fine, error) {
+	return pr.findFine(ctx, bson.D{{Key: "_id", Value: id}})
+}
+
+func (pr *PoliceRepo) GetFineByViolation(ctx context.Context, violation primitive.ObjectID) (Fine, error) {
+	return pr.findFine(ctx, bson.D{{Key: "violation", Value: violation}})
+}
+
+// Finds the fine the payment with the reference was made for
+func (pr *PoliceRepo) GetFineByReference(ctx context.Context, model, reference string) (Fine, error) {
+	return pr.findFine(ctx, bson.D{{Key: "model", Value: model}, {Key: "referenceNumber", Value: reference}})
+}
+
+// Returns fines of the offender, latest first
+func (pr *PoliceRepo) GetOffendersFines(ctx context.Context, jmbg string) (Fines, error) {
+	return pr.findFines(ctx, bson.D{{Key: "offender", Value: jmbg}})
+}
+
+// Returns fines in the state, or all of them for an empty one, latest first
+func (pr *PoliceRepo) GetFines(ctx context.Context, status string) (Fines, error) {
+	filter := bson.D{}
+	if status != "" {
+		filter = append(filter, bson.E{Key: "status", Value: status})
+	}
+	return pr.findFines(ctx, filter)
+}
+
+// Returns unpaid fines whose due date passed before the time
+func (pr *PoliceRepo) GetOverdueFines(ctx context.Context, at time.Time) (Fines, error) {
+	return pr.findFines(ctx, bson.D{
+		{Key: "status", Value: FineUnpaid},
+		{Key: "dueDate", Value: bson.D{{Key: "$lt", Value: at}}},
+	})
+}
+
+// Marks the unpaid fine as paid
+func (pr *PoliceRepo) MarkFinePaid(ctx context.Context, id primitive.ObjectID, amount float64, transactionID string, paidAt time.Time) (Fine, error) {
+	return pr.updateUnpaidFine(ctx, id, bson.D{
+		{Key: "status", Value: FinePaid},
+		{Key: "paidAmount", Value: amount},
+		{Key: "transactionID", Value: transactionID},
+		{Key: "paidAt", Value: paidAt},
+	})
+}
+
+// Marks the unpaid fine as sent to the court with the case the court opened for it
+func (pr *PoliceRepo) MarkFineEscalated(ctx context.Context, id primitive.ObjectID, courtCase string, at time.Time) (Fine, error) {
+	return pr.updateUnpaidFine(ctx, id, bson.D{
+		{Key: "status", Value: FineEscalated},
+		{Key: "courtCase", Value: courtCase},
+		{Key: "escalatedAt", Value: at},
+	})
+}
+
+// Marks the unpaid fine as contested with the objection of the offender
+func (pr *PoliceRepo) ContestFine(ctx context.Context, id primitive.ObjectID, objection Objection) (Fine, error) {
+	return pr.updateUnpaidFine(ctx, id, bson.D{
+		{Key: "status", Value: FineContested},
+		{Key: "objection", Value: objection},
+	})
+}
+
+// Cancels the unpaid fine, when the violation it was issued for is voided
+func (pr *PoliceRepo) CancelFine(ctx context.Context, id primitive.ObjectID, at time.Time) (Fine, error) {
+	return pr.updateUnpaidFine(ctx, id, bson.D{
+		{Key: "status", Value: FineCancelled},
+		{Key: "cancelledAt", Value: at},
+	})
+}
+
+// Returns contested fines the court hasn't opened a case for yet
+func (pr *PoliceRepo) GetContestedFinesNotSent(ctx context.Context) (Fines, error) {
+	return pr.findFines(ctx, bson.D{
+		{Key: "status", Value: FineContested},
+		{Key: "courtCase", Value: bson.D{{Key: "$exists", Value: false}}},
+	})
+}
+
+// Records the case the court opened for the contested fine
+func (pr *PoliceRepo) SetContestedFineCase(ctx context.Context, id primitive.ObjectID, courtCase string, at time.Time) error {
+	filter := bson.D{{Key: "_id", Value: id}, {Key: "status", Value: FineContested}}
+	update := bson.D{{Key: "$set", Value: bson.D{
+		{Key: "courtCase", Value: courtCase},
+		{Key: "escalatedAt", Value: at},
+	}}}
+
+	result, err := pr.getPoliceCollection("fines").UpdateOne(ctx, filter, update)
+	if err != nil {
+		return err
+	}
+	if result.MatchedCount == 0 {
+		return ErrFineNotFound
+	}
+	return nil
+}
+
+func (pr *PoliceRepo) updateUnpaidFine(ctx context.Context, id primitive.ObjectID, set bson.D) (Fine, error) {
+	filter := bson.D{{Key: "_id", Value: id}, {Key: "status", Value: FineUnpaid}}
+	opts := options.FindOneAndUpdate().SetReturnDocument(options.After)
+
+	var fine Fine
+	err := pr.getPoliceCollection("fines").FindOneAndUpdate(ctx, filter, bson.D{{Key: "$set", Value: set}}, opts).Decode(&fine)
+	if err != nil {
+		if !errors.Is(err, mongo.ErrNoDocuments) {
+			return Fine{}, err
+		}
+		if _, err := pr.GetFineByID(ctx, id); err != nil {
+			return Fine{}, err
+		}
+		return Fine{}, ErrFineNotUnpaid
+	}
+
+	return fine, nil
+}
+
+func (pr *PoliceRepo) findFine(ctx context.Context, filter bson.D) (Fine, error) {
+	var fine Fine
+	err := pr.getPoliceCollection("fines").FindOne(ctx, filter).Decode(&fine)
+	if err != nil {
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			return Fine{}, ErrFineNotFound
+		}
+		return Fine{}, err
+	}
+
+	return fine, nil
+}
+
+func (pr *PoliceRepo) findFines(ctx context.Context, filter bson.D) (Fines, error) {
+	opts := options.Find().SetSort(bson.D{{Key: "issuedAt", Value: -1}})
+	cursor, err := pr.getPoliceCollection("fines").Find(ctx, filter, opts)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	fines := Fines{}
+	if err = cursor.All(ctx, &fines); err != nil {
+		return nil, err
+	}
+
+	return fines, nil
+}
@@ -0,0 +1,93 @@
+package data
+
+import (
+	"slices"
+	"testing"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+func TestNewFine(t *testing.T) {
+	catalogue := DefaultOffences.catalogue()
+	issuedAt := time.Date(2024, time.March, 28, 14, 30, 0, 0, time.UTC)
+
+	tests := []struct {
+		name         string
+		offences     []string
+		wantFined    bool
+		wantAmount   float64
+		wantOffences []string
+	}{
+		{
+			name:         "single offence",
+			offences:     []string{OffenceImproperTires},
+			wantFined:    true,
+			wantAmount:   5000,
+			wantOffences: []string{OffenceImproperTires},
+		},
+		{
+			name:         "fines of the offences add up",
+			offences:     []string{OffenceSpeeding, OffenceRunningRedLight, OffenceRegistrationExpired},
+			wantFined:    true,
+			wantAmount:   18000,
+			wantOffences: []string{OffenceSpeeding, OffenceRunningRedLight, OffenceRegistrationExpired},
+		},
+		{
+			name:      "criminal offence goes to court",
+			offences:  []string{OffenceSpeeding, OffenceEndangeringTraffic},
+			wantFined: false,
+		},
+		{
+			name:      "offence without a fine",
+			offences:  []string{OffenceUnclassified},
+			wantFined: false,
+		},
+		{
+			name:      "no offences",
+			wantFined: false,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			violation := TrafficViolation{ID: primitive.NewObjectID(), ViolatorJMBG: "0101990710006"}
+			for _, code := range tt.offences {
+				violation.AddOffence(catalogue.Item(code, nil, ""))
+			}
+
+			fine, fined := NewFine(violation, issuedAt)
+			if fined != tt.wantFined {
+				t.Fatalf("NewFine() fined = %v, want %v", fined, tt.wantFined)
+			}
+			if !fined {
+				return
+			}
+
+			if fine.Amount != tt.wantAmount || fine.DiscountedAmount != tt.wantAmount/2 {
+				t.Errorf("NewFine() amount = %v discounted %v, want %v discounted %v", fine.Amount, fine.DiscountedAmount, tt.wantAmount, tt.wantAmount/2)
+			}
+			if !slices.Equal(fine.Offences, tt.wantOffences) {
+				t.Errorf("NewFine() offences = %v, want %v", fine.Offences, tt.wantOffences)
+			}
+			if fine.Violation != violation.ID || fine.Offender != violation.ViolatorJMBG {
+				t.Errorf("NewFine() issued for %s to %s, want %s to %s", fine.Violation.Hex(), fine.Offender, violation.ID.Hex(), violation.ViolatorJMBG)
+			}
+			if fine.Status != FineUnpaid || fine.Model != FineModel || fine.RecipientAccount != FineRecipientAccount || fine.Currency != FineCurrency {
+				t.Errorf("NewFine() = %+v, want an unpaid fine payable to the traffic fines account", fine)
+			}
+
+			// Deadlines run to the end of the day, counted from the day of issue
+			wantDates := map[string][2]time.Time{
+				"discount":  {fine.DiscountUntil, time.Date(2024, time.April, 5, 23, 59, 59, 0, time.UTC)},
+				"objection": {fine.ObjectionUntil, time.Date(2024, time.April, 5, 23, 59, 59, 0, time.UTC)},
+				"due":       {fine.DueDate, time.Date(2024, time.April, 27, 23, 59, 59, 0, time.UTC)},
+			}
+			for deadline, dates := range wantDates {
+				if !dates[0].Equal(dates[1]) {
+					t.Errorf("NewFine() %s deadline = %v, want %v", deadline, dates[0], dates[1])
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,129 @@
+package data
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// GeoJSON geometry types the police works with
+const (
+	GeoTypePoint   = "Point"
+	GeoTypePolygon = "Polygon"
+)
+
+var (
+	ErrInvalidPosition  = errors.New("position must be a GeoJSON point with longitude between -180 and 180 and latitude between -90 and 90")
+	ErrInvalidArea      = errors.New("area must be a GeoJSON polygon of closed rings with at least four positions")
+	ErrInvalidRoad      = errors.New("road reference needs the road it is on")
+	ErrMunicipalityName = errors.New("municipality needs a name")
+)
+
+// GeoJSON point, coordinates are longitude first, then latitude
+type GeoPoint struct {
+	Type        string    `bson:"type" json:"type"`
+	Coordinates []float64 `bson:"coordinates" json:"coordinates"`
+}
+
+// GeoJSON polygon, the first ring is the boundary and the others are holes in it
+type GeoPolygon struct {
+	Type        string        `bson:"type" json:"type"`
+	Coordinates [][][]float64 `bson:"coordinates" json:"coordinates"`
+}
+
+// Place on the state road network, section and kilometre as on road markers
+type RoadReference struct {
+	Road      string   `bson:"road" json:"road"`
+	Section   string   `bson:"section,omitempty" json:"section,omitempty"`
+	Kilometre *float64 `bson:"kilometre,omitempty" json:"kilometre,omitempty"`
+}
+
+// Where a check was carried out or a violation was found, next to the free-text location
+type Place struct {
+	Position *GeoPoint      `bson:"position,omitempty" json:"position,omitempty"`
+	Road     *RoadReference `bson:"road,omitempty" json:"road,omitempty"`
+}
+
+// Boundary of a municipality, violations are looked up by it
+type Municipality struct {
+	Name     string     `bson:"name" json:"name"`
+	Boundary GeoPolygon `bson:"boundary" json:"boundary"`
+}
+
+type Municipalities []Municipality
+
+func NewGeoPoint(longitude, latitude float64) GeoPoint {
+	return GeoPoint{Type: GeoTypePoint, Coordinates: []float64{longitude, latitude}}
+}
+
+func (p *Place) Validate() error {
+	if p.Position != nil && !p.Position.valid() {
+		return ErrInvalidPosition
+	}
+	if p.Road != nil {
+		p.Road.Road = strings.TrimSpace(p.Road.Road)
+		if p.Road.Road == "" {
+			return ErrInvalidRoad
+		}
+	}
+	return nil
+}
+
+func (p *GeoPoint) valid() bool {
+	return p.Type == GeoTypePoint && len(p.Coordinates) == 2 && validPosition(p.Coordinates)
+}
+
+func (p *GeoPolygon) Validate() error {
+	if p.Type != GeoTypePolygon || len(p.Coordinates) == 0 {
+		return ErrInvalidArea
+	}
+	for _, ring := range p.Coordinates {
+		if len(ring) < 4 {
+			return ErrInvalidArea
+		}
+		for _, position := range ring {
+			if len(position) != 2 || !validPosition(position) {
+				return ErrInvalidArea
+			}
+		}
+		first, last := ring[0], ring[len(ring)-1]
+		if first[0] != last[0] || first[1] != last[1] {
+			return fmt.Errorf("%w, ring isn't closed", ErrInvalidArea)
+		}
+	}
+	return nil
+}
+
+func (m *Municipality) Validate() error {
+	m.Name = strings.TrimSpace(m.Name)
+	if m.Name == "" {
+		return ErrMunicipalityName
+	}
+	return m.Boundary.Validate()
+}
+
+func validPosition(position []float64) bool {
+	return position[0] >= -180 && position[0] <= 180 && position[1] >= -90 && position[1] <= 90
+}
+
+func (p *GeoPolygon) FromJSON(r io.Reader) error {
+	d := json.NewDecoder(r)
+	return d.Decode(p)
+}
+
+func (m *Municipality) ToJSON(w io.Writer) error {
+	e := json.NewEncoder(w)
+	return e.Encode(m)
+}
+
+func (m *Municipality) FromJSON(r io.Reader) error {
+	d := json.NewDecoder(r)
+	return d.Decode(m)
+}
+
+func (ms *Municipalities) ToJSON(w io.Writer) error {
+	e := json.NewEncoder(w)
+	return e.Encode(ms)
+}
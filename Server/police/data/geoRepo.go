@@ -0,0 +1,101 @@
+package data
+
+import (
+	"context"
+	"errors"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+var ErrMunicipalityNotFound = errors.New("municipality not found")
+
+//Spatial methods
+
+// Returns violations found within the radius in meters of the point, nearest first.
+// Violations recorded without a position are never returned
+func (pr *PoliceRepo) GetViolationsNear(ctx context.Context, point GeoPoint, radius float64) ([]*TrafficViolation, error) {
+	return pr.findViolations(ctx, bson.D{{Key: "position", Value: bson.D{{Key: "$nearSphere", Value: bson.D{
+		{Key: "$geometry", Value: point},
+		{Key: "$maxDistance", Value: radius},
+	}}}}})
+}
+
+// Returns violations found inside the area
+func (pr *PoliceRepo) GetViolationsWithin(ctx context.Context, area GeoPolygon) ([]*TrafficViolation, error) {
+	return pr.findViolations(ctx, bson.D{{Key: "position", Value: bson.D{{Key: "$geoWithin", Value: bson.D{
+		{Key: "$geometry", Value: area},
+	}}}}})
+}
+
+// Saves the boundary of the municipality, replacing the one saved before
+func (pr *PoliceRepo) SaveMunicipality(ctx context.Context, municipality Municipality) error {
+	filter := bson.D{{Key: "name", Value: municipality.Name}}
+	opts := options.Replace().SetUpsert(true)
+
+	_, err := pr.getPoliceCollection("municipalities").ReplaceOne(ctx, filter, municipality, opts)
+	return err
+}
+
+func (pr *PoliceRepo) GetMunicipality(ctx context.Context, name string) (Municipality, error) {
+	var municipality Municipality
+	err := pr.getPoliceCollection("municipalities").FindOne(ctx, bson.D{{Key: "name", Value: name}}).Decode(&municipality)
+	if err != nil {
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			return Municipality{}, ErrMunicipalityNotFound
+		}
+		return Municipality{}, err
+	}
+
+	return municipality, nil
+}
+
+// Returns the municipality whose boundary contains the point
+func (pr *PoliceRepo) GetMunicipalityAt(ctx context.Context, point GeoPoint) (Municipality, error) {
+	filter := bson.D{{Key: "boundary", Value: bson.D{{Key: "$geoIntersects", Value: bson.D{
+		{Key: "$geometry", Value: point},
+	}}}}}
+
+	var municipality Municipality
+	err := pr.getPoliceCollection("municipalities").FindOne(ctx, filter).Decode(&municipality)
+	if err != nil {
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			return Municipality{}, ErrMunicipalityNotFound
+		}
+		return Municipality{}, err
+	}
+
+	return municipality, nil
+}
+
+func (pr *PoliceRepo) GetMunicipalities(ctx context.Context) (Municipalities, error) {
+	opts := options.Find().SetSort(bson.D{{Key: "name", Value: 1}})
+	cursor, err := pr.getPoliceCollection("municipalities").Find(ctx, bson.D{}, opts)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	municipalities := Municipalities{}
+	if err = cursor.All(ctx, &municipalities); err != nil {
+		return nil, err
+	}
+
+	return municipalities, nil
+}
+
+func (pr *PoliceRepo) findViolations(ctx context.Context, filter bson.D) ([]*TrafficViolation, error) {
+	cursor, err := pr.getPoliceCollection("traffic_violations").Find(ctx, append(filter, notVoided))
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	violations := []*TrafficViolation{}
+	if err = cursor.All(ctx, &violations); err != nil {
+		return nil, err
+	}
+
+	return violations, nil
+}
@@ -0,0 +1,141 @@
+package data
+
+import (
+	"encoding/json"
+	"io"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// Types of roadside checks
+const (
+	CheckFull         = "FULL"
+	CheckAlcohol      = "ALCOHOL"
+	CheckDrivingBan   = "DRIVING_BAN"
+	CheckPermit       = "PERMIT"
+	CheckTire         = "TIRE"
+	CheckRegistration = "REGISTRATION"
+)
+
+// Traffic police officer. JMBG ties the officer to the SSO account they log in with
+type Officer struct {
+	ID          primitive.ObjectID `bson:"_id,omitempty" json:"id"`
+	JMBG        string             `bson:"jmbg" json:"jmbg"`
+	BadgeNumber string             `bson:"badgeNumber" json:"badgeNumber"`
+	FirstName   string             `bson:"firstName" json:"firstName"`
+	LastName    string             `bson:"lastName" json:"lastName"`
+	Rank        string             `bson:"rank" json:"rank"`
+	Station     string             `bson:"station" json:"station"`
+	Supervisor  bool               `bson:"supervisor" json:"supervisor"`
+	Active      bool               `bson:"active" json:"active"`
+}
+
+type Officers []Officer
+
+// Patrol unit of a station, identified on the radio by its call sign
+type Patrol struct {
+	ID       primitive.ObjectID `bson:"_id,omitempty" json:"id"`
+	CallSign string             `bson:"callSign" json:"callSign"`
+	Station  string             `bson:"station" json:"station"`
+	Vehicle  string             `bson:"vehicle" json:"vehicle"`
+}
+
+type Patrols []Patrol
+
+// Officers manning a patrol unit for a period of time
+type Shift struct {
+	ID        primitive.ObjectID   `bson:"_id,omitempty" json:"id"`
+	Patrol    primitive.ObjectID   `bson:"patrol" json:"patrol"`
+	Station   string               `bson:"station" json:"station"`
+	Officers  []primitive.ObjectID `bson:"officers" json:"officers"`
+	Start     time.Time            `bson:"start" json:"start"`
+	End       time.Time            `bson:"end" json:"end"`
+	CreatedBy primitive.ObjectID   `bson:"createdBy" json:"createdBy"`
+}
+
+type Shifts []Shift
+
+// Officer, patrol and shift a check or violation was recorded by. Violations
+// recorded before officers were kept have none
+type Stamp struct {
+	Officer     primitive.ObjectID `bson:"officer" json:"officer"`
+	BadgeNumber string             `bson:"badgeNumber" json:"badgeNumber"`
+	Patrol      primitive.ObjectID `bson:"patrol" json:"patrol"`
+	CallSign    string             `bson:"callSign" json:"callSign"`
+	Shift       primitive.ObjectID `bson:"shift" json:"shift"`
+}
+
+// Roadside check, kept whether or not it found a violation
+type Check struct {
+	ID         primitive.ObjectID `bson:"_id,omitempty" json:"id"`
+	Type       string             `bson:"type" json:"type"`
+	JMBG       string             `bson:"jmbg" json:"jmbg"`
+	Plates     string             `bson:"plates,omitempty" json:"plates,omitempty"`
+	Location   string             `bson:"location" json:"location"`
+	Time       time.Time          `bson:"time" json:"time"`
+	Place      `bson:",inline"`
+	Violation  primitive.ObjectID `bson:"violation,omitempty" json:"violation,omitempty"`
+	RecordedBy Stamp              `bson:"recordedBy" json:"recordedBy"`
+}
+
+type Checks []Check
+
+// What a shift did, for supervisors
+type ShiftActivity struct {
+	Shift      Shift               `json:"shift"`
+	Patrol     Patrol              `json:"patrol"`
+	Officers   Officers            `json:"officers"`
+	Checks     Checks              `json:"checks"`
+	Violations []*TrafficViolation `json:"violations"`
+}
+
+func (o *Officer) ToJSON(w io.Writer) error {
+	e := json.NewEncoder(w)
+	return e.Encode(o)
+}
+
+func (o *Officer) FromJSON(r io.Reader) error {
+	d := json.NewDecoder(r)
+	return d.Decode(o)
+}
+
+func (o *Officers) ToJSON(w io.Writer) error {
+	e := json.NewEncoder(w)
+	return e.Encode(o)
+}
+
+func (p *Patrol) ToJSON(w io.Writer) error {
+	e := json.NewEncoder(w)
+	return e.Encode(p)
+}
+
+func (p *Patrol) FromJSON(r io.Reader) error {
+	d := json.NewDecoder(r)
+	return d.Decode(p)
+}
+
+func (p *Patrols) ToJSON(w io.Writer) error {
+	e := json.NewEncoder(w)
+	return e.Encode(p)
+}
+
+func (s *Shift) ToJSON(w io.Writer) error {
+	e := json.NewEncoder(w)
+	return e.Encode(s)
+}
+
+func (s *Shift) FromJSON(r io.Reader) error {
+	d := json.NewDecoder(r)
+	return d.Decode(s)
+}
+
+func (s *Shifts) ToJSON(w io.Writer) error {
+	e := json.NewEncoder(w)
+	return e.Encode(s)
+}
+
+func (sa *ShiftActivity) ToJSON(w io.Writer) error {
+	e := json.NewEncoder(w)
+	return e.Encode(sa)
+}
@@ -0,0 +1,383 @@
+package data
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+var (
+	ErrOfficerNotFound = errors.New("officer not found")
+	ErrOfficerExists   = errors.New("officer with the badge number or JMBG already exists")
+	ErrPatrolNotFound  = errors.New("patrol not found")
+	ErrPatrolExists    = errors.New("patrol with the call sign already exists")
+	ErrShiftNotFound   = errors.New("shift not found")
+	ErrNotOnShift      = errors.New("officer is not on shift")
+	ErrShiftOverlaps   = errors.New("officer or patrol is already on another shift at that time")
+)
+
+// Creates indexes the queries rely on and the uniqueness of officers, patrols, offence codes and rule versions
+func (pr *PoliceRepo) EnsureIndexes(ctx context.Context) error {
+	// Passes of a camera used to be indexed without keeping them unique
+	if err := pr.dropIndex(ctx, "camera_passes", "cameraId_1_plate_1_timestamp_1"); err != nil {
+		return err
+	}
+
+	indexes := map[string][]mongo.IndexModel{
+		"officers": {
+			{Keys: bson.D{{Key: "badgeNumber", Value: 1}}, Options: options.Index().SetUnique(true)},
+			{Keys: bson.D{{Key: "jmbg", Value: 1}}, Options: options.Index().SetUnique(true)},
+			{Keys: bson.D{{Key: "station", Value: 1}}},
+		},
+		"patrols": {
+			{Keys: bson.D{{Key: "callSign", Value: 1}}, Options: options.Index().SetUnique(true)},
+		},
+		"shifts": {
+			{Keys: bson.D{{Key: "officers", Value: 1}, {Key: "start", Value: 1}, {Key: "end", Value: 1}}},
+			{Keys: bson.D{{Key: "patrol", Value: 1}, {Key: "start", Value: 1}}},
+			{Keys: bson.D{{Key: "station", Value: 1}, {Key: "start", Value: -1}}},
+		},
+		"checks": {
+			{Keys: bson.D{{Key: "recordedBy.shift", Value: 1}, {Key: "time", Value: 1}}},
+			{Keys: bson.D{{Key: "jmbg", Value: 1}}},
+			{Keys: bson.D{{Key: "position", Value: "2dsphere"}}},
+		},
+		"stops": {
+			{Keys: bson.D{{Key: "recordedBy.shift", Value: 1}, {Key: "openedAt", Value: -1}}},
+			{Keys: bson.D{{Key: "jmbg", Value: 1}}},
+		},
+		"traffic_violations": {
+			{Keys: bson.D{{Key: "violatorJMBG", Value: 1}}},
+			{Keys: bson.D{{Key: "recordedBy.shift", Value: 1}}},
+			{Keys: bson.D{{Key: "offences.code", Value: 1}}},
+			{Keys: bson.D{{Key: "position", Value: "2dsphere"}}},
+			{Keys: bson.D{{Key: "accident", Value: 1}}, Options: options.Index().SetSparse(true)},
+			{Keys: bson.D{{Key: "chargePending", Value: 1}}, Options: options.Index().SetSparse(true)},
+		},
+		"municipalities": {
+			{Keys: bson.D{{Key: "name", Value: 1}}, Options: options.Index().SetUnique(true)},
+			{Keys: bson.D{{Key: "boundary", Value: "2dsphere"}}},
+		},
+		"accidents": {
+			{Keys: bson.D{{Key: "time", Value: -1}}},
+			{Keys: bson.D{{Key: "municipality", Value: 1}, {Key: "time", Value: -1}}},
+			{Keys: bson.D{{Key: "persons.jmbg", Value: 1}}},
+			{Keys: bson.D{{Key: "position", Value: "2dsphere"}}},
+		},
+		"fines": {
+			{Keys: bson.D{{Key: "violation", Value: 1}}, Options: options.Index().SetUnique(true)},
+			{Keys: bson.D{{Key: "referenceNumber", Value: 1}}, Options: options.Index().SetUnique(true)},
+			{Keys: bson.D{{Key: "offender", Value: 1}, {Key: "issuedAt", Value: -1}}},
+			{Keys: bson.D{{Key: "status", Value: 1}, {Key: "dueDate", Value: 1}}},
+		},
+		"evidence": {
+			{Keys: bson.D{{Key: "violation", Value: 1}, {Key: "uploadedAt", Value: 1}}},
+			{Keys: bson.D{{Key: "blobKey", Value: 1}}, Options: options.Index().SetSparse(true)},
+		},
+		"evidence_custody": {
+			{Keys: bson.D{{Key: "evidence", Value: 1}, {Key: "at", Value: 1}}},
+			{Keys: bson.D{{Key: "violation", Value: 1}}},
+		},
+		"camera_passes": {
+			{Keys: bson.D{{Key: "cameraId", Value: 1}, {Key: "plate", Value: 1}, {Key: "timestamp", Value: 1}}, Options: options.Index().SetName("camera_pass").SetUnique(true)},
+		},
+		"camera_reviews": {
+			{Keys: bson.D{{Key: "status", Value: 1}, {Key: "receivedAt", Value: 1}}},
+		},
+		"rules": {
+			{Keys: bson.D{{Key: "version", Value: 1}}, Options: options.Index().SetUnique(true)},
+			{Keys: bson.D{{Key: "effectiveFrom", Value: -1}}},
+		},
+		"offences": {
+			{Keys: bson.D{{Key: "code", Value: 1}}, Options: options.Index().SetUnique(true)},
+		},
+	}
+
+	for collection, models := range indexes {
+		_, err := pr.getPoliceCollection(collection).Indexes().CreateMany(ctx, models)
+		if err != nil {
+			return fmt.Errorf("failed to create indexes for %s: %v", collection, err)
+		}
+	}
+
+	return nil
+}
+
+func (pr *PoliceRepo) dropIndex(ctx context.Context, collection, name string) error {
+	_, err := pr.getPoliceCollection(collection).Indexes().DropOne(ctx, name)
+	var cmdErr mongo.CommandError
+	if err != nil && !(errors.As(err, &cmdErr) && (cmdErr.Name == "IndexNotFound" || cmdErr.Name == "NamespaceNotFound")) {
+		return fmt.Errorf("failed to drop index %s of %s: %v", name, collection, err)
+	}
+	return nil
+}
+
+//Officer methods
+
+func (pr *PoliceRepo) CreateOfficer(ctx context.Context, officer *Officer) error {
+	officer.ID = primitive.NewObjectID()
+
+	_, err := pr.getPoliceCollection("officers").InsertOne(ctx, officer)
+	if mongo.IsDuplicateKeyError(err) {
+		return ErrOfficerExists
+	}
+	return err
+}
+
+// Returns officers of the station, or of all stations for an empty one
+func (pr *PoliceRepo) GetOfficers(ctx context.Context, station string) (Officers, error) {
+	filter := bson.D{}
+	if station != "" {
+		filter = append(filter, bson.E{Key: "station", Value: station})
+	}
+
+	opts := options.Find().SetSort(bson.D{{Key: "badgeNumber", Value: 1}})
+	cursor, err := pr.getPoliceCollection("officers").Find(ctx, filter, opts)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	officers := Officers{}
+	if err = cursor.All(ctx, &officers); err != nil {
+		return nil, err
+	}
+
+	return officers, nil
+}
+
+func (pr *PoliceRepo) GetOfficerByJMBG(ctx context.Context, jmbg string) (Officer, error) {
+	return pr.findOfficer(ctx, bson.D{{Key: "jmbg", Value: jmbg}})
+}
+
+func (pr *PoliceRepo) GetOfficerByID(ctx context.Context, id primitive.ObjectID) (Officer, error) {
+	return pr.findOfficer(ctx, bson.D{{Key: "_id", Value: id}})
+}
+
+// Changes rank, station and standing of the officer. Badge number and JMBG stay as issued
+func (pr *PoliceRepo) UpdateOfficer(ctx context.Context, id primitive.ObjectID, update Officer) (Officer, error) {
+	set := bson.D{
+		{Key: "rank", Value: update.Rank},
+		{Key: "station", Value: update.Station},
+		{Key: "supervisor", Value: update.Supervisor},
+		{Key: "active", Value: update.Active},
+	}
+
+	var officer Officer
+	opts := options.FindOneAndUpdate().SetReturnDocument(options.After)
+	err := pr.getPoliceCollection("officers").FindOneAndUpdate(ctx, bson.D{{Key: "_id", Value: id}}, bson.D{{Key: "$set", Value: set}}, opts).Decode(&officer)
+	if err != nil {
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			return Officer{}, ErrOfficerNotFound
+		}
+		return Officer{}, err
+	}
+
+	return officer, nil
+}
+
+//Patrol methods
+
+func (pr *PoliceRepo) CreatePatrol(ctx context.Context, patrol *Patrol) error {
+	patrol.ID = primitive.NewObjectID()
+
+	_, err := pr.getPoliceCollection("patrols").InsertOne(ctx, patrol)
+	if mongo.IsDuplicateKeyError(err) {
+		return ErrPatrolExists
+	}
+	return err
+}
+
+// Returns patrols of the station, or of all stations for an empty one
+func (pr *PoliceRepo) GetPatrols(ctx context.Context, station string) (Patrols, error) {
+	filter := bson.D{}
+	if station != "" {
+		filter = append(filter, bson.E{Key: "station", Value: station})
+	}
+
+	opts := options.Find().SetSort(bson.D{{Key: "callSign", Value: 1}})
+	cursor, err := pr.getPoliceCollection("patrols").Find(ctx, filter, opts)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	patrols := Patrols{}
+	if err = cursor.All(ctx, &patrols); err != nil {
+		return nil, err
+	}
+
+	return patrols, nil
+}
+
+func (pr *PoliceRepo) GetPatrolByID(ctx context.Context, id primitive.ObjectID) (Patrol, error) {
+	var patrol Patrol
+	err := pr.getPoliceCollection("patrols").FindOne(ctx, bson.D{{Key: "_id", Value: id}}).Decode(&patrol)
+	if err != nil {
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			return Patrol{}, ErrPatrolNotFound
+		}
+		return Patrol{}, err
+	}
+
+	return patrol, nil
+}
+
+//Shift methods
+
+// Saves shift unless one of its officers or its patrol is on another shift that overlaps it
+func (pr *PoliceRepo) CreateShift(ctx context.Context, shift *Shift) error {
+	collection := pr.getPoliceCollection("shifts")
+
+	filter := bson.D{
+		{Key: "$or", Value: bson.A{
+			bson.D{{Key: "patrol", Value: shift.Patrol}},
+			bson.D{{Key: "officers", Value: bson.D{{Key: "$in", Value: shift.Officers}}}},
+		}},
+		{Key: "start", Value: bson.D{{Key: "$lt", Value: shift.End}}},
+		{Key: "end", Value: bson.D{{Key: "$gt", Value: shift.Start}}},
+	}
+
+	count, err := collection.CountDocuments(ctx, filter)
+	if err != nil {
+		return err
+	}
+	if count > 0 {
+		return ErrShiftOverlaps
+	}
+
+	shift.ID = primitive.NewObjectID()
+	_, err = collection.InsertOne(ctx, shift)
+	return err
+}
+
+func (pr *PoliceRepo) GetShiftByID(ctx context.Context, id primitive.ObjectID) (Shift, error) {
+	var shift Shift
+	err := pr.getPoliceCollection("shifts").FindOne(ctx, bson.D{{Key: "_id", Value: id}}).Decode(&shift)
+	if err != nil {
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			return Shift{}, ErrShiftNotFound
+		}
+		return Shift{}, err
+	}
+
+	return shift, nil
+}
+
+// Returns shifts of the station that overlap the period, latest first
+func (pr *PoliceRepo) GetShifts(ctx context.Context, station string, from, to time.Time) (Shifts, error) {
+	filter := bson.D{
+		{Key: "start", Value: bson.D{{Key: "$lt", Value: to}}},
+		{Key: "end", Value: bson.D{{Key: "$gt", Value: from}}},
+	}
+	if station != "" {
+		filter = append(filter, bson.E{Key: "station", Value: station})
+	}
+
+	opts := options.Find().SetSort(bson.D{{Key: "start", Value: -1}})
+	cursor, err := pr.getPoliceCollection("shifts").Find(ctx, filter, opts)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	shifts := Shifts{}
+	if err = cursor.All(ctx, &shifts); err != nil {
+		return nil, err
+	}
+
+	return shifts, nil
+}
+
+// Returns the shift the officer is on at the given time
+func (pr *PoliceRepo) GetOfficersShift(ctx context.Context, officer primitive.ObjectID, at time.Time) (Shift, error) {
+	filter := bson.D{
+		{Key: "officers", Value: officer},
+		{Key: "start", Value: bson.D{{Key: "$lte", Value: at}}},
+		{Key: "end", Value: bson.D{{Key: "$gt", Value: at}}},
+	}
+
+	var shift Shift
+	err := pr.getPoliceCollection("shifts").FindOne(ctx, filter).Decode(&shift)
+	if err != nil {
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			return Shift{}, ErrNotOnShift
+		}
+		return Shift{}, err
+	}
+
+	return shift, nil
+}
+
+// Collects the patrol, officers, checks and violations of the shift
+func (pr *PoliceRepo) GetShiftActivity(ctx context.Context, id primitive.ObjectID) (ShiftActivity, error) {
+	shift, err := pr.GetShiftByID(ctx, id)
+	if err != nil {
+		return ShiftActivity{}, err
+	}
+
+	activity := ShiftActivity{Shift: shift, Officers: Officers{}, Checks: Checks{}, Violations: []*TrafficViolation{}}
+
+	activity.Patrol, err = pr.GetPatrolByID(ctx, shift.Patrol)
+	if err != nil && !errors.Is(err, ErrPatrolNotFound) {
+		return ShiftActivity{}, err
+	}
+
+	cursor, err := pr.getPoliceCollection("officers").Find(ctx, bson.D{{Key: "_id", Value: bson.D{{Key: "$in", Value: shift.Officers}}}})
+	if err != nil {
+		return ShiftActivity{}, err
+	}
+	if err = cursor.All(ctx, &activity.Officers); err != nil {
+		return ShiftActivity{}, err
+	}
+
+	byShift := bson.D{{Key: "recordedBy.shift", Value: id}}
+
+	cursor, err = pr.getPoliceCollection("checks").Find(ctx, byShift, options.Find().SetSort(bson.D{{Key: "time", Value: 1}}))
+	if err != nil {
+		return ShiftActivity{}, err
+	}
+	if err = cursor.All(ctx, &activity.Checks); err != nil {
+		return ShiftActivity{}, err
+	}
+
+	cursor, err = pr.getPoliceCollection("traffic_violations").Find(ctx, append(byShift, notVoided), options.Find().SetSort(bson.D{{Key: "time", Value: 1}}))
+	if err != nil {
+		return ShiftActivity{}, err
+	}
+	if err = cursor.All(ctx, &activity.Violations); err != nil {
+		return ShiftActivity{}, err
+	}
+
+	return activity, nil
+}
+
+//Check methods
+
+func (pr *PoliceRepo) SaveCheck(ctx context.Context, check *Check) error {
+	if check.ID.IsZero() {
+		check.ID = primitive.NewObjectID()
+	}
+
+	_, err := pr.getPoliceCollection("checks").InsertOne(ctx, check)
+	return err
+}
+
+func (pr *PoliceRepo) findOfficer(ctx context.Context, filter bson.D) (Officer, error) {
+	var officer Officer
+	err := pr.getPoliceCollection("officers").FindOne(ctx, filter).Decode(&officer)
+	if err != nil {
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			return Officer{}, ErrOfficerNotFound
+		}
+		return Officer{}, err
+	}
+
+	return officer, nil
+}
@@ -8,11 +8,6 @@ import (
 	"go.mongodb.org/mongo-driver/bson/primitive"
 )
 
-type TrafficPolice struct {
-	ID   primitive.ObjectID `bson:"_id,omitempty" json:"id"`
-	Name string             `bson:"name" json:"name"`
-}
-
 type TrafficViolation struct {
 	ID           primitive.ObjectID `bson:"_id,omitempty" json:"id"`
 	ViolatorJMBG string             `bson:"violatorJMBG" json:"violatorJMBG"`
@@ -20,37 +15,75 @@ type TrafficViolation struct {
 	Description  string             `bson:"description" json:"description"`
 	Time         time.Time          `bson:"time" json:"time"`
 	Location     string             `bson:"location" json:"location"`
-}
-
+	RecordedBy   *Stamp             `bson:"recordedBy,omitempty" json:"recordedBy,omitempty"`
+	// Camera that recorded the violation, for violations recorded without an officer
+	Camera string `bson:"camera,omitempty" json:"camera,omitempty"`
+	// Accident the violation was found to have caused
+	Accident *primitive.ObjectID `bson:"accident,omitempty" json:"accident,omitempty"`
+	// Violations are never removed or changed without a record, Version counts the
+	// amendments made to the violation, History records them and Status is empty until
+	// it is voided
+	Status      string              `bson:"status,omitempty" json:"status,omitempty"`
+	Version     int                 `bson:"version,omitempty" json:"version"`
+	VoidRequest *VoidRequest        `bson:"voidRequest,omitempty" json:"voidRequest,omitempty"`
+	History     ViolationAmendments `bson:"history,omitempty" json:"-"`
+	Offences    OffenceItems        `bson:"offences" json:"offences"`
+	// Set until the violation is fined or reported to the court, the escalation job
+	// retries violations that couldn't be when they were recorded
+	ChargePending bool `bson:"chargePending,omitempty" json:"-"`
+	Place         `bson:",inline"`
+}
+
+// Time is when the check was carried out, now if empty. Driver category and vehicle
+// type are what the officer found, the category is worked out from the permit if empty
 type DriverCheck struct {
-	JMBG         string  `bson:"jmbg" json:"jmbg"`
-	AlcoholLevel float64 `bson:"alcoholLevel" json:"alcoholLevel"`
-	Tire         string  `bson:"tire" json:"tire"`
-	PlatesNumber string  `bson:"platesNumber" json:"platesNumber"`
-	Location     string  `bson:"location" json:"location"`
+	JMBG           string    `bson:"jmbg" json:"jmbg"`
+	AlcoholLevel   *float64  `bson:"alcoholLevel" json:"alcoholLevel"`
+	Tire           string    `bson:"tire" json:"tire"`
+	PlatesNumber   string    `bson:"platesNumber" json:"platesNumber"`
+	Location       string    `bson:"location" json:"location"`
+	Time           time.Time `bson:"time" json:"time"`
+	DriverCategory string    `bson:"driverCategory" json:"driverCategory"`
+	VehicleType    string    `bson:"vehicleType" json:"vehicleType"`
+	// Insurance the officer found, the vehicle isn't checked for it if left out
+	Insured      *bool      `bson:"insured,omitempty" json:"insured,omitempty"`
+	PolicyNumber string     `bson:"policyNumber,omitempty" json:"policyNumber,omitempty"`
+	InsuredUntil *time.Time `bson:"insuredUntil,omitempty" json:"insuredUntil,omitempty"`
+	Place        `bson:",inline"`
 }
 
 type AlcoholRequest struct {
-	AlcoholLevel float64 `json:"alcoholLevel"`
-	JMBG         string  `json:"jmbg"`
-	Location     string  `json:"location"`
+	AlcoholLevel   float64   `json:"alcoholLevel"`
+	JMBG           string    `json:"jmbg"`
+	Location       string    `json:"location"`
+	Time           time.Time `json:"time"`
+	DriverCategory string    `json:"driverCategory"`
+	VehicleType    string    `json:"vehicleType"`
+	Place
 }
 
 type DriverBanAndPermitRequest struct {
-	JMBG     string `json:"jmbg"`
-	Location string `json:"location"`
+	JMBG     string    `json:"jmbg"`
+	Location string    `json:"location"`
+	Time     time.Time `json:"time"`
+	Place
 }
 
 type VehicleTireCheck struct {
-	TireType string `json:"tireType"`
-	JMBG     string `json:"jmbg"`
-	Location string `json:"location"`
+	TireType    string    `json:"tireType"`
+	JMBG        string    `json:"jmbg"`
+	Location    string    `json:"location"`
+	Time        time.Time `json:"time"`
+	VehicleType string    `json:"vehicleType"`
+	Place
 }
 
 type CheckVehicleRegistration struct {
-	PlatesNumber string `json:"platesNumber"`
-	JMBG         string `json:"jmbg"`
-	Location     string `json:"location"`
+	PlatesNumber string    `json:"platesNumber"`
+	JMBG         string    `json:"jmbg"`
+	Location     string    `json:"location"`
+	Time         time.Time `json:"time"`
+	Place
 }
 
 type Response struct {
@@ -59,20 +92,13 @@ type Response struct {
 }
 
 type DrivingBan struct {
-	ID       primitive.ObjectID `bson:"_id,omitempty" json:"id"`
-	Reason   string             `bson:"reason" json:"reason"`
-	Duration time.Time          `bson:"duration" json:"duration"`
-	Person   string             `bson:"person" json:"person"`
-}
-
-func (tp *TrafficPolice) ToJSON(w io.Writer) error {
-	e := json.NewEncoder(w)
-	return e.Encode(tp)
-}
-
-func (tp *TrafficPolice) FromJSON(r io.Reader) error {
-	d := json.NewDecoder(r)
-	return d.Decode(tp)
+	ID        primitive.ObjectID `bson:"_id,omitempty" json:"id"`
+	Reason    string             `bson:"reason" json:"reason"`
+	Start     time.Time          `bson:"start" json:"start"`
+	End       time.Time          `bson:"end" json:"end"`
+	Person    string             `bson:"person" json:"person"`
+	Authority string             `bson:"authority" json:"authority"`
+	CaseID    string             `bson:"caseID" json:"caseID"`
 }
 
 func (tv *TrafficViolation) ToJSON(w io.Writer) error {
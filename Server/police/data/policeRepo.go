@@ -73,6 +73,8 @@ func (pr *PoliceRepo) Initialize(ctx context.Context) error {
 		return err
 	}
 
+	speedingPosition := NewGeoPoint(19.8545, 45.2396)
+	drunkDrivingPosition := NewGeoPoint(19.8335, 45.2551)
 	trafficViolations := []TrafficViolation{
 		{
 			ID:           primitive.NewObjectID(),
@@ -81,6 +83,7 @@ func (pr *PoliceRepo) Initialize(ctx context.Context) error {
 			Description:  "Person was caught operating a vehicle above speed limit",
 			Time:         time.Now(),
 			Location:     "Novi Sad",
+			Place:        Place{Position: &speedingPosition, Road: &RoadReference{Road: "A1", Section: "Novi Sad - Beograd"}},
 		},
 		{
 			ID:           primitive.NewObjectID(),
@@ -89,6 +92,7 @@ func (pr *PoliceRepo) Initialize(ctx context.Context) error {
 			Description:  "Person was caught operating a vehicle under influence",
 			Time:         time.Now(),
 			Location:     "Novi Sad",
+			Place:        Place{Position: &drunkDrivingPosition},
 		},
 	}
 
@@ -102,6 +106,44 @@ func (pr *PoliceRepo) Initialize(ctx context.Context) error {
 		return err
 	}
 
+	for _, collection := range []string{"officers", "patrols", "shifts", "checks"} {
+		if err := db.Collection(collection).Drop(ctx); err != nil {
+			return err
+		}
+	}
+
+	officers := []interface{}{
+		Officer{ID: primitive.NewObjectID(), JMBG: "123456789", BadgeNumber: "NS-1001", FirstName: "Mika", LastName: "Mikic", Rank: "Inspektor", Station: "Novi Sad", Supervisor: true, Active: true},
+		Officer{ID: primitive.NewObjectID(), JMBG: "987654321", BadgeNumber: "NS-1002", FirstName: "Ana", LastName: "Anic", Rank: "Policajac", Station: "Novi Sad", Active: true},
+	}
+
+	_, err = db.Collection("officers").InsertMany(ctx, officers)
+	if err != nil {
+		return err
+	}
+
+	patrol := Patrol{ID: primitive.NewObjectID(), CallSign: "NS-01", Station: "Novi Sad", Vehicle: "NS-POL-01"}
+	_, err = db.Collection("patrols").InsertOne(ctx, patrol)
+	if err != nil {
+		return err
+	}
+
+	// Test officers are on shift for a day from loading the data
+	supervisor := officers[0].(Officer)
+	shift := Shift{
+		ID:        primitive.NewObjectID(),
+		Patrol:    patrol.ID,
+		Station:   patrol.Station,
+		Officers:  []primitive.ObjectID{supervisor.ID, officers[1].(Officer).ID},
+		Start:     time.Now().Add(-time.Hour),
+		End:       time.Now().Add(23 * time.Hour),
+		CreatedBy: supervisor.ID,
+	}
+	_, err = db.Collection("shifts").InsertOne(ctx, shift)
+	if err != nil {
+		return err
+	}
+
 	return nil
 }
 
@@ -111,6 +153,20 @@ func (pr *PoliceRepo) CreateTrafficViolation(ctx context.Context, violation *Tra
 	return err
 }
 
+// Saves the violations in one go
+func (pr *PoliceRepo) CreateTrafficViolations(ctx context.Context, violations []TrafficViolation) error {
+	if len(violations) == 0 {
+		return nil
+	}
+
+	documents := make([]interface{}, len(violations))
+	for i := range violations {
+		documents[i] = violations[i]
+	}
+	_, err := pr.getPoliceCollection("traffic_violations").InsertMany(ctx, documents)
+	return err
+}
+
 func (pr *PoliceRepo) GetTrafficViolationByID(ctx context.Context, id primitive.ObjectID) (*TrafficViolation, error) {
 	collection := pr.getPoliceCollection("traffic_violations")
 	violation := &TrafficViolation{}
@@ -123,7 +179,7 @@ func (pr *PoliceRepo) GetTrafficViolationByID(ctx context.Context, id primitive.
 
 func (pr *PoliceRepo) GetTrafficViolationsByJMBG(ctx context.Context, violatorJMBG string) ([]*TrafficViolation, error) {
 	collection := pr.getPoliceCollection("traffic_violations")
-	cursor, err := collection.Find(ctx, bson.M{"violatorJMBG": violatorJMBG})
+	cursor, err := collection.Find(ctx, bson.M{"violatorJMBG": violatorJMBG, "status": bson.M{"$ne": ViolationVoided}})
 	if err != nil {
 		return nil, err
 	}
@@ -140,9 +196,13 @@ func (pr *PoliceRepo) GetTrafficViolationsByJMBG(ctx context.Context, violatorJM
 	return violations, nil
 }
 
+// Matches violations that aren't voided, voided violations are only kept for their history
+var notVoided = bson.E{Key: "status", Value: bson.D{{Key: "$ne", Value: ViolationVoided}}}
+
+// Returns every violation that isn't voided
 func (pr *PoliceRepo) GetAllTrafficViolations(ctx context.Context) ([]*TrafficViolation, error) {
 	collection := pr.getPoliceCollection("traffic_violations")
-	cursor, err := collection.Find(ctx, bson.M{})
+	cursor, err := collection.Find(ctx, bson.M{"status": bson.M{"$ne": ViolationVoided}})
 	if err != nil {
 		return nil, err
 	}
@@ -162,16 +222,36 @@ func (pr *PoliceRepo) GetAllTrafficViolations(ctx context.Context) ([]*TrafficVi
 	return violations, nil
 }
 
-func (pr *PoliceRepo) UpdateTrafficViolation(ctx context.Context, id primitive.ObjectID, update *TrafficViolation) error {
-	collection := pr.getPoliceCollection("traffic_violations")
-	_, err := collection.ReplaceOne(ctx, bson.M{"_id": id}, update)
-	return err
+// Marks the violation as waiting for its fine or crime report, or clears the mark
+func (pr *PoliceRepo) SetViolationChargePending(ctx context.Context, id primitive.ObjectID, pending bool) error {
+	update := bson.D{{Key: "$unset", Value: bson.D{{Key: "chargePending", Value: ""}}}}
+	if pending {
+		update = bson.D{{Key: "$set", Value: bson.D{{Key: "chargePending", Value: true}}}}
+	}
+
+	result, err := pr.getPoliceCollection("traffic_violations").UpdateByID(ctx, id, update)
+	if err != nil {
+		return err
+	}
+	if result.MatchedCount == 0 {
+		return ErrViolationNotFound
+	}
+	return nil
 }
 
-func (pr *PoliceRepo) DeleteTrafficViolation(ctx context.Context, id primitive.ObjectID) error {
-	collection := pr.getPoliceCollection("traffic_violations")
-	_, err := collection.DeleteOne(ctx, bson.M{"_id": id})
-	return err
+// Returns violations still waiting for their fine or crime report, except voided ones
+func (pr *PoliceRepo) GetViolationsPendingCharge(ctx context.Context) ([]TrafficViolation, error) {
+	filter := bson.D{{Key: "chargePending", Value: true}, notVoided}
+	cursor, err := pr.getPoliceCollection("traffic_violations").Find(ctx, filter)
+	if err != nil {
+		return nil, err
+	}
+
+	violations := []TrafficViolation{}
+	if err = cursor.All(ctx, &violations); err != nil {
+		return nil, err
+	}
+	return violations, nil
 }
 
 func (pr *PoliceRepo) getPoliceCollection(nameOfCollection string) *mongo.Collection {
@@ -0,0 +1,67 @@
+package data
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo/integration/mtest"
+)
+
+func TestSetViolationChargePending(t *testing.T) {
+	mt := mtest.New(t, mtest.NewOptions().ClientType(mtest.Mock))
+
+	tests := []struct {
+		name     string
+		pending  bool
+		matched  int32
+		wantOp   string
+		wantErr  error
+		wantMark bool
+	}{
+		{name: "marked pending", pending: true, matched: 1, wantOp: "$set", wantMark: true},
+		{name: "mark cleared", pending: false, matched: 1, wantOp: "$unset"},
+		{name: "violation not found", pending: true, matched: 0, wantOp: "$set", wantErr: ErrViolationNotFound, wantMark: true},
+	}
+
+	for _, tt := range tests {
+		mt.Run(tt.name, func(mt *mtest.T) {
+			mt.AddMockResponses(mtest.CreateSuccessResponse(bson.E{Key: "n", Value: tt.matched}, bson.E{Key: "nModified", Value: tt.matched}))
+
+			pr := &PoliceRepo{cli: mt.Client}
+			id := primitive.NewObjectID()
+			err := pr.SetViolationChargePending(context.Background(), id, tt.pending)
+			if !errors.Is(err, tt.wantErr) {
+				mt.Fatalf("SetViolationChargePending() error = %v, want %v", err, tt.wantErr)
+			}
+
+			var got struct {
+				Updates []struct {
+					Q bson.M `bson:"q"`
+					U bson.M `bson:"u"`
+				} `bson:"updates"`
+			}
+			update := mt.GetStartedEvent()
+			if err := bson.Unmarshal(update.Command, &got); err != nil || len(got.Updates) != 1 {
+				mt.Fatalf("update %v can't be read: %v", update.Command, err)
+			}
+			if got.Updates[0].Q["_id"] != id {
+				mt.Errorf("violation updated with filter %v, want %s", got.Updates[0].Q, id.Hex())
+			}
+
+			// The mark is removed rather than set false, so only pending violations carry it
+			fields, ok := got.Updates[0].U[tt.wantOp].(bson.M)
+			if !ok || len(got.Updates[0].U) != 1 {
+				mt.Fatalf("violation updated with %v, want %s", got.Updates[0].U, tt.wantOp)
+			}
+			if _, ok := fields["chargePending"]; !ok {
+				mt.Errorf("violation updated with %v, want chargePending", fields)
+			}
+			if tt.wantMark && fields["chargePending"] != true {
+				mt.Errorf("violation updated with %v, want it marked pending", fields)
+			}
+		})
+	}
+}
@@ -0,0 +1,255 @@
+package data
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// Categories of drivers the alcohol limit depends on
+const (
+	DriverRegular      = "REGULAR"
+	DriverNovice       = "NOVICE"
+	DriverProfessional = "PROFESSIONAL"
+)
+
+// Types of vehicles rules can be set for
+const (
+	VehicleCar        = "CAR"
+	VehicleMotorcycle = "MOTORCYCLE"
+	VehicleTruck      = "TRUCK"
+	VehicleBus        = "BUS"
+)
+
+// Tire types
+const (
+	TireSummer = "SUMMER"
+	TireWinter = "WINTER"
+)
+
+var (
+	ErrInvalidDriverCategory = errors.New("driver category must be REGULAR, NOVICE or PROFESSIONAL")
+	ErrInvalidVehicleType    = errors.New("vehicle type must be CAR, MOTORCYCLE, TRUCK or BUS")
+	ErrInvalidAlcoholLimit   = errors.New("alcohol limits must not be negative")
+	ErrInvalidWinterPeriod   = errors.New("winter period must start and end on valid days of the year")
+	ErrInvalidNovicePeriod   = errors.New("novice period must not be negative")
+)
+
+// Legal thresholds and seasonal rules checks are evaluated against. Rule sets are never
+// changed once in effect, a legal change is a new version effective from its date
+type RuleSet struct {
+	ID            primitive.ObjectID `bson:"_id,omitempty" json:"id"`
+	Version       int                `bson:"version" json:"version"`
+	EffectiveFrom time.Time          `bson:"effectiveFrom" json:"effectiveFrom"`
+	LegalBasis    string             `bson:"legalBasis" json:"legalBasis"`
+	// Limit in promille for drivers and vehicles no more specific limit is set for
+	DefaultAlcoholLimit float64        `bson:"defaultAlcoholLimit" json:"defaultAlcoholLimit"`
+	AlcoholLimits       []AlcoholLimit `bson:"alcoholLimits" json:"alcoholLimits"`
+	// Years from the first permit a driver is a novice for
+	NovicePeriodYears int       `bson:"novicePeriodYears" json:"novicePeriodYears"`
+	WinterPeriod      DayPeriod `bson:"winterPeriod" json:"winterPeriod"`
+	// Vehicle types that need winter tires in the winter period, all of them if empty
+	WinterTireVehicles []string `bson:"winterTireVehicles" json:"winterTireVehicles"`
+	// Whether winter tires outside the winter period are a violation
+	WinterTiresOnlyInWinter bool      `bson:"winterTiresOnlyInWinter" json:"winterTiresOnlyInWinter"`
+	CreatedAt               time.Time `bson:"createdAt" json:"createdAt"`
+	CreatedBy               string    `bson:"createdBy" json:"createdBy"`
+}
+
+type RuleSets []RuleSet
+
+// Alcohol limit for a driver category, a vehicle type or both. Empty fields match any
+type AlcoholLimit struct {
+	DriverCategory string  `bson:"driverCategory,omitempty" json:"driverCategory,omitempty"`
+	VehicleType    string  `bson:"vehicleType,omitempty" json:"vehicleType,omitempty"`
+	Limit          float64 `bson:"limit" json:"limit"`
+}
+
+// Period of the year from the first day up to, but not including, the last. It wraps
+// over the new year when it ends before it starts
+type DayPeriod struct {
+	FromMonth time.Month `bson:"fromMonth" json:"fromMonth"`
+	FromDay   int        `bson:"fromDay" json:"fromDay"`
+	ToMonth   time.Month `bson:"toMonth" json:"toMonth"`
+	ToDay     int        `bson:"toDay" json:"toDay"`
+}
+
+// Rules the service starts with, as set by the Law on road traffic safety
+var DefaultRuleSet = RuleSet{
+	Version:             1,
+	EffectiveFrom:       time.Date(2009, time.December, 11, 0, 0, 0, 0, time.Local),
+	LegalBasis:          "Zakon o bezbednosti saobraćaja na putevima, Sl. glasnik RS 41/2009",
+	DefaultAlcoholLimit: 0.2,
+	AlcoholLimits: []AlcoholLimit{
+		{DriverCategory: DriverNovice, Limit: 0},
+		{DriverCategory: DriverProfessional, Limit: 0},
+		{VehicleType: VehicleTruck, Limit: 0},
+		{VehicleType: VehicleBus, Limit: 0},
+	},
+	NovicePeriodYears:       2,
+	WinterPeriod:            DayPeriod{FromMonth: time.November, FromDay: 1, ToMonth: time.April, ToDay: 1},
+	WinterTireVehicles:      []string{},
+	WinterTiresOnlyInWinter: true,
+}
+
+func (rs *RuleSet) Validate() error {
+	if rs.DefaultAlcoholLimit < 0 {
+		return ErrInvalidAlcoholLimit
+	}
+	if rs.NovicePeriodYears < 0 {
+		return ErrInvalidNovicePeriod
+	}
+	for _, limit := range rs.AlcoholLimits {
+		if limit.Limit < 0 {
+			return ErrInvalidAlcoholLimit
+		}
+		if limit.DriverCategory != "" && !ValidDriverCategory(limit.DriverCategory) {
+			return ErrInvalidDriverCategory
+		}
+		if limit.VehicleType != "" && !ValidVehicleType(limit.VehicleType) {
+			return ErrInvalidVehicleType
+		}
+	}
+	for _, vehicle := range rs.WinterTireVehicles {
+		if !ValidVehicleType(vehicle) {
+			return ErrInvalidVehicleType
+		}
+	}
+	if !validDay(rs.WinterPeriod.FromMonth, rs.WinterPeriod.FromDay) || !validDay(rs.WinterPeriod.ToMonth, rs.WinterPeriod.ToDay) {
+		return ErrInvalidWinterPeriod
+	}
+
+	return nil
+}
+
+// Returns the limit of the most specific rule matching the driver and the vehicle. A
+// rule for both wins over a rule for either, and the lowest limit wins among equals
+func (rs *RuleSet) AlcoholLimit(driverCategory, vehicleType string) float64 {
+	limit := rs.DefaultAlcoholLimit
+	best := -1
+
+	for _, rule := range rs.AlcoholLimits {
+		if rule.DriverCategory != "" && rule.DriverCategory != driverCategory {
+			continue
+		}
+		if rule.VehicleType != "" && rule.VehicleType != vehicleType {
+			continue
+		}
+
+		specificity := 0
+		if rule.DriverCategory != "" {
+			specificity++
+		}
+		if rule.VehicleType != "" {
+			specificity++
+		}
+
+		if specificity > best || specificity == best && rule.Limit < limit {
+			limit = rule.Limit
+			best = specificity
+		}
+	}
+
+	return limit
+}
+
+// Returns the category the officer declared, otherwise novice for drivers whose permit
+// was issued within the novice period before the check
+func (rs *RuleSet) DriverCategory(declared string, permit TrafficPermit, at time.Time) string {
+	if declared != "" {
+		return declared
+	}
+	if !permit.IssuedDate.IsZero() && at.Before(permit.IssuedDate.AddDate(rs.NovicePeriodYears, 0, 0)) {
+		return DriverNovice
+	}
+	return DriverRegular
+}
+
+func (rs *RuleSet) IsWinter(at time.Time) bool {
+	return rs.WinterPeriod.Contains(at)
+}
+
+// Reports whether the tires on the vehicle break the seasonal rules at the time
+func (rs *RuleSet) ImproperTires(tire, vehicleType string, at time.Time) bool {
+	winter := rs.IsWinter(at)
+
+	switch tire {
+	case TireSummer:
+		return winter && rs.needsWinterTires(vehicleType)
+	case TireWinter:
+		return !winter && rs.WinterTiresOnlyInWinter
+	}
+	return false
+}
+
+func (rs *RuleSet) needsWinterTires(vehicleType string) bool {
+	if len(rs.WinterTireVehicles) == 0 {
+		return true
+	}
+	for _, vehicle := range rs.WinterTireVehicles {
+		if vehicle == vehicleType {
+			return true
+		}
+	}
+	return false
+}
+
+func (p DayPeriod) Contains(at time.Time) bool {
+	day := dayOfYear(at.Month(), at.Day())
+	from := dayOfYear(p.FromMonth, p.FromDay)
+	to := dayOfYear(p.ToMonth, p.ToDay)
+
+	if from <= to {
+		return day >= from && day < to
+	}
+	return day >= from || day < to
+}
+
+// Period as "November 1 to April 1"
+func (p DayPeriod) String() string {
+	return fmt.Sprintf("%s %d to %s %d", p.FromMonth, p.FromDay, p.ToMonth, p.ToDay)
+}
+
+// Day as a number that orders days of a year the same way in leap and common years
+func dayOfYear(month time.Month, day int) int {
+	return int(month)*32 + day
+}
+
+func validDay(month time.Month, day int) bool {
+	if month < time.January || month > time.December || day < 1 {
+		return false
+	}
+	// Leap year, so that February 29 is allowed
+	return day <= time.Date(2024, month+1, 0, 0, 0, 0, 0, time.UTC).Day()
+}
+
+func ValidDriverCategory(category string) bool {
+	return category == DriverRegular || category == DriverNovice || category == DriverProfessional
+}
+
+func ValidVehicleType(vehicleType string) bool {
+	switch vehicleType {
+	case VehicleCar, VehicleMotorcycle, VehicleTruck, VehicleBus:
+		return true
+	}
+	return false
+}
+
+func (rs *RuleSet) ToJSON(w io.Writer) error {
+	e := json.NewEncoder(w)
+	return e.Encode(rs)
+}
+
+func (rs *RuleSet) FromJSON(r io.Reader) error {
+	d := json.NewDecoder(r)
+	return d.Decode(rs)
+}
+
+func (rs *RuleSets) ToJSON(w io.Writer) error {
+	e := json.NewEncoder(w)
+	return e.Encode(rs)
+}
@@ -0,0 +1,125 @@
+package data
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+var (
+	ErrNoRulesInEffect  = errors.New("no rules are in effect at that time")
+	ErrRuleSetNotFound  = errors.New("rule set not found")
+	ErrRuleSetInEffect  = errors.New("rule set is already in effect and can't be removed")
+	ErrRuleSetConflict  = errors.New("another rule set was saved at the same time, try again")
+	ErrRuleSetInThePast = errors.New("rule set can't take effect before the latest one in effect")
+)
+
+//Rule methods
+
+// Saves the default rules unless there already are rules
+func (pr *PoliceRepo) SeedRules(ctx context.Context) error {
+	rules := DefaultRuleSet
+	rules.ID = primitive.NewObjectID()
+	rules.CreatedAt = time.Now()
+
+	_, err := pr.getPoliceCollection("rules").UpdateOne(ctx,
+		bson.D{{Key: "version", Value: rules.Version}},
+		bson.D{{Key: "$setOnInsert", Value: rules}},
+		options.Update().SetUpsert(true))
+	return err
+}
+
+// Returns the rule set in effect at the time, the one that took effect last before it
+func (pr *PoliceRepo) GetRulesAt(ctx context.Context, at time.Time) (RuleSet, error) {
+	filter := bson.D{{Key: "effectiveFrom", Value: bson.D{{Key: "$lte", Value: at}}}}
+	opts := options.FindOne().SetSort(bson.D{{Key: "effectiveFrom", Value: -1}, {Key: "version", Value: -1}})
+
+	var rules RuleSet
+	err := pr.getPoliceCollection("rules").FindOne(ctx, filter, opts).Decode(&rules)
+	if err != nil {
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			return RuleSet{}, ErrNoRulesInEffect
+		}
+		return RuleSet{}, err
+	}
+
+	return rules, nil
+}
+
+// Returns every version of the rules, latest first
+func (pr *PoliceRepo) GetRuleSets(ctx context.Context) (RuleSets, error) {
+	opts := options.Find().SetSort(bson.D{{Key: "version", Value: -1}})
+	cursor, err := pr.getPoliceCollection("rules").Find(ctx, bson.D{}, opts)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	rules := RuleSets{}
+	if err = cursor.All(ctx, &rules); err != nil {
+		return nil, err
+	}
+
+	return rules, nil
+}
+
+// Saves the rules as the next version. Rules can't take effect before the ones
+// currently in effect, so checks already carried out keep being judged the same way
+func (pr *PoliceRepo) CreateRuleSet(ctx context.Context, rules *RuleSet) error {
+	current, err := pr.GetRulesAt(ctx, time.Now())
+	if err != nil && !errors.Is(err, ErrNoRulesInEffect) {
+		return err
+	}
+	if err == nil && !rules.EffectiveFrom.After(current.EffectiveFrom) {
+		return ErrRuleSetInThePast
+	}
+
+	var latest RuleSet
+	opts := options.FindOne().SetSort(bson.D{{Key: "version", Value: -1}})
+	err = pr.getPoliceCollection("rules").FindOne(ctx, bson.D{}, opts).Decode(&latest)
+	if err != nil && !errors.Is(err, mongo.ErrNoDocuments) {
+		return err
+	}
+
+	rules.ID = primitive.NewObjectID()
+	rules.Version = latest.Version + 1
+	rules.CreatedAt = time.Now()
+
+	_, err = pr.getPoliceCollection("rules").InsertOne(ctx, rules)
+	if mongo.IsDuplicateKeyError(err) {
+		return ErrRuleSetConflict
+	}
+	return err
+}
+
+// Removes a version that hasn't taken effect yet, for correcting mistakes before it does
+func (pr *PoliceRepo) DeleteRuleSet(ctx context.Context, version int) error {
+	collection := pr.getPoliceCollection("rules")
+
+	var rules RuleSet
+	err := collection.FindOne(ctx, bson.D{{Key: "version", Value: version}}).Decode(&rules)
+	if err != nil {
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			return ErrRuleSetNotFound
+		}
+		return err
+	}
+
+	result, err := collection.DeleteOne(ctx, bson.D{
+		{Key: "version", Value: version},
+		{Key: "effectiveFrom", Value: bson.D{{Key: "$gt", Value: time.Now()}}},
+	})
+	if err != nil {
+		return err
+	}
+	if result.DeletedCount == 0 {
+		return ErrRuleSetInEffect
+	}
+
+	return nil
+}
@@ -0,0 +1,85 @@
+package data
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/integration/mtest"
+)
+
+func TestGetRulesAt(t *testing.T) {
+	mt := mtest.New(t, mtest.NewOptions().ClientType(mtest.Mock))
+	at := time.Date(2024, time.March, 28, 14, 30, 0, 0, time.UTC)
+
+	inEffect := DefaultRuleSet
+	inEffect.Version = 2
+	inEffect.EffectiveFrom = time.Date(2024, time.January, 1, 0, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name        string
+		response    bson.D
+		wantVersion int
+		wantErr     error
+		// Whether the error of the database is passed on
+		wantDBErr bool
+	}{
+		{
+			name:        "rule set in effect",
+			response:    mtest.CreateCursorResponse(0, "policeDB.rules", mtest.FirstBatch, toDocument(mt, inEffect)),
+			wantVersion: 2,
+		},
+		{
+			name:     "no rules in effect yet",
+			response: mtest.CreateCursorResponse(0, "policeDB.rules", mtest.FirstBatch),
+			wantErr:  ErrNoRulesInEffect,
+		},
+		{
+			name:      "database fails",
+			response:  mtest.CreateCommandErrorResponse(mtest.CommandError{Code: 11601, Name: "Interrupted", Message: "operation was interrupted"}),
+			wantDBErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		mt.Run(tt.name, func(mt *mtest.T) {
+			mt.AddMockResponses(tt.response)
+
+			pr := &PoliceRepo{cli: mt.Client}
+			rules, err := pr.GetRulesAt(context.Background(), at)
+
+			var cmdErr mongo.CommandError
+			switch {
+			case tt.wantDBErr:
+				if !errors.As(err, &cmdErr) || cmdErr.Code != 11601 {
+					mt.Fatalf("GetRulesAt() error = %v, want the error of the database", err)
+				}
+			case !errors.Is(err, tt.wantErr):
+				mt.Fatalf("GetRulesAt() error = %v, want %v", err, tt.wantErr)
+			}
+			if rules.Version != tt.wantVersion {
+				mt.Errorf("GetRulesAt() version = %d, want %d", rules.Version, tt.wantVersion)
+			}
+
+			// The set that took effect last by the time is the one in effect, the latest
+			// version of it if two took effect at once
+			find := mt.GetStartedEvent()
+			filter := find.Command.Lookup("filter", "effectiveFrom", "$lte")
+			if !filter.Time().Equal(at) {
+				mt.Errorf("rules are read with filter %v, want those in effect by %v", find.Command.Lookup("filter"), at)
+			}
+
+			var sort bson.D
+			if err := find.Command.Lookup("sort").Unmarshal(&sort); err != nil {
+				mt.Fatalf("rules are read without a sort: %v", err)
+			}
+			want := bson.D{{Key: "effectiveFrom", Value: int32(-1)}, {Key: "version", Value: int32(-1)}}
+			if len(sort) != len(want) || sort[0] != want[0] || sort[1] != want[1] {
+				mt.Errorf("rules are read sorted by %v, want %v", sort, want)
+			}
+		})
+	}
+}
@@ -0,0 +1,257 @@
+package data
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// States of a roadside stop
+const (
+	StopOpen   = "OPEN"
+	StopClosed = "CLOSED"
+)
+
+// Checks run during a stop, besides the ones of single checks
+const (
+	CheckIdentity  = "IDENTITY"
+	CheckInsurance = "INSURANCE"
+	CheckWarrants  = "WARRANTS"
+	CheckStop      = "STOP"
+)
+
+// Checks an officer can run during a stop
+var StopCheckTypes = []string{CheckIdentity, CheckAlcohol, CheckTire, CheckDrivingBan, CheckPermit, CheckRegistration, CheckInsurance, CheckWarrants}
+
+// Outcomes of a check run during a stop. A record the check looks for that doesn't
+// exist, such as the permit of the driver, is NOT_FOUND and left to the officer. A check
+// whose service couldn't be reached is UNKNOWN and can be run again later
+const (
+	CheckPassed   = "PASSED"
+	CheckFailed   = "FAILED"
+	CheckNotFound = "NOT_FOUND"
+	CheckUnknown  = "UNKNOWN"
+)
+
+// Services checks look records up in
+const (
+	ServiceSSO   = "SSO"
+	ServiceMUP   = "MUP"
+	ServiceCourt = "COURT"
+)
+
+var (
+	ErrStopNotFound        = errors.New("stop not found")
+	ErrStopClosed          = errors.New("stop is closed")
+	ErrInvalidStopCheck    = errors.New("check must be IDENTITY, ALCOHOL, TIRE, DRIVING_BAN, PERMIT, REGISTRATION, INSURANCE or WARRANTS")
+	ErrStopDriverMissing   = errors.New("stop needs the JMBG of the driver and a location")
+	ErrAlcoholLevelMissing = errors.New("alcohol check needs the measured alcohol level")
+	ErrInvalidAlcoholLevel = errors.New("alcohol level must not be negative")
+	ErrInvalidTire         = errors.New("tire type must be either SUMMER or WINTER")
+	ErrStopPlatesMissing   = errors.New("registration check needs the plates of the vehicle")
+	ErrInsuranceMissing    = errors.New("insurance check needs whether the vehicle is insured")
+	ErrWarrantNotOnStop    = errors.New("warrant was not found by the warrants check of the stop")
+	ErrWarrantExecuted     = errors.New("warrant is already executed")
+)
+
+// Roadside stop of a driver. Checks are run one by one in any order and every result
+// is kept, so the stop shows what was checked, when and with what outcome. Violations
+// are recorded when the stop is closed
+type Stop struct {
+	ID     primitive.ObjectID `bson:"_id" json:"id"`
+	Status string             `bson:"status" json:"status"`
+	// Warrants the latest warrants check found that are yet to be executed, set by Summarize
+	ActiveWarrants []Warrant `bson:"-" json:"activeWarrants"`
+	JMBG           string    `bson:"jmbg" json:"jmbg"`
+	Plates         string    `bson:"plates,omitempty" json:"plates,omitempty"`
+	Location       string    `bson:"location" json:"location"`
+	Place          `bson:",inline"`
+	DriverCategory string               `bson:"driverCategory,omitempty" json:"driverCategory,omitempty"`
+	VehicleType    string               `bson:"vehicleType,omitempty" json:"vehicleType,omitempty"`
+	OpenedAt       time.Time            `bson:"openedAt" json:"openedAt"`
+	ClosedAt       *time.Time           `bson:"closedAt,omitempty" json:"closedAt,omitempty"`
+	RecordedBy     Stamp                `bson:"recordedBy" json:"recordedBy"`
+	Results        []StopCheckResult    `bson:"results" json:"results"`
+	Violations     []primitive.ObjectID `bson:"violations" json:"violations"`
+	Note           string               `bson:"note,omitempty" json:"note,omitempty"`
+	// Services the latest results of the checks couldn't reach, set by Summarize
+	Degraded []string `bson:"-" json:"degraded"`
+}
+
+type Stops []Stop
+
+// What the officer opens a stop with. Time is when the driver was stopped, now if empty
+type NewStop struct {
+	JMBG           string    `json:"jmbg"`
+	PlatesNumber   string    `json:"platesNumber"`
+	Location       string    `json:"location"`
+	Time           time.Time `json:"time"`
+	DriverCategory string    `json:"driverCategory"`
+	VehicleType    string    `json:"vehicleType"`
+	Place
+}
+
+// What the officer measured or saw for checks that aren't looked up in other services
+type StopCheckInput struct {
+	AlcoholLevel *float64   `bson:"alcoholLevel,omitempty" json:"alcoholLevel,omitempty"`
+	Tire         string     `bson:"tire,omitempty" json:"tire,omitempty"`
+	Insured      *bool      `bson:"insured,omitempty" json:"insured,omitempty"`
+	PolicyNumber string     `bson:"policyNumber,omitempty" json:"policyNumber,omitempty"`
+	InsuredUntil *time.Time `bson:"insuredUntil,omitempty" json:"insuredUntil,omitempty"`
+}
+
+// Outcome of a check run during the stop, with the offences it found
+type StopCheckResult struct {
+	ID        primitive.ObjectID `bson:"_id" json:"id"`
+	Type      string             `bson:"type" json:"type"`
+	Status    string             `bson:"status" json:"status"`
+	Finding   string             `bson:"finding" json:"finding"`
+	Offences  OffenceItems       `bson:"offences" json:"offences"`
+	Input     *StopCheckInput    `bson:"input,omitempty" json:"input,omitempty"`
+	Warrants  []Warrant          `bson:"warrants,omitempty" json:"warrants,omitempty"`
+	Service   string             `bson:"service,omitempty" json:"service,omitempty"`
+	CheckedAt time.Time          `bson:"checkedAt" json:"checkedAt"`
+	CheckedBy primitive.ObjectID `bson:"checkedBy" json:"checkedBy"`
+}
+
+// How the officer closes the stop. Offences of the checks in Dismiss are left out,
+// as when the driver is let off with a warning. Violations records violations of
+// other people found during the stop, such as the owner of the vehicle
+type StopClosing struct {
+	Dismiss     []string        `json:"dismiss"`
+	Violations  []StopViolation `json:"violations"`
+	Description string          `json:"description"`
+	Note        string          `json:"note"`
+}
+
+type StopViolation struct {
+	JMBG        string       `json:"jmbg"`
+	Offences    OffenceItems `json:"offences"`
+	Description string       `json:"description"`
+}
+
+// Warrant the court issued for a person, as the court returns it. It is active until an
+// officer executes it
+type Warrant struct {
+	ID               string     `bson:"id" json:"id"`
+	TrafficViolation string     `bson:"trafficViolation" json:"trafficViolation"`
+	IssuedOn         time.Time  `bson:"issuedOn" json:"issuedOn"`
+	IssuedFor        string     `bson:"issuedFor" json:"issuedFor"`
+	ExecutedAt       *time.Time `bson:"executedAt,omitempty" json:"executedAt,omitempty"`
+	ExecutedBy       string     `bson:"executedBy,omitempty" json:"executedBy,omitempty"`
+}
+
+// Officer who executed the warrant and when, as the court records it
+type WarrantExecution struct {
+	ExecutedBy string    `json:"executedBy"`
+	ExecutedAt time.Time `json:"executedAt"`
+}
+
+// Stop with the violations recorded when it was closed
+type StopReport struct {
+	Stop       Stop               `json:"stop"`
+	Violations []TrafficViolation `json:"violations"`
+}
+
+func ValidStopCheck(checkType string) bool {
+	for _, t := range StopCheckTypes {
+		if t == checkType {
+			return true
+		}
+	}
+	return false
+}
+
+// Latest result of every check run during the stop, in the order the checks were first run
+func (s *Stop) LatestResults() []StopCheckResult {
+	latest := []StopCheckResult{}
+	index := map[string]int{}
+	for _, result := range s.Results {
+		if i, ok := index[result.Type]; ok {
+			latest[i] = result
+			continue
+		}
+		index[result.Type] = len(latest)
+		latest = append(latest, result)
+	}
+	return latest
+}
+
+func (wa *Warrant) Active() bool {
+	return wa.ExecutedAt == nil
+}
+
+// Sets what the latest results of the checks add up to, the warrants still to be
+// executed and the services that couldn't be reached
+func (s *Stop) Summarize() {
+	s.ActiveWarrants = []Warrant{}
+	s.Degraded = []string{}
+	seen := map[string]bool{}
+	for _, result := range s.LatestResults() {
+		if result.Type == CheckWarrants {
+			for _, warrant := range result.Warrants {
+				if warrant.Active() {
+					s.ActiveWarrants = append(s.ActiveWarrants, warrant)
+				}
+			}
+		}
+		if result.Status == CheckUnknown && !seen[result.Service] {
+			seen[result.Service] = true
+			s.Degraded = append(s.Degraded, result.Service)
+		}
+	}
+}
+
+// Warrant the latest warrants check of the stop found
+func (s *Stop) Warrant(id string) (Warrant, bool) {
+	for _, result := range s.LatestResults() {
+		if result.Type != CheckWarrants {
+			continue
+		}
+		for _, warrant := range result.Warrants {
+			if warrant.ID == id {
+				return warrant, true
+			}
+		}
+	}
+	return Warrant{}, false
+}
+
+// Offences the latest results found, leaving out the checks dismissed
+func (s *Stop) Offences(dismiss []string) OffenceItems {
+	dismissed := map[string]bool{}
+	for _, checkType := range dismiss {
+		dismissed[checkType] = true
+	}
+
+	offences := OffenceItems{}
+	for _, result := range s.LatestResults() {
+		if result.Status == CheckFailed && !dismissed[result.Type] {
+			offences = append(offences, result.Offences...)
+		}
+	}
+	return offences
+}
+
+func (s *Stop) ToJSON(w io.Writer) error {
+	e := json.NewEncoder(w)
+	return e.Encode(s)
+}
+
+func (s *Stops) ToJSON(w io.Writer) error {
+	e := json.NewEncoder(w)
+	return e.Encode(s)
+}
+
+func (ns *NewStop) FromJSON(r io.Reader) error {
+	d := json.NewDecoder(r)
+	return d.Decode(ns)
+}
+
+func (sr *StopReport) ToJSON(w io.Writer) error {
+	e := json.NewEncoder(w)
+	return e.Encode(sr)
+}
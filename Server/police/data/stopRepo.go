@@ -0,0 +1,127 @@
+package data
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+//Stop methods
+
+func (pr *PoliceRepo) CreateStop(ctx context.Context, stop *Stop) error {
+	_, err := pr.getPoliceCollection("stops").InsertOne(ctx, stop)
+	return err
+}
+
+func (pr *PoliceRepo) GetStop(ctx context.Context, id primitive.ObjectID) (Stop, error) {
+	var stop Stop
+	err := pr.getPoliceCollection("stops").FindOne(ctx, bson.D{{Key: "_id", Value: id}}).Decode(&stop)
+	if err != nil {
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			return Stop{}, ErrStopNotFound
+		}
+		return Stop{}, err
+	}
+
+	return stop, nil
+}
+
+// Returns stops of the shift in the state given, or all of them, latest first
+func (pr *PoliceRepo) GetShiftStops(ctx context.Context, shift primitive.ObjectID, status string) (Stops, error) {
+	filter := bson.D{{Key: "recordedBy.shift", Value: shift}}
+	if status != "" {
+		filter = append(filter, bson.E{Key: "status", Value: status})
+	}
+
+	opts := options.Find().SetSort(bson.D{{Key: "openedAt", Value: -1}})
+	cursor, err := pr.getPoliceCollection("stops").Find(ctx, filter, opts)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	stops := Stops{}
+	if err = cursor.All(ctx, &stops); err != nil {
+		return nil, err
+	}
+
+	return stops, nil
+}
+
+// Adds the result of a check to the open stop
+func (pr *PoliceRepo) AddStopResult(ctx context.Context, id primitive.ObjectID, result StopCheckResult) (Stop, error) {
+	return pr.updateOpenStop(ctx, id, bson.D{{Key: "$push", Value: bson.D{{Key: "results", Value: result}}}})
+}
+
+// Closes the open stop with the violations recorded for it
+func (pr *PoliceRepo) CloseStop(ctx context.Context, id primitive.ObjectID, violations []primitive.ObjectID, note string, at time.Time) (Stop, error) {
+	return pr.updateOpenStop(ctx, id, bson.D{{Key: "$set", Value: bson.D{
+		{Key: "status", Value: StopClosed},
+		{Key: "closedAt", Value: at},
+		{Key: "violations", Value: violations},
+		{Key: "note", Value: note},
+	}}})
+}
+
+// Records the warrant found during the stop as executed, in every warrants check that found it
+func (pr *PoliceRepo) ExecuteStopWarrant(ctx context.Context, id primitive.ObjectID, warrant string, execution WarrantExecution) (Stop, error) {
+	update := bson.D{{Key: "$set", Value: bson.D{
+		{Key: "results.$[check].warrants.$[warrant].executedAt", Value: execution.ExecutedAt},
+		{Key: "results.$[check].warrants.$[warrant].executedBy", Value: execution.ExecutedBy},
+	}}}
+	opts := options.FindOneAndUpdate().
+		SetArrayFilters(options.ArrayFilters{Filters: []interface{}{
+			bson.D{{Key: "check.type", Value: CheckWarrants}},
+			bson.D{{Key: "warrant.id", Value: warrant}},
+		}}).
+		SetReturnDocument(options.After)
+
+	var stop Stop
+	err := pr.getPoliceCollection("stops").FindOneAndUpdate(ctx, bson.D{{Key: "_id", Value: id}}, update, opts).Decode(&stop)
+	if err != nil {
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			return Stop{}, ErrStopNotFound
+		}
+		return Stop{}, err
+	}
+
+	return stop, nil
+}
+
+// Opens the stop again, when the violations it was closed with couldn't be saved
+func (pr *PoliceRepo) ReopenStop(ctx context.Context, id primitive.ObjectID) error {
+	update := bson.D{
+		{Key: "$set", Value: bson.D{
+			{Key: "status", Value: StopOpen},
+			{Key: "violations", Value: []primitive.ObjectID{}},
+		}},
+		{Key: "$unset", Value: bson.D{{Key: "closedAt", Value: ""}, {Key: "note", Value: ""}}},
+	}
+
+	_, err := pr.getPoliceCollection("stops").UpdateOne(ctx, bson.D{{Key: "_id", Value: id}}, update)
+	return err
+}
+
+func (pr *PoliceRepo) updateOpenStop(ctx context.Context, id primitive.ObjectID, update bson.D) (Stop, error) {
+	filter := bson.D{{Key: "_id", Value: id}, {Key: "status", Value: StopOpen}}
+	opts := options.FindOneAndUpdate().SetReturnDocument(options.After)
+
+	var stop Stop
+	err := pr.getPoliceCollection("stops").FindOneAndUpdate(ctx, filter, update, opts).Decode(&stop)
+	if err != nil {
+		if !errors.Is(err, mongo.ErrNoDocuments) {
+			return Stop{}, err
+		}
+		if _, err := pr.GetStop(ctx, id); err != nil {
+			return Stop{}, err
+		}
+		return Stop{}, ErrStopClosed
+	}
+
+	return stop, nil
+}
@@ -52,11 +52,20 @@ type LegalEntity struct {
 	Address     Address `bson:"address" json:"address"`
 }
 
+// States of an issued permit that make it invalid, set by MUP while the holder is banned
+const (
+	PermitSuspended = "SUSPENDED"
+	PermitRevoked   = "REVOKED"
+)
+
 type TrafficPermit struct {
 	ID             primitive.ObjectID `bson:"_id,omitempty" json:"id"`
 	Number         string             `bson:"number" json:"number"`
+	IssuedDate     time.Time          `bson:"issuedDate" json:"issuedDate"`
 	ExpirationDate time.Time          `bson:"expirationDate" json:"expirationDate"`
+	Category       string             `bson:"category" json:"category"`
 	Person         string             `bson:"person" json:"person"`
+	Status         string             `bson:"status" json:"status"`
 }
 
 type Address struct {
@@ -0,0 +1,20 @@
+package data
+
+import (
+	"encoding/json"
+	"io"
+)
+
+const DocumentViolationNotice = "violationNotice"
+
+// Minimal disclosure about a document, returned by the public verification endpoint
+type DocumentVerification struct {
+	Document string `json:"document"`
+	Number   string `json:"number"`
+	Valid    bool   `json:"valid"`
+}
+
+func (dv *DocumentVerification) ToJSON(w io.Writer) error {
+	e := json.NewEncoder(w)
+	return e.Encode(dv)
+}
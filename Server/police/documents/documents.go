@@ -0,0 +1,54 @@
+package documents
+
+import (
+	"fmt"
+	"io"
+	"police/data"
+)
+
+const issuer = "Ministarstvo unutrašnjih poslova - Saobraćajna policija"
+
+// Writes violation notice (prekršajni nalog) issued to the violator
+func ViolationNotice(w io.Writer, violation data.TrafficViolation, violator data.Person, verifyURL string) error {
+	doc := newDocument(issuer, "Prekršajni nalog")
+
+	doc.section("Podaci o nalogu")
+	doc.field("Broj naloga", violation.ID.Hex())
+	doc.field("Vreme prekršaja", violation.Time.Format(dateFormat+" 15:04"))
+	doc.field("Mesto prekršaja", violation.Location)
+	if violation.Road != nil {
+		doc.field("Put", roadReference(*violation.Road))
+	}
+	if violation.Position != nil {
+		doc.field("Koordinate", fmt.Sprintf("%.6f, %.6f", violation.Position.Coordinates[1], violation.Position.Coordinates[0]))
+	}
+
+	doc.section("Okrivljeni")
+	doc.field("Ime i prezime", violator.FirstName+" "+violator.LastName)
+	doc.field("JMBG", violation.ViolatorJMBG)
+
+	doc.section("Prekršaj")
+	doc.field("Razlog", violation.Reason)
+	doc.field("Opis", violation.Description)
+
+	doc.section("Pouka")
+	doc.paragraph("Okrivljeni koji ne prihvati odgovornost za prekršaj ima pravo da u roku od osam dana " +
+		"od dana uručenja prekršajnog naloga podnese zahtev za sudsko odlučivanje.")
+
+	if err := doc.verificationCode(verifyURL); err != nil {
+		return err
+	}
+
+	return doc.write(w)
+}
+
+func roadReference(road data.RoadReference) string {
+	reference := road.Road
+	if road.Section != "" {
+		reference += ", deonica " + road.Section
+	}
+	if road.Kilometre != nil {
+		reference += fmt.Sprintf(", km %.1f", *road.Kilometre)
+	}
+	return reference
+}
@@ -0,0 +1,295 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"police/data"
+	"strconv"
+	"time"
+
+	"github.com/gorilla/mux"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+var errUnknownMunicipality = errors.New("municipality is not known")
+
+// Reports an accident along with the violations found to have caused it, fining them or
+// reporting them to the court. The municipality is worked out from the position unless
+// the officer names it
+func (ph *PoliceHandler) CreateAccident(w http.ResponseWriter, r *http.Request) {
+	var newAccident data.NewAccident
+	if err := newAccident.FromJSON(r.Body); err != nil {
+		http.Error(w, "Failed to decode request body", http.StatusBadRequest)
+		log.Printf("Failed to decode request body: %v\n", err)
+		return
+	}
+	if err := newAccident.Validate(); err != nil {
+		writeAccidentError(w, err)
+		return
+	}
+
+	happenedAt, err := checkTime(newAccident.Time)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	stamp, err := ph.stampFromToken(r, happenedAt)
+	if err != nil {
+		writeStampError(w, err)
+		return
+	}
+
+	municipality, err := ph.accidentMunicipality(r.Context(), newAccident)
+	if err != nil {
+		writeAccidentError(w, err)
+		return
+	}
+
+	accident := data.Accident{
+		ID:            primitive.NewObjectID(),
+		Time:          happenedAt,
+		Location:      newAccident.Location,
+		Place:         newAccident.Place,
+		Municipality:  municipality,
+		RoadCondition: newAccident.RoadCondition,
+		Vehicles:      newAccident.Vehicles,
+		Persons:       newAccident.Persons,
+		Severity:      data.AccidentSeverity(newAccident.Persons),
+		Damage:        newAccident.Damage,
+		Description:   newAccident.Description,
+		Responsible:   newAccident.Responsible,
+		Violations:    []primitive.ObjectID{},
+		RecordedBy:    stamp,
+		RecordedAt:    time.Now(),
+	}
+	if accident.Persons == nil {
+		accident.Persons = []data.AccidentPerson{}
+	}
+
+	violations, err := ph.accidentViolations(r.Context(), accident, newAccident.Violations)
+	if err != nil {
+		writeAccidentError(w, err)
+		return
+	}
+	for _, violation := range violations {
+		accident.Violations = append(accident.Violations, violation.ID)
+	}
+
+	// The accident is saved first, so its violations never point to an accident that doesn't exist
+	if err := ph.repo.CreateAccident(r.Context(), &accident); err != nil {
+		http.Error(w, "Failed to save accident", http.StatusInternalServerError)
+		log.Printf("Failed to save accident: %v\n", err)
+		return
+	}
+	if err := ph.repo.CreateTrafficViolations(r.Context(), violations); err != nil {
+		if err := ph.repo.DeleteAccident(r.Context(), accident.ID); err != nil {
+			log.Printf("Failed to remove accident %s: %v\n", accident.ID.Hex(), err)
+		}
+		http.Error(w, "Failed to save accident violations", http.StatusInternalServerError)
+		log.Printf("Failed to save violations of accident %s: %v\n", accident.ID.Hex(), err)
+		return
+	}
+	ph.fineAccidentViolations(r.Context(), violations, ph.extractTokenFromHeader(r))
+
+	report := data.AccidentReport{Accident: accident, Violations: violations}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	report.ToJSON(w)
+}
+
+// Returns accidents, latest first, in ?municipality= and of ?severity= when given
+func (ph *PoliceHandler) GetAccidents(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+	accidents, err := ph.repo.GetAccidents(r.Context(), query.Get("municipality"), query.Get("severity"))
+	if err != nil {
+		http.Error(w, "Failed to retrieve accidents", http.StatusInternalServerError)
+		log.Printf("Failed to retrieve accidents: %v\n", err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	accidents.ToJSON(w)
+}
+
+func (ph *PoliceHandler) GetAccident(w http.ResponseWriter, r *http.Request) {
+	accident, ok := ph.readAccident(w, r)
+	if !ok {
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	accident.ToJSON(w)
+}
+
+// Records violations found to have caused the accident after it was reported, as when
+// the investigation ends. Violators must be among the people involved
+func (ph *PoliceHandler) AddAccidentViolations(w http.ResponseWriter, r *http.Request) {
+	var newViolations data.AccidentViolations
+	if err := newViolations.FromJSON(r.Body); err != nil {
+		http.Error(w, "Failed to decode request body", http.StatusBadRequest)
+		log.Printf("Failed to decode request body: %v\n", err)
+		return
+	}
+	if len(newViolations) == 0 {
+		writeAccidentError(w, data.ErrAccidentViolator)
+		return
+	}
+
+	accident, ok := ph.readAccident(w, r)
+	if !ok {
+		return
+	}
+
+	violations, err := ph.accidentViolations(r.Context(), accident, newViolations)
+	if err != nil {
+		writeAccidentError(w, err)
+		return
+	}
+	ids := make([]primitive.ObjectID, len(violations))
+	for i, violation := range violations {
+		ids[i] = violation.ID
+	}
+
+	accident, err = ph.repo.AddAccidentViolations(r.Context(), accident.ID, ids)
+	if err != nil {
+		writeAccidentError(w, err)
+		return
+	}
+	if err := ph.repo.CreateTrafficViolations(r.Context(), violations); err != nil {
+		if err := ph.repo.RemoveAccidentViolations(r.Context(), accident.ID, ids); err != nil {
+			log.Printf("Failed to unlink violations from accident %s: %v\n", accident.ID.Hex(), err)
+		}
+		http.Error(w, "Failed to save accident violations", http.StatusInternalServerError)
+		log.Printf("Failed to save violations of accident %s: %v\n", accident.ID.Hex(), err)
+		return
+	}
+	ph.fineAccidentViolations(r.Context(), violations, ph.extractTokenFromHeader(r))
+
+	report := data.AccidentReport{Accident: accident, Violations: violations}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	report.ToJSON(w)
+}
+
+// Returns accidents of ?year= counted by month, municipality and severity. Accidents
+// outside any known municipality are counted under an empty one
+func (ph *PoliceHandler) GetAccidentCounts(w http.ResponseWriter, r *http.Request) {
+	year, err := strconv.Atoi(r.URL.Query().Get("year"))
+	if err != nil || year < 1 {
+		http.Error(w, "Invalid year", http.StatusBadRequest)
+		return
+	}
+
+	from := time.Date(year, time.January, 1, 0, 0, 0, 0, time.UTC)
+	counts, err := ph.repo.CountAccidents(r.Context(), from, from.AddDate(1, 0, 0))
+	if err != nil {
+		http.Error(w, "Failed to count accidents", http.StatusInternalServerError)
+		log.Printf("Failed to count accidents: %v\n", err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	counts.ToJSON(w)
+}
+
+func (ph *PoliceHandler) readAccident(w http.ResponseWriter, r *http.Request) (data.Accident, bool) {
+	id, err := primitive.ObjectIDFromHex(mux.Vars(r)["id"])
+	if err != nil {
+		http.Error(w, "Invalid accident ID", http.StatusBadRequest)
+		return data.Accident{}, false
+	}
+
+	accident, err := ph.repo.GetAccident(r.Context(), id)
+	if err != nil {
+		writeAccidentError(w, err)
+		return data.Accident{}, false
+	}
+
+	return accident, true
+}
+
+// Municipality the officer named, which must be known, or the one the position is in
+func (ph *PoliceHandler) accidentMunicipality(ctx context.Context, newAccident data.NewAccident) (string, error) {
+	if newAccident.Municipality != "" {
+		municipality, err := ph.repo.GetMunicipality(ctx, newAccident.Municipality)
+		if errors.Is(err, data.ErrMunicipalityNotFound) {
+			return "", fmt.Errorf("%w: %s", errUnknownMunicipality, newAccident.Municipality)
+		}
+		return municipality.Name, err
+	}
+	if newAccident.Position == nil {
+		return "", nil
+	}
+
+	municipality, err := ph.repo.GetMunicipalityAt(ctx, *newAccident.Position)
+	if errors.Is(err, data.ErrMunicipalityNotFound) {
+		return "", nil
+	}
+	return municipality.Name, err
+}
+
+// Builds violations of people involved in the accident, at its time and place
+func (ph *PoliceHandler) accidentViolations(ctx context.Context, accident data.Accident, newViolations []data.AccidentViolation) ([]data.TrafficViolation, error) {
+	violations := []data.TrafficViolation{}
+	for _, newViolation := range newViolations {
+		if !accident.Involves(newViolation.JMBG) || len(newViolation.Offences) == 0 {
+			return nil, data.ErrAccidentViolator
+		}
+
+		violation := data.TrafficViolation{
+			ID:           primitive.NewObjectID(),
+			ViolatorJMBG: newViolation.JMBG,
+			Description:  newViolation.Description,
+			Time:         accident.Time,
+			Location:     accident.Location,
+			Place:        accident.Place,
+			RecordedBy:   &accident.RecordedBy,
+			Accident:     &accident.ID,
+			// Fined or reported once saved, until then the escalation job picks it up
+			ChargePending: true,
+		}
+		if err := ph.setOffences(ctx, &violation, newViolation.Offences); err != nil {
+			return nil, err
+		}
+		violations = append(violations, violation)
+	}
+	return violations, nil
+}
+
+// Fines the violations or reports them to the court. They are already saved pending, so
+// failing to do so is only logged and the escalation job retries them
+func (ph *PoliceHandler) fineAccidentViolations(ctx context.Context, violations []data.TrafficViolation, token string) {
+	for _, violation := range violations {
+		if err := ph.fineOrReport(ctx, violation, token); err != nil {
+			log.Printf("Failed to issue fine or send crime report for violation %s, left for the escalation job: %v\n", violation.ID.Hex(), err)
+		}
+	}
+}
+
+func writeAccidentError(w http.ResponseWriter, err error) {
+	switch {
+	case errors.Is(err, data.ErrAccidentNotFound):
+		http.Error(w, "Accident not found", http.StatusNotFound)
+	case errors.Is(err, data.ErrAccidentLocationMissing), errors.Is(err, data.ErrInvalidRoadCondition),
+		errors.Is(err, data.ErrAccidentVehiclesMissing), errors.Is(err, data.ErrAccidentVehicleTwice),
+		errors.Is(err, data.ErrAccidentPersonMissing), errors.Is(err, data.ErrAccidentPersonTwice),
+		errors.Is(err, data.ErrInvalidAccidentRole), errors.Is(err, data.ErrInvalidInjury),
+		errors.Is(err, data.ErrAccidentPersonVehicle), errors.Is(err, data.ErrAccidentDriverTwice),
+		errors.Is(err, data.ErrResponsibleNotInvolved), errors.Is(err, data.ErrAccidentViolator),
+		errors.Is(err, data.ErrInvalidVehicleType), errors.Is(err, data.ErrInvalidPosition),
+		errors.Is(err, data.ErrInvalidRoad), errors.Is(err, errUnknownMunicipality):
+		http.Error(w, err.Error(), http.StatusBadRequest)
+	case errors.Is(err, errUnknownOffence):
+		writeOffenceError(w, err)
+	default:
+		http.Error(w, "Failed to process accident", http.StatusInternalServerError)
+		log.Printf("Failed to process accident: %v\n", err)
+	}
+}
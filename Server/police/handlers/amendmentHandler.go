@@ -0,0 +1,432 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"log"
+	"net/http"
+	"police/data"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/gorilla/mux"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+var errViolationOfAnotherStation = errors.New("traffic violation belongs to another station")
+
+// Amends the violation with the changes in the body. The old and new values are kept
+// in the history of the violation along with the officer and the reason, the violation
+// is never changed otherwise
+func (ph *PoliceHandler) UpdateTrafficViolation(w http.ResponseWriter, r *http.Request) {
+	var request data.AmendmentRequest
+	if err := request.FromJSON(r.Body); err != nil {
+		http.Error(w, "Failed to decode request body", http.StatusBadRequest)
+		log.Printf("Failed to decode request body: %v\n", err)
+		return
+	}
+	request.Reason = strings.TrimSpace(request.Reason)
+	if request.Reason == "" {
+		writeAmendmentError(w, data.ErrAmendmentReason)
+		return
+	}
+
+	officer, err := ph.getOfficer(r)
+	if err != nil {
+		writeStampError(w, err)
+		return
+	}
+
+	violation, ok := ph.readViolation(w, r)
+	if !ok {
+		return
+	}
+	if err := data.ViolationStateError(violation.Status); err != nil {
+		writeAmendmentError(w, err)
+		return
+	}
+
+	amended, err := ph.applyChanges(r.Context(), violation, request.Changes)
+	if err != nil {
+		writeAmendmentError(w, err)
+		return
+	}
+
+	fields, old, changed := data.DiffViolation(violation, amended)
+	if len(fields) == 0 {
+		writeAmendmentError(w, data.ErrAmendmentEmpty)
+		return
+	}
+
+	// The fine was issued to the violator for the offences, changing them would leave it wrong
+	if slices.Contains(fields, data.FieldViolator) || slices.Contains(fields, data.FieldOffences) {
+		err := ph.checkViolationFine(r.Context(), violation.ID, data.ErrViolationFined, data.FineUnpaid, data.FinePaid, data.FineContested, data.FineEscalated)
+		if err != nil {
+			writeAmendmentError(w, err)
+			return
+		}
+	}
+
+	set := bson.D{}
+	for _, field := range fields {
+		set = append(set, bson.E{Key: field, Value: amendedValue(amended, field)})
+	}
+
+	amendment := newAmendment(violation, officer, data.AmendmentChanged, request.Reason)
+	amendment.Fields, amendment.Old, amendment.New = fields, &old, &changed
+
+	violation, err = ph.repo.AmendTrafficViolation(r.Context(), amendment, "", bson.D{{Key: "$set", Value: set}})
+	if err != nil {
+		writeAmendmentError(w, err)
+		return
+	}
+
+	writeViolation(w, http.StatusOK, violation)
+}
+
+// Requests the violation be voided for the reason in the body. The violation stays in
+// effect until a supervisor approves the request
+func (ph *PoliceHandler) RequestViolationVoid(w http.ResponseWriter, r *http.Request) {
+	decision, officer, ok := ph.readVoidDecision(w, r, ph.getOfficer)
+	if !ok {
+		return
+	}
+
+	violation, ok := ph.readViolation(w, r)
+	if !ok {
+		return
+	}
+	if err := data.ViolationStateError(violation.Status); err != nil {
+		writeAmendmentError(w, err)
+		return
+	}
+	if err := ph.checkViolationFine(r.Context(), violation.ID, data.ErrViolationInCourt, data.FineContested, data.FineEscalated); err != nil {
+		writeAmendmentError(w, err)
+		return
+	}
+
+	amendment := newAmendment(violation, officer, data.AmendmentVoidRequested, decision.Reason)
+	request := data.VoidRequest{
+		Reason:      decision.Reason,
+		RequestedBy: amendment.AmendedBy,
+		RequestedAt: amendment.AmendedAt,
+	}
+	update := bson.D{{Key: "$set", Value: bson.D{
+		{Key: "status", Value: data.ViolationVoidRequested},
+		{Key: "voidRequest", Value: request},
+	}}}
+
+	violation, err := ph.repo.AmendTrafficViolation(r.Context(), amendment, "", update)
+	if err != nil {
+		writeAmendmentError(w, err)
+		return
+	}
+
+	writeViolation(w, http.StatusAccepted, violation)
+}
+
+// Supervisor approves voiding of the violation, which cancels its fine if unpaid. The
+// supervisor can't approve their own request, and a violation whose fine went to court
+// in the meantime is left for the court to decide
+func (ph *PoliceHandler) ApproveViolationVoid(w http.ResponseWriter, r *http.Request) {
+	supervisor, err := ph.getSupervisor(r)
+	if err != nil {
+		writeStampError(w, err)
+		return
+	}
+
+	violation, ok := ph.readViolation(w, r)
+	if !ok {
+		return
+	}
+	if violation.Status != data.ViolationVoidRequested || violation.VoidRequest == nil {
+		writeAmendmentError(w, voidRequestError(violation.Status))
+		return
+	}
+	if violation.VoidRequest.RequestedBy.Officer == supervisor.ID {
+		writeAmendmentError(w, data.ErrOwnVoidRequest)
+		return
+	}
+	if err := ph.checkViolationStation(r.Context(), violation, supervisor); err != nil {
+		writeAmendmentError(w, err)
+		return
+	}
+	if err := ph.checkViolationFine(r.Context(), violation.ID, data.ErrViolationInCourt, data.FineContested, data.FineEscalated); err != nil {
+		writeAmendmentError(w, err)
+		return
+	}
+
+	amendment := newAmendment(violation, supervisor, data.AmendmentVoided, violation.VoidRequest.Reason)
+	update := bson.D{{Key: "$set", Value: bson.D{
+		{Key: "status", Value: data.ViolationVoided},
+		{Key: "voidRequest.approvedBy", Value: amendment.AmendedBy},
+		{Key: "voidRequest.approvedAt", Value: amendment.AmendedAt},
+	}}}
+
+	violation, err = ph.repo.AmendTrafficViolation(r.Context(), amendment, data.ViolationVoidRequested, update)
+	if err != nil {
+		writeAmendmentError(w, err)
+		return
+	}
+	ph.cancelViolationFine(r.Context(), violation.ID, amendment.AmendedAt)
+
+	writeViolation(w, http.StatusOK, violation)
+}
+
+// Supervisor rejects voiding of the violation for the reason in the body, leaving it in effect
+func (ph *PoliceHandler) RejectViolationVoid(w http.ResponseWriter, r *http.Request) {
+	decision, supervisor, ok := ph.readVoidDecision(w, r, ph.getSupervisor)
+	if !ok {
+		return
+	}
+
+	violation, ok := ph.readViolation(w, r)
+	if !ok {
+		return
+	}
+	if violation.Status != data.ViolationVoidRequested {
+		writeAmendmentError(w, voidRequestError(violation.Status))
+		return
+	}
+	if err := ph.checkViolationStation(r.Context(), violation, supervisor); err != nil {
+		writeAmendmentError(w, err)
+		return
+	}
+
+	amendment := newAmendment(violation, supervisor, data.AmendmentVoidRejected, decision.Reason)
+	update := bson.D{{Key: "$unset", Value: bson.D{
+		{Key: "status", Value: ""},
+		{Key: "voidRequest", Value: ""},
+	}}}
+
+	violation, err := ph.repo.AmendTrafficViolation(r.Context(), amendment, data.ViolationVoidRequested, update)
+	if err != nil {
+		writeAmendmentError(w, err)
+		return
+	}
+
+	writeViolation(w, http.StatusOK, violation)
+}
+
+// Returns the violation as it is now with every change made to it, oldest first
+func (ph *PoliceHandler) GetViolationHistory(w http.ResponseWriter, r *http.Request) {
+	violation, ok := ph.readViolation(w, r)
+	if !ok {
+		return
+	}
+
+	history := data.ViolationHistory{Violation: violation, Amendments: violation.History}
+	if history.Amendments == nil {
+		history.Amendments = data.ViolationAmendments{}
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	history.ToJSON(w)
+}
+
+func (ph *PoliceHandler) readViolation(w http.ResponseWriter, r *http.Request) (data.TrafficViolation, bool) {
+	id, err := primitive.ObjectIDFromHex(mux.Vars(r)["id"])
+	if err != nil {
+		http.Error(w, "Invalid violation ID", http.StatusBadRequest)
+		return data.TrafficViolation{}, false
+	}
+
+	violation, err := ph.repo.GetTrafficViolationByID(r.Context(), id)
+	if err != nil {
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			err = data.ErrViolationNotFound
+		}
+		writeAmendmentError(w, err)
+		return data.TrafficViolation{}, false
+	}
+
+	return *violation, true
+}
+
+// Reads the reason from the body and the officer allowed to give it from the token
+func (ph *PoliceHandler) readVoidDecision(w http.ResponseWriter, r *http.Request, getOfficer func(*http.Request) (data.Officer, error)) (data.VoidDecision, data.Officer, bool) {
+	var decision data.VoidDecision
+	if err := decision.FromJSON(r.Body); err != nil {
+		http.Error(w, "Failed to decode request body", http.StatusBadRequest)
+		log.Printf("Failed to decode request body: %v\n", err)
+		return data.VoidDecision{}, data.Officer{}, false
+	}
+	decision.Reason = strings.TrimSpace(decision.Reason)
+	if decision.Reason == "" {
+		writeAmendmentError(w, data.ErrAmendmentReason)
+		return data.VoidDecision{}, data.Officer{}, false
+	}
+
+	officer, err := getOfficer(r)
+	if err != nil {
+		writeStampError(w, err)
+		return data.VoidDecision{}, data.Officer{}, false
+	}
+
+	return decision, officer, true
+}
+
+// Checks the supervisor is of the station the violation belongs to, that of the shift it
+// was recorded on. Violations recorded by a camera are on no shift, they belong to the
+// station of the officer who requested voiding them
+func (ph *PoliceHandler) checkViolationStation(ctx context.Context, violation data.TrafficViolation, supervisor data.Officer) error {
+	var station string
+	switch {
+	case violation.RecordedBy != nil && !violation.RecordedBy.Shift.IsZero():
+		shift, err := ph.repo.GetShiftByID(ctx, violation.RecordedBy.Shift)
+		if err != nil {
+			return err
+		}
+		station = shift.Station
+	case violation.VoidRequest != nil:
+		officer, err := ph.repo.GetOfficerByID(ctx, violation.VoidRequest.RequestedBy.Officer)
+		if err != nil {
+			return err
+		}
+		station = officer.Station
+	}
+
+	if station != supervisor.Station {
+		return errViolationOfAnotherStation
+	}
+	return nil
+}
+
+// Applies the changes to a copy of the violation, leaving out fields that aren't given
+func (ph *PoliceHandler) applyChanges(ctx context.Context, violation data.TrafficViolation, changes data.TrafficViolation) (data.TrafficViolation, error) {
+	amended := violation
+	if changes.ViolatorJMBG != "" {
+		amended.ViolatorJMBG = changes.ViolatorJMBG
+	}
+	if len(changes.Offences) > 0 || changes.Reason != "" {
+		amended.Reason = changes.Reason
+		if err := ph.setOffences(ctx, &amended, changes.Offences); err != nil {
+			return data.TrafficViolation{}, err
+		}
+	}
+	if changes.Description != "" {
+		amended.Description = changes.Description
+	}
+	if !changes.Time.IsZero() {
+		amended.Time = changes.Time
+	}
+	if changes.Location != "" {
+		amended.Location = changes.Location
+	}
+	if changes.Position != nil || changes.Road != nil {
+		if err := changes.Place.Validate(); err != nil {
+			return data.TrafficViolation{}, err
+		}
+		if changes.Position != nil {
+			amended.Position = changes.Position
+		}
+		if changes.Road != nil {
+			amended.Road = changes.Road
+		}
+	}
+	return amended, nil
+}
+
+// Value of the field of the violation, by the name DiffViolation gives it
+func amendedValue(violation data.TrafficViolation, field string) interface{} {
+	switch field {
+	case data.FieldViolator:
+		return violation.ViolatorJMBG
+	case data.FieldReason:
+		return violation.Reason
+	case data.FieldDescription:
+		return violation.Description
+	case data.FieldTime:
+		return violation.Time
+	case data.FieldLocation:
+		return violation.Location
+	case data.FieldOffences:
+		return violation.Offences
+	case data.FieldPosition:
+		return violation.Position
+	case data.FieldRoad:
+		return violation.Road
+	}
+	return nil
+}
+
+func newAmendment(violation data.TrafficViolation, officer data.Officer, kind, reason string) data.ViolationAmendment {
+	return data.ViolationAmendment{
+		ID:        primitive.NewObjectID(),
+		Violation: violation.ID,
+		Version:   violation.Version + 1,
+		Kind:      kind,
+		Reason:    reason,
+		AmendedBy: data.OfficerRef{Officer: officer.ID, BadgeNumber: officer.BadgeNumber},
+		AmendedAt: time.Now(),
+	}
+}
+
+// Fails with the error given when the violation was fined and its fine is in one of the states
+func (ph *PoliceHandler) checkViolationFine(ctx context.Context, violation primitive.ObjectID, stateErr error, states ...string) error {
+	fine, err := ph.repo.GetFineByViolation(ctx, violation)
+	if err != nil {
+		if errors.Is(err, data.ErrFineNotFound) {
+			return nil
+		}
+		return err
+	}
+	if slices.Contains(states, fine.Status) {
+		return stateErr
+	}
+	return nil
+}
+
+// Cancels the fine of the voided violation if it is still unpaid. Fines before the court
+// keep the violation from being voided, so the violation is already voided and fines
+// that can't be cancelled, as when paid, are only logged
+func (ph *PoliceHandler) cancelViolationFine(ctx context.Context, violation primitive.ObjectID, at time.Time) {
+	fine, err := ph.repo.GetFineByViolation(ctx, violation)
+	if err != nil {
+		if !errors.Is(err, data.ErrFineNotFound) {
+			log.Printf("Failed to retrieve fine of voided violation %s: %v\n", violation.Hex(), err)
+		}
+		return
+	}
+
+	if _, err := ph.repo.CancelFine(ctx, fine.ID, at); err != nil {
+		log.Printf("Failed to cancel fine %s of voided violation %s: %v\n", fine.ID.Hex(), violation.Hex(), err)
+	}
+}
+
+func voidRequestError(status string) error {
+	if status == data.ViolationVoided {
+		return data.ErrViolationVoided
+	}
+	return data.ErrNoVoidRequest
+}
+
+func writeViolation(w http.ResponseWriter, status int, violation data.TrafficViolation) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	violation.ToJSON(w)
+}
+
+func writeAmendmentError(w http.ResponseWriter, err error) {
+	switch {
+	case errors.Is(err, data.ErrViolationNotFound):
+		http.Error(w, "Traffic violation not found", http.StatusNotFound)
+	case errors.Is(err, data.ErrAmendmentReason), errors.Is(err, data.ErrAmendmentEmpty),
+		errors.Is(err, data.ErrInvalidPosition), errors.Is(err, data.ErrInvalidRoad):
+		http.Error(w, err.Error(), http.StatusBadRequest)
+	case errors.Is(err, data.ErrOwnVoidRequest), errors.Is(err, errViolationOfAnotherStation):
+		http.Error(w, err.Error(), http.StatusForbidden)
+	case errors.Is(err, data.ErrViolationVoided), errors.Is(err, data.ErrViolationVoidPending),
+		errors.Is(err, data.ErrNoVoidRequest), errors.Is(err, data.ErrViolationChanged),
+		errors.Is(err, data.ErrViolationFined), errors.Is(err, data.ErrViolationInCourt):
+		http.Error(w, err.Error(), http.StatusConflict)
+	case errors.Is(err, errUnknownOffence):
+		writeOffenceError(w, err)
+	default:
+		http.Error(w, "Failed to amend traffic violation", http.StatusInternalServerError)
+		log.Printf("Failed to amend traffic violation: %v\n", err)
+	}
+}
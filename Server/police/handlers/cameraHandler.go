@@ -0,0 +1,617 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"mime"
+	"net/http"
+	"os"
+	"path/filepath"
+	"police/data"
+	"slices"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gorilla/mux"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+var errUnreadableReview = errors.New("event could not be read and can only be dismissed")
+
+const (
+	// Longest a single lookup of a plate in MUP may take
+	cameraLookupTimeout = 2 * time.Second
+	// Plates looked up in MUP at the same time
+	cameraLookupWorkers = 8
+)
+
+// Owner the reviewer found for the vehicle of the event. The owner is looked up in
+// MUP again if it is left out
+type cameraReviewResolution struct {
+	JMBG string `json:"jmbg"`
+	Note string `json:"note"`
+}
+
+// Takes a batch of camera events as JSON or, with Content-Type text/csv, as CSV in the
+// format described by data.CameraCSVColumns. Violations are recorded for the events
+// over the limit, events that can't be recorded on their own go to the review queue
+func (ph *PoliceHandler) IngestCameraEvents(w http.ResponseWriter, r *http.Request) {
+	subject, _, err := ph.getSubjectAndRole(ph.extractTokenFromHeader(r))
+	if err != nil {
+		http.Error(w, "Invalid token", http.StatusUnauthorized)
+		return
+	}
+
+	mediaType, _, _ := mime.ParseMediaType(r.Header.Get("Content-Type"))
+
+	var events data.CameraEvents
+	var unreadable []data.UnreadableCameraEvent
+	if mediaType == "text/csv" {
+		events, unreadable, err = data.ReadCameraEventsCSV(r.Body)
+	} else {
+		events, unreadable, err = data.ReadCameraEventsJSON(r.Body)
+	}
+	if err != nil {
+		http.Error(w, "Failed to read camera events: "+err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	result, err := ph.ingestCameraEvents(r.Context(), events, unreadable, "api:"+subject)
+	if err != nil {
+		http.Error(w, "Failed to ingest camera events", http.StatusInternalServerError)
+		log.Printf("Failed to ingest camera events: %v\n", err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	result.ToJSON(w)
+}
+
+func (ph *PoliceHandler) GetCameras(w http.ResponseWriter, r *http.Request) {
+	cameras, err := ph.repo.GetCameras(r.Context())
+	if err != nil {
+		http.Error(w, "Failed to retrieve cameras", http.StatusInternalServerError)
+		log.Printf("Failed to retrieve cameras: %v\n", err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	cameras.ToJSON(w)
+}
+
+// Registers the camera under the ID from the path, or changes the one registered
+func (ph *PoliceHandler) SaveCamera(w http.ResponseWriter, r *http.Request) {
+	subject, _, err := ph.getSubjectAndRole(ph.extractTokenFromHeader(r))
+	if err != nil {
+		http.Error(w, "Invalid token", http.StatusUnauthorized)
+		return
+	}
+
+	var camera data.Camera
+	if err := camera.FromJSON(r.Body); err != nil {
+		http.Error(w, "Failed to decode request body", http.StatusBadRequest)
+		log.Printf("Failed to decode request body: %v\n", err)
+		return
+	}
+	camera.ID = mux.Vars(r)["id"]
+	if err := camera.Validate(); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	camera.SavedAt = time.Now()
+	camera.SavedBy = subject
+
+	if err := ph.repo.SaveCamera(r.Context(), camera); err != nil {
+		http.Error(w, "Failed to save camera", http.StatusInternalServerError)
+		log.Printf("Failed to save camera: %v\n", err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	camera.ToJSON(w)
+}
+
+// Returns events in review in the state given by ?status=, or all of them
+func (ph *PoliceHandler) GetCameraReviews(w http.ResponseWriter, r *http.Request) {
+	reviews, err := ph.repo.GetCameraReviews(r.Context(), r.URL.Query().Get("status"))
+	if err != nil {
+		http.Error(w, "Failed to retrieve events in review", http.StatusInternalServerError)
+		log.Printf("Failed to retrieve events in review: %v\n", err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	reviews.ToJSON(w)
+}
+
+// Records the violation for the event in review, against the owner the reviewer found
+// or the one MUP has for the plate now
+func (ph *PoliceHandler) ResolveCameraReview(w http.ResponseWriter, r *http.Request) {
+	review, resolution, subject, ok := ph.readCameraReview(w, r)
+	if !ok {
+		return
+	}
+	if review.Event == nil {
+		http.Error(w, errUnreadableReview.Error(), http.StatusConflict)
+		return
+	}
+	event := *review.Event
+
+	camera, err := ph.repo.GetCamera(r.Context(), event.CameraID)
+	if err != nil {
+		if errors.Is(err, data.ErrCameraNotFound) {
+			http.Error(w, "Camera of the event is not registered", http.StatusConflict)
+			return
+		}
+		http.Error(w, "Failed to retrieve camera", http.StatusInternalServerError)
+		log.Printf("Failed to retrieve camera: %v\n", err)
+		return
+	}
+
+	token, err := serviceToken()
+	if err != nil {
+		http.Error(w, "Failed to resolve event", http.StatusInternalServerError)
+		log.Printf("Failed to sign service token: %v\n", err)
+		return
+	}
+
+	owner := strings.TrimSpace(resolution.JMBG)
+	if owner == "" {
+		registration, err := ph.mup.GetRegistrationByPlate(r.Context(), data.PlateRequest{Plate: event.Plate}, token)
+		if err != nil {
+			http.Error(w, "Failed to look up the plate", http.StatusBadGateway)
+			log.Printf("Failed to look up plate %s: %v\n", event.Plate, err)
+			return
+		}
+		if registration.RegistrationNumber == "" || registration.Owner == "" {
+			http.Error(w, "Plate is not registered, the owner must be given", http.StatusConflict)
+			return
+		}
+		owner = registration.Owner
+	}
+
+	recorded, err := ph.repo.CameraPassRecorded(r.Context(), event)
+	if err != nil {
+		http.Error(w, "Failed to resolve event", http.StatusInternalServerError)
+		log.Printf("Failed to look up camera passes: %v\n", err)
+		return
+	}
+	if recorded {
+		http.Error(w, "A violation is already recorded for this pass of the vehicle", http.StatusConflict)
+		return
+	}
+
+	catalogue, err := ph.repo.GetCatalogue(r.Context())
+	if err != nil {
+		writeOffenceError(w, err)
+		return
+	}
+	violation := cameraViolation(event, camera, owner, catalogue)
+
+	// The review is closed first, so two reviewers can't record the same event twice
+	review, err = ph.repo.CloseCameraReview(r.Context(), review.ID, data.ReviewResolved, subject, resolution.Note, violation.ID, time.Now())
+	if err != nil {
+		writeCameraReviewError(w, err)
+		return
+	}
+
+	saved, err := ph.recordCameraViolations(r.Context(), []data.TrafficViolation{violation}, data.CameraEvents{event}, token)
+	if err != nil || len(saved) == 0 {
+		if err := ph.repo.ReopenCameraReview(r.Context(), review.ID); err != nil {
+			log.Printf("Failed to reopen event in review %s: %v\n", review.ID.Hex(), err)
+		}
+		if err == nil {
+			http.Error(w, "A violation is already recorded for this pass of the vehicle", http.StatusConflict)
+			return
+		}
+		http.Error(w, "Failed to create traffic violation", http.StatusInternalServerError)
+		log.Printf("Failed to create traffic violation: %v\n", err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	review.ToJSON(w)
+}
+
+// Closes the event in review without a violation
+func (ph *PoliceHandler) DismissCameraReview(w http.ResponseWriter, r *http.Request) {
+	review, resolution, subject, ok := ph.readCameraReview(w, r)
+	if !ok {
+		return
+	}
+
+	review, err := ph.repo.CloseCameraReview(r.Context(), review.ID, data.ReviewDismissed, subject, resolution.Note, primitive.NilObjectID, time.Now())
+	if err != nil {
+		writeCameraReviewError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	review.ToJSON(w)
+}
+
+// Processes event files dropped in the directory until ctx is done. Files are moved to
+// processed/ once ingested and to failed/ if they can't be read. Files should be moved
+// into the directory whole, since any *.json or *.csv file there is picked up
+func (ph *PoliceHandler) WatchCameraInbox(ctx context.Context, dir string, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		if err := ph.processCameraInbox(ctx, dir); err != nil {
+			log.Printf("Failed to process camera inbox %s: %v\n", dir, err)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+func (ph *PoliceHandler) processCameraInbox(ctx context.Context, dir string) error {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return err
+	}
+
+	for _, entry := range entries {
+		if !entry.Type().IsRegular() {
+			continue
+		}
+		name := entry.Name()
+		ext := strings.ToLower(filepath.Ext(name))
+		if ext != ".json" && ext != ".csv" {
+			continue
+		}
+
+		target := "processed"
+		result, err := ph.ingestCameraFile(ctx, filepath.Join(dir, name), ext)
+		if err != nil {
+			log.Printf("Failed to ingest camera events from %s: %v\n", name, err)
+			target = "failed"
+		} else {
+			log.Printf("Camera events from %s: %d received, %d violations, %d within tolerance, %d duplicates, %d to review",
+				name, result.Received, result.Violations, result.WithinTolerance, result.Duplicates, result.Queued)
+		}
+
+		if err := os.MkdirAll(filepath.Join(dir, target), 0o755); err != nil {
+			return err
+		}
+		if err := os.Rename(filepath.Join(dir, name), filepath.Join(dir, target, name)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (ph *PoliceHandler) ingestCameraFile(ctx context.Context, path, ext string) (data.CameraIngestResult, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return data.CameraIngestResult{}, err
+	}
+	defer file.Close()
+
+	read := data.ReadCameraEventsJSON
+	if ext == ".csv" {
+		read = data.ReadCameraEventsCSV
+	}
+	events, unreadable, err := read(file)
+	if err != nil {
+		return data.CameraIngestResult{}, err
+	}
+
+	return ph.ingestCameraEvents(ctx, events, unreadable, "file:"+filepath.Base(path))
+}
+
+// Turns the events into violations. Events are checked in turn for being readable,
+// coming from a registered camera, being over the limit after the tolerance and being
+// a new pass of the vehicle, and the owner of the vehicle is looked up in MUP. Events
+// over the limit that fail a check are queued for review
+func (ph *PoliceHandler) ingestCameraEvents(ctx context.Context, events data.CameraEvents, unreadable []data.UnreadableCameraEvent, source string) (data.CameraIngestResult, error) {
+	now := time.Now()
+	result := data.CameraIngestResult{Received: len(events) + len(unreadable), ViolationIDs: []string{}}
+	reviews := data.CameraReviews{}
+	queue := func(event *data.CameraEvent, raw, reason, detail string) {
+		reviews = append(reviews, data.CameraReview{
+			ID:         primitive.NewObjectID(),
+			Event:      event,
+			Raw:        raw,
+			Source:     source,
+			Reason:     reason,
+			Detail:     detail,
+			Status:     data.ReviewPending,
+			ReceivedAt: now,
+		})
+	}
+
+	for _, u := range unreadable {
+		queue(nil, u.Raw, data.ReviewInvalidEvent, u.Err.Error())
+	}
+
+	cameras := map[string]*data.Camera{}
+	accepted := data.CameraEvents{}
+	for i := range events {
+		event := events[i]
+		if err := event.Validate(); err != nil {
+			queue(&event, "", data.ReviewInvalidEvent, err.Error())
+			continue
+		}
+		if event.Timestamp.After(now.Add(maxClockSkew)) {
+			queue(&event, "", data.ReviewInvalidEvent, data.ErrCameraEventInFuture.Error())
+			continue
+		}
+		if !event.IsViolation() {
+			result.WithinTolerance++
+			continue
+		}
+
+		camera, ok := cameras[event.CameraID]
+		if !ok {
+			found, err := ph.repo.GetCamera(ctx, event.CameraID)
+			if err != nil && !errors.Is(err, data.ErrCameraNotFound) {
+				return result, err
+			}
+			if err == nil {
+				camera = &found
+			}
+			cameras[event.CameraID] = camera
+		}
+		if camera == nil || !camera.Active {
+			queue(&event, "", data.ReviewUnknownCamera, "camera is not registered or not active")
+			continue
+		}
+		if event.Timestamp.Before(now.Add(-data.CameraEventMaxAge)) {
+			queue(&event, "", data.ReviewEventTooOld, fmt.Sprintf("events older than %v are recorded by hand", data.CameraEventMaxAge))
+			continue
+		}
+
+		accepted = append(accepted, event)
+	}
+
+	// A burst is kept as its earliest event
+	sort.SliceStable(accepted, func(i, j int) bool { return accepted[i].Timestamp.Before(accepted[j].Timestamp) })
+	passes := data.CameraEvents{}
+	for _, event := range accepted {
+		duplicate := false
+		for _, pass := range passes {
+			if event.SamePass(pass) {
+				duplicate = true
+				break
+			}
+		}
+		if !duplicate {
+			recorded, err := ph.repo.CameraPassRecorded(ctx, event)
+			if err != nil {
+				return result, err
+			}
+			duplicate = recorded
+		}
+		if duplicate {
+			result.Duplicates++
+			continue
+		}
+		passes = append(passes, event)
+	}
+
+	token, err := serviceToken()
+	if err != nil {
+		return result, err
+	}
+	catalogue, err := ph.repo.GetCatalogue(ctx)
+	if err != nil {
+		return result, err
+	}
+
+	plates := []string{}
+	for _, event := range passes {
+		if !slices.Contains(plates, event.Plate) {
+			plates = append(plates, event.Plate)
+		}
+	}
+	owners, lookupErrs := ph.lookupPlateOwners(ctx, plates, token)
+
+	violations := []data.TrafficViolation{}
+	recordedEvents := data.CameraEvents{}
+	for i := range passes {
+		event := passes[i]
+		if err, failed := lookupErrs[event.Plate]; failed {
+			queue(&event, "", data.ReviewLookupFailed, err.Error())
+			continue
+		}
+		owner := owners[event.Plate]
+		if owner == "" {
+			queue(&event, "", data.ReviewUnknownPlate, "no registration found for the plate")
+			continue
+		}
+
+		violations = append(violations, cameraViolation(event, *cameras[event.CameraID], owner, catalogue))
+		recordedEvents = append(recordedEvents, event)
+	}
+
+	saved, err := ph.recordCameraViolations(ctx, violations, recordedEvents, token)
+	if err != nil {
+		return result, err
+	}
+	for _, violation := range saved {
+		result.ViolationIDs = append(result.ViolationIDs, violation.ID.Hex())
+	}
+	result.Violations = len(saved)
+	result.Duplicates += len(violations) - len(saved)
+
+	if err := ph.repo.CreateCameraReviews(ctx, reviews); err != nil {
+		return result, err
+	}
+	result.Queued = len(reviews)
+
+	return result, nil
+}
+
+// Looks the owners of the plates up in MUP, a few plates at a time and each with its own
+// timeout. Plates that couldn't be looked up have their error in the second map, plates
+// without a registration have an empty owner
+func (ph *PoliceHandler) lookupPlateOwners(ctx context.Context, plates []string, token string) (map[string]string, map[string]error) {
+	owners := map[string]string{}
+	errs := map[string]error{}
+
+	var mu sync.Mutex
+	var wg sync.WaitGroup
+	slots := make(chan struct{}, cameraLookupWorkers)
+	for _, plate := range plates {
+		wg.Add(1)
+		go func(plate string) {
+			defer wg.Done()
+			slots <- struct{}{}
+			defer func() { <-slots }()
+
+			callCtx, cancel := context.WithTimeout(ctx, cameraLookupTimeout)
+			defer cancel()
+			registration, err := ph.mup.GetRegistrationByPlate(callCtx, data.PlateRequest{Plate: plate}, token)
+
+			mu.Lock()
+			defer mu.Unlock()
+			if err != nil {
+				errs[plate] = err
+				return
+			}
+			owners[plate] = registration.Owner
+		}(plate)
+	}
+	wg.Wait()
+
+	return owners, errs
+}
+
+// Saves the violations with the passes they were recorded for, attaches the frames of
+// the events and issues the fines. Violations of passes already recorded are left out,
+// the ones saved are returned. A fine that fails is logged, the violation stands
+func (ph *PoliceHandler) recordCameraViolations(ctx context.Context, violations []data.TrafficViolation, events data.CameraEvents, token string) ([]data.TrafficViolation, error) {
+	passes := make([]data.CameraPass, len(violations))
+	for i, violation := range violations {
+		passes[i] = data.CameraPass{
+			ID:        primitive.NewObjectID(),
+			CameraID:  events[i].CameraID,
+			Plate:     events[i].Plate,
+			Timestamp: events[i].Timestamp,
+			Violation: violation.ID,
+		}
+	}
+
+	saved, err := ph.repo.CreateCameraViolations(ctx, violations, passes)
+	if err != nil {
+		return nil, err
+	}
+
+	frames := data.EvidenceFiles{}
+	for i, violation := range violations {
+		if events[i].Frame == "" || !slices.ContainsFunc(saved, func(v data.TrafficViolation) bool { return v.ID == violation.ID }) {
+			continue
+		}
+		frames = append(frames, data.Evidence{
+			ID:          primitive.NewObjectID(),
+			Violation:   violation.ID,
+			Kind:        data.EvidenceSpeedCamera,
+			Description: "Frame of camera " + events[i].CameraID,
+			Reference:   events[i].Frame,
+			UploadedBy:  "camera:" + events[i].CameraID,
+			UploadedAt:  events[i].Timestamp,
+		})
+	}
+	if err := ph.repo.CreateEvidence(ctx, frames, data.Admin); err != nil {
+		log.Printf("Failed to save camera frames: %v\n", err)
+	}
+
+	for _, violation := range saved {
+		if err := ph.fineOrReport(ctx, violation, token); err != nil {
+			log.Printf("Failed to issue fine or send crime report for violation %s, left for the escalation job: %v\n", violation.ID.Hex(), err)
+		}
+	}
+	return saved, nil
+}
+
+// Violation of the owner of the vehicle the event was recorded for, at the place of the camera
+func cameraViolation(event data.CameraEvent, camera data.Camera, owner string, catalogue data.Catalogue) data.TrafficViolation {
+	violation := data.TrafficViolation{
+		ID:           primitive.NewObjectID(),
+		ViolatorJMBG: owner,
+		Time:         event.Timestamp,
+		Location:     camera.Location,
+		Place:        camera.Place,
+		Camera:       camera.ID,
+		// Fined once saved, until then the escalation job picks it up
+		ChargePending: true,
+	}
+
+	switch event.Type {
+	case data.CameraEventRedLight:
+		violation.AddOffence(catalogue.Item(data.OffenceRunningRedLight, nil, event.Plate))
+		violation.Description = fmt.Sprintf("Camera %s recorded vehicle %s passing a red light. ", camera.ID, event.Plate)
+	default:
+		speed := event.RecordedSpeed()
+		detail := fmt.Sprintf("measured %.0f km/h, limit %.0f km/h", event.MeasuredSpeed, event.SpeedLimit)
+		violation.AddOffence(catalogue.Item(data.OffenceSpeeding, &speed, detail))
+		violation.Description = fmt.Sprintf("Camera %s measured vehicle %s at %.0f km/h where the limit is %.0f km/h, %.0f km/h after the tolerance. ",
+			camera.ID, event.Plate, event.MeasuredSpeed, event.SpeedLimit, speed)
+	}
+	return violation
+}
+
+// Reads the token, the event in review from the path and the body, writing an error if any is invalid
+func (ph *PoliceHandler) readCameraReview(w http.ResponseWriter, r *http.Request) (data.CameraReview, cameraReviewResolution, string, bool) {
+	var resolution cameraReviewResolution
+
+	subject, _, err := ph.getSubjectAndRole(ph.extractTokenFromHeader(r))
+	if err != nil {
+		http.Error(w, "Invalid token", http.StatusUnauthorized)
+		return data.CameraReview{}, resolution, "", false
+	}
+
+	id, err := primitive.ObjectIDFromHex(mux.Vars(r)["id"])
+	if err != nil {
+		http.Error(w, "Invalid review ID", http.StatusBadRequest)
+		return data.CameraReview{}, resolution, "", false
+	}
+
+	if err := json.NewDecoder(r.Body).Decode(&resolution); err != nil && !errors.Is(err, io.EOF) {
+		http.Error(w, "Failed to decode request body", http.StatusBadRequest)
+		log.Printf("Failed to decode request body: %v\n", err)
+		return data.CameraReview{}, resolution, "", false
+	}
+
+	review, err := ph.repo.GetCameraReview(r.Context(), id)
+	if err != nil {
+		writeCameraReviewError(w, err)
+		return data.CameraReview{}, resolution, "", false
+	}
+	if review.Status != data.ReviewPending {
+		http.Error(w, data.ErrCameraReviewClosed.Error(), http.StatusConflict)
+		return data.CameraReview{}, resolution, "", false
+	}
+
+	return review, resolution, subject, true
+}
+
+func writeCameraReviewError(w http.ResponseWriter, err error) {
+	switch {
+	case errors.Is(err, data.ErrCameraReviewNotFound):
+		http.Error(w, "Event in review not found", http.StatusNotFound)
+	case errors.Is(err, data.ErrCameraReviewClosed):
+		http.Error(w, err.Error(), http.StatusConflict)
+	default:
+		http.Error(w, "Failed to retrieve event in review", http.StatusInternalServerError)
+		log.Printf("Failed to retrieve event in review: %v\n", err)
+	}
+}
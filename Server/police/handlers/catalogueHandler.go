@@ -0,0 +1,95 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"police/data"
+	"strings"
+)
+
+var errUnknownOffence = errors.New("offence is not in the catalogue")
+
+// Returns offences in use, or every offence of the catalogue with ?all=true
+func (ph *PoliceHandler) GetOffences(w http.ResponseWriter, r *http.Request) {
+	offences, err := ph.repo.GetOffences(r.Context(), r.URL.Query().Get("all") != "true")
+	if err != nil {
+		http.Error(w, "Failed to retrieve violation catalogue", http.StatusInternalServerError)
+		log.Printf("Failed to retrieve violation catalogue: %v\n", err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	offences.ToJSON(w)
+}
+
+// Creates an offence or replaces the one with the same code. Offences are retired by
+// saving them as inactive, so violations recorded with them keep a valid code
+func (ph *PoliceHandler) SaveOffence(w http.ResponseWriter, r *http.Request) {
+	var offence data.Offence
+	if err := offence.FromJSON(r.Body); err != nil {
+		http.Error(w, "Failed to decode request body", http.StatusBadRequest)
+		log.Printf("Failed to decode request body: %v\n", err)
+		return
+	}
+
+	offence.Code = strings.ToUpper(strings.TrimSpace(offence.Code))
+	if offence.Code == "" || offence.Title == "" {
+		http.Error(w, "Offence needs a code and a title", http.StatusBadRequest)
+		return
+	}
+	if offence.Severity != data.SeverityMisdemeanor && offence.Severity != data.SeverityCriminal {
+		http.Error(w, fmt.Sprintf("Severity must be %s or %s", data.SeverityMisdemeanor, data.SeverityCriminal), http.StatusBadRequest)
+		return
+	}
+	if offence.FineMin < 0 || offence.FineMax < offence.FineMin || offence.Points < 0 {
+		http.Error(w, "Fine range and points must not be negative and the lowest fine must not exceed the highest", http.StatusBadRequest)
+		return
+	}
+
+	if err := ph.repo.SaveOffence(r.Context(), offence); err != nil {
+		http.Error(w, "Failed to save offence", http.StatusInternalServerError)
+		log.Printf("Failed to save offence: %v\n", err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	offence.ToJSON(w)
+}
+
+// Sets offences of a violation an officer wrote by hand. Offences are looked up in the
+// catalogue by code, and violations written with a reason only have it split into offences
+func (ph *PoliceHandler) setOffences(ctx context.Context, violation *data.TrafficViolation, offences data.OffenceItems) error {
+	catalogue, err := ph.repo.GetCatalogue(ctx)
+	if err != nil {
+		return err
+	}
+
+	if len(offences) == 0 {
+		violation.Offences = catalogue.ParseReason(violation.Reason)
+		return nil
+	}
+
+	violation.Offences = nil
+	for _, offence := range offences {
+		if _, ok := catalogue[offence.Code]; !ok {
+			return fmt.Errorf("%w: %s", errUnknownOffence, offence.Code)
+		}
+		violation.AddOffence(catalogue.Item(offence.Code, offence.MeasuredValue, offence.Detail))
+	}
+
+	return nil
+}
+
+func writeOffenceError(w http.ResponseWriter, err error) {
+	if errors.Is(err, errUnknownOffence) {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	http.Error(w, "Failed to read violation catalogue", http.StatusInternalServerError)
+	log.Printf("Failed to read violation catalogue: %v\n", err)
+}
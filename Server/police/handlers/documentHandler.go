@@ -0,0 +1,111 @@
+package handlers
+
+import (
+	"bytes"
+	"errors"
+	"log"
+	"net/http"
+	"police/data"
+	"police/documents"
+
+	"github.com/dgrijalva/jwt-go"
+	"github.com/gorilla/mux"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+// Returns violation notice as PDF to the violator or to a police officer
+func (ph *PoliceHandler) GetViolationNotice(w http.ResponseWriter, r *http.Request) {
+	tokenString := ph.extractTokenFromHeader(r)
+	jmbg, role, err := ph.getSubjectAndRole(tokenString)
+	if err != nil {
+		http.Error(w, "Invalid token", http.StatusUnauthorized)
+		return
+	}
+
+	objectID, err := primitive.ObjectIDFromHex(mux.Vars(r)["id"])
+	if err != nil {
+		http.Error(w, "Invalid violation ID", http.StatusBadRequest)
+		return
+	}
+
+	violation, err := ph.repo.GetTrafficViolationByID(r.Context(), objectID)
+	if err != nil {
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			http.Error(w, "Traffic violation not found", http.StatusNotFound)
+			return
+		}
+		http.Error(w, "Failed to retrieve traffic violation", http.StatusInternalServerError)
+		log.Printf("Failed to retrieve traffic violation: %v\n", err)
+		return
+	}
+
+	if role != data.Admin && violation.ViolatorJMBG != jmbg {
+		http.Error(w, "Forbidden", http.StatusForbidden)
+		return
+	}
+
+	violator, err := ph.sso.GetPersonByJMBG(r.Context(), violation.ViolatorJMBG, tokenString)
+	if err != nil {
+		log.Printf("Failed to retrieve violator: %v\n", err)
+		violator = data.Person{JMBG: violation.ViolatorJMBG}
+	}
+
+	verifyURL := ph.publicURL + "/api/v1/verify/violation/" + violation.ID.Hex()
+
+	var buf bytes.Buffer
+	if err := documents.ViolationNotice(&buf, *violation, violator, verifyURL); err != nil {
+		http.Error(w, "Failed to create violation notice", http.StatusInternalServerError)
+		log.Printf("Failed to create violation notice: %v\n", err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/pdf")
+	w.Header().Set("Content-Disposition", "inline; filename=\"prekrsajni-nalog-"+violation.ID.Hex()+".pdf\"")
+	w.WriteHeader(http.StatusOK)
+	w.Write(buf.Bytes())
+}
+
+// Public endpoint the violation notice QR code links to
+func (ph *PoliceHandler) VerifyViolationNotice(w http.ResponseWriter, r *http.Request) {
+	objectID, err := primitive.ObjectIDFromHex(mux.Vars(r)["id"])
+	if err != nil {
+		http.Error(w, "Invalid violation ID", http.StatusBadRequest)
+		return
+	}
+
+	verification := data.DocumentVerification{
+		Document: data.DocumentViolationNotice,
+		Number:   objectID.Hex(),
+	}
+
+	_, err = ph.repo.GetTrafficViolationByID(r.Context(), objectID)
+	if err != nil && !errors.Is(err, mongo.ErrNoDocuments) {
+		http.Error(w, "Failed to verify violation notice", http.StatusInternalServerError)
+		return
+	}
+	verification.Valid = err == nil
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	verification.ToJSON(w)
+}
+
+// Returns JMBG and role of the token owner
+func (ph *PoliceHandler) getSubjectAndRole(tokenString string) (string, string, error) {
+	claims := jwt.MapClaims{}
+	token, err := jwt.ParseWithClaims(tokenString, claims, func(token *jwt.Token) (interface{}, error) {
+		return secretKey, nil
+	})
+	if err != nil || !token.Valid {
+		return "", "", errors.New("invalid token")
+	}
+
+	jmbg, ok1 := claims["sub"].(string)
+	role, ok2 := claims["role"].(string)
+	if !ok1 || !ok2 {
+		return "", "", errors.New("invalid token claims")
+	}
+
+	return jmbg, role, nil
+}
@@ -0,0 +1,416 @@
+package handlers
+
+import (
+	"common/storage"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"io"
+	"log"
+	"mime"
+	"mime/multipart"
+	"net/http"
+	"path/filepath"
+	"police/data"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gorilla/mux"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+const (
+	maxEvidenceSize  = 10 << 20
+	maxEvidenceFiles = 5
+	// Room for the request and multipart headers on top of the evidence
+	maxEvidenceFormSize = maxEvidenceFiles*maxEvidenceSize + 1<<20
+	// Parts above this size are buffered on disk while the form is parsed
+	maxEvidenceFormMemory = 1 << 20
+)
+
+var (
+	errEvidenceTooLarge     = errors.New("evidence exceeds the size limit")
+	errTooManyEvidenceFiles = errors.New("too many evidence files")
+	errUnsupportedEvidence  = errors.New("evidence must be a PDF, JPEG or PNG file")
+	errInvalidEvidenceKind  = errors.New("unknown kind of evidence")
+	errEvidenceWithoutFile  = errors.New("evidence of this kind needs a file")
+	errReferenceWithFile    = errors.New("bodycam clips are attached as a reference, not a file")
+	errReferenceMissing     = errors.New("bodycam clip needs a reference to the clip")
+	errInvalidChecksum      = errors.New("checksum must be a hex encoded SHA-256")
+	allowedEvidenceContents = []string{"application/pdf", "image/jpeg", "image/png"}
+	sha256Pattern           = regexp.MustCompile(`^[0-9a-f]{64}$`)
+)
+
+// Evidence the officer attaches, sent as the body for bodycam clips or as the 'request'
+// field of a multipart form with files under 'evidence'. SHA256 is the checksum the
+// bodycam system keeps for the clip
+type evidenceRequest struct {
+	Kind        string `json:"kind"`
+	Description string `json:"description"`
+	Reference   string `json:"reference"`
+	SHA256      string `json:"sha256"`
+}
+
+// Attaches evidence the officer collected to the violation
+func (ph *PoliceHandler) AttachEvidence(w http.ResponseWriter, r *http.Request) {
+	jmbg, role, err := ph.getSubjectAndRole(ph.extractTokenFromHeader(r))
+	if err != nil {
+		http.Error(w, "Invalid token", http.StatusUnauthorized)
+		return
+	}
+
+	id, err := primitive.ObjectIDFromHex(mux.Vars(r)["id"])
+	if err != nil {
+		http.Error(w, "Invalid violation ID", http.StatusBadRequest)
+		return
+	}
+	if _, err := ph.repo.GetTrafficViolationByID(r.Context(), id); err != nil {
+		http.Error(w, "Traffic violation not found", http.StatusNotFound)
+		return
+	}
+
+	var request evidenceRequest
+	files, cleanup, err := decodeEvidenceForm(w, r, &request)
+	defer cleanup()
+	if err != nil {
+		if !writeEvidenceError(w, err) {
+			http.Error(w, "Failed to decode request body", http.StatusBadRequest)
+			log.Printf("Failed to decode request body: %v\n", err)
+		}
+		return
+	}
+
+	evidence, err := ph.newEvidence(r.Context(), id, request, jmbg, files)
+	if err != nil {
+		if !writeEvidenceError(w, err) {
+			http.Error(w, "Failed to store evidence", http.StatusInternalServerError)
+			log.Printf("Failed to store evidence: %v\n", err)
+		}
+		return
+	}
+
+	if err := ph.repo.CreateEvidence(r.Context(), evidence, role); err != nil {
+		ph.discardEvidence(r.Context(), evidence)
+		http.Error(w, "Failed to save evidence", http.StatusInternalServerError)
+		log.Printf("Failed to save evidence: %v\n", err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	evidence.ToJSON(w)
+}
+
+// Lists evidence attached to the violation, each listing is recorded in the chain of custody
+func (ph *PoliceHandler) GetViolationEvidence(w http.ResponseWriter, r *http.Request) {
+	jmbg, role, err := ph.getSubjectAndRole(ph.extractTokenFromHeader(r))
+	if err != nil {
+		http.Error(w, "Invalid token", http.StatusUnauthorized)
+		return
+	}
+
+	id, err := primitive.ObjectIDFromHex(mux.Vars(r)["id"])
+	if err != nil {
+		http.Error(w, "Invalid violation ID", http.StatusBadRequest)
+		return
+	}
+
+	evidence, err := ph.repo.GetViolationEvidence(r.Context(), id)
+	if err != nil {
+		http.Error(w, "Failed to retrieve evidence", http.StatusInternalServerError)
+		log.Printf("Failed to retrieve evidence: %v\n", err)
+		return
+	}
+
+	for _, e := range evidence {
+		if err := ph.repo.RecordCustody(r.Context(), e, data.CustodyViewed, jmbg, role, ""); err != nil {
+			http.Error(w, "Failed to record access to evidence", http.StatusInternalServerError)
+			log.Printf("Failed to record access to evidence %s: %v\n", e.ID.Hex(), err)
+			return
+		}
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	evidence.ToJSON(w)
+}
+
+// Streams evidence to the offender of the violation or an administrator, such as the
+// court. Access is recorded in the chain of custody before anything is sent, and the
+// content is checked against its checksum on the way out. Downloads made for a violation,
+// such as the court's for a hearing, pass it in ?violation= and get only its evidence
+func (ph *PoliceHandler) DownloadEvidence(w http.ResponseWriter, r *http.Request) {
+	jmbg, role, err := ph.getSubjectAndRole(ph.extractTokenFromHeader(r))
+	if err != nil {
+		http.Error(w, "Invalid token", http.StatusUnauthorized)
+		return
+	}
+
+	id, err := primitive.ObjectIDFromHex(mux.Vars(r)["id"])
+	if err != nil {
+		http.Error(w, "Invalid evidence ID", http.StatusBadRequest)
+		return
+	}
+
+	evidence, err := ph.repo.GetEvidence(r.Context(), id)
+	if err != nil {
+		writeEvidenceLookupError(w, err)
+		return
+	}
+	if violation := r.URL.Query().Get("violation"); violation != "" && violation != evidence.Violation.Hex() {
+		http.Error(w, "Evidence not found", http.StatusNotFound)
+		return
+	}
+	if role != data.Admin {
+		violation, err := ph.repo.GetTrafficViolationByID(r.Context(), evidence.Violation)
+		if err != nil || violation.ViolatorJMBG != jmbg {
+			http.Error(w, "Evidence not found", http.StatusNotFound)
+			return
+		}
+	}
+	if !evidence.HasContent() {
+		http.Error(w, "Evidence is kept elsewhere, see its reference", http.StatusConflict)
+		return
+	}
+
+	content, err := ph.blobs.Get(r.Context(), evidence.BlobKey)
+	if err != nil {
+		writeEvidenceLookupError(w, err)
+		return
+	}
+	defer content.Close()
+
+	if err := ph.repo.RecordCustody(r.Context(), evidence, data.CustodyDownloaded, jmbg, role, ""); err != nil {
+		http.Error(w, "Failed to record access to evidence", http.StatusInternalServerError)
+		log.Printf("Failed to record access to evidence %s: %v\n", evidence.ID.Hex(), err)
+		return
+	}
+
+	hash := sha256.New()
+	w.Header().Set("Content-Type", evidence.ContentType)
+	w.Header().Set("Content-Length", strconv.FormatInt(evidence.Size, 10))
+	w.Header().Set("Content-Disposition", mime.FormatMediaType("attachment", map[string]string{"filename": evidence.FileName}))
+	w.Header().Set("X-Checksum-SHA256", evidence.SHA256)
+	w.WriteHeader(http.StatusOK)
+	if _, err := io.Copy(w, io.TeeReader(content, hash)); err != nil {
+		log.Printf("Failed to write evidence '%s': %v\n", evidence.ID.Hex(), err)
+		return
+	}
+
+	// The client sees the mismatch against the checksum header, the log keeps it on record
+	if sum := hex.EncodeToString(hash.Sum(nil)); sum != evidence.SHA256 {
+		log.Printf("Evidence '%s' doesn't match its checksum, stored content hashes to %s\n", evidence.ID.Hex(), sum)
+		err := ph.repo.RecordCustody(context.WithoutCancel(r.Context()), evidence, data.CustodyIntegrityFailed, jmbg, role, "content hashes to "+sum)
+		if err != nil {
+			log.Printf("Failed to record integrity failure of evidence %s: %v\n", evidence.ID.Hex(), err)
+		}
+	}
+}
+
+// Returns the chain of custody of the evidence
+func (ph *PoliceHandler) GetEvidenceCustody(w http.ResponseWriter, r *http.Request) {
+	id, err := primitive.ObjectIDFromHex(mux.Vars(r)["id"])
+	if err != nil {
+		http.Error(w, "Invalid evidence ID", http.StatusBadRequest)
+		return
+	}
+
+	if _, err := ph.repo.GetEvidence(r.Context(), id); err != nil {
+		writeEvidenceLookupError(w, err)
+		return
+	}
+
+	entries, err := ph.repo.GetCustodyLog(r.Context(), id)
+	if err != nil {
+		http.Error(w, "Failed to retrieve chain of custody", http.StatusInternalServerError)
+		log.Printf("Failed to retrieve chain of custody: %v\n", err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	entries.ToJSON(w)
+}
+
+// Validates the evidence the officer attaches and stores its content. Bodycam clips
+// are only a reference, everything else comes with files
+func (ph *PoliceHandler) newEvidence(ctx context.Context, violation primitive.ObjectID, request evidenceRequest, uploadedBy string, files []*multipart.FileHeader) (data.EvidenceFiles, error) {
+	if !slices.Contains(data.OfficerEvidenceKinds, request.Kind) {
+		return nil, errInvalidEvidenceKind
+	}
+	description := strings.TrimSpace(request.Description)
+
+	if request.Kind != data.EvidenceBodycamClip {
+		if len(files) == 0 {
+			return nil, errEvidenceWithoutFile
+		}
+		return ph.storeEvidence(ctx, violation, request.Kind, description, uploadedBy, files)
+	}
+
+	if len(files) > 0 {
+		return nil, errReferenceWithFile
+	}
+	reference := strings.TrimSpace(request.Reference)
+	if reference == "" {
+		return nil, errReferenceMissing
+	}
+	checksum := strings.ToLower(request.SHA256)
+	if checksum != "" && !sha256Pattern.MatchString(checksum) {
+		return nil, errInvalidChecksum
+	}
+
+	return data.EvidenceFiles{{
+		ID:          primitive.NewObjectID(),
+		Violation:   violation,
+		Kind:        request.Kind,
+		Description: description,
+		SHA256:      checksum,
+		Reference:   reference,
+		UploadedBy:  uploadedBy,
+		UploadedAt:  time.Now(),
+	}}, nil
+}
+
+// Validates the files and stores their content in the blob store. The content type is
+// sniffed from the content itself, the one declared by the client is ignored. Content
+// stored before a file is rejected is discarded
+func (ph *PoliceHandler) storeEvidence(ctx context.Context, violation primitive.ObjectID, kind, description, uploadedBy string, files []*multipart.FileHeader) (data.EvidenceFiles, error) {
+	if len(files) > maxEvidenceFiles {
+		return nil, errTooManyEvidenceFiles
+	}
+
+	evidence := data.EvidenceFiles{}
+	for _, header := range files {
+		file, err := header.Open()
+		if err != nil {
+			ph.discardEvidence(ctx, evidence)
+			return nil, err
+		}
+		stored, err := storage.StoreContent(ctx, ph.blobs, "evidence/", file, maxEvidenceSize, allowedEvidenceContents)
+		file.Close()
+		if err != nil {
+			ph.discardEvidence(ctx, evidence)
+		}
+		switch {
+		case errors.Is(err, storage.ErrContentTooLarge):
+			return nil, errEvidenceTooLarge
+		case errors.Is(err, storage.ErrUnsupportedContent):
+			return nil, errUnsupportedEvidence
+		case err != nil:
+			return nil, err
+		}
+
+		evidence = append(evidence, data.Evidence{
+			ID:          primitive.NewObjectID(),
+			Violation:   violation,
+			Kind:        kind,
+			Description: description,
+			FileName:    filepath.Base(header.Filename),
+			ContentType: stored.ContentType,
+			Size:        stored.Size,
+			SHA256:      stored.SHA256,
+			BlobKey:     stored.Key,
+			UploadedBy:  uploadedBy,
+			UploadedAt:  time.Now(),
+		})
+	}
+
+	return evidence, nil
+}
+
+// Removes content of evidence that wasn't saved, unless other evidence keeps the same content
+func (ph *PoliceHandler) discardEvidence(ctx context.Context, evidence data.EvidenceFiles) {
+	keys := []string{}
+	for _, e := range evidence {
+		if e.HasContent() {
+			keys = append(keys, e.BlobKey)
+		}
+	}
+	if len(keys) == 0 {
+		return
+	}
+
+	if err := storage.DiscardContent(ctx, ph.blobs, keys, ph.repo.EvidenceBlobInUse); err != nil {
+		log.Printf("Failed to discard evidence content: %v\n", err)
+	}
+}
+
+// Evidence as it is sent to the court, with the address its content is downloaded from
+func (ph *PoliceHandler) evidenceRef(e data.Evidence) data.EvidenceRef {
+	ref := data.EvidenceRef{
+		ID:          e.ID.Hex(),
+		Kind:        e.Kind,
+		Description: e.Description,
+		FileName:    e.FileName,
+		ContentType: e.ContentType,
+		Size:        e.Size,
+		SHA256:      e.SHA256,
+		Reference:   e.Reference,
+	}
+	if e.HasContent() {
+		ref.URL = ph.publicURL + "/api/v1/evidence/" + e.ID.Hex()
+	}
+	return ref
+}
+
+// Decodes the request into v. JSON bodies carry only the request, multipart bodies
+// carry it in the 'request' field with files under 'evidence'.
+// The returned cleanup removes files buffered while parsing
+func decodeEvidenceForm(w http.ResponseWriter, r *http.Request, v interface{}) ([]*multipart.FileHeader, func(), error) {
+	mediaType, _, _ := mime.ParseMediaType(r.Header.Get("Content-Type"))
+	if mediaType != "multipart/form-data" {
+		return nil, func() {}, json.NewDecoder(r.Body).Decode(v)
+	}
+
+	r.Body = http.MaxBytesReader(w, r.Body, maxEvidenceFormSize)
+	if err := r.ParseMultipartForm(maxEvidenceFormMemory); err != nil {
+		var maxBytesErr *http.MaxBytesError
+		if errors.As(err, &maxBytesErr) {
+			return nil, func() {}, errEvidenceTooLarge
+		}
+		return nil, func() {}, err
+	}
+
+	cleanup := func() {
+		if err := r.MultipartForm.RemoveAll(); err != nil {
+			log.Printf("Failed to remove uploaded files: %v\n", err)
+		}
+	}
+
+	if err := json.Unmarshal([]byte(r.FormValue("request")), v); err != nil {
+		return nil, cleanup, err
+	}
+
+	return r.MultipartForm.File["evidence"], cleanup, nil
+}
+
+// Writes response for evidence validation errors, reports whether err was one of them
+func writeEvidenceError(w http.ResponseWriter, err error) bool {
+	switch {
+	case errors.Is(err, errEvidenceTooLarge):
+		http.Error(w, err.Error(), http.StatusRequestEntityTooLarge)
+	case errors.Is(err, errUnsupportedEvidence):
+		http.Error(w, err.Error(), http.StatusUnsupportedMediaType)
+	case errors.Is(err, errTooManyEvidenceFiles), errors.Is(err, errInvalidEvidenceKind),
+		errors.Is(err, errEvidenceWithoutFile), errors.Is(err, errReferenceWithFile),
+		errors.Is(err, errReferenceMissing), errors.Is(err, errInvalidChecksum):
+		http.Error(w, err.Error(), http.StatusBadRequest)
+	default:
+		return false
+	}
+	return true
+}
+
+func writeEvidenceLookupError(w http.ResponseWriter, err error) {
+	if errors.Is(err, data.ErrEvidenceNotFound) || errors.Is(err, storage.ErrBlobNotFound) {
+		http.Error(w, "Evidence not found", http.StatusNotFound)
+		return
+	}
+	http.Error(w, "Failed to retrieve evidence", http.StatusInternalServerError)
+	log.Printf("Failed to retrieve evidence: %v\n", err)
+}
@@ -0,0 +1,400 @@
+package handlers
+
+import (
+	"common/payments"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"police/clients"
+	"police/data"
+	"time"
+
+	"github.com/dgrijalva/jwt-go"
+	"github.com/gorilla/mux"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// Payment notices are small, anything larger is not from the gateway
+const maxPaymentNoticeSize = 64 << 10
+
+const (
+	// Longest a crime report may take to reach the court, deadlines of ctx bound it further
+	crimeReportTimeout = 2 * time.Second
+	// Reports that fail with a temporary error are sent at most this many times, the
+	// escalation job retries them after that
+	crimeReportAttempts = 2
+)
+
+var (
+	errPaymentNotReceived = errors.New("payment not received")
+	errFineUnderpaid      = errors.New("amount paid does not settle the fine")
+)
+
+// Subject of the token the service calls other services with in background jobs
+const serviceSubject = "police-service"
+
+// Fines of the citizen the token belongs to
+func (ph *PoliceHandler) GetMyFines(w http.ResponseWriter, r *http.Request) {
+	jmbg, _, err := ph.getSubjectAndRole(ph.extractTokenFromHeader(r))
+	if err != nil {
+		http.Error(w, "Invalid token", http.StatusUnauthorized)
+		return
+	}
+
+	fines, err := ph.repo.GetOffendersFines(r.Context(), jmbg)
+	if err != nil {
+		http.Error(w, "Failed to retrieve fines", http.StatusInternalServerError)
+		log.Printf("Failed to retrieve fines: %v\n", err)
+		return
+	}
+	setAmountsDue(fines, time.Now())
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	fines.ToJSON(w)
+}
+
+// Returns the fine to its offender or an administrator
+func (ph *PoliceHandler) GetFine(w http.ResponseWriter, r *http.Request) {
+	fine, ok := ph.getOwnFine(w, r)
+	if !ok {
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	fine.ToJSON(w)
+}
+
+// Checks payment of the fine with the payment gateway. Half of the fine settles it
+// if it was paid within the discount period. Only routed when a gateway that can be
+// asked is configured, otherwise payments arrive as notices from the gateway
+func (ph *PoliceHandler) ConfirmFinePayment(w http.ResponseWriter, r *http.Request) {
+	fine, ok := ph.getOwnFine(w, r)
+	if !ok {
+		return
+	}
+
+	if fine.Status == data.FinePaid {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusOK)
+		fine.ToJSON(w)
+		return
+	}
+	if fine.Status != data.FineUnpaid {
+		http.Error(w, "Fine is contested or sent to court and is paid as the court decides", http.StatusConflict)
+		return
+	}
+
+	fine, err := ph.confirmFinePayment(r.Context(), fine)
+	if err != nil {
+		switch {
+		case errors.Is(err, errPaymentNotReceived):
+			http.Error(w, "Payment not received yet", http.StatusConflict)
+		case errors.Is(err, errFineUnderpaid):
+			http.Error(w, err.Error(), http.StatusConflict)
+		case errors.Is(err, data.ErrFineNotUnpaid):
+			http.Error(w, err.Error(), http.StatusConflict)
+		default:
+			http.Error(w, "Failed to confirm payment", http.StatusBadGateway)
+			log.Printf("Failed to confirm payment of fine %s: %v\n", fine.ID.Hex(), err)
+		}
+		return
+	}
+
+	log.Printf("Fine '%s' confirmed as paid", fine.ID.Hex())
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	fine.ToJSON(w)
+}
+
+// Records the payment of a fine the payment gateway notifies the service of. The body
+// must be signed by the gateway, a notice of a payment already recorded is accepted again
+func (ph *PoliceHandler) ReceivePaymentNotice(w http.ResponseWriter, r *http.Request) {
+	body, err := io.ReadAll(http.MaxBytesReader(w, r.Body, maxPaymentNoticeSize))
+	if err != nil {
+		http.Error(w, "Failed to read request body", http.StatusBadRequest)
+		return
+	}
+	if !payments.VerifyNotice(ph.noticeKey, body, r.Header.Get(payments.SignatureHeader)) {
+		http.Error(w, "Invalid payment notice signature", http.StatusUnauthorized)
+		return
+	}
+
+	var notice payments.Notice
+	if err := json.Unmarshal(body, &notice); err != nil {
+		http.Error(w, "Failed to decode request body", http.StatusBadRequest)
+		log.Printf("Failed to decode payment notice: %v\n", err)
+		return
+	}
+
+	fine, err := ph.repo.GetFineByReference(r.Context(), notice.Model, notice.ReferenceNumber)
+	if err != nil {
+		writeFineError(w, err)
+		return
+	}
+
+	if fine.Status != data.FinePaid || fine.TransactionID != notice.TransactionID {
+		fine, err = ph.settleFine(r.Context(), fine, notice.Confirmation())
+		if err != nil {
+			switch {
+			case errors.Is(err, errFineUnderpaid), errors.Is(err, data.ErrFineNotUnpaid):
+				http.Error(w, err.Error(), http.StatusConflict)
+			default:
+				http.Error(w, "Failed to record payment", http.StatusInternalServerError)
+				log.Printf("Failed to record payment of fine %s: %v\n", fine.ID.Hex(), err)
+			}
+			return
+		}
+		log.Printf("Fine '%s' paid, transaction '%s'", fine.ID.Hex(), notice.TransactionID)
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	fine.ToJSON(w)
+}
+
+// Returns fines in the state given by ?status=, or all of them
+func (ph *PoliceHandler) GetFines(w http.ResponseWriter, r *http.Request) {
+	fines, err := ph.repo.GetFines(r.Context(), r.URL.Query().Get("status"))
+	if err != nil {
+		http.Error(w, "Failed to retrieve fines", http.StatusInternalServerError)
+		log.Printf("Failed to retrieve fines: %v\n", err)
+		return
+	}
+	setAmountsDue(fines, time.Now())
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	fines.ToJSON(w)
+}
+
+func (ph *PoliceHandler) GetViolationFine(w http.ResponseWriter, r *http.Request) {
+	id, err := primitive.ObjectIDFromHex(mux.Vars(r)["id"])
+	if err != nil {
+		http.Error(w, "Invalid violation ID", http.StatusBadRequest)
+		return
+	}
+
+	fine, err := ph.repo.GetFineByViolation(r.Context(), id)
+	if err != nil {
+		writeFineError(w, err)
+		return
+	}
+	fine.AmountDue = fine.AmountDueAt(time.Now())
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	fine.ToJSON(w)
+}
+
+// Sends unpaid fines to the court once they are overdue, along with contested fines the
+// court couldn't be reached for when they were contested, and fines or reports violations
+// left pending when they were recorded, until ctx is done
+func (ph *PoliceHandler) EscalateOverdueFines(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		if err := ph.escalateOverdueFines(ctx); err != nil {
+			log.Printf("Failed to escalate overdue fines: %v\n", err)
+		}
+		if err := ph.sendContestedFines(ctx); err != nil {
+			log.Printf("Failed to send contested fines: %v\n", err)
+		}
+		if err := ph.chargePendingViolations(ctx); err != nil {
+			log.Printf("Failed to charge pending violations: %v\n", err)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+func (ph *PoliceHandler) escalateOverdueFines(ctx context.Context) error {
+	now := time.Now()
+	fines, err := ph.repo.GetOverdueFines(ctx, now)
+	if err != nil {
+		return err
+	}
+	if len(fines) == 0 {
+		return nil
+	}
+
+	token, err := serviceToken()
+	if err != nil {
+		return err
+	}
+
+	for _, fine := range fines {
+		reason := fmt.Sprintf("Neplaćena novčana kazna po prekršajnom nalogu %s", fine.ReferenceNumber)
+		if violation, err := ph.repo.GetTrafficViolationByID(ctx, fine.Violation); err == nil {
+			reason += ": " + violation.Reason
+		}
+
+		courtCase, err := ph.court.CreateFineCase(ctx, data.UnpaidFine{
+			Fine:            fine.ID.Hex(),
+			Violation:       fine.Violation.Hex(),
+			ViolatorJMBG:    fine.Offender,
+			Reason:          reason,
+			Amount:          fine.Amount,
+			Currency:        fine.Currency,
+			ReferenceNumber: fine.ReferenceNumber,
+			DueDate:         fine.DueDate,
+		}, token)
+		if err != nil {
+			log.Printf("Failed to send overdue fine %s to court: %v\n", fine.ID.Hex(), err)
+			continue
+		}
+
+		// The court returns the same case for a fine sent twice, so a fine paid in the
+		// meantime is only logged and picked up by the court from there
+		_, err = ph.repo.MarkFineEscalated(ctx, fine.ID, courtCase, now)
+		if err != nil {
+			log.Printf("Failed to mark fine %s as sent to court case %s: %v\n", fine.ID.Hex(), courtCase, err)
+			continue
+		}
+		log.Printf("Overdue fine %s sent to court case %s", fine.ID.Hex(), courtCase)
+	}
+
+	return nil
+}
+
+// Issues the misdemeanour warrant fine for the violation, or reports the violation to
+// the court when it has a criminal offence or nothing to pay. The violation stays marked
+// pending until then, so the escalation job retries it when this fails
+func (ph *PoliceHandler) fineOrReport(ctx context.Context, violation data.TrafficViolation, token string) error {
+	if !violation.ChargePending {
+		if err := ph.repo.SetViolationChargePending(ctx, violation.ID, true); err != nil {
+			return err
+		}
+	}
+
+	var err error
+	if fine, ok := data.NewFine(violation, time.Now()); ok {
+		fine.ReferenceNumber = payments.GenerateReference97()
+		err = ph.repo.CreateFine(ctx, &fine)
+	} else {
+		// The court schedules one hearing per violation, so a report retried after its
+		// answer was lost doesn't schedule another
+		err = clients.CallWithRetry(ctx, crimeReportTimeout, crimeReportAttempts, func(ctx context.Context) error {
+			return ph.court.CreateCrimeReport(ctx, violation, token)
+		})
+	}
+	if err != nil {
+		return err
+	}
+
+	return ph.repo.SetViolationChargePending(ctx, violation.ID, false)
+}
+
+// Fines or reports the violations that couldn't be when they were recorded
+func (ph *PoliceHandler) chargePendingViolations(ctx context.Context) error {
+	violations, err := ph.repo.GetViolationsPendingCharge(ctx)
+	if err != nil {
+		return err
+	}
+	if len(violations) == 0 {
+		return nil
+	}
+
+	token, err := serviceToken()
+	if err != nil {
+		return err
+	}
+
+	for _, violation := range violations {
+		if err := ph.fineOrReport(ctx, violation, token); err != nil {
+			log.Printf("Failed to issue fine or send crime report for violation %s: %v\n", violation.ID.Hex(), err)
+			continue
+		}
+		log.Printf("Pending violation %s fined or reported to court", violation.ID.Hex())
+	}
+	return nil
+}
+
+func (ph *PoliceHandler) confirmFinePayment(ctx context.Context, fine data.Fine) (data.Fine, error) {
+	confirmation, err := ph.payments.ConfirmPayment(ctx, payments.Order{
+		Model:           fine.Model,
+		ReferenceNumber: fine.ReferenceNumber,
+		Amount:          fine.AmountDueAt(time.Now()),
+	})
+	if err != nil {
+		return fine, err
+	}
+	return ph.settleFine(ctx, fine, confirmation)
+}
+
+// Marks the fine paid if the payment settles it
+func (ph *PoliceHandler) settleFine(ctx context.Context, fine data.Fine, confirmation payments.Confirmation) (data.Fine, error) {
+	if !confirmation.Paid {
+		return fine, errPaymentNotReceived
+	}
+	if due := fine.AmountDueAt(confirmation.PaidAt); confirmation.Amount < due {
+		return fine, fmt.Errorf("%w: paid %.2f of %.2f %s", errFineUnderpaid, confirmation.Amount, due, fine.Currency)
+	}
+
+	return ph.repo.MarkFinePaid(ctx, fine.ID, confirmation.Amount, confirmation.TransactionID, confirmation.PaidAt)
+}
+
+// Reads the fine from the path and writes an error unless the token belongs to its
+// offender or an administrator
+func (ph *PoliceHandler) getOwnFine(w http.ResponseWriter, r *http.Request) (data.Fine, bool) {
+	jmbg, role, err := ph.getSubjectAndRole(ph.extractTokenFromHeader(r))
+	if err != nil {
+		http.Error(w, "Invalid token", http.StatusUnauthorized)
+		return data.Fine{}, false
+	}
+
+	id, err := primitive.ObjectIDFromHex(mux.Vars(r)["id"])
+	if err != nil {
+		http.Error(w, "Invalid fine ID", http.StatusBadRequest)
+		return data.Fine{}, false
+	}
+
+	fine, err := ph.repo.GetFineByID(r.Context(), id)
+	if err != nil {
+		writeFineError(w, err)
+		return data.Fine{}, false
+	}
+	if role != data.Admin && fine.Offender != jmbg {
+		http.Error(w, "Fine not found", http.StatusNotFound)
+		return data.Fine{}, false
+	}
+
+	fine.AmountDue = fine.AmountDueAt(time.Now())
+	return fine, true
+}
+
+func setAmountsDue(fines data.Fines, at time.Time) {
+	for i := range fines {
+		fines[i].AmountDue = fines[i].AmountDueAt(at)
+	}
+}
+
+// Short-lived administrator token for calls the service makes on its own
+func serviceToken() (string, error) {
+	claims := jwt.MapClaims{
+		"sub":  serviceSubject,
+		"role": data.Admin,
+		"exp":  time.Now().Add(5 * time.Minute).Unix(),
+	}
+	return jwt.NewWithClaims(jwt.SigningMethodHS256, claims).SignedString(secretKey)
+}
+
+func writeFineError(w http.ResponseWriter, err error) {
+	if errors.Is(err, data.ErrFineNotFound) {
+		http.Error(w, "Fine not found", http.StatusNotFound)
+		return
+	}
+	http.Error(w, "Failed to retrieve fine", http.StatusInternalServerError)
+	log.Printf("Failed to retrieve fine: %v\n", err)
+}
@@ -0,0 +1,140 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"log"
+	"net/http"
+	"police/data"
+	"strconv"
+
+	"github.com/gorilla/mux"
+)
+
+// Widest radius violations are looked up in around a point
+const maxSearchRadius = 50000
+
+var errInvalidRadius = errors.New("radius must be between 1 and 50000 meters")
+
+// Returns violations within ?radius= meters of the point at ?lon= and ?lat=, nearest first
+func (ph *PoliceHandler) GetViolationsNear(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+	longitude, errLon := strconv.ParseFloat(query.Get("lon"), 64)
+	latitude, errLat := strconv.ParseFloat(query.Get("lat"), 64)
+	if errLon != nil || errLat != nil {
+		http.Error(w, "Longitude and latitude are required", http.StatusBadRequest)
+		return
+	}
+	point := data.NewGeoPoint(longitude, latitude)
+	if err := (&data.Place{Position: &point}).Validate(); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	radius, err := strconv.ParseFloat(query.Get("radius"), 64)
+	if err != nil || radius < 1 || radius > maxSearchRadius {
+		http.Error(w, errInvalidRadius.Error(), http.StatusBadRequest)
+		return
+	}
+
+	violations, err := ph.repo.GetViolationsNear(r.Context(), point, radius)
+	if err != nil {
+		http.Error(w, "Failed to retrieve traffic violations", http.StatusInternalServerError)
+		log.Printf("Failed to retrieve traffic violations near a point: %v\n", err)
+		return
+	}
+
+	writeViolations(w, violations)
+}
+
+// Returns violations inside the GeoJSON polygon sent as the body
+func (ph *PoliceHandler) GetViolationsWithin(w http.ResponseWriter, r *http.Request) {
+	var area data.GeoPolygon
+	if err := area.FromJSON(r.Body); err != nil {
+		http.Error(w, "Failed to decode request body", http.StatusBadRequest)
+		log.Printf("Failed to decode request body: %v\n", err)
+		return
+	}
+	if err := area.Validate(); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	violations, err := ph.repo.GetViolationsWithin(r.Context(), area)
+	if err != nil {
+		http.Error(w, "Failed to retrieve traffic violations", http.StatusInternalServerError)
+		log.Printf("Failed to retrieve traffic violations within an area: %v\n", err)
+		return
+	}
+
+	writeViolations(w, violations)
+}
+
+// Returns violations inside the boundary of the municipality
+func (ph *PoliceHandler) GetMunicipalityViolations(w http.ResponseWriter, r *http.Request) {
+	municipality, err := ph.repo.GetMunicipality(r.Context(), mux.Vars(r)["name"])
+	if err != nil {
+		writeMunicipalityError(w, err)
+		return
+	}
+
+	violations, err := ph.repo.GetViolationsWithin(r.Context(), municipality.Boundary)
+	if err != nil {
+		http.Error(w, "Failed to retrieve traffic violations", http.StatusInternalServerError)
+		log.Printf("Failed to retrieve traffic violations in %s: %v\n", municipality.Name, err)
+		return
+	}
+
+	writeViolations(w, violations)
+}
+
+func (ph *PoliceHandler) GetMunicipalities(w http.ResponseWriter, r *http.Request) {
+	municipalities, err := ph.repo.GetMunicipalities(r.Context())
+	if err != nil {
+		writeMunicipalityError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	municipalities.ToJSON(w)
+}
+
+// Saves the boundary of a municipality, replacing the one saved before under the same name
+func (ph *PoliceHandler) SaveMunicipality(w http.ResponseWriter, r *http.Request) {
+	var municipality data.Municipality
+	if err := municipality.FromJSON(r.Body); err != nil {
+		http.Error(w, "Failed to decode request body", http.StatusBadRequest)
+		log.Printf("Failed to decode request body: %v\n", err)
+		return
+	}
+	if err := municipality.Validate(); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	if err := ph.repo.SaveMunicipality(r.Context(), municipality); err != nil {
+		http.Error(w, "Failed to save municipality", http.StatusInternalServerError)
+		log.Printf("Failed to save municipality: %v\n", err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	municipality.ToJSON(w)
+}
+
+func writeViolations(w http.ResponseWriter, violations []*data.TrafficViolation) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(violations)
+}
+
+func writeMunicipalityError(w http.ResponseWriter, err error) {
+	if errors.Is(err, data.ErrMunicipalityNotFound) {
+		http.Error(w, "Municipality not found", http.StatusNotFound)
+		return
+	}
+	http.Error(w, "Failed to retrieve municipalities", http.StatusInternalServerError)
+	log.Printf("Failed to retrieve municipalities: %v\n", err)
+}
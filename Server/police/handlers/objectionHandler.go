@@ -0,0 +1,186 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"police/data"
+	"strings"
+	"time"
+
+	"github.com/gorilla/mux"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+var (
+	errObjectionDeadline      = errors.New("deadline for objecting to the fine has passed")
+	errObjectionWithoutReason = errors.New("objection needs a statement")
+)
+
+// Statement of the offender, sent as the body or as the 'request' field of a multipart
+// form with evidence files under 'evidence'
+type objectionRequest struct {
+	Statement string `json:"statement"`
+}
+
+// Contests the fine on behalf of its offender within the objection deadline. The
+// violation goes to court with the statement and the evidence instead of being paid
+func (ph *PoliceHandler) SubmitObjection(w http.ResponseWriter, r *http.Request) {
+	jmbg, _, err := ph.getSubjectAndRole(ph.extractTokenFromHeader(r))
+	if err != nil {
+		http.Error(w, "Invalid token", http.StatusUnauthorized)
+		return
+	}
+
+	id, err := primitive.ObjectIDFromHex(mux.Vars(r)["id"])
+	if err != nil {
+		http.Error(w, "Invalid fine ID", http.StatusBadRequest)
+		return
+	}
+
+	fine, err := ph.repo.GetFineByID(r.Context(), id)
+	if err != nil {
+		writeFineError(w, err)
+		return
+	}
+	if fine.Offender != jmbg {
+		http.Error(w, "Fine not found", http.StatusNotFound)
+		return
+	}
+	if fine.Status != data.FineUnpaid {
+		http.Error(w, data.ErrFineNotUnpaid.Error(), http.StatusConflict)
+		return
+	}
+	if time.Now().After(fine.ObjectionUntil) {
+		http.Error(w, errObjectionDeadline.Error(), http.StatusConflict)
+		return
+	}
+
+	var request objectionRequest
+	files, cleanup, err := decodeEvidenceForm(w, r, &request)
+	defer cleanup()
+	if err != nil {
+		if !writeEvidenceError(w, err) {
+			http.Error(w, "Failed to decode request body", http.StatusBadRequest)
+			log.Printf("Failed to decode request body: %v\n", err)
+		}
+		return
+	}
+	request.Statement = strings.TrimSpace(request.Statement)
+	if request.Statement == "" {
+		http.Error(w, errObjectionWithoutReason.Error(), http.StatusBadRequest)
+		return
+	}
+
+	evidence, err := ph.storeEvidence(r.Context(), fine.Violation, data.EvidenceObjection, "", jmbg, files)
+	if err != nil {
+		if !writeEvidenceError(w, err) {
+			http.Error(w, "Failed to store evidence", http.StatusInternalServerError)
+			log.Printf("Failed to store evidence: %v\n", err)
+		}
+		return
+	}
+
+	objection := data.Objection{
+		Statement:   request.Statement,
+		Evidence:    []primitive.ObjectID{},
+		SubmittedAt: time.Now(),
+	}
+	for _, e := range evidence {
+		objection.Evidence = append(objection.Evidence, e.ID)
+	}
+
+	fine, err = ph.repo.ContestFine(r.Context(), fine.ID, objection)
+	if err != nil {
+		ph.discardEvidence(r.Context(), evidence)
+		if errors.Is(err, data.ErrFineNotUnpaid) {
+			http.Error(w, err.Error(), http.StatusConflict)
+			return
+		}
+		http.Error(w, "Failed to save objection", http.StatusInternalServerError)
+		log.Printf("Failed to save objection: %v\n", err)
+		return
+	}
+
+	// Evidence is saved once the fine is contested, so a rejected objection leaves no records
+	// behind. Content of evidence that isn't saved is discarded on every failure
+	if err := ph.repo.CreateEvidence(r.Context(), evidence, data.User); err != nil {
+		ph.discardEvidence(r.Context(), evidence)
+		http.Error(w, "Failed to save evidence", http.StatusInternalServerError)
+		log.Printf("Failed to save evidence: %v\n", err)
+		return
+	}
+
+	// The escalation job sends the case over if the court can't be reached now
+	status := http.StatusCreated
+	if err := ph.sendContestedFine(r.Context(), fine); err != nil {
+		log.Printf("Failed to send contested fine %s to court: %v\n", fine.ID.Hex(), err)
+		status = http.StatusAccepted
+	} else if fine, err = ph.repo.GetFineByID(r.Context(), fine.ID); err != nil {
+		writeFineError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	fine.ToJSON(w)
+}
+
+// Sends contested fines the court hasn't opened a case for yet
+func (ph *PoliceHandler) sendContestedFines(ctx context.Context) error {
+	fines, err := ph.repo.GetContestedFinesNotSent(ctx)
+	if err != nil {
+		return err
+	}
+
+	for _, fine := range fines {
+		if err := ph.sendContestedFine(ctx, fine); err != nil {
+			log.Printf("Failed to send contested fine %s to court: %v\n", fine.ID.Hex(), err)
+		}
+	}
+	return nil
+}
+
+// Opens a court case for the contested fine with the violation record and the evidence attached
+func (ph *PoliceHandler) sendContestedFine(ctx context.Context, fine data.Fine) error {
+	violation, err := ph.repo.GetTrafficViolationByID(ctx, fine.Violation)
+	if err != nil {
+		return err
+	}
+	evidence, err := ph.repo.GetViolationEvidence(ctx, fine.Violation)
+	if err != nil {
+		return err
+	}
+
+	contested := data.ContestedViolation{
+		Fine:            fine.ID.Hex(),
+		ReferenceNumber: fine.ReferenceNumber,
+		Amount:          fine.Amount,
+		Currency:        fine.Currency,
+		Statement:       fine.Objection.Statement,
+		SubmittedAt:     fine.Objection.SubmittedAt,
+		Violation:       *violation,
+		Evidence:        []data.EvidenceRef{},
+	}
+	for _, e := range evidence {
+		contested.Evidence = append(contested.Evidence, ph.evidenceRef(e))
+	}
+
+	token, err := serviceToken()
+	if err != nil {
+		return err
+	}
+
+	courtCase, err := ph.court.CreateObjectionCase(ctx, contested, token)
+	if err != nil {
+		return err
+	}
+
+	if err := ph.repo.SetContestedFineCase(ctx, fine.ID, courtCase, time.Now()); err != nil {
+		return fmt.Errorf("court case %s opened but not recorded: %w", courtCase, err)
+	}
+	log.Printf("Contested fine %s sent to court case %s", fine.ID.Hex(), courtCase)
+	return nil
+}
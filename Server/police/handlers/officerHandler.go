@@ -0,0 +1,382 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"log"
+	"net/http"
+	"police/data"
+	"time"
+
+	"github.com/gorilla/mux"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+var (
+	errNotOfficer    = errors.New("token does not belong to an active officer")
+	errNotSupervisor = errors.New("officer is not a supervisor")
+)
+
+// Longest period of shifts returned at once
+const maxShiftPeriod = 31 * 24 * time.Hour
+
+func (ph *PoliceHandler) CreateOfficer(w http.ResponseWriter, r *http.Request) {
+	var officer data.Officer
+	if err := officer.FromJSON(r.Body); err != nil {
+		http.Error(w, "Failed to decode request body", http.StatusBadRequest)
+		log.Printf("Failed to decode request body: %v\n", err)
+		return
+	}
+
+	if officer.JMBG == "" || officer.BadgeNumber == "" || officer.Station == "" {
+		http.Error(w, "Officer needs a JMBG, badge number and station", http.StatusBadRequest)
+		return
+	}
+	officer.Active = true
+
+	if err := ph.repo.CreateOfficer(r.Context(), &officer); err != nil {
+		if errors.Is(err, data.ErrOfficerExists) {
+			http.Error(w, err.Error(), http.StatusConflict)
+			return
+		}
+		http.Error(w, "Failed to create officer", http.StatusInternalServerError)
+		log.Printf("Failed to create officer: %v\n", err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	officer.ToJSON(w)
+}
+
+func (ph *PoliceHandler) GetOfficers(w http.ResponseWriter, r *http.Request) {
+	officers, err := ph.repo.GetOfficers(r.Context(), r.URL.Query().Get("station"))
+	if err != nil {
+		http.Error(w, "Failed to retrieve officers", http.StatusInternalServerError)
+		log.Printf("Failed to retrieve officers: %v\n", err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	officers.ToJSON(w)
+}
+
+// Changes rank, station, supervisor standing or deactivates the officer
+func (ph *PoliceHandler) UpdateOfficer(w http.ResponseWriter, r *http.Request) {
+	id, err := primitive.ObjectIDFromHex(mux.Vars(r)["id"])
+	if err != nil {
+		http.Error(w, "Invalid officer ID", http.StatusBadRequest)
+		return
+	}
+
+	var update data.Officer
+	if err := update.FromJSON(r.Body); err != nil {
+		http.Error(w, "Failed to decode request body", http.StatusBadRequest)
+		log.Printf("Failed to decode request body: %v\n", err)
+		return
+	}
+	if update.Station == "" {
+		http.Error(w, "Officer needs a station", http.StatusBadRequest)
+		return
+	}
+
+	officer, err := ph.repo.UpdateOfficer(r.Context(), id, update)
+	if err != nil {
+		if errors.Is(err, data.ErrOfficerNotFound) {
+			http.Error(w, "Officer not found", http.StatusNotFound)
+			return
+		}
+		http.Error(w, "Failed to update officer", http.StatusInternalServerError)
+		log.Printf("Failed to update officer: %v\n", err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	officer.ToJSON(w)
+}
+
+func (ph *PoliceHandler) CreatePatrol(w http.ResponseWriter, r *http.Request) {
+	var patrol data.Patrol
+	if err := patrol.FromJSON(r.Body); err != nil {
+		http.Error(w, "Failed to decode request body", http.StatusBadRequest)
+		log.Printf("Failed to decode request body: %v\n", err)
+		return
+	}
+
+	if patrol.CallSign == "" || patrol.Station == "" {
+		http.Error(w, "Patrol needs a call sign and station", http.StatusBadRequest)
+		return
+	}
+
+	if err := ph.repo.CreatePatrol(r.Context(), &patrol); err != nil {
+		if errors.Is(err, data.ErrPatrolExists) {
+			http.Error(w, err.Error(), http.StatusConflict)
+			return
+		}
+		http.Error(w, "Failed to create patrol", http.StatusInternalServerError)
+		log.Printf("Failed to create patrol: %v\n", err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	patrol.ToJSON(w)
+}
+
+func (ph *PoliceHandler) GetPatrols(w http.ResponseWriter, r *http.Request) {
+	patrols, err := ph.repo.GetPatrols(r.Context(), r.URL.Query().Get("station"))
+	if err != nil {
+		http.Error(w, "Failed to retrieve patrols", http.StatusInternalServerError)
+		log.Printf("Failed to retrieve patrols: %v\n", err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	patrols.ToJSON(w)
+}
+
+// Supervisor puts officers of the station on a patrol for a period of time
+func (ph *PoliceHandler) CreateShift(w http.ResponseWriter, r *http.Request) {
+	supervisor, err := ph.getSupervisor(r)
+	if err != nil {
+		writeStampError(w, err)
+		return
+	}
+
+	var shift data.Shift
+	if err := shift.FromJSON(r.Body); err != nil {
+		http.Error(w, "Failed to decode request body", http.StatusBadRequest)
+		log.Printf("Failed to decode request body: %v\n", err)
+		return
+	}
+
+	if len(shift.Officers) == 0 || !shift.End.After(shift.Start) {
+		http.Error(w, "Shift needs officers and must end after it starts", http.StatusBadRequest)
+		return
+	}
+
+	patrol, err := ph.repo.GetPatrolByID(r.Context(), shift.Patrol)
+	if err != nil {
+		if errors.Is(err, data.ErrPatrolNotFound) {
+			http.Error(w, "Patrol not found", http.StatusBadRequest)
+			return
+		}
+		http.Error(w, "Failed to retrieve patrol", http.StatusInternalServerError)
+		return
+	}
+	if patrol.Station != supervisor.Station {
+		http.Error(w, "Patrol belongs to another station", http.StatusForbidden)
+		return
+	}
+
+	for _, id := range shift.Officers {
+		officer, err := ph.repo.GetOfficerByID(r.Context(), id)
+		if err != nil {
+			if errors.Is(err, data.ErrOfficerNotFound) {
+				http.Error(w, "Officer "+id.Hex()+" not found", http.StatusBadRequest)
+				return
+			}
+			http.Error(w, "Failed to retrieve officer", http.StatusInternalServerError)
+			return
+		}
+		if !officer.Active || officer.Station != supervisor.Station {
+			http.Error(w, "Officer "+officer.BadgeNumber+" is not an active officer of the station", http.StatusBadRequest)
+			return
+		}
+	}
+
+	shift.Station = supervisor.Station
+	shift.CreatedBy = supervisor.ID
+
+	if err := ph.repo.CreateShift(r.Context(), &shift); err != nil {
+		if errors.Is(err, data.ErrShiftOverlaps) {
+			http.Error(w, err.Error(), http.StatusConflict)
+			return
+		}
+		http.Error(w, "Failed to create shift", http.StatusInternalServerError)
+		log.Printf("Failed to create shift: %v\n", err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	shift.ToJSON(w)
+}
+
+// Returns shifts of the supervisor's station between 'from' and 'to' (RFC 3339),
+// the last day by default
+func (ph *PoliceHandler) GetShifts(w http.ResponseWriter, r *http.Request) {
+	supervisor, err := ph.getSupervisor(r)
+	if err != nil {
+		writeStampError(w, err)
+		return
+	}
+
+	to := time.Now()
+	from := to.Add(-24 * time.Hour)
+
+	query := r.URL.Query()
+	if value := query.Get("from"); value != "" {
+		if from, err = time.Parse(time.RFC3339, value); err != nil {
+			http.Error(w, "Invalid 'from' time", http.StatusBadRequest)
+			return
+		}
+	}
+	if value := query.Get("to"); value != "" {
+		if to, err = time.Parse(time.RFC3339, value); err != nil {
+			http.Error(w, "Invalid 'to' time", http.StatusBadRequest)
+			return
+		}
+	}
+	if !to.After(from) || to.Sub(from) > maxShiftPeriod {
+		http.Error(w, "Period must end after it starts and span at most 31 days", http.StatusBadRequest)
+		return
+	}
+
+	shifts, err := ph.repo.GetShifts(r.Context(), supervisor.Station, from, to)
+	if err != nil {
+		http.Error(w, "Failed to retrieve shifts", http.StatusInternalServerError)
+		log.Printf("Failed to retrieve shifts: %v\n", err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	shifts.ToJSON(w)
+}
+
+// Returns checks and violations the shift recorded
+func (ph *PoliceHandler) GetShiftActivity(w http.ResponseWriter, r *http.Request) {
+	supervisor, err := ph.getSupervisor(r)
+	if err != nil {
+		writeStampError(w, err)
+		return
+	}
+
+	id, err := primitive.ObjectIDFromHex(mux.Vars(r)["id"])
+	if err != nil {
+		http.Error(w, "Invalid shift ID", http.StatusBadRequest)
+		return
+	}
+
+	activity, err := ph.repo.GetShiftActivity(r.Context(), id)
+	if err != nil {
+		if errors.Is(err, data.ErrShiftNotFound) {
+			http.Error(w, "Shift not found", http.StatusNotFound)
+			return
+		}
+		http.Error(w, "Failed to retrieve shift activity", http.StatusInternalServerError)
+		log.Printf("Failed to retrieve shift activity: %v\n", err)
+		return
+	}
+
+	if activity.Shift.Station != supervisor.Station {
+		http.Error(w, "Shift belongs to another station", http.StatusForbidden)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	activity.ToJSON(w)
+}
+
+// Returns the active officer the token belongs to
+func (ph *PoliceHandler) getOfficer(r *http.Request) (data.Officer, error) {
+	jmbg, _, err := ph.getSubjectAndRole(ph.extractTokenFromHeader(r))
+	if err != nil {
+		return data.Officer{}, errNotOfficer
+	}
+
+	officer, err := ph.repo.GetOfficerByJMBG(r.Context(), jmbg)
+	if err != nil {
+		if errors.Is(err, data.ErrOfficerNotFound) {
+			return data.Officer{}, errNotOfficer
+		}
+		return data.Officer{}, err
+	}
+	if !officer.Active {
+		return data.Officer{}, errNotOfficer
+	}
+
+	return officer, nil
+}
+
+func (ph *PoliceHandler) getSupervisor(r *http.Request) (data.Officer, error) {
+	officer, err := ph.getOfficer(r)
+	if err != nil {
+		return data.Officer{}, err
+	}
+	if !officer.Supervisor {
+		return data.Officer{}, errNotSupervisor
+	}
+
+	return officer, nil
+}
+
+// Identifies the officer from the token and the patrol they were on shift with at the time
+func (ph *PoliceHandler) stampFromToken(r *http.Request, at time.Time) (data.Stamp, error) {
+	officer, err := ph.getOfficer(r)
+	if err != nil {
+		return data.Stamp{}, err
+	}
+
+	shift, err := ph.repo.GetOfficersShift(r.Context(), officer.ID, at)
+	if err != nil {
+		return data.Stamp{}, err
+	}
+
+	patrol, err := ph.repo.GetPatrolByID(r.Context(), shift.Patrol)
+	if err != nil {
+		return data.Stamp{}, err
+	}
+
+	return data.Stamp{
+		Officer:     officer.ID,
+		BadgeNumber: officer.BadgeNumber,
+		Patrol:      patrol.ID,
+		CallSign:    patrol.CallSign,
+		Shift:       shift.ID,
+	}, nil
+}
+
+// Starts record of a check with the subject, place, time and stamp of the violation it may find
+func newCheck(checkType string, violation data.TrafficViolation, plates string) data.Check {
+	return data.Check{
+		Type:       checkType,
+		JMBG:       violation.ViolatorJMBG,
+		Plates:     plates,
+		Location:   violation.Location,
+		Place:      violation.Place,
+		Time:       violation.Time,
+		RecordedBy: *violation.RecordedBy,
+	}
+}
+
+// Records the check with the violation it found, if any. The check has already been
+// carried out, so failing to record it is only logged
+func (ph *PoliceHandler) recordCheck(ctx context.Context, check data.Check, violation *data.TrafficViolation) {
+	if violation != nil {
+		check.Violation = violation.ID
+	}
+
+	if err := ph.repo.SaveCheck(ctx, &check); err != nil {
+		log.Printf("Failed to record %s check of %s: %v\n", check.Type, check.JMBG, err)
+	}
+}
+
+func writeStampError(w http.ResponseWriter, err error) {
+	switch {
+	case errors.Is(err, errNotOfficer):
+		http.Error(w, "Only active police officers can do this", http.StatusForbidden)
+	case errors.Is(err, errNotSupervisor):
+		http.Error(w, "Only supervisors can do this", http.StatusForbidden)
+	case errors.Is(err, data.ErrNotOnShift):
+		http.Error(w, "Officer is not on shift", http.StatusForbidden)
+	default:
+		http.Error(w, "Failed to identify officer", http.StatusInternalServerError)
+		log.Printf("Failed to identify officer: %v\n", err)
+	}
+}
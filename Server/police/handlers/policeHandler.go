@@ -1,6 +1,8 @@
 package handlers
 
 import (
+	"common/payments"
+	"common/storage"
 	"encoding/json"
 	"fmt"
 	"log"
@@ -20,15 +22,20 @@ type KeyProduct struct{}
 var secretKey = []byte("eUpravaT2")
 
 type PoliceHandler struct {
-	repo  *data.PoliceRepo
-	court clients.CourtClient
-	mup   clients.MupClient
-	sso   clients.SSOClient
+	repo      *data.PoliceRepo
+	court     clients.CourtClient
+	mup       clients.MupClient
+	sso       clients.SSOClient
+	payments  payments.Gateway
+	noticeKey []byte
+	blobs     storage.BlobStore
+	publicURL string
 }
 
-// Constructor
-func NewPoliceHandler(r *data.PoliceRepo, c clients.CourtClient, m clients.MupClient, s clients.SSOClient) *PoliceHandler {
-	return &PoliceHandler{r, c, m, s}
+// Constructor. publicURL is the address the service is reachable at from outside, used in verification links.
+// Payments are confirmed by the gateway p when one is given, otherwise only by notices signed with noticeKey
+func NewPoliceHandler(r *data.PoliceRepo, c clients.CourtClient, m clients.MupClient, s clients.SSOClient, p payments.Gateway, noticeKey []byte, b storage.BlobStore, publicURL string) *PoliceHandler {
+	return &PoliceHandler{r, c, m, s, p, noticeKey, b, publicURL}
 }
 
 // Ping
@@ -48,200 +55,120 @@ func (ph *PoliceHandler) CreateTrafficViolation(w http.ResponseWriter, r *http.R
 		return
 	}
 
-	violation.ID = primitive.NewObjectID()
+	if err := violation.Place.Validate(); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
 
-	err = ph.repo.CreateTrafficViolation(r.Context(), &violation)
+	stamp, err := ph.stampFromToken(r, time.Now())
 	if err != nil {
-		http.Error(w, "Failed to create traffic violation", http.StatusInternalServerError)
-		log.Printf("Failed to create traffic violation: %v\n", err)
+		writeStampError(w, err)
 		return
 	}
 
-	w.Header().Set("Content-Type", "application/json")
-	w.WriteHeader(http.StatusCreated)
-	json.NewEncoder(w).Encode(violation)
-}
+	violation.ID = primitive.NewObjectID()
+	violation.RecordedBy = &stamp
 
-func (ph *PoliceHandler) CheckAll(w http.ResponseWriter, r *http.Request) {
-	var driverCheck data.DriverCheck
-	err := json.NewDecoder(r.Body).Decode(&driverCheck)
+	err = ph.setOffences(r.Context(), &violation, violation.Offences)
 	if err != nil {
-		http.Error(w, "Failed to decode request body", http.StatusBadRequest)
-		log.Printf("Failed to decode request body: %v\n", err)
+		writeOffenceError(w, err)
 		return
 	}
 
-	if driverCheck.JMBG == "" || driverCheck.AlcoholLevel == 0 || driverCheck.Tire == "" || driverCheck.Location == "" || driverCheck.PlatesNumber == "" {
-		http.Error(w, "All fields (JMBG, AlcoholLevel, Tire, Location) are required", http.StatusBadRequest)
-		log.Printf("Missing required fields: JMBG=%v, AlcoholLevel=%v, Tire=%v, Location=%v, Plates Number=%v\n", driverCheck.JMBG, driverCheck.AlcoholLevel, driverCheck.Tire, driverCheck.Location, driverCheck.PlatesNumber)
+	err = ph.repo.CreateTrafficViolation(r.Context(), &violation)
+	if err != nil {
+		http.Error(w, "Failed to create traffic violation", http.StatusInternalServerError)
+		log.Printf("Failed to create traffic violation: %v\n", err)
 		return
 	}
 
-	violation := data.TrafficViolation{
-		ID:           primitive.NewObjectID(),
-		Time:         time.Now(),
-		ViolatorJMBG: driverCheck.JMBG,
-		Location:     driverCheck.Location,
-	}
-
-	token := ph.extractTokenFromHeader(r)
-
-	_, err = ph.sso.GetPersonByJMBG(r.Context(), driverCheck.JMBG, token)
+	err = ph.fineOrReport(r.Context(), violation, ph.extractTokenFromHeader(r))
 	if err != nil {
-		http.Error(w, "Error with services communication", http.StatusBadRequest)
-		log.Printf("Error while communicating with SSO service: %s", err.Error())
+		http.Error(w, "Failed to issue fine or send crime report", http.StatusInternalServerError)
+		log.Printf("Failed to issue fine or send crime report: %v\n", err)
 		return
 	}
 
-	// Check alcohol level
-	if driverCheck.AlcoholLevel < 0 {
-		http.Error(w, "Alcohol level must be bigger than 0.", http.StatusBadRequest)
-		return
-	} else if driverCheck.AlcoholLevel > 0.2 {
-		violation.Reason += fmt.Sprintf("Drunk driving: %.2f. ", driverCheck.AlcoholLevel)
-		violation.Description += "Driver was caught operating a vehicle with a blood alcohol level above the legal limit. "
-	} else {
-		log.Printf("Driver was caught operating a vehicle with a blood alcohol level within the legal limit.")
-	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(violation)
+}
 
-	// Check tire type
-	now := time.Now()
-	switch driverCheck.Tire {
-	case "SUMMER":
-		if now.Month() >= time.November || now.Month() < time.April {
-			violation.Reason += "Improper tire usage: SUMMER tires during winter period. "
-			violation.Description += "Driver was caught operating a vehicle with SUMMER tires during the winter period. "
-			log.Printf("Improper tire usage: JMBG=%v, Tire=%v\n", driverCheck.JMBG, driverCheck.Tire)
-		}
-	case "WINTER":
-		if now.Month() >= time.April && now.Month() < time.November {
-			violation.Reason += "Improper tire usage: WINTER tires during summer period. "
-			violation.Description += "Driver was caught operating a vehicle with WINTER tires during the summer period. "
-			log.Printf("Improper tire usage: JMBG=%v, Tire=%v\n", driverCheck.JMBG, driverCheck.Tire)
-		}
-	default:
-		http.Error(w, "Invalid tire type provided. Must be either SUMMER or WINTER", http.StatusBadRequest)
-		log.Printf("Invalid tire type: JMBG=%v, Tire=%v\n", driverCheck.JMBG, driverCheck.Tire)
-		return
-	}
+func (ph *PoliceHandler) CheckAlcoholLevel(w http.ResponseWriter, r *http.Request) {
+	var alcoholLevel data.AlcoholRequest
 
-	// Check driving ban
-	jmbgRequest := data.JMBGRequest{JMBG: driverCheck.JMBG}
-	drivingBan, err := ph.mup.CheckDrivingBan(r.Context(), jmbgRequest, token)
+	err := json.NewDecoder(r.Body).Decode(&alcoholLevel)
 	if err != nil {
-		http.Error(w, "Failed to check driving ban: "+err.Error(), http.StatusBadRequest)
-		log.Printf("Failed to check driving ban: %v\n", err)
+		http.Error(w, "Failed to decode request body", http.StatusBadRequest)
+		log.Printf("Failed to decode request body: %v\n", err)
 		return
 	}
 
-	if drivingBan != nil && drivingBan.Duration.After(time.Now()) {
-		violation.Reason += "Driving ban is in effect \n"
-		violation.Description += "Driver was found to be operating a vehicle under active driving ban. Reason: " + drivingBan.Reason + "\n"
-		log.Print("Driving ban is in effect")
-	}
-
-	// Check driving permit
-	permit, err := ph.mup.GetDrivingPermitByJMBG(r.Context(), jmbgRequest, token)
+	checkedAt, err := checkTime(alcoholLevel.Time)
 	if err != nil {
-		log.Printf("Failed to check driving permit: %v\n", err)
-		http.Error(w, "Failed to check driving permit", http.StatusBadRequest)
+		http.Error(w, err.Error(), http.StatusBadRequest)
 		return
 	}
 
-	if permit.Number == "" {
-		log.Printf("Not found driving permit.")
-		http.Error(w, "Not found driving permit.", http.StatusBadRequest)
+	if err := alcoholLevel.Place.Validate(); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
 		return
 	}
 
-	if permit.ExpirationDate.Before(time.Now()) {
-		violation.Reason += "Driving permit expired. "
-		violation.Description += "Driver was found to have an expired driving permit. "
-		log.Print("Driving permit is expired")
-	}
-
-	// Check vehicle registration
-	plates := data.PlateRequest{
-		Plate: driverCheck.PlatesNumber,
-	}
-
-	registration, err := ph.mup.GetRegistrationByPlate(r.Context(), plates, token)
+	stamp, err := ph.stampFromToken(r, checkedAt)
 	if err != nil {
-		log.Printf("Failed to check registration by plate: %v\n", err)
-		http.Error(w, "Failed to check registration by plate", http.StatusBadRequest)
+		writeStampError(w, err)
 		return
 	}
 
-	if registration.RegistrationNumber == "" {
-		w.Header().Set("Content-Type", "application/json")
-		w.WriteHeader(http.StatusBadRequest)
-		fmt.Fprintf(w, "Wrong plates number in body request")
+	err = validateCheckSubject(alcoholLevel.DriverCategory, alcoholLevel.VehicleType)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
 		return
 	}
 
-	if registration.ExpirationDate.Before(time.Now()) {
-		violation.Reason += "Vehicle registration expired. "
-		violation.Description += "Driver was found to be operating a vehicle with an expired registration. "
-		log.Print("Vehicle registration is expired")
-	}
-
-	// Create traffic violation if any reasons exist
-	if violation.Reason != "" {
-		err = ph.repo.CreateTrafficViolation(r.Context(), &violation)
-		if err != nil {
-			http.Error(w, "Failed to create traffic violation", http.StatusInternalServerError)
-			log.Printf("Failed to create traffic violation: %v\n", err)
-			return
-		}
-
-		err = ph.court.CreateCrimeReport(r.Context(), violation, token)
-		if err != nil {
-			http.Error(w, "Failed to send crime report", http.StatusInternalServerError)
-			log.Printf("Failed to send crime report: %v\n", err)
-			return
-		}
-
-		response := data.Response{
-			Data:    violation,
-			Message: "Driver has been fined",
-		}
-
-		w.Header().Set("Content-Type", "application/json")
-		w.WriteHeader(http.StatusCreated)
-		json.NewEncoder(w).Encode(response)
-	} else {
-		response := data.Response{
-			Message: "All checks passed, no violations found.",
-		}
-		w.Header().Set("Content-Type", "application/json")
-		w.WriteHeader(http.StatusOK)
-		json.NewEncoder(w).Encode(response)
+	rules, err := ph.repo.GetRulesAt(r.Context(), checkedAt)
+	if err != nil {
+		writeRulesError(w, err)
+		return
 	}
-}
-
-func (ph *PoliceHandler) CheckAlcoholLevel(w http.ResponseWriter, r *http.Request) {
-	var alcoholLevel data.AlcoholRequest
 
-	err := json.NewDecoder(r.Body).Decode(&alcoholLevel)
+	catalogue, err := ph.repo.GetCatalogue(r.Context())
 	if err != nil {
-		http.Error(w, "Failed to decode request body", http.StatusBadRequest)
-		log.Printf("Failed to decode request body: %v\n", err)
+		writeOffenceError(w, err)
 		return
 	}
 
 	violation := data.TrafficViolation{
 		ID:           primitive.NewObjectID(),
-		Time:         time.Now(),
+		Time:         checkedAt,
 		ViolatorJMBG: alcoholLevel.JMBG,
 		Location:     alcoholLevel.Location,
+		Place:        alcoholLevel.Place,
+		RecordedBy:   &stamp,
 	}
+	check := newCheck(data.CheckAlcohol, violation, "")
 
 	response := data.Response{}
+	token := ph.extractTokenFromHeader(r)
 
-	if alcoholLevel.AlcoholLevel > 0.2 {
-		violation.Reason = fmt.Sprintf("drunk driving: %.2f \n", alcoholLevel.AlcoholLevel)
-		violation.Description = "Driver was caught operating a vehicle with a blood alcohol level above the legal limit. \n"
+	// Novice drivers are told apart by their permit unless the officer declared the category
+	var permit data.TrafficPermit
+	if alcoholLevel.DriverCategory == "" {
+		permit, err = ph.mup.GetDrivingPermitByJMBG(r.Context(), data.JMBGRequest{JMBG: alcoholLevel.JMBG}, token)
+		if err != nil {
+			log.Printf("Failed to check driving permit, applying the limit for regular drivers: %v\n", err)
+		}
+	}
+
+	category := rules.DriverCategory(alcoholLevel.DriverCategory, permit, checkedAt)
+	limit := rules.AlcoholLimit(category, alcoholLevel.VehicleType)
+	if alcoholLevel.AlcoholLevel > limit {
+		violation.AddOffence(catalogue.Item(data.OffenceDrunkDriving, &alcoholLevel.AlcoholLevel, category))
+		violation.Description = fmt.Sprintf("Driver was caught operating a vehicle with a blood alcohol level above the legal limit of %.2f. \n", limit)
 	} else {
+		ph.recordCheck(r.Context(), check, nil)
+
 		response.Message = "Driver was caught operating a vehicle with a blood alcohol level within the legal limit."
 		w.Header().Set("Content-Type", "application/json")
 		w.WriteHeader(http.StatusOK)
@@ -249,7 +176,6 @@ func (ph *PoliceHandler) CheckAlcoholLevel(w http.ResponseWriter, r *http.Reques
 		return
 	}
 
-	token := ph.extractTokenFromHeader(r)
 	_, err = ph.sso.GetPersonByJMBG(r.Context(), alcoholLevel.JMBG, token)
 	if err != nil {
 		http.Error(w, "Error with services communication", http.StatusBadRequest)
@@ -264,13 +190,15 @@ func (ph *PoliceHandler) CheckAlcoholLevel(w http.ResponseWriter, r *http.Reques
 		return
 	}
 
-	err = ph.court.CreateCrimeReport(r.Context(), violation, token)
+	err = ph.fineOrReport(r.Context(), violation, token)
 	if err != nil {
-		http.Error(w, "Failed to send crime report", http.StatusBadRequest)
-		log.Printf("Failed to send crime report: %v\n", err)
+		http.Error(w, "Failed to issue fine or send crime report", http.StatusBadRequest)
+		log.Printf("Failed to issue fine or send crime report: %v\n", err)
 		return
 	}
 
+	ph.recordCheck(r.Context(), check, &violation)
+
 	response.Message = "Driver has more alcohol in his blood than is allowed."
 	response.Data = violation
 
@@ -288,12 +216,38 @@ func (ph *PoliceHandler) CheckDriverBan(w http.ResponseWriter, r *http.Request)
 		return
 	}
 
+	checkedAt, err := checkTime(driverBan.Time)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	if err := driverBan.Place.Validate(); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	stamp, err := ph.stampFromToken(r, checkedAt)
+	if err != nil {
+		writeStampError(w, err)
+		return
+	}
+
+	catalogue, err := ph.repo.GetCatalogue(r.Context())
+	if err != nil {
+		writeOffenceError(w, err)
+		return
+	}
+
 	violation := data.TrafficViolation{
 		ID:           primitive.NewObjectID(),
-		Time:         time.Now(),
+		Time:         checkedAt,
 		ViolatorJMBG: driverBan.JMBG,
 		Location:     driverBan.Location,
+		Place:        driverBan.Place,
+		RecordedBy:   &stamp,
 	}
+	check := newCheck(data.CheckDrivingBan, violation, "")
 
 	token := ph.extractTokenFromHeader(r)
 
@@ -308,18 +262,20 @@ func (ph *PoliceHandler) CheckDriverBan(w http.ResponseWriter, r *http.Request)
 		return
 	}
 
-	drivingBan, err := ph.mup.CheckDrivingBan(r.Context(), jmbgRequest, token)
+	drivingBans, err := ph.mup.GetActiveDrivingBans(r.Context(), jmbgRequest, token)
 	if err != nil {
 		http.Error(w, "Failed to check driving ban: "+err.Error(), http.StatusBadRequest)
 		log.Printf("Failed to check driving ban: %v\n", err)
 		return
 	}
 
-	if drivingBan != nil && drivingBan.Duration.After(time.Now()) {
-		violation.Reason += "Driving ban is in effect \n"
-		violation.Description += "Driver was found to be operating a vehicle under active driving ban. Reason: " + drivingBan.Reason + "\n"
-		log.Print("Driving ban is in effect")
+	if len(drivingBans) > 0 {
+		violation.AddOffence(catalogue.Item(data.OffenceDrivingWhileBanned, nil, ""))
+		violation.Description += describeDrivingBans(drivingBans)
+		log.Printf("%d driving bans are in effect", len(drivingBans))
 	} else {
+		ph.recordCheck(r.Context(), check, nil)
+
 		response := data.Response{
 			Message: "The driver is not under a driving ban.",
 		}
@@ -336,13 +292,15 @@ func (ph *PoliceHandler) CheckDriverBan(w http.ResponseWriter, r *http.Request)
 		return
 	}
 
-	err = ph.court.CreateCrimeReport(r.Context(), violation, token)
+	err = ph.fineOrReport(r.Context(), violation, token)
 	if err != nil {
-		http.Error(w, "Failed to send crime report", http.StatusInternalServerError)
-		log.Printf("Failed to send crime report: %v\n", err)
+		http.Error(w, "Failed to issue fine or send crime report", http.StatusInternalServerError)
+		log.Printf("Failed to issue fine or send crime report: %v\n", err)
 		return
 	}
 
+	ph.recordCheck(r.Context(), check, &violation)
+
 	response := data.Response{
 		Message: "Traffic violation created successfully",
 		Data:    violation,
@@ -362,12 +320,38 @@ func (ph *PoliceHandler) CheckDriverPermitValidity(w http.ResponseWriter, r *htt
 		return
 	}
 
+	checkedAt, err := checkTime(driverBan.Time)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	if err := driverBan.Place.Validate(); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	stamp, err := ph.stampFromToken(r, checkedAt)
+	if err != nil {
+		writeStampError(w, err)
+		return
+	}
+
+	catalogue, err := ph.repo.GetCatalogue(r.Context())
+	if err != nil {
+		writeOffenceError(w, err)
+		return
+	}
+
 	violation := data.TrafficViolation{
 		ID:           primitive.NewObjectID(),
-		Time:         time.Now(),
+		Time:         checkedAt,
 		ViolatorJMBG: driverBan.JMBG,
 		Location:     driverBan.Location,
+		Place:        driverBan.Place,
+		RecordedBy:   &stamp,
 	}
+	check := newCheck(data.CheckPermit, violation, "")
 
 	response := data.Response{}
 
@@ -390,11 +374,25 @@ func (ph *PoliceHandler) CheckDriverPermitValidity(w http.ResponseWriter, r *htt
 		return
 	}
 
-	if permit.ExpirationDate.Before(time.Now()) {
-		violation.Reason += "Driving permit expired \n"
+	switch {
+	case permit.Status == data.PermitRevoked:
+		violation.AddOffence(catalogue.Item(data.OffencePermitRevoked, nil, ""))
+		violation.Description += "Driver was found to have a revoked driving permit. \n"
+		response.Message = "Driver has a revoked driving permit."
+		log.Print("Driving permit is revoked")
+	case permit.Status == data.PermitSuspended:
+		violation.AddOffence(catalogue.Item(data.OffencePermitSuspended, nil, ""))
+		violation.Description += "Driver was found to have a suspended driving permit. \n"
+		response.Message = "Driver has a suspended driving permit."
+		log.Print("Driving permit is suspended")
+	case permit.ExpirationDate.Before(checkedAt):
+		violation.AddOffence(catalogue.Item(data.OffencePermitExpired, nil, ""))
 		violation.Description += "Driver was found to have an expired driving permit. \n"
+		response.Message = "Driver has an expired driving permit."
 		log.Print("Driving permit is expired")
-	} else {
+	default:
+		ph.recordCheck(r.Context(), check, nil)
+
 		response.Message = "The driver permit is valid."
 		w.Header().Set("Content-Type", "application/json")
 		w.WriteHeader(http.StatusOK)
@@ -409,14 +407,15 @@ func (ph *PoliceHandler) CheckDriverPermitValidity(w http.ResponseWriter, r *htt
 		return
 	}
 
-	err = ph.court.CreateCrimeReport(r.Context(), violation, token)
+	err = ph.fineOrReport(r.Context(), violation, token)
 	if err != nil {
-		http.Error(w, "Failed to send crime report", http.StatusInternalServerError)
-		log.Printf("Failed to send crime report: %v\n", err)
+		http.Error(w, "Failed to issue fine or send crime report", http.StatusInternalServerError)
+		log.Printf("Failed to issue fine or send crime report: %v\n", err)
 		return
 	}
 
-	response.Message = "Driver has an expired driving permit."
+	ph.recordCheck(r.Context(), check, &violation)
+
 	response.Data = violation
 
 	w.Header().Set("Content-Type", "application/json")
@@ -433,50 +432,80 @@ func (ph *PoliceHandler) CheckVehicleTire(w http.ResponseWriter, r *http.Request
 		return
 	}
 
-	response := data.Response{}
-	token := ph.extractTokenFromHeader(r)
+	err = validateCheckSubject("", tireType.VehicleType)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
 
-	now := time.Now()
-	year := now.Year()
+	checkedAt, err := checkTime(tireType.Time)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
 
-	startWinterPeriod := time.Date(year, time.November, 1, 0, 0, 0, 0, time.Local)
-	endWinterPeriod := time.Date(year+1, time.April, 1, 0, 0, 0, 0, time.Local)
-	startSummerPeriod := time.Date(year, time.April, 1, 0, 0, 0, 0, time.Local)
-	endSummerPeriod := time.Date(year, time.November, 1, 0, 0, 0, 0, time.Local)
+	if err := tireType.Place.Validate(); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
 
-	if now.Month() >= time.November || now.Month() < time.April {
-		endSummerPeriod = time.Date(year+1, time.November, 1, 0, 0, 0, 0, time.Local)
+	stamp, err := ph.stampFromToken(r, checkedAt)
+	if err != nil {
+		writeStampError(w, err)
+		return
+	}
+
+	rules, err := ph.repo.GetRulesAt(r.Context(), checkedAt)
+	if err != nil {
+		writeRulesError(w, err)
+		return
+	}
+
+	response := data.Response{}
+	token := ph.extractTokenFromHeader(r)
+
+	catalogue, err := ph.repo.GetCatalogue(r.Context())
+	if err != nil {
+		writeOffenceError(w, err)
+		return
 	}
 
 	violation := data.TrafficViolation{
 		ID:           primitive.NewObjectID(),
-		Time:         now,
+		Time:         checkedAt,
 		ViolatorJMBG: tireType.JMBG,
 		Location:     tireType.Location,
+		Place:        tireType.Place,
+		RecordedBy:   &stamp,
 	}
+	check := newCheck(data.CheckTire, violation, "")
+
+	improperTires := rules.ImproperTires(tireType.TireType, tireType.VehicleType, checkedAt)
 
 	switch tireType.TireType {
-	case "WINTER":
-		if now.After(startWinterPeriod) && now.Before(endWinterPeriod) {
+	case data.TireWinter:
+		if !improperTires {
+			ph.recordCheck(r.Context(), check, nil)
 			response.Message = "No violation for winter tires"
 			w.Header().Set("Content-Type", "application/json")
 			w.WriteHeader(http.StatusOK)
 			json.NewEncoder(w).Encode(response)
 			return
 		}
-		violation.Reason = "Improper tire usage: WINTER tires outside summer period"
-		violation.Description = "Driver was caught operating a vehicle with WINTER tires outside the summer period (April 1 to November 1), which is against regulations."
+		violation.AddOffence(catalogue.Item(data.OffenceImproperTires, nil, tireType.TireType))
+		violation.Description = "Driver was caught operating a vehicle with WINTER tires outside the winter period (" + rules.WinterPeriod.String() + "), which is against regulations."
 
-	case "SUMMER":
-		if now.After(startSummerPeriod) && now.Before(endSummerPeriod) {
-			response.Message = "No violation for summer tires in the summer period"
+	case data.TireSummer:
+		if !improperTires {
+			ph.recordCheck(r.Context(), check, nil)
+			response.Message = "No violation for summer tires"
 			w.Header().Set("Content-Type", "application/json")
 			w.WriteHeader(http.StatusOK)
 			json.NewEncoder(w).Encode(response)
 			return
 		}
-		violation.Reason = "Improper tire usage: SUMMER tires during winter period"
-		violation.Description = "Driver was caught operating a vehicle with SUMMER tires during the winter period (November 1 to April 1), which is against regulations."
+		violation.AddOffence(catalogue.Item(data.OffenceImproperTires, nil, tireType.TireType))
+		violation.Description = "Driver was caught operating a vehicle with SUMMER tires during the winter period (" + rules.WinterPeriod.String() + "), which is against regulations."
 
 	default:
 		response.Message = "Invalid tire type specified"
@@ -503,16 +532,18 @@ func (ph *PoliceHandler) CheckVehicleTire(w http.ResponseWriter, r *http.Request
 		return
 	}
 
-	err = ph.court.CreateCrimeReport(r.Context(), violation, token)
+	err = ph.fineOrReport(r.Context(), violation, token)
 	if err != nil {
-		response.Message = "Failed to send crime report"
+		response.Message = "Failed to issue fine or send crime report"
 		w.Header().Set("Content-Type", "application/json")
 		w.WriteHeader(http.StatusBadRequest)
 		json.NewEncoder(w).Encode(response)
-		log.Printf("Failed to send crime report: %v\n", err)
+		log.Printf("Failed to issue fine or send crime report: %v\n", err)
 		return
 	}
 
+	ph.recordCheck(r.Context(), check, &violation)
+
 	response.Message = "Traffic violation created successfully."
 	response.Data = violation
 	w.Header().Set("Content-Type", "application/json")
@@ -534,12 +565,38 @@ func (ph *PoliceHandler) CheckVehicleRegistration(w http.ResponseWriter, r *http
 		return
 	}
 
+	checkedAt, err := checkTime(checkVehicleRegistration.Time)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	if err := checkVehicleRegistration.Place.Validate(); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	stamp, err := ph.stampFromToken(r, checkedAt)
+	if err != nil {
+		writeStampError(w, err)
+		return
+	}
+
+	catalogue, err := ph.repo.GetCatalogue(r.Context())
+	if err != nil {
+		writeOffenceError(w, err)
+		return
+	}
+
 	violation := data.TrafficViolation{
 		ID:           primitive.NewObjectID(),
-		Time:         time.Now(),
+		Time:         checkedAt,
 		ViolatorJMBG: checkVehicleRegistration.JMBG,
 		Location:     checkVehicleRegistration.Location,
+		Place:        checkVehicleRegistration.Place,
+		RecordedBy:   &stamp,
 	}
+	check := newCheck(data.CheckRegistration, violation, checkVehicleRegistration.PlatesNumber)
 
 	token := ph.extractTokenFromHeader(r)
 
@@ -569,11 +626,13 @@ func (ph *PoliceHandler) CheckVehicleRegistration(w http.ResponseWriter, r *http
 		return
 	}
 
-	if registration.ExpirationDate.Before(time.Now()) {
-		violation.Reason = "Vehicle registration expired"
+	if registration.ExpirationDate.Before(checkedAt) {
+		violation.AddOffence(catalogue.Item(data.OffenceRegistrationExpired, nil, ""))
 		violation.Description = "Driver was found to be operating a vehicle with an expired registration."
 		log.Print("Vehicle registration is expired")
 	} else {
+		ph.recordCheck(r.Context(), check, nil)
+
 		response := data.Response{
 			Message: "The vehicle registration has not expired.",
 		}
@@ -596,18 +655,20 @@ func (ph *PoliceHandler) CheckVehicleRegistration(w http.ResponseWriter, r *http
 		return
 	}
 
-	err = ph.court.CreateCrimeReport(r.Context(), violation, token)
+	err = ph.fineOrReport(r.Context(), violation, token)
 	if err != nil {
 		response := data.Response{
-			Message: "Failed to send crime report",
+			Message: "Failed to issue fine or send crime report",
 		}
 		w.Header().Set("Content-Type", "application/json")
 		w.WriteHeader(http.StatusBadRequest)
 		json.NewEncoder(w).Encode(response)
-		log.Printf("Failed to send crime report: %v\n", err)
+		log.Printf("Failed to issue fine or send crime report: %v\n", err)
 		return
 	}
 
+	ph.recordCheck(r.Context(), check, &violation)
+
 	response := data.Response{
 		Message: "Traffic violation recorded successfully",
 		Data:    violation,
@@ -696,91 +757,14 @@ func (ph *PoliceHandler) GetAllTrafficViolations(w http.ResponseWriter, r *http.
 	json.NewEncoder(w).Encode(violations)
 }
 
-func (ph *PoliceHandler) UpdateTrafficViolation(w http.ResponseWriter, r *http.Request) {
-	vars := mux.Vars(r)
-	violationID, ok := vars["id"]
-	if !ok {
-		http.Error(w, "Missing violation ID", http.StatusBadRequest)
-		log.Println("Missing violation ID in request")
-		return
-	}
-
-	objectID, err := primitive.ObjectIDFromHex(violationID)
-	if err != nil {
-		http.Error(w, "Invalid violation ID", http.StatusBadRequest)
-		log.Printf("Invalid violation ID: %v\n", err)
-		return
-	}
-
-	existingViolation, err := ph.repo.GetTrafficViolationByID(r.Context(), objectID)
-	if err != nil {
-		http.Error(w, "Failed to retrieve traffic violation", http.StatusInternalServerError)
-		log.Printf("Failed to retrieve traffic violation: %v\n", err)
-		return
-	}
-
-	var update data.TrafficViolation
-	err = json.NewDecoder(r.Body).Decode(&update)
-	if err != nil {
-		http.Error(w, "Failed to decode request body", http.StatusBadRequest)
-		log.Printf("Failed to decode request body: %v\n", err)
-		return
-	}
-
-	if update.ViolatorJMBG != "" {
-		existingViolation.ViolatorJMBG = update.ViolatorJMBG
-	}
-	if update.Reason != "" {
-		existingViolation.Reason = update.Reason
-	}
-	if update.Description != "" {
-		existingViolation.Description = update.Description
-	}
-	if !update.Time.IsZero() {
-		existingViolation.Time = update.Time
-	}
-	if update.Location != "" {
-		existingViolation.Location = update.Location
+// Describes every ban in effect, each on its own line
+func describeDrivingBans(drivingBans []data.DrivingBan) string {
+	var description string
+	for _, drivingBan := range drivingBans {
+		description += "Driver was found to be operating a vehicle under active driving ban until " +
+			drivingBan.End.Format("02.01.2006") + ". Reason: " + drivingBan.Reason + "\n"
 	}
-
-	err = ph.repo.UpdateTrafficViolation(r.Context(), objectID, existingViolation)
-	if err != nil {
-		http.Error(w, "Failed to update traffic violation", http.StatusInternalServerError)
-		log.Printf("Failed to update traffic violation: %v\n", err)
-		return
-	}
-
-	w.Header().Set("Content-Type", "application/json")
-	w.WriteHeader(http.StatusOK)
-	json.NewEncoder(w).Encode(existingViolation)
-}
-
-func (ph *PoliceHandler) DeleteTrafficViolation(w http.ResponseWriter, r *http.Request) {
-	vars := mux.Vars(r)
-	violationID, ok := vars["id"]
-	if !ok {
-		http.Error(w, "Missing violation ID", http.StatusBadRequest)
-		log.Println("Missing violation ID in request")
-		return
-	}
-
-	objectID, err := primitive.ObjectIDFromHex(violationID)
-	if err != nil {
-		http.Error(w, "Invalid violation ID", http.StatusBadRequest)
-		log.Printf("Invalid violation ID: %v\n", err)
-		return
-	}
-
-	err = ph.repo.DeleteTrafficViolation(r.Context(), objectID)
-	if err != nil {
-		http.Error(w, "Failed to delete traffic violation", http.StatusInternalServerError)
-		log.Printf("Failed to delete traffic violation: %v\n", err)
-		return
-	}
-
-	w.Header().Set("Content-Type", "application/json")
-	w.WriteHeader(http.StatusOK)
-	json.NewEncoder(w).Encode(map[string]string{"message": "Traffic violation deleted successfully"})
+	return description
 }
 
 // JWT middleware
@@ -0,0 +1,156 @@
+package handlers
+
+import (
+	"errors"
+	"log"
+	"net/http"
+	"police/data"
+	"strconv"
+	"time"
+
+	"github.com/gorilla/mux"
+)
+
+var (
+	errCheckInFuture = errors.New("time of the check is in the future")
+	errCheckTooOld   = errors.New("time of the check is too far in the past")
+)
+
+// How far ahead of the server clock the device of an officer may be
+const maxClockSkew = 5 * time.Minute
+
+// Longest time a check may be submitted after it was carried out, for patrols without signal
+const maxCheckDelay = 7 * 24 * time.Hour
+
+// Returns the rules in effect now, or at ?at= given in RFC3339
+func (ph *PoliceHandler) GetEffectiveRules(w http.ResponseWriter, r *http.Request) {
+	at := time.Now()
+	if value := r.URL.Query().Get("at"); value != "" {
+		parsed, err := time.Parse(time.RFC3339, value)
+		if err != nil {
+			http.Error(w, "Time must be in RFC3339 format", http.StatusBadRequest)
+			return
+		}
+		at = parsed
+	}
+
+	rules, err := ph.repo.GetRulesAt(r.Context(), at)
+	if err != nil {
+		writeRulesError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	rules.ToJSON(w)
+}
+
+func (ph *PoliceHandler) GetRuleSets(w http.ResponseWriter, r *http.Request) {
+	rules, err := ph.repo.GetRuleSets(r.Context())
+	if err != nil {
+		http.Error(w, "Failed to retrieve rules", http.StatusInternalServerError)
+		log.Printf("Failed to retrieve rules: %v\n", err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	rules.ToJSON(w)
+}
+
+// Saves the rules as a new version, taking effect from the given time
+func (ph *PoliceHandler) CreateRuleSet(w http.ResponseWriter, r *http.Request) {
+	var rules data.RuleSet
+	if err := rules.FromJSON(r.Body); err != nil {
+		http.Error(w, "Failed to decode request body", http.StatusBadRequest)
+		log.Printf("Failed to decode request body: %v\n", err)
+		return
+	}
+
+	if rules.EffectiveFrom.IsZero() {
+		http.Error(w, "Rules need the time they take effect from", http.StatusBadRequest)
+		return
+	}
+	if err := rules.Validate(); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if rules.AlcoholLimits == nil {
+		rules.AlcoholLimits = []data.AlcoholLimit{}
+	}
+	if rules.WinterTireVehicles == nil {
+		rules.WinterTireVehicles = []string{}
+	}
+
+	jmbg, _, err := ph.getSubjectAndRole(ph.extractTokenFromHeader(r))
+	if err != nil {
+		http.Error(w, "Invalid token", http.StatusUnauthorized)
+		return
+	}
+	rules.CreatedBy = jmbg
+
+	if err := ph.repo.CreateRuleSet(r.Context(), &rules); err != nil {
+		writeRulesError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	rules.ToJSON(w)
+}
+
+// Removes a version of the rules that hasn't taken effect yet
+func (ph *PoliceHandler) DeleteRuleSet(w http.ResponseWriter, r *http.Request) {
+	version, err := strconv.Atoi(mux.Vars(r)["version"])
+	if err != nil {
+		http.Error(w, "Invalid rules version", http.StatusBadRequest)
+		return
+	}
+
+	if err := ph.repo.DeleteRuleSet(r.Context(), version); err != nil {
+		writeRulesError(w, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// Returns the time the check was carried out, now if the officer didn't send one
+func checkTime(at time.Time) (time.Time, error) {
+	now := time.Now()
+	if at.IsZero() {
+		return now, nil
+	}
+	if at.After(now.Add(maxClockSkew)) {
+		return time.Time{}, errCheckInFuture
+	}
+	if at.Before(now.Add(-maxCheckDelay)) {
+		return time.Time{}, errCheckTooOld
+	}
+	return at, nil
+}
+
+// Validates what the officer declared about the driver and the vehicle
+func validateCheckSubject(driverCategory, vehicleType string) error {
+	if driverCategory != "" && !data.ValidDriverCategory(driverCategory) {
+		return data.ErrInvalidDriverCategory
+	}
+	if vehicleType != "" && !data.ValidVehicleType(vehicleType) {
+		return data.ErrInvalidVehicleType
+	}
+	return nil
+}
+
+func writeRulesError(w http.ResponseWriter, err error) {
+	switch {
+	case errors.Is(err, data.ErrRuleSetNotFound):
+		http.Error(w, err.Error(), http.StatusNotFound)
+	case errors.Is(err, data.ErrRuleSetInEffect), errors.Is(err, data.ErrRuleSetConflict), errors.Is(err, data.ErrRuleSetInThePast):
+		http.Error(w, err.Error(), http.StatusConflict)
+	case errors.Is(err, data.ErrNoRulesInEffect):
+		http.Error(w, err.Error(), http.StatusNotFound)
+	default:
+		http.Error(w, "Failed to read check rules", http.StatusInternalServerError)
+		log.Printf("Failed to read check rules: %v\n", err)
+	}
+}
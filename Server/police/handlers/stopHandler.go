@@ -0,0 +1,811 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"police/clients"
+	"police/data"
+	"police/domain"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gorilla/mux"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+var (
+	errStopOfAnotherShift      = errors.New("stop belongs to another shift")
+	errStopViolationIncomplete = errors.New("violations need the JMBG of the offender and at least one offence")
+	errDriverNotFound          = errors.New("driver not found")
+	errCourtUnavailable        = errors.New("court service unavailable")
+)
+
+const (
+	// Longest a single call to another service may take during a stop
+	stopLookupTimeout = 3 * time.Second
+	// Calls that fail with a temporary error are made at most this many times
+	stopLookupAttempts = 3
+	// Longest the checks of a request may take together with their retries, so the stop
+	// is still recorded and answered within the write timeout of the server
+	stopCheckBudget = 3500 * time.Millisecond
+	// Longest fining the violations of a closed stop and reporting them to the court may
+	// take in the request. What isn't done by then is left to the escalation job
+	stopChargeBudget = time.Second
+)
+
+// What a check run during a stop is evaluated against. Checks of a stop may run at the
+// same time and share the permit of the driver, which is looked up once
+type stopCheck struct {
+	stop       data.Stop
+	rules      data.RuleSet
+	catalogue  data.Catalogue
+	officer    primitive.ObjectID
+	token      string
+	permitOnce sync.Once
+	permit     data.TrafficPermit
+	permitErr  error
+}
+
+// Opens a stop of the driver, for the checks to be run one by one
+func (ph *PoliceHandler) OpenStop(w http.ResponseWriter, r *http.Request) {
+	var newStop data.NewStop
+	if err := newStop.FromJSON(r.Body); err != nil {
+		http.Error(w, "Failed to decode request body", http.StatusBadRequest)
+		log.Printf("Failed to decode request body: %v\n", err)
+		return
+	}
+
+	stop, ok := ph.newStop(w, r, newStop)
+	if !ok {
+		return
+	}
+
+	check, err := ph.newStopCheck(r.Context(), stop, stop.RecordedBy.Officer, ph.extractTokenFromHeader(r))
+	if err != nil {
+		writeStopError(w, err)
+		return
+	}
+
+	// The driver is identified first, a stop of a person SSO doesn't know isn't opened
+	identity, err := ph.evaluateStopCheck(r.Context(), check, data.CheckIdentity, data.StopCheckInput{})
+	if err != nil {
+		writeStopError(w, err)
+		return
+	}
+	stop.Results = append(stop.Results, identity)
+
+	if err := ph.repo.CreateStop(r.Context(), &stop); err != nil {
+		http.Error(w, "Failed to open stop", http.StatusInternalServerError)
+		log.Printf("Failed to open stop: %v\n", err)
+		return
+	}
+	stop.Summarize()
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	stop.ToJSON(w)
+}
+
+func (ph *PoliceHandler) GetStop(w http.ResponseWriter, r *http.Request) {
+	id, err := primitive.ObjectIDFromHex(mux.Vars(r)["id"])
+	if err != nil {
+		http.Error(w, "Invalid stop ID", http.StatusBadRequest)
+		return
+	}
+
+	stop, err := ph.repo.GetStop(r.Context(), id)
+	if err != nil {
+		writeStopError(w, err)
+		return
+	}
+	stop.Summarize()
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	stop.ToJSON(w)
+}
+
+// Returns stops of the shift the officer is on, in the state given by ?status=
+func (ph *PoliceHandler) GetShiftStops(w http.ResponseWriter, r *http.Request) {
+	stamp, err := ph.stampFromToken(r, time.Now())
+	if err != nil {
+		writeStampError(w, err)
+		return
+	}
+
+	stops, err := ph.repo.GetShiftStops(r.Context(), stamp.Shift, r.URL.Query().Get("status"))
+	if err != nil {
+		http.Error(w, "Failed to retrieve stops", http.StatusInternalServerError)
+		log.Printf("Failed to retrieve stops: %v\n", err)
+		return
+	}
+	for i := range stops {
+		stops[i].Summarize()
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	stops.ToJSON(w)
+}
+
+// Runs the check given in the path during the open stop and returns the stop with its
+// result. Alcohol, tire and insurance checks take what the officer found in the body.
+// A check run again is kept along with its earlier results, the latest one counts, so
+// a check left UNKNOWN while a service was down is run again once it is back
+func (ph *PoliceHandler) RunStopCheck(w http.ResponseWriter, r *http.Request) {
+	checkType := strings.ToUpper(mux.Vars(r)["type"])
+	if !data.ValidStopCheck(checkType) {
+		http.Error(w, data.ErrInvalidStopCheck.Error(), http.StatusBadRequest)
+		return
+	}
+
+	var input data.StopCheckInput
+	if err := json.NewDecoder(r.Body).Decode(&input); err != nil && !errors.Is(err, io.EOF) {
+		http.Error(w, "Failed to decode request body", http.StatusBadRequest)
+		log.Printf("Failed to decode request body: %v\n", err)
+		return
+	}
+
+	stop, stamp, ok := ph.getShiftStop(w, r)
+	if !ok {
+		return
+	}
+
+	check, err := ph.newStopCheck(r.Context(), stop, stamp.Officer, ph.extractTokenFromHeader(r))
+	if err != nil {
+		writeStopError(w, err)
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(r.Context(), stopCheckBudget)
+	result, err := ph.evaluateStopCheck(ctx, check, checkType, input)
+	cancel()
+	if err != nil {
+		writeStopError(w, err)
+		return
+	}
+
+	stop, err = ph.repo.AddStopResult(r.Context(), stop.ID, result)
+	if err != nil {
+		writeStopError(w, err)
+		return
+	}
+	stop.Summarize()
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	stop.ToJSON(w)
+}
+
+// Closes the stop, recording a violation of the driver for the offences its checks
+// found and any other violations the officer lists
+func (ph *PoliceHandler) CloseStop(w http.ResponseWriter, r *http.Request) {
+	var closing data.StopClosing
+	if err := json.NewDecoder(r.Body).Decode(&closing); err != nil && !errors.Is(err, io.EOF) {
+		http.Error(w, "Failed to decode request body", http.StatusBadRequest)
+		log.Printf("Failed to decode request body: %v\n", err)
+		return
+	}
+
+	stop, _, ok := ph.getShiftStop(w, r)
+	if !ok {
+		return
+	}
+
+	report, err := ph.closeStop(r.Context(), stop, closing, ph.extractTokenFromHeader(r))
+	if err != nil {
+		writeStopError(w, err)
+		return
+	}
+	report.Stop.Summarize()
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	report.ToJSON(w)
+}
+
+// Stops the driver, runs every check there is input for and closes the stop in one go.
+// Checks run at the same time. Records the checks look for that don't exist are reported
+// instead of ending the check, as are checks of services that couldn't be reached
+func (ph *PoliceHandler) CheckAll(w http.ResponseWriter, r *http.Request) {
+	var driverCheck data.DriverCheck
+	err := json.NewDecoder(r.Body).Decode(&driverCheck)
+	if err != nil {
+		http.Error(w, "Failed to decode request body", http.StatusBadRequest)
+		log.Printf("Failed to decode request body: %v\n", err)
+		return
+	}
+
+	if driverCheck.JMBG == "" || driverCheck.AlcoholLevel == nil || driverCheck.Tire == "" || driverCheck.Location == "" {
+		http.Error(w, "Fields jmbg, alcoholLevel, tire and location are required", http.StatusBadRequest)
+		return
+	}
+
+	stop, ok := ph.newStop(w, r, data.NewStop{
+		JMBG:           driverCheck.JMBG,
+		PlatesNumber:   driverCheck.PlatesNumber,
+		Location:       driverCheck.Location,
+		Time:           driverCheck.Time,
+		DriverCategory: driverCheck.DriverCategory,
+		VehicleType:    driverCheck.VehicleType,
+		Place:          driverCheck.Place,
+	})
+	if !ok {
+		return
+	}
+
+	token := ph.extractTokenFromHeader(r)
+	check, err := ph.newStopCheck(r.Context(), stop, stop.RecordedBy.Officer, token)
+	if err != nil {
+		writeStopError(w, err)
+		return
+	}
+
+	inputs := map[string]data.StopCheckInput{
+		data.CheckAlcohol: {AlcoholLevel: driverCheck.AlcoholLevel},
+		data.CheckTire:    {Tire: driverCheck.Tire},
+	}
+	if driverCheck.Insured != nil {
+		inputs[data.CheckInsurance] = data.StopCheckInput{Insured: driverCheck.Insured, PolicyNumber: driverCheck.PolicyNumber, InsuredUntil: driverCheck.InsuredUntil}
+	}
+
+	checkTypes := []string{}
+	for _, checkType := range data.StopCheckTypes {
+		_, given := inputs[checkType]
+		if (checkType == data.CheckInsurance && !given) || (checkType == data.CheckRegistration && stop.Plates == "") {
+			continue
+		}
+		checkTypes = append(checkTypes, checkType)
+	}
+
+	ctx, cancel := context.WithTimeout(r.Context(), stopCheckBudget)
+	results := make([]data.StopCheckResult, len(checkTypes))
+	errs := make([]error, len(checkTypes))
+	var wg sync.WaitGroup
+	for i, checkType := range checkTypes {
+		wg.Add(1)
+		go func(i int, checkType string) {
+			defer wg.Done()
+			results[i], errs[i] = ph.evaluateStopCheck(ctx, check, checkType, inputs[checkType])
+		}(i, checkType)
+	}
+	wg.Wait()
+	cancel()
+
+	for _, err := range errs {
+		if err != nil {
+			writeStopError(w, err)
+			return
+		}
+	}
+
+	stop.Results = results
+	if err := ph.repo.CreateStop(r.Context(), &stop); err != nil {
+		http.Error(w, "Failed to record stop", http.StatusInternalServerError)
+		log.Printf("Failed to record stop: %v\n", err)
+		return
+	}
+
+	report, err := ph.closeStop(r.Context(), stop, data.StopClosing{}, token)
+	if err != nil {
+		writeStopError(w, err)
+		return
+	}
+	report.Stop.Summarize()
+
+	response := data.Response{
+		Data:    report,
+		Message: "All checks passed, no violations found.",
+	}
+	status := http.StatusOK
+	if len(report.Violations) > 0 {
+		response.Message = "Driver has been fined"
+		status = http.StatusCreated
+	}
+	if len(report.Stop.ActiveWarrants) > 0 {
+		response.Message = fmt.Sprintf("Driver has %d active warrants. %s", len(report.Stop.ActiveWarrants), response.Message)
+	}
+	if len(report.Stop.Degraded) > 0 {
+		response.Message += " Checks against " + strings.Join(report.Stop.Degraded, ", ") + " could not be completed."
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(response)
+}
+
+// Validates the stop to open, writing an error if it can't be opened
+func (ph *PoliceHandler) newStop(w http.ResponseWriter, r *http.Request, newStop data.NewStop) (data.Stop, bool) {
+	if newStop.JMBG == "" || newStop.Location == "" {
+		http.Error(w, data.ErrStopDriverMissing.Error(), http.StatusBadRequest)
+		return data.Stop{}, false
+	}
+
+	stoppedAt, err := checkTime(newStop.Time)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return data.Stop{}, false
+	}
+
+	if err := newStop.Place.Validate(); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return data.Stop{}, false
+	}
+
+	if err := validateCheckSubject(newStop.DriverCategory, newStop.VehicleType); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return data.Stop{}, false
+	}
+
+	stamp, err := ph.stampFromToken(r, stoppedAt)
+	if err != nil {
+		writeStampError(w, err)
+		return data.Stop{}, false
+	}
+
+	stop := data.Stop{
+		ID:             primitive.NewObjectID(),
+		Status:         data.StopOpen,
+		JMBG:           newStop.JMBG,
+		Plates:         data.NormalizePlate(newStop.PlatesNumber),
+		Location:       newStop.Location,
+		Place:          newStop.Place,
+		DriverCategory: newStop.DriverCategory,
+		VehicleType:    newStop.VehicleType,
+		OpenedAt:       stoppedAt,
+		RecordedBy:     stamp,
+		Results:        []data.StopCheckResult{},
+		Violations:     []primitive.ObjectID{},
+	}
+	return stop, true
+}
+
+// Reads the open stop from the path, writing an error unless the officer is on the shift that opened it
+func (ph *PoliceHandler) getShiftStop(w http.ResponseWriter, r *http.Request) (data.Stop, data.Stamp, bool) {
+	stop, stamp, ok := ph.getStopOfShift(w, r)
+	if !ok {
+		return data.Stop{}, data.Stamp{}, false
+	}
+	if stop.Status != data.StopOpen {
+		writeStopError(w, data.ErrStopClosed)
+		return data.Stop{}, data.Stamp{}, false
+	}
+
+	return stop, stamp, true
+}
+
+// Reads the stop from the path, open or closed, writing an error unless the officer is on the shift that opened it
+func (ph *PoliceHandler) getStopOfShift(w http.ResponseWriter, r *http.Request) (data.Stop, data.Stamp, bool) {
+	id, err := primitive.ObjectIDFromHex(mux.Vars(r)["id"])
+	if err != nil {
+		http.Error(w, "Invalid stop ID", http.StatusBadRequest)
+		return data.Stop{}, data.Stamp{}, false
+	}
+
+	stamp, err := ph.stampFromToken(r, time.Now())
+	if err != nil {
+		writeStampError(w, err)
+		return data.Stop{}, data.Stamp{}, false
+	}
+
+	stop, err := ph.repo.GetStop(r.Context(), id)
+	if err != nil {
+		writeStopError(w, err)
+		return data.Stop{}, data.Stamp{}, false
+	}
+	if stop.RecordedBy.Shift != stamp.Shift {
+		writeStopError(w, errStopOfAnotherShift)
+		return data.Stop{}, data.Stamp{}, false
+	}
+
+	return stop, stamp, true
+}
+
+// Marks the active warrant the stop found executed by the officer, in the court first and
+// then on the stop. The stop may already be closed, as when the driver was taken in
+func (ph *PoliceHandler) ExecuteStopWarrant(w http.ResponseWriter, r *http.Request) {
+	stop, stamp, ok := ph.getStopOfShift(w, r)
+	if !ok {
+		return
+	}
+
+	warrant, found := stop.Warrant(mux.Vars(r)["warrant"])
+	if !found {
+		writeStopError(w, data.ErrWarrantNotOnStop)
+		return
+	}
+	if !warrant.Active() {
+		writeStopError(w, data.ErrWarrantExecuted)
+		return
+	}
+
+	// The court knows officers by their badge numbers
+	execution := data.WarrantExecution{ExecutedBy: stamp.BadgeNumber, ExecutedAt: time.Now()}
+
+	ctx, cancel := context.WithTimeout(r.Context(), stopLookupTimeout)
+	defer cancel()
+
+	_, err := ph.court.ExecuteWarrant(ctx, warrant.ID, execution, ph.extractTokenFromHeader(r))
+	if err != nil {
+		var respErr domain.ErrResp
+		switch {
+		case errors.As(err, &respErr) && respErr.StatusCode == http.StatusNotFound:
+			err = data.ErrWarrantNotOnStop
+		case errors.As(err, &respErr) && respErr.StatusCode == http.StatusConflict:
+			err = data.ErrWarrantExecuted
+		default:
+			err = fmt.Errorf("%w: %v", errCourtUnavailable, err)
+		}
+		writeStopError(w, err)
+		return
+	}
+
+	stop, err = ph.repo.ExecuteStopWarrant(r.Context(), stop.ID, warrant.ID, execution)
+	if err != nil {
+		http.Error(w, "Warrant was executed in the court, but failed to record it on the stop", http.StatusInternalServerError)
+		log.Printf("Failed to record execution of warrant %s on stop %s: %v\n", warrant.ID, stop.ID.Hex(), err)
+		return
+	}
+	stop.Summarize()
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	stop.ToJSON(w)
+}
+
+// Reads the rules in effect when the driver was stopped and the catalogue
+func (ph *PoliceHandler) newStopCheck(ctx context.Context, stop data.Stop, officer primitive.ObjectID, token string) (*stopCheck, error) {
+	rules, err := ph.repo.GetRulesAt(ctx, stop.OpenedAt)
+	if err != nil {
+		return nil, err
+	}
+
+	catalogue, err := ph.repo.GetCatalogue(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	return &stopCheck{stop: stop, rules: rules, catalogue: catalogue, officer: officer, token: token}, nil
+}
+
+// Runs the check and returns its result. Errors are only returned for input the check
+// can't be run with, a service that can't be reached leaves the result UNKNOWN
+func (ph *PoliceHandler) evaluateStopCheck(ctx context.Context, check *stopCheck, checkType string, input data.StopCheckInput) (data.StopCheckResult, error) {
+	stop := check.stop
+	result := data.StopCheckResult{
+		ID:        primitive.NewObjectID(),
+		Type:      checkType,
+		Status:    data.CheckPassed,
+		Offences:  data.OffenceItems{},
+		CheckedAt: time.Now(),
+		CheckedBy: check.officer,
+	}
+	fail := func(code string, measured *float64, detail, finding string) {
+		result.Status = data.CheckFailed
+		result.Offences = append(result.Offences, check.catalogue.Item(code, measured, detail))
+		result.Finding += finding
+	}
+	unknown := func(service string, err error) {
+		result.Status = data.CheckUnknown
+		result.Finding = service + " could not be reached, the check can be run again later."
+		log.Printf("Failed to run %s check of stop %s: %v\n", checkType, stop.ID.Hex(), err)
+	}
+
+	switch checkType {
+	case data.CheckIdentity:
+		result.Service = data.ServiceSSO
+		var person data.Person
+		err := lookup(ctx, func(ctx context.Context) error {
+			var err error
+			person, err = ph.sso.GetPersonByJMBG(ctx, stop.JMBG, check.token)
+			return err
+		})
+		var respErr domain.ErrResp
+		switch {
+		case errors.As(err, &respErr) && respErr.StatusCode == http.StatusNotFound:
+			return result, errDriverNotFound
+		case err != nil:
+			unknown(data.ServiceSSO, err)
+		default:
+			result.Finding = "Driver is " + person.FirstName + " " + person.LastName + "."
+		}
+
+	case data.CheckAlcohol:
+		if input.AlcoholLevel == nil {
+			return result, data.ErrAlcoholLevelMissing
+		}
+		if *input.AlcoholLevel < 0 {
+			return result, data.ErrInvalidAlcoholLevel
+		}
+		result.Input = &data.StopCheckInput{AlcoholLevel: input.AlcoholLevel}
+
+		// Novice drivers are told apart by their permit unless the officer declared the category
+		var permit data.TrafficPermit
+		permitUnknown := false
+		if stop.DriverCategory == "" {
+			var err error
+			permit, err = ph.stopPermit(ctx, check)
+			if err != nil {
+				log.Printf("Failed to check driving permit, applying the limit for regular drivers: %v\n", err)
+				permitUnknown = true
+			}
+		}
+
+		category := check.rules.DriverCategory(stop.DriverCategory, permit, stop.OpenedAt)
+		limit := check.rules.AlcoholLimit(category, stop.VehicleType)
+		if *input.AlcoholLevel > limit {
+			fail(data.OffenceDrunkDriving, input.AlcoholLevel, category,
+				fmt.Sprintf("Blood alcohol level of %.2f is above the legal limit of %.2f. ", *input.AlcoholLevel, limit))
+		} else {
+			result.Finding = fmt.Sprintf("Blood alcohol level of %.2f is within the legal limit of %.2f.", *input.AlcoholLevel, limit)
+		}
+		if permitUnknown {
+			result.Finding += " The permit could not be looked up, the limit for regular drivers was applied."
+		}
+
+	case data.CheckTire:
+		if input.Tire != data.TireSummer && input.Tire != data.TireWinter {
+			return result, data.ErrInvalidTire
+		}
+		result.Input = &data.StopCheckInput{Tire: input.Tire}
+
+		if check.rules.ImproperTires(input.Tire, stop.VehicleType, stop.OpenedAt) {
+			fail(data.OffenceImproperTires, nil, input.Tire, fmt.Sprintf("Vehicle has %s tires out of their season. ", input.Tire))
+		} else {
+			result.Finding = fmt.Sprintf("Vehicle has %s tires, as the season requires.", input.Tire)
+		}
+
+	case data.CheckDrivingBan:
+		result.Service = data.ServiceMUP
+		var drivingBans []data.DrivingBan
+		err := lookup(ctx, func(ctx context.Context) error {
+			var err error
+			drivingBans, err = ph.mup.GetActiveDrivingBans(ctx, data.JMBGRequest{JMBG: stop.JMBG}, check.token)
+			return err
+		})
+		if err != nil {
+			unknown(data.ServiceMUP, err)
+		} else if len(drivingBans) > 0 {
+			fail(data.OffenceDrivingWhileBanned, nil, "", describeDrivingBans(drivingBans))
+		} else {
+			result.Finding = "No driving ban is in effect."
+		}
+
+	case data.CheckPermit:
+		result.Service = data.ServiceMUP
+		permit, err := ph.stopPermit(ctx, check)
+		if err != nil {
+			unknown(data.ServiceMUP, err)
+			break
+		}
+		if permit.Number == "" {
+			result.Status = data.CheckNotFound
+			result.Finding = "Driver has no driving permit on record."
+			break
+		}
+
+		switch permit.Status {
+		case data.PermitSuspended:
+			fail(data.OffencePermitSuspended, nil, "", "Driving permit "+permit.Number+" is suspended. ")
+		case data.PermitRevoked:
+			fail(data.OffencePermitRevoked, nil, "", "Driving permit "+permit.Number+" is revoked. ")
+		}
+		if permit.ExpirationDate.Before(stop.OpenedAt) {
+			fail(data.OffencePermitExpired, nil, "", "Driving permit "+permit.Number+" expired on "+permit.ExpirationDate.Format("02.01.2006")+". ")
+		}
+		if result.Status == data.CheckPassed {
+			result.Finding = "Driving permit " + permit.Number + " is valid until " + permit.ExpirationDate.Format("02.01.2006") + "."
+		}
+
+	case data.CheckRegistration:
+		if stop.Plates == "" {
+			return result, data.ErrStopPlatesMissing
+		}
+		result.Service = data.ServiceMUP
+		var registration data.Registration
+		err := lookup(ctx, func(ctx context.Context) error {
+			var err error
+			registration, err = ph.mup.GetRegistrationByPlate(ctx, data.PlateRequest{Plate: stop.Plates}, check.token)
+			return err
+		})
+		if err != nil {
+			unknown(data.ServiceMUP, err)
+			break
+		}
+		if registration.RegistrationNumber == "" {
+			result.Status = data.CheckNotFound
+			result.Finding = "No vehicle is registered with plates " + stop.Plates + "."
+			break
+		}
+
+		if registration.ExpirationDate.Before(stop.OpenedAt) {
+			fail(data.OffenceRegistrationExpired, nil, "", "Registration "+registration.RegistrationNumber+" expired on "+registration.ExpirationDate.Format("02.01.2006")+". ")
+		} else {
+			result.Finding = "Registration " + registration.RegistrationNumber + " is valid until " + registration.ExpirationDate.Format("02.01.2006") + "."
+		}
+
+	case data.CheckInsurance:
+		if input.Insured == nil {
+			return result, data.ErrInsuranceMissing
+		}
+		result.Input = &data.StopCheckInput{Insured: input.Insured, PolicyNumber: input.PolicyNumber, InsuredUntil: input.InsuredUntil}
+
+		switch {
+		case !*input.Insured:
+			fail(data.OffenceUninsuredVehicle, nil, "", "Vehicle has no compulsory insurance. ")
+		case input.InsuredUntil != nil && input.InsuredUntil.Before(stop.OpenedAt):
+			fail(data.OffenceUninsuredVehicle, nil, input.PolicyNumber, "Insurance policy "+input.PolicyNumber+" expired on "+input.InsuredUntil.Format("02.01.2006")+". ")
+		default:
+			result.Finding = "Vehicle is insured."
+		}
+
+	case data.CheckWarrants:
+		result.Service = data.ServiceCourt
+		var warrants []data.Warrant
+		err := lookup(ctx, func(ctx context.Context) error {
+			var err error
+			warrants, err = ph.court.GetWarrants(ctx, stop.JMBG, check.token)
+			return err
+		})
+		if err != nil {
+			unknown(data.ServiceCourt, err)
+			break
+		}
+
+		// Executed warrants are kept with the result so the stop shows them, only active ones fail the check
+		result.Warrants = warrants
+		active := []string{}
+		for _, warrant := range warrants {
+			if warrant.Active() {
+				active = append(active, warrant.ID)
+			}
+		}
+		if len(active) > 0 {
+			result.Status = data.CheckFailed
+			result.Finding = fmt.Sprintf("WARRANT HIT: court issued %d active warrants for the driver: %s.", len(active), strings.Join(active, ", "))
+		} else {
+			result.Finding = "No active warrants are issued for the driver."
+		}
+	}
+
+	result.Finding = strings.TrimSpace(result.Finding)
+	return result, nil
+}
+
+// Looks the permit of the driver up once for all checks of the stop that need it
+func (ph *PoliceHandler) stopPermit(ctx context.Context, check *stopCheck) (data.TrafficPermit, error) {
+	check.permitOnce.Do(func() {
+		check.permitErr = lookup(ctx, func(ctx context.Context) error {
+			var err error
+			check.permit, err = ph.mup.GetDrivingPermitByJMBG(ctx, data.JMBGRequest{JMBG: check.stop.JMBG}, check.token)
+			return err
+		})
+	})
+	return check.permit, check.permitErr
+}
+
+// Calls another service with the timeout and retries of lookups during a stop
+func lookup(ctx context.Context, call func(ctx context.Context) error) error {
+	return clients.CallWithRetry(ctx, stopLookupTimeout, stopLookupAttempts, call)
+}
+
+// Closes the stop and records its violations, fining them or reporting them to the court
+func (ph *PoliceHandler) closeStop(ctx context.Context, stop data.Stop, closing data.StopClosing, token string) (data.StopReport, error) {
+	for _, checkType := range closing.Dismiss {
+		if !data.ValidStopCheck(checkType) {
+			return data.StopReport{}, data.ErrInvalidStopCheck
+		}
+	}
+
+	base := data.TrafficViolation{
+		Time:       stop.OpenedAt,
+		Location:   stop.Location,
+		Place:      stop.Place,
+		RecordedBy: &stop.RecordedBy,
+		// Saved pending, so a violation whose fine or report fails below isn't lost
+		ChargePending: true,
+	}
+
+	violations := []data.TrafficViolation{}
+	if offences := stop.Offences(closing.Dismiss); len(offences) > 0 {
+		violation := base
+		violation.ID = primitive.NewObjectID()
+		violation.ViolatorJMBG = stop.JMBG
+		violation.Description = closing.Description
+		for _, result := range stop.LatestResults() {
+			if result.Status == data.CheckFailed && len(result.Offences) > 0 {
+				violation.Description += " " + result.Finding
+			}
+		}
+		violation.Description = strings.TrimSpace(violation.Description)
+		for _, offence := range offences {
+			violation.AddOffence(offence)
+		}
+		violations = append(violations, violation)
+	}
+
+	for _, other := range closing.Violations {
+		if other.JMBG == "" || len(other.Offences) == 0 {
+			return data.StopReport{}, errStopViolationIncomplete
+		}
+		violation := base
+		violation.ID = primitive.NewObjectID()
+		violation.ViolatorJMBG = other.JMBG
+		violation.Description = other.Description
+		if err := ph.setOffences(ctx, &violation, other.Offences); err != nil {
+			return data.StopReport{}, err
+		}
+		violations = append(violations, violation)
+	}
+
+	ids := make([]primitive.ObjectID, len(violations))
+	for i, violation := range violations {
+		ids[i] = violation.ID
+	}
+
+	// The stop is closed first, so it can't be closed twice with the violations recorded twice
+	stop, err := ph.repo.CloseStop(ctx, stop.ID, ids, closing.Note, time.Now())
+	if err != nil {
+		return data.StopReport{}, err
+	}
+
+	if err := ph.repo.CreateTrafficViolations(ctx, violations); err != nil {
+		if err := ph.repo.ReopenStop(ctx, stop.ID); err != nil {
+			log.Printf("Failed to reopen stop %s: %v\n", stop.ID.Hex(), err)
+		}
+		return data.StopReport{}, err
+	}
+	chargeCtx, cancel := context.WithTimeout(ctx, stopChargeBudget)
+	for _, violation := range violations {
+		if err := ph.fineOrReport(chargeCtx, violation, token); err != nil {
+			log.Printf("Failed to issue fine or send crime report for violation %s, left for the escalation job: %v\n", violation.ID.Hex(), err)
+		}
+	}
+	cancel()
+
+	check := newCheck(data.CheckStop, base, stop.Plates)
+	check.JMBG = stop.JMBG
+	if len(violations) == 0 {
+		ph.recordCheck(ctx, check, nil)
+	}
+	for i := range violations {
+		ph.recordCheck(ctx, check, &violations[i])
+	}
+
+	return data.StopReport{Stop: stop, Violations: violations}, nil
+}
+
+func writeStopError(w http.ResponseWriter, err error) {
+	switch {
+	case errors.Is(err, data.ErrStopNotFound):
+		http.Error(w, "Stop not found", http.StatusNotFound)
+	case errors.Is(err, errDriverNotFound):
+		http.Error(w, "Driver not found", http.StatusNotFound)
+	case errors.Is(err, data.ErrWarrantNotOnStop):
+		http.Error(w, err.Error(), http.StatusNotFound)
+	case errors.Is(err, data.ErrStopClosed), errors.Is(err, data.ErrWarrantExecuted):
+		http.Error(w, err.Error(), http.StatusConflict)
+	case errors.Is(err, errCourtUnavailable):
+		http.Error(w, "Failed to reach the court", http.StatusBadGateway)
+		log.Printf("Failed to reach the court: %v\n", err)
+	case errors.Is(err, errStopOfAnotherShift):
+		http.Error(w, err.Error(), http.StatusForbidden)
+	case errors.Is(err, data.ErrInvalidStopCheck), errors.Is(err, data.ErrAlcoholLevelMissing), errors.Is(err, data.ErrInvalidAlcoholLevel),
+		errors.Is(err, data.ErrInvalidTire), errors.Is(err, data.ErrStopPlatesMissing), errors.Is(err, data.ErrInsuranceMissing),
+		errors.Is(err, errStopViolationIncomplete):
+		http.Error(w, err.Error(), http.StatusBadRequest)
+	case errors.Is(err, errUnknownOffence):
+		writeOffenceError(w, err)
+	case errors.Is(err, data.ErrNoRulesInEffect):
+		writeRulesError(w, err)
+	default:
+		http.Error(w, "Failed to process stop", http.StatusInternalServerError)
+		log.Printf("Failed to process stop: %v\n", err)
+	}
+}
@@ -1,6 +1,9 @@
 package main
 
 import (
+	"common/deadlines"
+	"common/payments"
+	"common/storage"
 	"context"
 	"log"
 	"net/http"
@@ -16,6 +19,15 @@ import (
 	"github.com/gorilla/mux"
 )
 
+// Evidence files are up to tens of megabytes, far more than fits the server timeouts
+const evidenceTransferTimeout = 5 * time.Minute
+
+// Migrations go through every traffic violation, far more than fits the startup timeout
+const migrationTimeout = 10 * time.Minute
+
+// Time open requests and the database connection get to finish on shutdown
+const shutdownTimeout = 30 * time.Second
+
 func main() {
 	port := os.Getenv("PORT")
 	if len(port) == 0 {
@@ -35,9 +47,18 @@ func main() {
 	if err != nil {
 		logger.Fatal(err.Error())
 	}
-	defer store.Disconnect(timeoutContext)
+	defer func() {
+		ctx, cancel := context.WithTimeout(context.Background(), shutdownTimeout)
+		defer cancel()
+		store.Disconnect(ctx)
+	}()
 	store.Ping()
 
+	err = store.EnsureIndexes(timeoutContext)
+	if err != nil {
+		logger.Fatalf("Failed to create indexes: %s", err.Error())
+	}
+
 	// Set LOAD_DB_TEST_DATA to 'false' for persistence between shutdowns
 	if os.Getenv("LOAD_DB_TEST_DATA") == "true" {
 		err = store.Initialize(context.Background())
@@ -46,6 +67,35 @@ func main() {
 		}
 	}
 
+	err = store.SeedCatalogue(timeoutContext)
+	if err != nil {
+		logger.Fatalf("Failed to seed violation catalogue: %s", err.Error())
+	}
+
+	err = store.SeedRules(timeoutContext)
+	if err != nil {
+		logger.Fatalf("Failed to seed check rules: %s", err.Error())
+	}
+
+	migrationContext, cancelMigrations := context.WithTimeout(context.Background(), migrationTimeout)
+
+	migrated, err := store.MigrateViolationReasons(migrationContext)
+	if err != nil {
+		logger.Fatalf("Failed to migrate violation reasons: %s", err.Error())
+	}
+	if migrated > 0 {
+		logger.Printf("Split free-text reasons of %d traffic violations into offences\n", migrated)
+	}
+
+	migrated, err = store.MigrateViolationAmendments(migrationContext)
+	if err != nil {
+		logger.Fatalf("Failed to migrate violation history: %s", err.Error())
+	}
+	if migrated > 0 {
+		logger.Printf("Moved %d history entries into their traffic violations\n", migrated)
+	}
+	cancelMigrations()
+
 	courtClient := &http.Client{
 		Transport: &http.Transport{
 			MaxIdleConns:        10,
@@ -74,24 +124,100 @@ func main() {
 	mup := clients.NewMupClient(mupClient, os.Getenv("MUP_SERVICE_URI"))
 	sso := clients.NewSSOClient(ssoClient, os.Getenv("SSO_SERVICE_URI"))
 
-	handler := handlers.NewPoliceHandler(store, court, mup, sso)
+	publicURL := os.Getenv("PUBLIC_URL")
+	if publicURL == "" {
+		publicURL = "http://localhost:" + port
+	}
+
+	// Fake gateway confirms every payment and is for local development only. Otherwise
+	// payments are recorded only from notices the bank gateway signs with the shared secret
+	var paymentGateway payments.Gateway
+	if os.Getenv("FAKE_PAYMENT_GATEWAY") == "true" {
+		logger.Println("Fake payment gateway in use, every fine can be confirmed as paid")
+		paymentGateway = payments.NewFakeGateway()
+	}
+	noticeKey := []byte(os.Getenv("PAYMENT_NOTICE_SECRET"))
+	if len(noticeKey) == 0 {
+		logger.Println("PAYMENT_NOTICE_SECRET is not set, payment notices will be rejected")
+	}
+
+	blobs, err := newBlobStore(context.Background())
+	if err != nil {
+		logger.Fatalf("Failed to open evidence storage: %s", err.Error())
+	}
+
+	handler := handlers.NewPoliceHandler(store, court, mup, sso, paymentGateway, noticeKey, blobs, publicURL)
 
 	router := mux.NewRouter()
 	// Router methods
 	router.HandleFunc("/api/v1/traffic-violation/jmbg", handler.GetTrafficViolationsByJMBG).Methods(http.MethodGet)
 	router.HandleFunc("/api/v1/traffic-violation", handler.GetAllTrafficViolations).Methods(http.MethodGet)
+	router.HandleFunc("/api/v1/traffic-violation/{id}/notice", handler.GetViolationNotice).Methods(http.MethodGet)
+	router.HandleFunc("/api/v1/verify/violation/{id}", handler.VerifyViolationNotice).Methods(http.MethodGet)
+	router.HandleFunc("/api/v1/offences", handler.GetOffences).Methods(http.MethodGet)
+	router.HandleFunc("/api/v1/rules/effective", handler.GetEffectiveRules).Methods(http.MethodGet)
+	router.HandleFunc("/api/v1/my-fines", handler.GetMyFines).Methods(http.MethodGet)
+	router.HandleFunc("/api/v1/fines/{id}", handler.GetFine).Methods(http.MethodGet)
+	if paymentGateway != nil {
+		router.HandleFunc("/api/v1/fines/{id}/confirm-payment", handler.ConfirmFinePayment).Methods(http.MethodPost)
+	}
+	router.HandleFunc("/api/v1/payments/notice", handler.ReceivePaymentNotice).Methods(http.MethodPost)
+	router.HandleFunc("/api/v1/fines/{id}/objection", deadlines.Extend(evidenceTransferTimeout, handler.SubmitObjection)).Methods(http.MethodPost)
+	router.HandleFunc("/api/v1/evidence/{id}", deadlines.Extend(evidenceTransferTimeout, handler.DownloadEvidence)).Methods(http.MethodGet)
+	router.HandleFunc("/api/v1/accidents/counts", handler.GetAccidentCounts).Methods(http.MethodGet)
 
 	authorizedRouter := router.Methods("GET", "POST", "PUT", "DELETE").Subrouter()
 	authorizedRouter.HandleFunc("/api/v1/traffic-violation", handler.CreateTrafficViolation).Methods(http.MethodPost)
+	authorizedRouter.HandleFunc("/api/v1/traffic-violation/near", handler.GetViolationsNear).Methods(http.MethodGet)
+	authorizedRouter.HandleFunc("/api/v1/traffic-violation/within", handler.GetViolationsWithin).Methods(http.MethodPost)
+	authorizedRouter.HandleFunc("/api/v1/traffic-violation/municipality/{name}", handler.GetMunicipalityViolations).Methods(http.MethodGet)
+	authorizedRouter.HandleFunc("/api/v1/municipalities", handler.GetMunicipalities).Methods(http.MethodGet)
+	authorizedRouter.HandleFunc("/api/v1/municipalities", handler.SaveMunicipality).Methods(http.MethodPut)
 	authorizedRouter.HandleFunc("/api/v1/traffic-violation/{id}", handler.GetTrafficViolationByID).Methods(http.MethodGet)
 	authorizedRouter.HandleFunc("/api/v1/traffic-violation/{id}", handler.UpdateTrafficViolation).Methods(http.MethodPut)
-	authorizedRouter.HandleFunc("/api/v1/traffic-violation/{id}", handler.DeleteTrafficViolation).Methods(http.MethodDelete)
+	authorizedRouter.HandleFunc("/api/v1/traffic-violation/{id}", handler.RequestViolationVoid).Methods(http.MethodDelete)
+	authorizedRouter.HandleFunc("/api/v1/traffic-violation/{id}/void/approve", handler.ApproveViolationVoid).Methods(http.MethodPost)
+	authorizedRouter.HandleFunc("/api/v1/traffic-violation/{id}/void/reject", handler.RejectViolationVoid).Methods(http.MethodPost)
+	authorizedRouter.HandleFunc("/api/v1/traffic-violation/{id}/history", handler.GetViolationHistory).Methods(http.MethodGet)
 	authorizedRouter.HandleFunc("/api/v1/traffic-violation/check-all", handler.CheckAll).Methods(http.MethodPost)
 	authorizedRouter.HandleFunc("/api/v1/traffic-violation/check-alcohol-level", handler.CheckAlcoholLevel).Methods(http.MethodPost)
 	authorizedRouter.HandleFunc("/api/v1/traffic-violation/check-driver-ban", handler.CheckDriverBan).Methods(http.MethodPost)
 	authorizedRouter.HandleFunc("/api/v1/traffic-violation/check-driver-permit", handler.CheckDriverPermitValidity).Methods(http.MethodPost)
 	authorizedRouter.HandleFunc("/api/v1/traffic-violation/check-vehicle-registration", handler.CheckVehicleRegistration).Methods(http.MethodPost)
 	authorizedRouter.HandleFunc("/api/v1/traffic-violation/check-vehicle-tire", handler.CheckVehicleTire).Methods(http.MethodPost)
+	authorizedRouter.HandleFunc("/api/v1/stops", handler.OpenStop).Methods(http.MethodPost)
+	authorizedRouter.HandleFunc("/api/v1/stops", handler.GetShiftStops).Methods(http.MethodGet)
+	authorizedRouter.HandleFunc("/api/v1/stops/{id}", handler.GetStop).Methods(http.MethodGet)
+	authorizedRouter.HandleFunc("/api/v1/stops/{id}/checks/{type}", handler.RunStopCheck).Methods(http.MethodPost)
+	authorizedRouter.HandleFunc("/api/v1/stops/{id}/close", handler.CloseStop).Methods(http.MethodPost)
+	authorizedRouter.HandleFunc("/api/v1/stops/{id}/warrants/{warrant}/execute", handler.ExecuteStopWarrant).Methods(http.MethodPost)
+	authorizedRouter.HandleFunc("/api/v1/accidents", handler.CreateAccident).Methods(http.MethodPost)
+	authorizedRouter.HandleFunc("/api/v1/accidents", handler.GetAccidents).Methods(http.MethodGet)
+	authorizedRouter.HandleFunc("/api/v1/accidents/{id}", handler.GetAccident).Methods(http.MethodGet)
+	authorizedRouter.HandleFunc("/api/v1/accidents/{id}/violations", handler.AddAccidentViolations).Methods(http.MethodPost)
+	authorizedRouter.HandleFunc("/api/v1/officers", handler.GetOfficers).Methods(http.MethodGet)
+	authorizedRouter.HandleFunc("/api/v1/officers", handler.CreateOfficer).Methods(http.MethodPost)
+	authorizedRouter.HandleFunc("/api/v1/officers/{id}", handler.UpdateOfficer).Methods(http.MethodPut)
+	authorizedRouter.HandleFunc("/api/v1/patrols", handler.GetPatrols).Methods(http.MethodGet)
+	authorizedRouter.HandleFunc("/api/v1/patrols", handler.CreatePatrol).Methods(http.MethodPost)
+	authorizedRouter.HandleFunc("/api/v1/shifts", handler.GetShifts).Methods(http.MethodGet)
+	authorizedRouter.HandleFunc("/api/v1/shifts", handler.CreateShift).Methods(http.MethodPost)
+	authorizedRouter.HandleFunc("/api/v1/shifts/{id}/activity", handler.GetShiftActivity).Methods(http.MethodGet)
+	authorizedRouter.HandleFunc("/api/v1/offences", handler.SaveOffence).Methods(http.MethodPost)
+	authorizedRouter.HandleFunc("/api/v1/traffic-violation/{id}/fine", handler.GetViolationFine).Methods(http.MethodGet)
+	authorizedRouter.HandleFunc("/api/v1/traffic-violation/{id}/evidence", deadlines.Extend(evidenceTransferTimeout, handler.AttachEvidence)).Methods(http.MethodPost)
+	authorizedRouter.HandleFunc("/api/v1/traffic-violation/{id}/evidence", handler.GetViolationEvidence).Methods(http.MethodGet)
+	authorizedRouter.HandleFunc("/api/v1/evidence/{id}/custody", handler.GetEvidenceCustody).Methods(http.MethodGet)
+	authorizedRouter.HandleFunc("/api/v1/fines", handler.GetFines).Methods(http.MethodGet)
+	authorizedRouter.HandleFunc("/api/v1/camera-events", handler.IngestCameraEvents).Methods(http.MethodPost)
+	authorizedRouter.HandleFunc("/api/v1/cameras", handler.GetCameras).Methods(http.MethodGet)
+	authorizedRouter.HandleFunc("/api/v1/cameras/{id}", handler.SaveCamera).Methods(http.MethodPut)
+	authorizedRouter.HandleFunc("/api/v1/camera-reviews", handler.GetCameraReviews).Methods(http.MethodGet)
+	authorizedRouter.HandleFunc("/api/v1/camera-reviews/{id}/resolve", handler.ResolveCameraReview).Methods(http.MethodPost)
+	authorizedRouter.HandleFunc("/api/v1/camera-reviews/{id}/dismiss", handler.DismissCameraReview).Methods(http.MethodPost)
+	authorizedRouter.HandleFunc("/api/v1/rules", handler.GetRuleSets).Methods(http.MethodGet)
+	authorizedRouter.HandleFunc("/api/v1/rules", handler.CreateRuleSet).Methods(http.MethodPost)
+	authorizedRouter.HandleFunc("/api/v1/rules/{version}", handler.DeleteRuleSet).Methods(http.MethodDelete)
 
 	authorizedRouter.Use(handler.AuthorizeRoles("ADMIN"))
 
@@ -116,6 +242,16 @@ func main() {
 	}
 	logger.Printf("Server listening on port: %s\n", port)
 
+	// Fines nobody paid by their due date go to court without a request
+	jobsContext, stopJobs := context.WithCancel(context.Background())
+	defer stopJobs()
+	go handler.EscalateOverdueFines(jobsContext, time.Hour)
+
+	// Offline batches of camera events are dropped in the inbox directory
+	if inbox := os.Getenv("CAMERA_INBOX"); inbox != "" {
+		go handler.WatchCameraInbox(jobsContext, inbox, time.Minute)
+	}
+
 	go func() {
 		err := server.ListenAndServe()
 		if err != nil {
@@ -129,10 +265,27 @@ func main() {
 
 	sig := <-sigCh
 	logger.Printf("Recieved terminate, starting gracefull shutdown: %v\n", sig)
+	stopJobs()
 
 	// Gracefull shutdown
-	if server.Shutdown(timeoutContext) != nil {
+	shutdownContext, cancelShutdown := context.WithTimeout(context.Background(), shutdownTimeout)
+	defer cancelShutdown()
+	if server.Shutdown(shutdownContext) != nil {
 		logger.Fatalln("Cannot gracefully shutdown")
 	}
 	logger.Println("Server gracefully stopped")
 }
+
+// Evidence goes to an S3-compatible store when S3_ENDPOINT is set, otherwise to the filesystem
+func newBlobStore(ctx context.Context) (storage.BlobStore, error) {
+	endpoint := os.Getenv("S3_ENDPOINT")
+	if endpoint != "" {
+		return storage.NewS3Store(ctx, endpoint, os.Getenv("S3_ACCESS_KEY"), os.Getenv("S3_SECRET_KEY"), os.Getenv("S3_BUCKET"), os.Getenv("S3_USE_SSL") == "true")
+	}
+
+	root := os.Getenv("EVIDENCE_PATH")
+	if root == "" {
+		root = "evidence"
+	}
+	return storage.NewFileSystemStore(root)
+}
@@ -597,6 +597,30 @@ func (sr *SSORepo) ResetPassword(passwordResetCode, newPassword string) error {
 	return nil
 }
 
+// Sets MUP office of the account with specified email
+func (sr *SSORepo) SetAccountOffice(email, office string) error {
+	persons := sr.getPersonsCollection()
+	filter := bson.M{"account.email": email}
+
+	update := bson.M{"$set": bson.M{"account.office": office}}
+	if office == "" {
+		update = bson.M{"$unset": bson.M{"account.office": ""}}
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	result, err := persons.UpdateOne(ctx, filter, update)
+	if err != nil {
+		return err
+	}
+	if result.MatchedCount == 0 {
+		return errors.New("account not found")
+	}
+
+	return nil
+}
+
 // Returns Account for specified email.
 func (sr *SSORepo) FindAccountByEmail(email string) (Account, error) {
 	persons := sr.getPersonsCollection()
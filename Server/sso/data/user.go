@@ -27,6 +27,7 @@ type Account struct {
 	PasswordResetCode string             `bson:"passwordResetCode" json:"passwordResetCode"`
 	Role              string             `bson:"role" json:"role"`
 	Activated         bool               `bson:"activated" json:"activated"`
+	Office            string             `bson:"office,omitempty" json:"office,omitempty"` // MUP office of a clerk, empty for citizens
 }
 
 type Address struct {
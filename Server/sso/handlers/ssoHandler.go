@@ -208,7 +208,7 @@ func (sh *SSOHandler) Login(w http.ResponseWriter, r *http.Request) {
 			return
 		}
 
-		token, err := sh.generateToken(legalEntity.MB, legalEntity.Name, account.Role)
+		token, err := sh.generateToken(legalEntity.MB, legalEntity.Name, account.Role, account.Office)
 		if err != nil {
 			http.Error(w, "Failed to generate token", http.StatusInternalServerError)
 			log.Printf("Failed to generate token for '%s'", credentials.Email)
@@ -221,7 +221,7 @@ func (sh *SSOHandler) Login(w http.ResponseWriter, r *http.Request) {
 
 		log.Printf("User '%s' successfully logged in from '%s'", credentials.Email, r.RemoteAddr)
 	} else {
-		token, err := sh.generateToken(person.JMBG, person.FirstName, account.Role)
+		token, err := sh.generateToken(person.JMBG, person.FirstName, account.Role, account.Office)
 		if err != nil {
 			http.Error(w, "Failed to generate token", http.StatusInternalServerError)
 			log.Printf("Failed to generate token for '%s'", credentials.Email)
@@ -361,6 +361,36 @@ func (sh *SSOHandler) ResetPassword(w http.ResponseWriter, r *http.Request) {
 	log.Printf("Successfully reset password for code: %s", requestBody.PasswordResetCode)
 }
 
+// Binds account to the MUP office its clerk works at. Empty office unbinds it
+func (sh *SSOHandler) SetAccountOffice(w http.ResponseWriter, r *http.Request) {
+	params := mux.Vars(r)
+	email := params["email"]
+
+	var requestBody struct {
+		Office string `json:"office"`
+	}
+
+	if err := json.NewDecoder(r.Body).Decode(&requestBody); err != nil {
+		http.Error(w, InvalidRequestBody, http.StatusBadRequest)
+		return
+	}
+
+	log.Printf("Setting office of account '%s' to '%s'", email, requestBody.Office)
+	err := sh.repo.SetAccountOffice(email, requestBody.Office)
+	if err != nil {
+		if err.Error() == "account not found" {
+			http.Error(w, "Account not found", http.StatusNotFound)
+			return
+		}
+		http.Error(w, "Failed to set account office", http.StatusInternalServerError)
+		log.Printf("Failed to set account office: %s", err.Error())
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+	log.Printf("Successfully set office of account '%s'", email)
+}
+
 // Helper function for retrieving person based on AccountID
 func (sh *SSOHandler) getPersonByID(accountID string) (data.Person, error) {
 	person, err := sh.repo.GetPersonByID(accountID)
@@ -441,7 +471,7 @@ func (sh *SSOHandler) validateCredentials(email, password string) error {
 }
 
 // Generates token for logged in user
-func (sh *SSOHandler) generateToken(jmbg, name, role string) (string, error) {
+func (sh *SSOHandler) generateToken(jmbg, name, role, office string) (string, error) {
 	expirationTime := time.Now().Add(24 * time.Hour)
 	claims := jwt.MapClaims{
 		"sub":  jmbg,
@@ -450,6 +480,11 @@ func (sh *SSOHandler) generateToken(jmbg, name, role string) (string, error) {
 		"exp":  expirationTime.Unix(),
 	}
 
+	// Binds clerks to the office they work at
+	if office != "" {
+		claims["office"] = office
+	}
+
 	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
 	tokenString, err := token.SignedString(secretKey)
 	if err != nil {
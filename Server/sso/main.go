@@ -64,6 +64,10 @@ func main() {
 	authorizedRouter.HandleFunc("/api/v1/user/mb/{mb}", ssoHandler.GetLegalEntityByMB).Methods("GET")
 	authorizedRouter.Use(ssoHandler.AuthorizeRoles("USER", "ADMIN"))
 
+	adminRouter := router.Methods("PUT").Subrouter()
+	adminRouter.HandleFunc("/api/v1/user/email/{email}/office", ssoHandler.SetAccountOffice).Methods("PUT")
+	adminRouter.Use(ssoHandler.AuthorizeRoles("ADMIN"))
+
 	cors := gorillaHandlers.CORS(
 		gorillaHandlers.AllowedOrigins([]string{"*"}),
 		gorillaHandlers.AllowedMethods([]string{"GET", "HEAD", "POST", "PUT", "DELETE", "OPTIONS"}),
@@ -6,6 +6,7 @@ import (
 	"fmt"
 	"net/http"
 	"statistics/data"
+	"strconv"
 )
 
 type PoliceClient struct {
@@ -45,3 +46,30 @@ func (pc *PoliceClient) GetTrafficViolations(ctx context.Context, token string)
 
 	return violations, nil
 }
+
+// Returns accidents of the year counted by month, municipality and severity
+func (pc *PoliceClient) GetAccidentCounts(ctx context.Context, year int, token string) (data.AccidentCounts, error) {
+	url := pc.address + "/accidents/counts?year=" + strconv.Itoa(year)
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Authorization", "Bearer "+token)
+
+	resp, err := pc.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("failed to retrieve accident counts: %s", resp.Status)
+	}
+
+	var counts data.AccidentCounts
+	if err := json.NewDecoder(resp.Body).Decode(&counts); err != nil {
+		return nil, err
+	}
+
+	return counts, nil
+}
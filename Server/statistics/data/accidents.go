@@ -0,0 +1,58 @@
+package data
+
+import (
+	"encoding/json"
+	"io"
+)
+
+// Municipality accidents outside any known municipality are counted under
+const UnknownMunicipality = "Unknown"
+
+// Accidents of a month in a municipality with the same severity, as the police counts them
+type AccidentCount struct {
+	Month        string `json:"month"`
+	Municipality string `json:"municipality"`
+	Severity     string `json:"severity"`
+	Count        int    `json:"count"`
+}
+
+type AccidentCounts []AccidentCount
+
+// Accidents of a year in total and by severity, municipality and month, along with the
+// counts they add up from
+type AccidentsReport struct {
+	Year           int            `json:"year"`
+	Total          int            `json:"total"`
+	BySeverity     map[string]int `json:"bySeverity"`
+	ByMunicipality map[string]int `json:"byMunicipality"`
+	ByMonth        map[string]int `json:"byMonth"`
+	Counts         AccidentCounts `json:"counts"`
+}
+
+func NewAccidentsReport(year int, counts AccidentCounts) AccidentsReport {
+	report := AccidentsReport{
+		Year:           year,
+		BySeverity:     map[string]int{},
+		ByMunicipality: map[string]int{},
+		ByMonth:        map[string]int{},
+		Counts:         AccidentCounts{},
+	}
+
+	for _, count := range counts {
+		if count.Municipality == "" {
+			count.Municipality = UnknownMunicipality
+		}
+		report.Total += count.Count
+		report.BySeverity[count.Severity] += count.Count
+		report.ByMunicipality[count.Municipality] += count.Count
+		report.ByMonth[count.Month] += count.Count
+		report.Counts = append(report.Counts, count)
+	}
+
+	return report
+}
+
+func (ar *AccidentsReport) ToJSON(w io.Writer) error {
+	e := json.NewEncoder(w)
+	return e.Encode(ar)
+}
@@ -0,0 +1,106 @@
+package data
+
+import (
+	"encoding/json"
+	"io"
+	"math"
+	"sort"
+)
+
+// GeoJSON point, coordinates are longitude first, then latitude
+type GeoPoint struct {
+	Type        string    `bson:"type" json:"type"`
+	Coordinates []float64 `bson:"coordinates" json:"coordinates"`
+}
+
+// Place on the state road network where the police found the violation
+type RoadReference struct {
+	Road      string   `bson:"road" json:"road"`
+	Section   string   `bson:"section,omitempty" json:"section,omitempty"`
+	Kilometre *float64 `bson:"kilometre,omitempty" json:"kilometre,omitempty"`
+}
+
+// GeoJSON feature collection of grid cells with violations counted in each
+type HotspotGrid struct {
+	Type     string           `json:"type"`
+	CellSize float64          `json:"cellSize"`
+	Features []HotspotFeature `json:"features"`
+}
+
+type HotspotFeature struct {
+	Type       string         `json:"type"`
+	Geometry   HotspotCell    `json:"geometry"`
+	Properties HotspotSummary `json:"properties"`
+}
+
+// GeoJSON polygon of a grid cell
+type HotspotCell struct {
+	Type        string        `json:"type"`
+	Coordinates [][][]float64 `json:"coordinates"`
+}
+
+// Violations in a cell, in total and by offence code
+type HotspotSummary struct {
+	Count    int            `json:"count"`
+	Offences map[string]int `json:"offences"`
+}
+
+type cellKey struct {
+	x, y int
+}
+
+// Counts the violations in square cells of cellSize degrees, aligned to whole multiples
+// of it, leaving out cells with fewer than minCount violations. Cells with the most
+// violations come first. Violations without a position are skipped
+func NewHotspotGrid(violations TrafficViolations, cellSize float64, minCount int) HotspotGrid {
+	counts := map[cellKey]*HotspotSummary{}
+	for _, violation := range violations {
+		if violation.Position == nil || len(violation.Position.Coordinates) != 2 {
+			continue
+		}
+
+		key := cellKey{
+			x: int(math.Floor(violation.Position.Coordinates[0] / cellSize)),
+			y: int(math.Floor(violation.Position.Coordinates[1] / cellSize)),
+		}
+		summary, ok := counts[key]
+		if !ok {
+			summary = &HotspotSummary{Offences: map[string]int{}}
+			counts[key] = summary
+		}
+
+		summary.Count++
+		for _, offence := range violation.Offences {
+			summary.Offences[offence.Code]++
+		}
+	}
+
+	grid := HotspotGrid{Type: "FeatureCollection", CellSize: cellSize, Features: []HotspotFeature{}}
+	for key, summary := range counts {
+		if summary.Count < minCount {
+			continue
+		}
+
+		west, south := float64(key.x)*cellSize, float64(key.y)*cellSize
+		east, north := west+cellSize, south+cellSize
+		grid.Features = append(grid.Features, HotspotFeature{
+			Type: "Feature",
+			Geometry: HotspotCell{
+				Type:        "Polygon",
+				Coordinates: [][][]float64{{{west, south}, {east, south}, {east, north}, {west, north}, {west, south}}},
+			},
+			Properties: *summary,
+		})
+	}
+
+	sort.Slice(grid.Features, func(i, j int) bool {
+		return grid.Features[i].Properties.Count > grid.Features[j].Properties.Count
+	})
+
+	return grid
+}
+
+func (hg *HotspotGrid) ToJSON(w io.Writer) error {
+	e := json.NewEncoder(w)
+	return e.Encode(hg)
+}
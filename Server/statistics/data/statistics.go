@@ -66,10 +66,21 @@ type TrafficViolation struct {
 	Description  string             `bson:"description" json:"description"`
 	Time         time.Time          `bson:"time" json:"time"`
 	Location     string             `bson:"location" json:"location"`
+	Offences     []Offence          `bson:"offences" json:"offences"`
+	Position     *GeoPoint          `bson:"position,omitempty" json:"position,omitempty"`
+	Road         *RoadReference     `bson:"road,omitempty" json:"road,omitempty"`
 }
 
 type TrafficViolations []TrafficViolation
 
+// Offence of a traffic violation, as coded in the police violation catalogue
+type Offence struct {
+	Code     string `bson:"code" json:"code"`
+	Title    string `bson:"title" json:"title"`
+	Severity string `bson:"severity" json:"severity"`
+	Points   int    `bson:"points" json:"points"`
+}
+
 type BrandCount struct {
 	Brand string `json:"brand"`
 	Count int    `json:"count"`
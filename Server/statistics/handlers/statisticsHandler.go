@@ -4,6 +4,7 @@ import (
 	"encoding/json"
 	"log"
 	"net/http"
+	"slices"
 	"sort"
 	"statistics/clients"
 	"statistics/data"
@@ -20,6 +21,13 @@ const FailedToEncodeStatistics = "Failed to encode statistics"
 const InvalidID = "Invalid ID"
 const FailedToDecodeRequestBody = "Failed to decode request body"
 
+// Size of hotspot grid cells in degrees, the default is roughly a kilometre
+const (
+	defaultHotspotCellSize = 0.01
+	minHotspotCellSize     = 0.001
+	maxHotspotCellSize     = 1
+)
+
 var secretKey = []byte("eUpravaT2")
 
 type StatisticsHandler struct {
@@ -318,9 +326,16 @@ func (sh *StatisticsHandler) GetTrafficViolationsReport(rw http.ResponseWriter,
 	report := make(map[string]int)
 	totalViolations := 0
 
+	// Violations are counted once for each of their offences, by catalogue code.
+	// Violations police hasn't coded yet are counted by their reason
 	for _, violation := range violations {
 		if violation.Time.Year() == year {
-			report[violation.Reason]++
+			if len(violation.Offences) == 0 {
+				report[violation.Reason]++
+			}
+			for _, offence := range violation.Offences {
+				report[offence.Code]++
+			}
 			totalViolations++
 		}
 	}
@@ -335,6 +350,108 @@ func (sh *StatisticsHandler) GetTrafficViolationsReport(rw http.ResponseWriter,
 	}
 }
 
+// Returns GeoJSON grid of cells ?cellSize= degrees wide with violations counted in each,
+// for violations of ?year= and ?offence= code when given. Cells with fewer than
+// ?minCount= violations are left out
+func (sh *StatisticsHandler) GetTrafficViolationHotspots(rw http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+
+	cellSize := defaultHotspotCellSize
+	if value := query.Get("cellSize"); value != "" {
+		parsed, err := strconv.ParseFloat(value, 64)
+		if err != nil || parsed < minHotspotCellSize || parsed > maxHotspotCellSize {
+			http.Error(rw, "Cell size must be between 0.001 and 1 degree", http.StatusBadRequest)
+			return
+		}
+		cellSize = parsed
+	}
+
+	minCount := 1
+	if value := query.Get("minCount"); value != "" {
+		parsed, err := strconv.Atoi(value)
+		if err != nil || parsed < 1 {
+			http.Error(rw, "Minimum count must be a positive number", http.StatusBadRequest)
+			return
+		}
+		minCount = parsed
+	}
+
+	year := 0
+	if value := query.Get("year"); value != "" {
+		parsed, err := strconv.Atoi(value)
+		if err != nil {
+			http.Error(rw, "Invalid year", http.StatusBadRequest)
+			return
+		}
+		year = parsed
+	}
+	offence := query.Get("offence")
+
+	token := sh.extractTokenFromHeader(r)
+	violations, err := sh.police.GetTrafficViolations(r.Context(), token)
+	if err != nil {
+		sh.logger.Println("Failed to retrieve traffic violations:", err)
+		http.Error(rw, "Failed to retrieve traffic violations", http.StatusInternalServerError)
+		return
+	}
+
+	selected := data.TrafficViolations{}
+	for _, violation := range violations {
+		if year != 0 && violation.Time.Year() != year {
+			continue
+		}
+		if offence != "" && !slices.ContainsFunc(violation.Offences, func(o data.Offence) bool { return o.Code == offence }) {
+			continue
+		}
+		selected = append(selected, violation)
+	}
+
+	grid := data.NewHotspotGrid(selected, cellSize, minCount)
+
+	rw.Header().Set(ContentType, "application/geo+json")
+	rw.WriteHeader(http.StatusOK)
+	if err := grid.ToJSON(rw); err != nil {
+		sh.logger.Println("Failed to encode traffic violation hotspots:", err)
+	}
+}
+
+// Returns accidents of the year in total and by severity, municipality and month,
+// for ?municipality= only when given
+func (sh *StatisticsHandler) GetTrafficAccidentsReport(rw http.ResponseWriter, r *http.Request) {
+	year, err := strconv.Atoi(mux.Vars(r)["year"])
+	if err != nil || year < 1 {
+		http.Error(rw, "Invalid year", http.StatusBadRequest)
+		return
+	}
+	municipality := r.URL.Query().Get("municipality")
+
+	token := sh.extractTokenFromHeader(r)
+	counts, err := sh.police.GetAccidentCounts(r.Context(), year, token)
+	if err != nil {
+		sh.logger.Println("Failed to retrieve accident counts:", err)
+		http.Error(rw, "Failed to retrieve accident counts", http.StatusInternalServerError)
+		return
+	}
+
+	if municipality != "" {
+		selected := data.AccidentCounts{}
+		for _, count := range counts {
+			if count.Municipality == municipality || (count.Municipality == "" && municipality == data.UnknownMunicipality) {
+				selected = append(selected, count)
+			}
+		}
+		counts = selected
+	}
+
+	report := data.NewAccidentsReport(year, counts)
+
+	rw.Header().Set(ContentType, ApplicationJson)
+	rw.WriteHeader(http.StatusOK)
+	if err := report.ToJSON(rw); err != nil {
+		sh.logger.Println("Failed to encode traffic accidents report:", err)
+	}
+}
+
 // JWT middleware
 func (sh *StatisticsHandler) AuthorizeRoles(allowedRoles ...string) mux.MiddlewareFunc {
 	return func(next http.Handler) http.Handler {
@@ -109,6 +109,8 @@ func main() {
 
 	router.HandleFunc("/api/v1/registered-vehicles/{year}", statisticsHandler.GetRegisteredVehiclesByYear).Methods("GET")
 	router.HandleFunc("/api/v1/traffic-violations-report/{year}", statisticsHandler.GetTrafficViolationsReport).Methods("GET")
+	router.HandleFunc("/api/v1/traffic-violations-hotspots", statisticsHandler.GetTrafficViolationHotspots).Methods("GET")
+	router.HandleFunc("/api/v1/traffic-accidents-report/{year}", statisticsHandler.GetTrafficAccidentsReport).Methods("GET")
 
 	cors := gorillaHandlers.CORS(
 		gorillaHandlers.AllowedOrigins([]string{"*"}),